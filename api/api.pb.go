@@ -214,67 +214,4018 @@ func (m *DirContentsResponse_Entry) GetPopulatedSize() int64 {
 	return 0
 }
 
+type ServiceInfoRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServiceInfoRequest) Reset()         { *m = ServiceInfoRequest{} }
+func (m *ServiceInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*ServiceInfoRequest) ProtoMessage()    {}
+func (*ServiceInfoRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{2}
+}
+
+func (m *ServiceInfoRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServiceInfoRequest.Unmarshal(m, b)
+}
+func (m *ServiceInfoRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServiceInfoRequest.Marshal(b, m, deterministic)
+}
+func (m *ServiceInfoRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServiceInfoRequest.Merge(m, src)
+}
+func (m *ServiceInfoRequest) XXX_Size() int {
+	return xxx_messageInfo_ServiceInfoRequest.Size(m)
+}
+func (m *ServiceInfoRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServiceInfoRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServiceInfoRequest proto.InternalMessageInfo
+
+type ServiceInfoResponse struct {
+	Version              string   `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	MountPoint           string   `protobuf:"bytes,2,opt,name=mountPoint,proto3" json:"mountPoint,omitempty"`
+	UptimeSeconds        int64    `protobuf:"varint,3,opt,name=uptimeSeconds,proto3" json:"uptimeSeconds,omitempty"`
+	Pid                  int64    `protobuf:"varint,4,opt,name=pid,proto3" json:"pid,omitempty"`
+	RepoPath             string   `protobuf:"bytes,5,opt,name=repoPath,proto3" json:"repoPath,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ServiceInfoResponse) Reset()         { *m = ServiceInfoResponse{} }
+func (m *ServiceInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*ServiceInfoResponse) ProtoMessage()    {}
+func (*ServiceInfoResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{3}
+}
+
+func (m *ServiceInfoResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ServiceInfoResponse.Unmarshal(m, b)
+}
+func (m *ServiceInfoResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ServiceInfoResponse.Marshal(b, m, deterministic)
+}
+func (m *ServiceInfoResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ServiceInfoResponse.Merge(m, src)
+}
+func (m *ServiceInfoResponse) XXX_Size() int {
+	return xxx_messageInfo_ServiceInfoResponse.Size(m)
+}
+func (m *ServiceInfoResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ServiceInfoResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ServiceInfoResponse proto.InternalMessageInfo
+
+func (m *ServiceInfoResponse) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *ServiceInfoResponse) GetMountPoint() string {
+	if m != nil {
+		return m.MountPoint
+	}
+	return ""
+}
+
+func (m *ServiceInfoResponse) GetUptimeSeconds() int64 {
+	if m != nil {
+		return m.UptimeSeconds
+	}
+	return 0
+}
+
+func (m *ServiceInfoResponse) GetPid() int64 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *ServiceInfoResponse) GetRepoPath() string {
+	if m != nil {
+		return m.RepoPath
+	}
+	return ""
+}
+
+type PushRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Label                string   `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Message              string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	ExpectedBlockID      []byte   `protobuf:"bytes,4,opt,name=expectedBlockID,proto3" json:"expectedBlockID,omitempty"`
+	Force                bool     `protobuf:"varint,5,opt,name=force,proto3" json:"force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushRequest) Reset()         { *m = PushRequest{} }
+func (m *PushRequest) String() string { return proto.CompactTextString(m) }
+func (*PushRequest) ProtoMessage()    {}
+func (*PushRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{4}
+}
+
+func (m *PushRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushRequest.Unmarshal(m, b)
+}
+func (m *PushRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushRequest.Marshal(b, m, deterministic)
+}
+func (m *PushRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushRequest.Merge(m, src)
+}
+func (m *PushRequest) XXX_Size() int {
+	return xxx_messageInfo_PushRequest.Size(m)
+}
+func (m *PushRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushRequest proto.InternalMessageInfo
+
+func (m *PushRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *PushRequest) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *PushRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *PushRequest) GetExpectedBlockID() []byte {
+	if m != nil {
+		return m.ExpectedBlockID
+	}
+	return nil
+}
+
+func (m *PushRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+type PushResponse struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushResponse) Reset()         { *m = PushResponse{} }
+func (m *PushResponse) String() string { return proto.CompactTextString(m) }
+func (*PushResponse) ProtoMessage()    {}
+func (*PushResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{5}
+}
+
+func (m *PushResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushResponse.Unmarshal(m, b)
+}
+func (m *PushResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushResponse.Marshal(b, m, deterministic)
+}
+func (m *PushResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushResponse.Merge(m, src)
+}
+func (m *PushResponse) XXX_Size() int {
+	return xxx_messageInfo_PushResponse.Size(m)
+}
+func (m *PushResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushResponse proto.InternalMessageInfo
+
+func (m *PushResponse) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+type AddRemoteRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Source               string   `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddRemoteRequest) Reset()         { *m = AddRemoteRequest{} }
+func (m *AddRemoteRequest) String() string { return proto.CompactTextString(m) }
+func (*AddRemoteRequest) ProtoMessage()    {}
+func (*AddRemoteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{6}
+}
+
+func (m *AddRemoteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddRemoteRequest.Unmarshal(m, b)
+}
+func (m *AddRemoteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddRemoteRequest.Marshal(b, m, deterministic)
+}
+func (m *AddRemoteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddRemoteRequest.Merge(m, src)
+}
+func (m *AddRemoteRequest) XXX_Size() int {
+	return xxx_messageInfo_AddRemoteRequest.Size(m)
+}
+func (m *AddRemoteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddRemoteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddRemoteRequest proto.InternalMessageInfo
+
+func (m *AddRemoteRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *AddRemoteRequest) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+type AddRemoteResponse struct {
+	ID                   int64    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddRemoteResponse) Reset()         { *m = AddRemoteResponse{} }
+func (m *AddRemoteResponse) String() string { return proto.CompactTextString(m) }
+func (*AddRemoteResponse) ProtoMessage()    {}
+func (*AddRemoteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{7}
+}
+
+func (m *AddRemoteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddRemoteResponse.Unmarshal(m, b)
+}
+func (m *AddRemoteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddRemoteResponse.Marshal(b, m, deterministic)
+}
+func (m *AddRemoteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddRemoteResponse.Merge(m, src)
+}
+func (m *AddRemoteResponse) XXX_Size() int {
+	return xxx_messageInfo_AddRemoteResponse.Size(m)
+}
+func (m *AddRemoteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddRemoteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddRemoteResponse proto.InternalMessageInfo
+
+func (m *AddRemoteResponse) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+type MkdirRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MkdirRequest) Reset()         { *m = MkdirRequest{} }
+func (m *MkdirRequest) String() string { return proto.CompactTextString(m) }
+func (*MkdirRequest) ProtoMessage()    {}
+func (*MkdirRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{8}
+}
+
+func (m *MkdirRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MkdirRequest.Unmarshal(m, b)
+}
+func (m *MkdirRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MkdirRequest.Marshal(b, m, deterministic)
+}
+func (m *MkdirRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MkdirRequest.Merge(m, src)
+}
+func (m *MkdirRequest) XXX_Size() int {
+	return xxx_messageInfo_MkdirRequest.Size(m)
+}
+func (m *MkdirRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MkdirRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MkdirRequest proto.InternalMessageInfo
+
+func (m *MkdirRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type MkdirResponse struct {
+	ID                   int64    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MkdirResponse) Reset()         { *m = MkdirResponse{} }
+func (m *MkdirResponse) String() string { return proto.CompactTextString(m) }
+func (*MkdirResponse) ProtoMessage()    {}
+func (*MkdirResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{9}
+}
+
+func (m *MkdirResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MkdirResponse.Unmarshal(m, b)
+}
+func (m *MkdirResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MkdirResponse.Marshal(b, m, deterministic)
+}
+func (m *MkdirResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MkdirResponse.Merge(m, src)
+}
+func (m *MkdirResponse) XXX_Size() int {
+	return xxx_messageInfo_MkdirResponse.Size(m)
+}
+func (m *MkdirResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MkdirResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MkdirResponse proto.InternalMessageInfo
+
+func (m *MkdirResponse) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+type RemoveRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveRequest) Reset()         { *m = RemoveRequest{} }
+func (m *RemoveRequest) String() string { return proto.CompactTextString(m) }
+func (*RemoveRequest) ProtoMessage()    {}
+func (*RemoveRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{10}
+}
+
+func (m *RemoveRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveRequest.Unmarshal(m, b)
+}
+func (m *RemoveRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveRequest.Marshal(b, m, deterministic)
+}
+func (m *RemoveRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveRequest.Merge(m, src)
+}
+func (m *RemoveRequest) XXX_Size() int {
+	return xxx_messageInfo_RemoveRequest.Size(m)
+}
+func (m *RemoveRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveRequest proto.InternalMessageInfo
+
+func (m *RemoveRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type RemoveResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RemoveResponse) Reset()         { *m = RemoveResponse{} }
+func (m *RemoveResponse) String() string { return proto.CompactTextString(m) }
+func (*RemoveResponse) ProtoMessage()    {}
+func (*RemoveResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{11}
+}
+
+func (m *RemoveResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RemoveResponse.Unmarshal(m, b)
+}
+func (m *RemoveResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RemoveResponse.Marshal(b, m, deterministic)
+}
+func (m *RemoveResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RemoveResponse.Merge(m, src)
+}
+func (m *RemoveResponse) XXX_Size() int {
+	return xxx_messageInfo_RemoveResponse.Size(m)
+}
+func (m *RemoveResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RemoveResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RemoveResponse proto.InternalMessageInfo
+
+type RenameRequest struct {
+	SrcPath              string   `protobuf:"bytes,1,opt,name=srcPath,proto3" json:"srcPath,omitempty"`
+	DstPath              string   `protobuf:"bytes,2,opt,name=dstPath,proto3" json:"dstPath,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenameRequest) Reset()         { *m = RenameRequest{} }
+func (m *RenameRequest) String() string { return proto.CompactTextString(m) }
+func (*RenameRequest) ProtoMessage()    {}
+func (*RenameRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{12}
+}
+
+func (m *RenameRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenameRequest.Unmarshal(m, b)
+}
+func (m *RenameRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenameRequest.Marshal(b, m, deterministic)
+}
+func (m *RenameRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenameRequest.Merge(m, src)
+}
+func (m *RenameRequest) XXX_Size() int {
+	return xxx_messageInfo_RenameRequest.Size(m)
+}
+func (m *RenameRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenameRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenameRequest proto.InternalMessageInfo
+
+func (m *RenameRequest) GetSrcPath() string {
+	if m != nil {
+		return m.SrcPath
+	}
+	return ""
+}
+
+func (m *RenameRequest) GetDstPath() string {
+	if m != nil {
+		return m.DstPath
+	}
+	return ""
+}
+
+type RenameResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RenameResponse) Reset()         { *m = RenameResponse{} }
+func (m *RenameResponse) String() string { return proto.CompactTextString(m) }
+func (*RenameResponse) ProtoMessage()    {}
+func (*RenameResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{13}
+}
+
+func (m *RenameResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RenameResponse.Unmarshal(m, b)
+}
+func (m *RenameResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RenameResponse.Marshal(b, m, deterministic)
+}
+func (m *RenameResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RenameResponse.Merge(m, src)
+}
+func (m *RenameResponse) XXX_Size() int {
+	return xxx_messageInfo_RenameResponse.Size(m)
+}
+func (m *RenameResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RenameResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RenameResponse proto.InternalMessageInfo
+
+type FreezeRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FreezeRequest) Reset()         { *m = FreezeRequest{} }
+func (m *FreezeRequest) String() string { return proto.CompactTextString(m) }
+func (*FreezeRequest) ProtoMessage()    {}
+func (*FreezeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{14}
+}
+
+func (m *FreezeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FreezeRequest.Unmarshal(m, b)
+}
+func (m *FreezeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FreezeRequest.Marshal(b, m, deterministic)
+}
+func (m *FreezeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FreezeRequest.Merge(m, src)
+}
+func (m *FreezeRequest) XXX_Size() int {
+	return xxx_messageInfo_FreezeRequest.Size(m)
+}
+func (m *FreezeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FreezeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FreezeRequest proto.InternalMessageInfo
+
+func (m *FreezeRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type FreezeResponse struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FreezeResponse) Reset()         { *m = FreezeResponse{} }
+func (m *FreezeResponse) String() string { return proto.CompactTextString(m) }
+func (*FreezeResponse) ProtoMessage()    {}
+func (*FreezeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{15}
+}
+
+func (m *FreezeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FreezeResponse.Unmarshal(m, b)
+}
+func (m *FreezeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FreezeResponse.Marshal(b, m, deterministic)
+}
+func (m *FreezeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FreezeResponse.Merge(m, src)
+}
+func (m *FreezeResponse) XXX_Size() int {
+	return xxx_messageInfo_FreezeResponse.Size(m)
+}
+func (m *FreezeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FreezeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FreezeResponse proto.InternalMessageInfo
+
+func (m *FreezeResponse) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+type PrefetchRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrefetchRequest) Reset()         { *m = PrefetchRequest{} }
+func (m *PrefetchRequest) String() string { return proto.CompactTextString(m) }
+func (*PrefetchRequest) ProtoMessage()    {}
+func (*PrefetchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{16}
+}
+
+func (m *PrefetchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrefetchRequest.Unmarshal(m, b)
+}
+func (m *PrefetchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrefetchRequest.Marshal(b, m, deterministic)
+}
+func (m *PrefetchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrefetchRequest.Merge(m, src)
+}
+func (m *PrefetchRequest) XXX_Size() int {
+	return xxx_messageInfo_PrefetchRequest.Size(m)
+}
+func (m *PrefetchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrefetchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrefetchRequest proto.InternalMessageInfo
+
+func (m *PrefetchRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type PrefetchResponse struct {
+	Size                 int64    `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PrefetchResponse) Reset()         { *m = PrefetchResponse{} }
+func (m *PrefetchResponse) String() string { return proto.CompactTextString(m) }
+func (*PrefetchResponse) ProtoMessage()    {}
+func (*PrefetchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{17}
+}
+
+func (m *PrefetchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PrefetchResponse.Unmarshal(m, b)
+}
+func (m *PrefetchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PrefetchResponse.Marshal(b, m, deterministic)
+}
+func (m *PrefetchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrefetchResponse.Merge(m, src)
+}
+func (m *PrefetchResponse) XXX_Size() int {
+	return xxx_messageInfo_PrefetchResponse.Size(m)
+}
+func (m *PrefetchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrefetchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrefetchResponse proto.InternalMessageInfo
+
+func (m *PrefetchResponse) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type EvictRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EvictRequest) Reset()         { *m = EvictRequest{} }
+func (m *EvictRequest) String() string { return proto.CompactTextString(m) }
+func (*EvictRequest) ProtoMessage()    {}
+func (*EvictRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{18}
+}
+
+func (m *EvictRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EvictRequest.Unmarshal(m, b)
+}
+func (m *EvictRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EvictRequest.Marshal(b, m, deterministic)
+}
+func (m *EvictRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EvictRequest.Merge(m, src)
+}
+func (m *EvictRequest) XXX_Size() int {
+	return xxx_messageInfo_EvictRequest.Size(m)
+}
+func (m *EvictRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EvictRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EvictRequest proto.InternalMessageInfo
+
+func (m *EvictRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type EvictResponse struct {
+	BytesFreed           int64    `protobuf:"varint,1,opt,name=bytesFreed,proto3" json:"bytesFreed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EvictResponse) Reset()         { *m = EvictResponse{} }
+func (m *EvictResponse) String() string { return proto.CompactTextString(m) }
+func (*EvictResponse) ProtoMessage()    {}
+func (*EvictResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{19}
+}
+
+func (m *EvictResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EvictResponse.Unmarshal(m, b)
+}
+func (m *EvictResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EvictResponse.Marshal(b, m, deterministic)
+}
+func (m *EvictResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EvictResponse.Merge(m, src)
+}
+func (m *EvictResponse) XXX_Size() int {
+	return xxx_messageInfo_EvictResponse.Size(m)
+}
+func (m *EvictResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_EvictResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EvictResponse proto.InternalMessageInfo
+
+func (m *EvictResponse) GetBytesFreed() int64 {
+	if m != nil {
+		return m.BytesFreed
+	}
+	return 0
+}
+
+type RefreshRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshRequest) Reset()         { *m = RefreshRequest{} }
+func (m *RefreshRequest) String() string { return proto.CompactTextString(m) }
+func (*RefreshRequest) ProtoMessage()    {}
+func (*RefreshRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{20}
+}
+
+func (m *RefreshRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshRequest.Unmarshal(m, b)
+}
+func (m *RefreshRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshRequest.Marshal(b, m, deterministic)
+}
+func (m *RefreshRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshRequest.Merge(m, src)
+}
+func (m *RefreshRequest) XXX_Size() int {
+	return xxx_messageInfo_RefreshRequest.Size(m)
+}
+func (m *RefreshRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshRequest proto.InternalMessageInfo
+
+func (m *RefreshRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type RefreshResponse struct {
+	UpdatedCount         int64    `protobuf:"varint,1,opt,name=updatedCount,proto3" json:"updatedCount,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RefreshResponse) Reset()         { *m = RefreshResponse{} }
+func (m *RefreshResponse) String() string { return proto.CompactTextString(m) }
+func (*RefreshResponse) ProtoMessage()    {}
+func (*RefreshResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{21}
+}
+
+func (m *RefreshResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RefreshResponse.Unmarshal(m, b)
+}
+func (m *RefreshResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RefreshResponse.Marshal(b, m, deterministic)
+}
+func (m *RefreshResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RefreshResponse.Merge(m, src)
+}
+func (m *RefreshResponse) XXX_Size() int {
+	return xxx_messageInfo_RefreshResponse.Size(m)
+}
+func (m *RefreshResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RefreshResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RefreshResponse proto.InternalMessageInfo
+
+func (m *RefreshResponse) GetUpdatedCount() int64 {
+	if m != nil {
+		return m.UpdatedCount
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{22}
+}
+
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(m, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+type StatsResponse struct {
+	LocalTotal           uint64                    `protobuf:"varint,1,opt,name=localTotal,proto3" json:"localTotal,omitempty"`
+	LocalFree            uint64                    `protobuf:"varint,2,opt,name=localFree,proto3" json:"localFree,omitempty"`
+	LocalAvail           uint64                    `protobuf:"varint,3,opt,name=localAvail,proto3" json:"localAvail,omitempty"`
+	FreezerUsed          int64                     `protobuf:"varint,4,opt,name=freezerUsed,proto3" json:"freezerUsed,omitempty"`
+	WritableUsed         int64                     `protobuf:"varint,5,opt,name=writableUsed,proto3" json:"writableUsed,omitempty"`
+	CacheQuota           int64                     `protobuf:"varint,6,opt,name=cacheQuota,proto3" json:"cacheQuota,omitempty"`
+	InodeCount           uint32                    `protobuf:"varint,7,opt,name=inodeCount,proto3" json:"inodeCount,omitempty"`
+	MaxINodes            uint32                    `protobuf:"varint,8,opt,name=maxINodes,proto3" json:"maxINodes,omitempty"`
+	Transfers            []*StatsResponse_Transfer `protobuf:"bytes,9,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *StatsResponse) Reset()         { *m = StatsResponse{} }
+func (m *StatsResponse) String() string { return proto.CompactTextString(m) }
+func (*StatsResponse) ProtoMessage()    {}
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{23}
+}
+
+func (m *StatsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsResponse.Unmarshal(m, b)
+}
+func (m *StatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsResponse.Marshal(b, m, deterministic)
+}
+func (m *StatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsResponse.Merge(m, src)
+}
+func (m *StatsResponse) XXX_Size() int {
+	return xxx_messageInfo_StatsResponse.Size(m)
+}
+func (m *StatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsResponse proto.InternalMessageInfo
+
+func (m *StatsResponse) GetLocalTotal() uint64 {
+	if m != nil {
+		return m.LocalTotal
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetLocalFree() uint64 {
+	if m != nil {
+		return m.LocalFree
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetLocalAvail() uint64 {
+	if m != nil {
+		return m.LocalAvail
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetFreezerUsed() int64 {
+	if m != nil {
+		return m.FreezerUsed
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetWritableUsed() int64 {
+	if m != nil {
+		return m.WritableUsed
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetCacheQuota() int64 {
+	if m != nil {
+		return m.CacheQuota
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetInodeCount() uint32 {
+	if m != nil {
+		return m.InodeCount
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetMaxINodes() uint32 {
+	if m != nil {
+		return m.MaxINodes
+	}
+	return 0
+}
+
+func (m *StatsResponse) GetTransfers() []*StatsResponse_Transfer {
+	if m != nil {
+		return m.Transfers
+	}
+	return nil
+}
+
+type StatsResponse_Transfer struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	StartTimeSeconds     int64    `protobuf:"varint,2,opt,name=startTimeSeconds,proto3" json:"startTimeSeconds,omitempty"`
+	Start                int64    `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	Offset               int64    `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	End                  int64    `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+	BytesPerSecond       float32  `protobuf:"fixed32,6,opt,name=bytesPerSecond,proto3" json:"bytesPerSecond,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatsResponse_Transfer) Reset()         { *m = StatsResponse_Transfer{} }
+func (m *StatsResponse_Transfer) String() string { return proto.CompactTextString(m) }
+func (*StatsResponse_Transfer) ProtoMessage()    {}
+func (*StatsResponse_Transfer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{23, 0}
+}
+
+func (m *StatsResponse_Transfer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsResponse_Transfer.Unmarshal(m, b)
+}
+func (m *StatsResponse_Transfer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsResponse_Transfer.Marshal(b, m, deterministic)
+}
+func (m *StatsResponse_Transfer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsResponse_Transfer.Merge(m, src)
+}
+func (m *StatsResponse_Transfer) XXX_Size() int {
+	return xxx_messageInfo_StatsResponse_Transfer.Size(m)
+}
+func (m *StatsResponse_Transfer) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsResponse_Transfer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsResponse_Transfer proto.InternalMessageInfo
+
+func (m *StatsResponse_Transfer) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *StatsResponse_Transfer) GetStartTimeSeconds() int64 {
+	if m != nil {
+		return m.StartTimeSeconds
+	}
+	return 0
+}
+
+func (m *StatsResponse_Transfer) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *StatsResponse_Transfer) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *StatsResponse_Transfer) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *StatsResponse_Transfer) GetBytesPerSecond() float32 {
+	if m != nil {
+		return m.BytesPerSecond
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
+func (m *StatusRequest) String() string { return proto.CompactTextString(m) }
+func (*StatusRequest) ProtoMessage()    {}
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{24}
+}
+
+func (m *StatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusRequest.Unmarshal(m, b)
+}
+func (m *StatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusRequest.Marshal(b, m, deterministic)
+}
+func (m *StatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusRequest.Merge(m, src)
+}
+func (m *StatusRequest) XXX_Size() int {
+	return xxx_messageInfo_StatusRequest.Size(m)
+}
+func (m *StatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusRequest proto.InternalMessageInfo
+
+type StatusResponse struct {
+	Transfers            []*StatusResponse_Transfer `protobuf:"bytes,1,rep,name=transfers,proto3" json:"transfers,omitempty"`
+	Copies               []*StatusResponse_Copy     `protobuf:"bytes,2,rep,name=copies,proto3" json:"copies,omitempty"`
+	FreezerUsed          int64                      `protobuf:"varint,3,opt,name=freezerUsed,proto3" json:"freezerUsed,omitempty"`
+	FreezerBlocks        int64                      `protobuf:"varint,4,opt,name=freezerBlocks,proto3" json:"freezerBlocks,omitempty"`
+	DirtyFileCount       int64                      `protobuf:"varint,5,opt,name=dirtyFileCount,proto3" json:"dirtyFileCount,omitempty"`
+	DirtyFileBytes       int64                      `protobuf:"varint,6,opt,name=dirtyFileBytes,proto3" json:"dirtyFileBytes,omitempty"`
+	WritableUsed         int64                      `protobuf:"varint,7,opt,name=writableUsed,proto3" json:"writableUsed,omitempty"`
+	Mounts               []*StatusResponse_Mount    `protobuf:"bytes,8,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	MountPoint           string                     `protobuf:"bytes,9,opt,name=mountPoint,proto3" json:"mountPoint,omitempty"`
+	RepoPath             string                     `protobuf:"bytes,10,opt,name=repoPath,proto3" json:"repoPath,omitempty"`
+	UptimeSeconds        int64                      `protobuf:"varint,11,opt,name=uptimeSeconds,proto3" json:"uptimeSeconds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *StatusResponse) Reset()         { *m = StatusResponse{} }
+func (m *StatusResponse) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse) ProtoMessage()    {}
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25}
+}
+
+func (m *StatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse.Unmarshal(m, b)
+}
+func (m *StatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse.Marshal(b, m, deterministic)
+}
+func (m *StatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse.Merge(m, src)
+}
+func (m *StatusResponse) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse.Size(m)
+}
+func (m *StatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse proto.InternalMessageInfo
+
+func (m *StatusResponse) GetTransfers() []*StatusResponse_Transfer {
+	if m != nil {
+		return m.Transfers
+	}
+	return nil
+}
+
+func (m *StatusResponse) GetCopies() []*StatusResponse_Copy {
+	if m != nil {
+		return m.Copies
+	}
+	return nil
+}
+
+func (m *StatusResponse) GetFreezerUsed() int64 {
+	if m != nil {
+		return m.FreezerUsed
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetFreezerBlocks() int64 {
+	if m != nil {
+		return m.FreezerBlocks
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetDirtyFileCount() int64 {
+	if m != nil {
+		return m.DirtyFileCount
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetDirtyFileBytes() int64 {
+	if m != nil {
+		return m.DirtyFileBytes
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetWritableUsed() int64 {
+	if m != nil {
+		return m.WritableUsed
+	}
+	return 0
+}
+
+func (m *StatusResponse) GetMounts() []*StatusResponse_Mount {
+	if m != nil {
+		return m.Mounts
+	}
+	return nil
+}
+
+func (m *StatusResponse) GetMountPoint() string {
+	if m != nil {
+		return m.MountPoint
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetRepoPath() string {
+	if m != nil {
+		return m.RepoPath
+	}
+	return ""
+}
+
+func (m *StatusResponse) GetUptimeSeconds() int64 {
+	if m != nil {
+		return m.UptimeSeconds
+	}
+	return 0
+}
+
+type StatusResponse_Transfer struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	StartTimeSeconds     int64    `protobuf:"varint,2,opt,name=startTimeSeconds,proto3" json:"startTimeSeconds,omitempty"`
+	Start                int64    `protobuf:"varint,3,opt,name=start,proto3" json:"start,omitempty"`
+	Offset               int64    `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	End                  int64    `protobuf:"varint,5,opt,name=end,proto3" json:"end,omitempty"`
+	BytesPerSecond       float32  `protobuf:"fixed32,6,opt,name=bytesPerSecond,proto3" json:"bytesPerSecond,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusResponse_Transfer) Reset()         { *m = StatusResponse_Transfer{} }
+func (m *StatusResponse_Transfer) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse_Transfer) ProtoMessage()    {}
+func (*StatusResponse_Transfer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25, 0}
+}
+
+func (m *StatusResponse_Transfer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse_Transfer.Unmarshal(m, b)
+}
+func (m *StatusResponse_Transfer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse_Transfer.Marshal(b, m, deterministic)
+}
+func (m *StatusResponse_Transfer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse_Transfer.Merge(m, src)
+}
+func (m *StatusResponse_Transfer) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse_Transfer.Size(m)
+}
+func (m *StatusResponse_Transfer) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse_Transfer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse_Transfer proto.InternalMessageInfo
+
+func (m *StatusResponse_Transfer) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *StatusResponse_Transfer) GetStartTimeSeconds() int64 {
+	if m != nil {
+		return m.StartTimeSeconds
+	}
+	return 0
+}
+
+func (m *StatusResponse_Transfer) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *StatusResponse_Transfer) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *StatusResponse_Transfer) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *StatusResponse_Transfer) GetBytesPerSecond() float32 {
+	if m != nil {
+		return m.BytesPerSecond
+	}
+	return 0
+}
+
+type StatusResponse_Copy struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	Start                int64    `protobuf:"varint,2,opt,name=start,proto3" json:"start,omitempty"`
+	End                  int64    `protobuf:"varint,3,opt,name=end,proto3" json:"end,omitempty"`
+	StartTimeNanos       int64    `protobuf:"varint,4,opt,name=startTimeNanos,proto3" json:"startTimeNanos,omitempty"`
+	EndTimeNanos         int64    `protobuf:"varint,5,opt,name=endTimeNanos,proto3" json:"endTimeNanos,omitempty"`
+	Complete             bool     `protobuf:"varint,6,opt,name=complete,proto3" json:"complete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusResponse_Copy) Reset()         { *m = StatusResponse_Copy{} }
+func (m *StatusResponse_Copy) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse_Copy) ProtoMessage()    {}
+func (*StatusResponse_Copy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25, 1}
+}
+
+func (m *StatusResponse_Copy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse_Copy.Unmarshal(m, b)
+}
+func (m *StatusResponse_Copy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse_Copy.Marshal(b, m, deterministic)
+}
+func (m *StatusResponse_Copy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse_Copy.Merge(m, src)
+}
+func (m *StatusResponse_Copy) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse_Copy.Size(m)
+}
+func (m *StatusResponse_Copy) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse_Copy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse_Copy proto.InternalMessageInfo
+
+func (m *StatusResponse_Copy) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *StatusResponse_Copy) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *StatusResponse_Copy) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *StatusResponse_Copy) GetStartTimeNanos() int64 {
+	if m != nil {
+		return m.StartTimeNanos
+	}
+	return 0
+}
+
+func (m *StatusResponse_Copy) GetEndTimeNanos() int64 {
+	if m != nil {
+		return m.EndTimeNanos
+	}
+	return 0
+}
+
+func (m *StatusResponse_Copy) GetComplete() bool {
+	if m != nil {
+		return m.Complete
+	}
+	return false
+}
+
+type StatusResponse_Mount struct {
+	ID                   int64    `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,3,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	LeaseName            string   `protobuf:"bytes,4,opt,name=leaseName,proto3" json:"leaseName,omitempty"`
+	LastRenewalSeconds   int64    `protobuf:"varint,5,opt,name=lastRenewalSeconds,proto3" json:"lastRenewalSeconds,omitempty"`
+	LeaseExpirySeconds   int64    `protobuf:"varint,6,opt,name=leaseExpirySeconds,proto3" json:"leaseExpirySeconds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StatusResponse_Mount) Reset()         { *m = StatusResponse_Mount{} }
+func (m *StatusResponse_Mount) String() string { return proto.CompactTextString(m) }
+func (*StatusResponse_Mount) ProtoMessage()    {}
+func (*StatusResponse_Mount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{25, 2}
+}
+
+func (m *StatusResponse_Mount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatusResponse_Mount.Unmarshal(m, b)
+}
+func (m *StatusResponse_Mount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatusResponse_Mount.Marshal(b, m, deterministic)
+}
+func (m *StatusResponse_Mount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatusResponse_Mount.Merge(m, src)
+}
+func (m *StatusResponse_Mount) XXX_Size() int {
+	return xxx_messageInfo_StatusResponse_Mount.Size(m)
+}
+func (m *StatusResponse_Mount) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatusResponse_Mount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatusResponse_Mount proto.InternalMessageInfo
+
+func (m *StatusResponse_Mount) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *StatusResponse_Mount) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *StatusResponse_Mount) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *StatusResponse_Mount) GetLeaseName() string {
+	if m != nil {
+		return m.LeaseName
+	}
+	return ""
+}
+
+func (m *StatusResponse_Mount) GetLastRenewalSeconds() int64 {
+	if m != nil {
+		return m.LastRenewalSeconds
+	}
+	return 0
+}
+
+func (m *StatusResponse_Mount) GetLeaseExpirySeconds() int64 {
+	if m != nil {
+		return m.LeaseExpirySeconds
+	}
+	return 0
+}
+
+type WatchEventsRequest struct {
+	Types                []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchEventsRequest) Reset()         { *m = WatchEventsRequest{} }
+func (m *WatchEventsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchEventsRequest) ProtoMessage()    {}
+func (*WatchEventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{26}
+}
+
+func (m *WatchEventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchEventsRequest.Unmarshal(m, b)
+}
+func (m *WatchEventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchEventsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchEventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchEventsRequest.Merge(m, src)
+}
+func (m *WatchEventsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchEventsRequest.Size(m)
+}
+func (m *WatchEventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchEventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchEventsRequest proto.InternalMessageInfo
+
+func (m *WatchEventsRequest) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+func (m *WatchEventsRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type Event struct {
+	Type                 string   `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TimeNanos            int64    `protobuf:"varint,2,opt,name=timeNanos,proto3" json:"timeNanos,omitempty"`
+	Pid                  int64    `protobuf:"varint,3,opt,name=pid,proto3" json:"pid,omitempty"`
+	ID                   int64    `protobuf:"varint,4,opt,name=ID,proto3" json:"ID,omitempty"`
+	Path                 string   `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,6,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	Offset               int64    `protobuf:"varint,7,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length               int64    `protobuf:"varint,8,opt,name=length,proto3" json:"length,omitempty"`
+	DurationMicros       int64    `protobuf:"varint,9,opt,name=durationMicros,proto3" json:"durationMicros,omitempty"`
+	Label                string   `protobuf:"bytes,10,opt,name=label,proto3" json:"label,omitempty"`
+	Writable             bool     `protobuf:"varint,11,opt,name=writable,proto3" json:"writable,omitempty"`
+	BlockCount           int64    `protobuf:"varint,12,opt,name=blockCount,proto3" json:"blockCount,omitempty"`
+	Dropped              int64    `protobuf:"varint,13,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	Source               string   `protobuf:"bytes,14,opt,name=source,proto3" json:"source,omitempty"`
+	Pinned               bool     `protobuf:"varint,15,opt,name=pinned,proto3" json:"pinned,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Event) Reset()         { *m = Event{} }
+func (m *Event) String() string { return proto.CompactTextString(m) }
+func (*Event) ProtoMessage()    {}
+func (*Event) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{27}
+}
+
+func (m *Event) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Event.Unmarshal(m, b)
+}
+func (m *Event) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Event.Marshal(b, m, deterministic)
+}
+func (m *Event) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Event.Merge(m, src)
+}
+func (m *Event) XXX_Size() int {
+	return xxx_messageInfo_Event.Size(m)
+}
+func (m *Event) XXX_DiscardUnknown() {
+	xxx_messageInfo_Event.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Event proto.InternalMessageInfo
+
+func (m *Event) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Event) GetTimeNanos() int64 {
+	if m != nil {
+		return m.TimeNanos
+	}
+	return 0
+}
+
+func (m *Event) GetPid() int64 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *Event) GetID() int64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *Event) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *Event) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *Event) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *Event) GetLength() int64 {
+	if m != nil {
+		return m.Length
+	}
+	return 0
+}
+
+func (m *Event) GetDurationMicros() int64 {
+	if m != nil {
+		return m.DurationMicros
+	}
+	return 0
+}
+
+func (m *Event) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *Event) GetWritable() bool {
+	if m != nil {
+		return m.Writable
+	}
+	return false
+}
+
+func (m *Event) GetBlockCount() int64 {
+	if m != nil {
+		return m.BlockCount
+	}
+	return 0
+}
+
+func (m *Event) GetDropped() int64 {
+	if m != nil {
+		return m.Dropped
+	}
+	return 0
+}
+
+func (m *Event) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *Event) GetPinned() bool {
+	if m != nil {
+		return m.Pinned
+	}
+	return false
+}
+
+type ListLabelsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListLabelsRequest) Reset()         { *m = ListLabelsRequest{} }
+func (m *ListLabelsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListLabelsRequest) ProtoMessage()    {}
+func (*ListLabelsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{28}
+}
+
+func (m *ListLabelsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLabelsRequest.Unmarshal(m, b)
+}
+func (m *ListLabelsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLabelsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListLabelsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLabelsRequest.Merge(m, src)
+}
+func (m *ListLabelsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListLabelsRequest.Size(m)
+}
+func (m *ListLabelsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLabelsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLabelsRequest proto.InternalMessageInfo
+
+type ListLabelsResponse struct {
+	Labels               []string `protobuf:"bytes,1,rep,name=labels,proto3" json:"labels,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListLabelsResponse) Reset()         { *m = ListLabelsResponse{} }
+func (m *ListLabelsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListLabelsResponse) ProtoMessage()    {}
+func (*ListLabelsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{29}
+}
+
+func (m *ListLabelsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLabelsResponse.Unmarshal(m, b)
+}
+func (m *ListLabelsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLabelsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListLabelsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLabelsResponse.Merge(m, src)
+}
+func (m *ListLabelsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListLabelsResponse.Size(m)
+}
+func (m *ListLabelsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLabelsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLabelsResponse proto.InternalMessageInfo
+
+func (m *ListLabelsResponse) GetLabels() []string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+type LabelHistoryRequest struct {
+	Label                string   `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LabelHistoryRequest) Reset()         { *m = LabelHistoryRequest{} }
+func (m *LabelHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*LabelHistoryRequest) ProtoMessage()    {}
+func (*LabelHistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{30}
+}
+
+func (m *LabelHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LabelHistoryRequest.Unmarshal(m, b)
+}
+func (m *LabelHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LabelHistoryRequest.Marshal(b, m, deterministic)
+}
+func (m *LabelHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelHistoryRequest.Merge(m, src)
+}
+func (m *LabelHistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_LabelHistoryRequest.Size(m)
+}
+func (m *LabelHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelHistoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelHistoryRequest proto.InternalMessageInfo
+
+func (m *LabelHistoryRequest) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+type LabelHistoryResponse struct {
+	Versions             []*LabelHistoryResponse_Version `protobuf:"bytes,1,rep,name=versions,proto3" json:"versions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                        `json:"-"`
+	XXX_unrecognized     []byte                          `json:"-"`
+	XXX_sizecache        int32                           `json:"-"`
+}
+
+func (m *LabelHistoryResponse) Reset()         { *m = LabelHistoryResponse{} }
+func (m *LabelHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*LabelHistoryResponse) ProtoMessage()    {}
+func (*LabelHistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{31}
+}
+
+func (m *LabelHistoryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LabelHistoryResponse.Unmarshal(m, b)
+}
+func (m *LabelHistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LabelHistoryResponse.Marshal(b, m, deterministic)
+}
+func (m *LabelHistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelHistoryResponse.Merge(m, src)
+}
+func (m *LabelHistoryResponse) XXX_Size() int {
+	return xxx_messageInfo_LabelHistoryResponse.Size(m)
+}
+func (m *LabelHistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelHistoryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelHistoryResponse proto.InternalMessageInfo
+
+func (m *LabelHistoryResponse) GetVersions() []*LabelHistoryResponse_Version {
+	if m != nil {
+		return m.Versions
+	}
+	return nil
+}
+
+type LabelHistoryResponse_Version struct {
+	Version              int32    `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	TimeNanos            int64    `protobuf:"varint,2,opt,name=timeNanos,proto3" json:"timeNanos,omitempty"`
+	Host                 string   `protobuf:"bytes,3,opt,name=host,proto3" json:"host,omitempty"`
+	User                 string   `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,5,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	Parent               []byte   `protobuf:"bytes,6,opt,name=parent,proto3" json:"parent,omitempty"`
+	Message              string   `protobuf:"bytes,7,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LabelHistoryResponse_Version) Reset()         { *m = LabelHistoryResponse_Version{} }
+func (m *LabelHistoryResponse_Version) String() string { return proto.CompactTextString(m) }
+func (*LabelHistoryResponse_Version) ProtoMessage()    {}
+func (*LabelHistoryResponse_Version) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{31, 0}
+}
+
+func (m *LabelHistoryResponse_Version) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LabelHistoryResponse_Version.Unmarshal(m, b)
+}
+func (m *LabelHistoryResponse_Version) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LabelHistoryResponse_Version.Marshal(b, m, deterministic)
+}
+func (m *LabelHistoryResponse_Version) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelHistoryResponse_Version.Merge(m, src)
+}
+func (m *LabelHistoryResponse_Version) XXX_Size() int {
+	return xxx_messageInfo_LabelHistoryResponse_Version.Size(m)
+}
+func (m *LabelHistoryResponse_Version) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelHistoryResponse_Version.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelHistoryResponse_Version proto.InternalMessageInfo
+
+func (m *LabelHistoryResponse_Version) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *LabelHistoryResponse_Version) GetTimeNanos() int64 {
+	if m != nil {
+		return m.TimeNanos
+	}
+	return 0
+}
+
+func (m *LabelHistoryResponse_Version) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *LabelHistoryResponse_Version) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *LabelHistoryResponse_Version) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *LabelHistoryResponse_Version) GetParent() []byte {
+	if m != nil {
+		return m.Parent
+	}
+	return nil
+}
+
+func (m *LabelHistoryResponse_Version) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type DiffRequest struct {
+	From                 string   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiffRequest) Reset()         { *m = DiffRequest{} }
+func (m *DiffRequest) String() string { return proto.CompactTextString(m) }
+func (*DiffRequest) ProtoMessage()    {}
+func (*DiffRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{32}
+}
+
+func (m *DiffRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiffRequest.Unmarshal(m, b)
+}
+func (m *DiffRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiffRequest.Marshal(b, m, deterministic)
+}
+func (m *DiffRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiffRequest.Merge(m, src)
+}
+func (m *DiffRequest) XXX_Size() int {
+	return xxx_messageInfo_DiffRequest.Size(m)
+}
+func (m *DiffRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiffRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiffRequest proto.InternalMessageInfo
+
+func (m *DiffRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *DiffRequest) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+type DiffResponse struct {
+	Changes              []*DiffResponse_Change `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *DiffResponse) Reset()         { *m = DiffResponse{} }
+func (m *DiffResponse) String() string { return proto.CompactTextString(m) }
+func (*DiffResponse) ProtoMessage()    {}
+func (*DiffResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{33}
+}
+
+func (m *DiffResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiffResponse.Unmarshal(m, b)
+}
+func (m *DiffResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiffResponse.Marshal(b, m, deterministic)
+}
+func (m *DiffResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiffResponse.Merge(m, src)
+}
+func (m *DiffResponse) XXX_Size() int {
+	return xxx_messageInfo_DiffResponse.Size(m)
+}
+func (m *DiffResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiffResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiffResponse proto.InternalMessageInfo
+
+func (m *DiffResponse) GetChanges() []*DiffResponse_Change {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
+type DiffResponse_Change struct {
+	Kind                 string   `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	OldPath              string   `protobuf:"bytes,3,opt,name=oldPath,proto3" json:"oldPath,omitempty"`
+	IsDir                bool     `protobuf:"varint,4,opt,name=isDir,proto3" json:"isDir,omitempty"`
+	OldBlockID           []byte   `protobuf:"bytes,5,opt,name=oldBlockID,proto3" json:"oldBlockID,omitempty"`
+	OldSize              int64    `protobuf:"varint,6,opt,name=oldSize,proto3" json:"oldSize,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,7,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	Size                 int64    `protobuf:"varint,8,opt,name=size,proto3" json:"size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiffResponse_Change) Reset()         { *m = DiffResponse_Change{} }
+func (m *DiffResponse_Change) String() string { return proto.CompactTextString(m) }
+func (*DiffResponse_Change) ProtoMessage()    {}
+func (*DiffResponse_Change) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{33, 0}
+}
+
+func (m *DiffResponse_Change) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiffResponse_Change.Unmarshal(m, b)
+}
+func (m *DiffResponse_Change) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiffResponse_Change.Marshal(b, m, deterministic)
+}
+func (m *DiffResponse_Change) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiffResponse_Change.Merge(m, src)
+}
+func (m *DiffResponse_Change) XXX_Size() int {
+	return xxx_messageInfo_DiffResponse_Change.Size(m)
+}
+func (m *DiffResponse_Change) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiffResponse_Change.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiffResponse_Change proto.InternalMessageInfo
+
+func (m *DiffResponse_Change) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *DiffResponse_Change) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *DiffResponse_Change) GetOldPath() string {
+	if m != nil {
+		return m.OldPath
+	}
+	return ""
+}
+
+func (m *DiffResponse_Change) GetIsDir() bool {
+	if m != nil {
+		return m.IsDir
+	}
+	return false
+}
+
+func (m *DiffResponse_Change) GetOldBlockID() []byte {
+	if m != nil {
+		return m.OldBlockID
+	}
+	return nil
+}
+
+func (m *DiffResponse_Change) GetOldSize() int64 {
+	if m != nil {
+		return m.OldSize
+	}
+	return 0
+}
+
+func (m *DiffResponse_Change) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *DiffResponse_Change) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type MergeRequest struct {
+	Base                 string   `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	A                    string   `protobuf:"bytes,2,opt,name=a,proto3" json:"a,omitempty"`
+	B                    string   `protobuf:"bytes,3,opt,name=b,proto3" json:"b,omitempty"`
+	Label                string   `protobuf:"bytes,4,opt,name=label,proto3" json:"label,omitempty"`
+	Message              string   `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MergeRequest) Reset()         { *m = MergeRequest{} }
+func (m *MergeRequest) String() string { return proto.CompactTextString(m) }
+func (*MergeRequest) ProtoMessage()    {}
+func (*MergeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{34}
+}
+
+func (m *MergeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MergeRequest.Unmarshal(m, b)
+}
+func (m *MergeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MergeRequest.Marshal(b, m, deterministic)
+}
+func (m *MergeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MergeRequest.Merge(m, src)
+}
+func (m *MergeRequest) XXX_Size() int {
+	return xxx_messageInfo_MergeRequest.Size(m)
+}
+func (m *MergeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MergeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MergeRequest proto.InternalMessageInfo
+
+func (m *MergeRequest) GetBase() string {
+	if m != nil {
+		return m.Base
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetA() string {
+	if m != nil {
+		return m.A
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetB() string {
+	if m != nil {
+		return m.B
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *MergeRequest) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type MergeResponse struct {
+	BlockID              []byte                    `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	Conflicts            []*MergeResponse_Conflict `protobuf:"bytes,2,rep,name=conflicts,proto3" json:"conflicts,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                  `json:"-"`
+	XXX_unrecognized     []byte                    `json:"-"`
+	XXX_sizecache        int32                     `json:"-"`
+}
+
+func (m *MergeResponse) Reset()         { *m = MergeResponse{} }
+func (m *MergeResponse) String() string { return proto.CompactTextString(m) }
+func (*MergeResponse) ProtoMessage()    {}
+func (*MergeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{35}
+}
+
+func (m *MergeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MergeResponse.Unmarshal(m, b)
+}
+func (m *MergeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MergeResponse.Marshal(b, m, deterministic)
+}
+func (m *MergeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MergeResponse.Merge(m, src)
+}
+func (m *MergeResponse) XXX_Size() int {
+	return xxx_messageInfo_MergeResponse.Size(m)
+}
+func (m *MergeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MergeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MergeResponse proto.InternalMessageInfo
+
+func (m *MergeResponse) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *MergeResponse) GetConflicts() []*MergeResponse_Conflict {
+	if m != nil {
+		return m.Conflicts
+	}
+	return nil
+}
+
+type MergeResponse_Conflict struct {
+	Kind                 string   `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MergeResponse_Conflict) Reset()         { *m = MergeResponse_Conflict{} }
+func (m *MergeResponse_Conflict) String() string { return proto.CompactTextString(m) }
+func (*MergeResponse_Conflict) ProtoMessage()    {}
+func (*MergeResponse_Conflict) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{35, 0}
+}
+
+func (m *MergeResponse_Conflict) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MergeResponse_Conflict.Unmarshal(m, b)
+}
+func (m *MergeResponse_Conflict) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MergeResponse_Conflict.Marshal(b, m, deterministic)
+}
+func (m *MergeResponse_Conflict) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MergeResponse_Conflict.Merge(m, src)
+}
+func (m *MergeResponse_Conflict) XXX_Size() int {
+	return xxx_messageInfo_MergeResponse_Conflict.Size(m)
+}
+func (m *MergeResponse_Conflict) XXX_DiscardUnknown() {
+	xxx_messageInfo_MergeResponse_Conflict.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MergeResponse_Conflict proto.InternalMessageInfo
+
+func (m *MergeResponse_Conflict) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *MergeResponse_Conflict) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type CreateSnapshotRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateSnapshotRequest) Reset()         { *m = CreateSnapshotRequest{} }
+func (m *CreateSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateSnapshotRequest) ProtoMessage()    {}
+func (*CreateSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{36}
+}
+
+func (m *CreateSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateSnapshotRequest.Unmarshal(m, b)
+}
+func (m *CreateSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateSnapshotRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateSnapshotRequest.Merge(m, src)
+}
+func (m *CreateSnapshotRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateSnapshotRequest.Size(m)
+}
+func (m *CreateSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateSnapshotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateSnapshotRequest proto.InternalMessageInfo
+
+func (m *CreateSnapshotRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *CreateSnapshotRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type CreateSnapshotResponse struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateSnapshotResponse) Reset()         { *m = CreateSnapshotResponse{} }
+func (m *CreateSnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*CreateSnapshotResponse) ProtoMessage()    {}
+func (*CreateSnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{37}
+}
+
+func (m *CreateSnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateSnapshotResponse.Unmarshal(m, b)
+}
+func (m *CreateSnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateSnapshotResponse.Marshal(b, m, deterministic)
+}
+func (m *CreateSnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateSnapshotResponse.Merge(m, src)
+}
+func (m *CreateSnapshotResponse) XXX_Size() int {
+	return xxx_messageInfo_CreateSnapshotResponse.Size(m)
+}
+func (m *CreateSnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateSnapshotResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateSnapshotResponse proto.InternalMessageInfo
+
+func (m *CreateSnapshotResponse) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+type ListSnapshotsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListSnapshotsRequest) Reset()         { *m = ListSnapshotsRequest{} }
+func (m *ListSnapshotsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListSnapshotsRequest) ProtoMessage()    {}
+func (*ListSnapshotsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{38}
+}
+
+func (m *ListSnapshotsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSnapshotsRequest.Unmarshal(m, b)
+}
+func (m *ListSnapshotsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSnapshotsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListSnapshotsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSnapshotsRequest.Merge(m, src)
+}
+func (m *ListSnapshotsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListSnapshotsRequest.Size(m)
+}
+func (m *ListSnapshotsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSnapshotsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSnapshotsRequest proto.InternalMessageInfo
+
+type ListSnapshotsResponse struct {
+	Snapshots            []*ListSnapshotsResponse_Snapshot `protobuf:"bytes,1,rep,name=snapshots,proto3" json:"snapshots,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *ListSnapshotsResponse) Reset()         { *m = ListSnapshotsResponse{} }
+func (m *ListSnapshotsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListSnapshotsResponse) ProtoMessage()    {}
+func (*ListSnapshotsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{39}
+}
+
+func (m *ListSnapshotsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSnapshotsResponse.Unmarshal(m, b)
+}
+func (m *ListSnapshotsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSnapshotsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListSnapshotsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSnapshotsResponse.Merge(m, src)
+}
+func (m *ListSnapshotsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListSnapshotsResponse.Size(m)
+}
+func (m *ListSnapshotsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSnapshotsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSnapshotsResponse proto.InternalMessageInfo
+
+func (m *ListSnapshotsResponse) GetSnapshots() []*ListSnapshotsResponse_Snapshot {
+	if m != nil {
+		return m.Snapshots
+	}
+	return nil
+}
+
+type ListSnapshotsResponse_Snapshot struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,3,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	CreatedNanos         int64    `protobuf:"varint,4,opt,name=createdNanos,proto3" json:"createdNanos,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListSnapshotsResponse_Snapshot) Reset()         { *m = ListSnapshotsResponse_Snapshot{} }
+func (m *ListSnapshotsResponse_Snapshot) String() string { return proto.CompactTextString(m) }
+func (*ListSnapshotsResponse_Snapshot) ProtoMessage()    {}
+func (*ListSnapshotsResponse_Snapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{39, 0}
+}
+
+func (m *ListSnapshotsResponse_Snapshot) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSnapshotsResponse_Snapshot.Unmarshal(m, b)
+}
+func (m *ListSnapshotsResponse_Snapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSnapshotsResponse_Snapshot.Marshal(b, m, deterministic)
+}
+func (m *ListSnapshotsResponse_Snapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSnapshotsResponse_Snapshot.Merge(m, src)
+}
+func (m *ListSnapshotsResponse_Snapshot) XXX_Size() int {
+	return xxx_messageInfo_ListSnapshotsResponse_Snapshot.Size(m)
+}
+func (m *ListSnapshotsResponse_Snapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSnapshotsResponse_Snapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSnapshotsResponse_Snapshot proto.InternalMessageInfo
+
+func (m *ListSnapshotsResponse_Snapshot) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ListSnapshotsResponse_Snapshot) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ListSnapshotsResponse_Snapshot) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *ListSnapshotsResponse_Snapshot) GetCreatedNanos() int64 {
+	if m != nil {
+		return m.CreatedNanos
+	}
+	return 0
+}
+
+type RestoreSnapshotRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestoreSnapshotRequest) Reset()         { *m = RestoreSnapshotRequest{} }
+func (m *RestoreSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreSnapshotRequest) ProtoMessage()    {}
+func (*RestoreSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{40}
+}
+
+func (m *RestoreSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreSnapshotRequest.Unmarshal(m, b)
+}
+func (m *RestoreSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreSnapshotRequest.Marshal(b, m, deterministic)
+}
+func (m *RestoreSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreSnapshotRequest.Merge(m, src)
+}
+func (m *RestoreSnapshotRequest) XXX_Size() int {
+	return xxx_messageInfo_RestoreSnapshotRequest.Size(m)
+}
+func (m *RestoreSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreSnapshotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreSnapshotRequest proto.InternalMessageInfo
+
+func (m *RestoreSnapshotRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RestoreSnapshotRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+type RestoreSnapshotResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestoreSnapshotResponse) Reset()         { *m = RestoreSnapshotResponse{} }
+func (m *RestoreSnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*RestoreSnapshotResponse) ProtoMessage()    {}
+func (*RestoreSnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{41}
+}
+
+func (m *RestoreSnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreSnapshotResponse.Unmarshal(m, b)
+}
+func (m *RestoreSnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreSnapshotResponse.Marshal(b, m, deterministic)
+}
+func (m *RestoreSnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreSnapshotResponse.Merge(m, src)
+}
+func (m *RestoreSnapshotResponse) XXX_Size() int {
+	return xxx_messageInfo_RestoreSnapshotResponse.Size(m)
+}
+func (m *RestoreSnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreSnapshotResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreSnapshotResponse proto.InternalMessageInfo
+
+type DeleteSnapshotRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteSnapshotRequest) Reset()         { *m = DeleteSnapshotRequest{} }
+func (m *DeleteSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteSnapshotRequest) ProtoMessage()    {}
+func (*DeleteSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{42}
+}
+
+func (m *DeleteSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteSnapshotRequest.Unmarshal(m, b)
+}
+func (m *DeleteSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteSnapshotRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteSnapshotRequest.Merge(m, src)
+}
+func (m *DeleteSnapshotRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteSnapshotRequest.Size(m)
+}
+func (m *DeleteSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteSnapshotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteSnapshotRequest proto.InternalMessageInfo
+
+func (m *DeleteSnapshotRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type DeleteSnapshotResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteSnapshotResponse) Reset()         { *m = DeleteSnapshotResponse{} }
+func (m *DeleteSnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteSnapshotResponse) ProtoMessage()    {}
+func (*DeleteSnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{43}
+}
+
+func (m *DeleteSnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteSnapshotResponse.Unmarshal(m, b)
+}
+func (m *DeleteSnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteSnapshotResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteSnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteSnapshotResponse.Merge(m, src)
+}
+func (m *DeleteSnapshotResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteSnapshotResponse.Size(m)
+}
+func (m *DeleteSnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteSnapshotResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteSnapshotResponse proto.InternalMessageInfo
+
+type CheckoutRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Label                string   `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	Force                bool     `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckoutRequest) Reset()         { *m = CheckoutRequest{} }
+func (m *CheckoutRequest) String() string { return proto.CompactTextString(m) }
+func (*CheckoutRequest) ProtoMessage()    {}
+func (*CheckoutRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{44}
+}
+
+func (m *CheckoutRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckoutRequest.Unmarshal(m, b)
+}
+func (m *CheckoutRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckoutRequest.Marshal(b, m, deterministic)
+}
+func (m *CheckoutRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckoutRequest.Merge(m, src)
+}
+func (m *CheckoutRequest) XXX_Size() int {
+	return xxx_messageInfo_CheckoutRequest.Size(m)
+}
+func (m *CheckoutRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckoutRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckoutRequest proto.InternalMessageInfo
+
+func (m *CheckoutRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *CheckoutRequest) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *CheckoutRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+type CheckoutResponse struct {
+	BlockID              []byte   `protobuf:"bytes,1,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CheckoutResponse) Reset()         { *m = CheckoutResponse{} }
+func (m *CheckoutResponse) String() string { return proto.CompactTextString(m) }
+func (*CheckoutResponse) ProtoMessage()    {}
+func (*CheckoutResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{45}
+}
+
+func (m *CheckoutResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CheckoutResponse.Unmarshal(m, b)
+}
+func (m *CheckoutResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CheckoutResponse.Marshal(b, m, deterministic)
+}
+func (m *CheckoutResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CheckoutResponse.Merge(m, src)
+}
+func (m *CheckoutResponse) XXX_Size() int {
+	return xxx_messageInfo_CheckoutResponse.Size(m)
+}
+func (m *CheckoutResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CheckoutResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CheckoutResponse proto.InternalMessageInfo
+
+func (m *CheckoutResponse) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+type UnmountLabelRequest struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Force                bool     `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnmountLabelRequest) Reset()         { *m = UnmountLabelRequest{} }
+func (m *UnmountLabelRequest) String() string { return proto.CompactTextString(m) }
+func (*UnmountLabelRequest) ProtoMessage()    {}
+func (*UnmountLabelRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{46}
+}
+
+func (m *UnmountLabelRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnmountLabelRequest.Unmarshal(m, b)
+}
+func (m *UnmountLabelRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnmountLabelRequest.Marshal(b, m, deterministic)
+}
+func (m *UnmountLabelRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnmountLabelRequest.Merge(m, src)
+}
+func (m *UnmountLabelRequest) XXX_Size() int {
+	return xxx_messageInfo_UnmountLabelRequest.Size(m)
+}
+func (m *UnmountLabelRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnmountLabelRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnmountLabelRequest proto.InternalMessageInfo
+
+func (m *UnmountLabelRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *UnmountLabelRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+type UnmountLabelResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UnmountLabelResponse) Reset()         { *m = UnmountLabelResponse{} }
+func (m *UnmountLabelResponse) String() string { return proto.CompactTextString(m) }
+func (*UnmountLabelResponse) ProtoMessage()    {}
+func (*UnmountLabelResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{47}
+}
+
+func (m *UnmountLabelResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UnmountLabelResponse.Unmarshal(m, b)
+}
+func (m *UnmountLabelResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UnmountLabelResponse.Marshal(b, m, deterministic)
+}
+func (m *UnmountLabelResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UnmountLabelResponse.Merge(m, src)
+}
+func (m *UnmountLabelResponse) XXX_Size() int {
+	return xxx_messageInfo_UnmountLabelResponse.Size(m)
+}
+func (m *UnmountLabelResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UnmountLabelResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UnmountLabelResponse proto.InternalMessageInfo
+
+type ListLeasesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListLeasesRequest) Reset()         { *m = ListLeasesRequest{} }
+func (m *ListLeasesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListLeasesRequest) ProtoMessage()    {}
+func (*ListLeasesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{48}
+}
+
+func (m *ListLeasesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLeasesRequest.Unmarshal(m, b)
+}
+func (m *ListLeasesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLeasesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListLeasesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLeasesRequest.Merge(m, src)
+}
+func (m *ListLeasesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListLeasesRequest.Size(m)
+}
+func (m *ListLeasesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLeasesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLeasesRequest proto.InternalMessageInfo
+
+type ListLeasesResponse struct {
+	Leases               []*ListLeasesResponse_Lease `protobuf:"bytes,1,rep,name=leases,proto3" json:"leases,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                    `json:"-"`
+	XXX_unrecognized     []byte                      `json:"-"`
+	XXX_sizecache        int32                       `json:"-"`
+}
+
+func (m *ListLeasesResponse) Reset()         { *m = ListLeasesResponse{} }
+func (m *ListLeasesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListLeasesResponse) ProtoMessage()    {}
+func (*ListLeasesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{49}
+}
+
+func (m *ListLeasesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLeasesResponse.Unmarshal(m, b)
+}
+func (m *ListLeasesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLeasesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListLeasesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLeasesResponse.Merge(m, src)
+}
+func (m *ListLeasesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListLeasesResponse.Size(m)
+}
+func (m *ListLeasesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLeasesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLeasesResponse proto.InternalMessageInfo
+
+func (m *ListLeasesResponse) GetLeases() []*ListLeasesResponse_Lease {
+	if m != nil {
+		return m.Leases
+	}
+	return nil
+}
+
+type ListLeasesResponse_Lease struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	BlockID              []byte   `protobuf:"bytes,2,opt,name=blockID,proto3" json:"blockID,omitempty"`
+	ExpiryNanos          int64    `protobuf:"varint,3,opt,name=expiryNanos,proto3" json:"expiryNanos,omitempty"`
+	Host                 string   `protobuf:"bytes,4,opt,name=host,proto3" json:"host,omitempty"`
+	Repo                 string   `protobuf:"bytes,5,opt,name=repo,proto3" json:"repo,omitempty"`
+	IsOwn                bool     `protobuf:"varint,6,opt,name=isOwn,proto3" json:"isOwn,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListLeasesResponse_Lease) Reset()         { *m = ListLeasesResponse_Lease{} }
+func (m *ListLeasesResponse_Lease) String() string { return proto.CompactTextString(m) }
+func (*ListLeasesResponse_Lease) ProtoMessage()    {}
+func (*ListLeasesResponse_Lease) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{49, 0}
+}
+
+func (m *ListLeasesResponse_Lease) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListLeasesResponse_Lease.Unmarshal(m, b)
+}
+func (m *ListLeasesResponse_Lease) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListLeasesResponse_Lease.Marshal(b, m, deterministic)
+}
+func (m *ListLeasesResponse_Lease) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListLeasesResponse_Lease.Merge(m, src)
+}
+func (m *ListLeasesResponse_Lease) XXX_Size() int {
+	return xxx_messageInfo_ListLeasesResponse_Lease.Size(m)
+}
+func (m *ListLeasesResponse_Lease) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListLeasesResponse_Lease.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListLeasesResponse_Lease proto.InternalMessageInfo
+
+func (m *ListLeasesResponse_Lease) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ListLeasesResponse_Lease) GetBlockID() []byte {
+	if m != nil {
+		return m.BlockID
+	}
+	return nil
+}
+
+func (m *ListLeasesResponse_Lease) GetExpiryNanos() int64 {
+	if m != nil {
+		return m.ExpiryNanos
+	}
+	return 0
+}
+
+func (m *ListLeasesResponse_Lease) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *ListLeasesResponse_Lease) GetRepo() string {
+	if m != nil {
+		return m.Repo
+	}
+	return ""
+}
+
+func (m *ListLeasesResponse_Lease) GetIsOwn() bool {
+	if m != nil {
+		return m.IsOwn
+	}
+	return false
+}
+
+type ApplyRequest struct {
+	Links                []*ApplyRequest_Link `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	Prune                bool                 `protobuf:"varint,2,opt,name=prune,proto3" json:"prune,omitempty"`
+	Force                bool                 `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
+	DryRun               bool                 `protobuf:"varint,4,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ApplyRequest) Reset()         { *m = ApplyRequest{} }
+func (m *ApplyRequest) String() string { return proto.CompactTextString(m) }
+func (*ApplyRequest) ProtoMessage()    {}
+func (*ApplyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{50}
+}
+
+func (m *ApplyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApplyRequest.Unmarshal(m, b)
+}
+func (m *ApplyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApplyRequest.Marshal(b, m, deterministic)
+}
+func (m *ApplyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApplyRequest.Merge(m, src)
+}
+func (m *ApplyRequest) XXX_Size() int {
+	return xxx_messageInfo_ApplyRequest.Size(m)
+}
+func (m *ApplyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApplyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApplyRequest proto.InternalMessageInfo
+
+func (m *ApplyRequest) GetLinks() []*ApplyRequest_Link {
+	if m != nil {
+		return m.Links
+	}
+	return nil
+}
+
+func (m *ApplyRequest) GetPrune() bool {
+	if m != nil {
+		return m.Prune
+	}
+	return false
+}
+
+func (m *ApplyRequest) GetForce() bool {
+	if m != nil {
+		return m.Force
+	}
+	return false
+}
+
+func (m *ApplyRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type ApplyRequest_Link struct {
+	Path                 string   `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Source               string   `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Generation           int64    `protobuf:"varint,3,opt,name=generation,proto3" json:"generation,omitempty"`
+	Etag                 string   `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ApplyRequest_Link) Reset()         { *m = ApplyRequest_Link{} }
+func (m *ApplyRequest_Link) String() string { return proto.CompactTextString(m) }
+func (*ApplyRequest_Link) ProtoMessage()    {}
+func (*ApplyRequest_Link) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{50, 0}
+}
+
+func (m *ApplyRequest_Link) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApplyRequest_Link.Unmarshal(m, b)
+}
+func (m *ApplyRequest_Link) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApplyRequest_Link.Marshal(b, m, deterministic)
+}
+func (m *ApplyRequest_Link) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApplyRequest_Link.Merge(m, src)
+}
+func (m *ApplyRequest_Link) XXX_Size() int {
+	return xxx_messageInfo_ApplyRequest_Link.Size(m)
+}
+func (m *ApplyRequest_Link) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApplyRequest_Link.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApplyRequest_Link proto.InternalMessageInfo
+
+func (m *ApplyRequest_Link) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ApplyRequest_Link) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *ApplyRequest_Link) GetGeneration() int64 {
+	if m != nil {
+		return m.Generation
+	}
+	return 0
+}
+
+func (m *ApplyRequest_Link) GetEtag() string {
+	if m != nil {
+		return m.Etag
+	}
+	return ""
+}
+
+type ApplyResponse struct {
+	Changes              []*ApplyResponse_Change `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	Applied              bool                    `protobuf:"varint,2,opt,name=applied,proto3" json:"applied,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *ApplyResponse) Reset()         { *m = ApplyResponse{} }
+func (m *ApplyResponse) String() string { return proto.CompactTextString(m) }
+func (*ApplyResponse) ProtoMessage()    {}
+func (*ApplyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{51}
+}
+
+func (m *ApplyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApplyResponse.Unmarshal(m, b)
+}
+func (m *ApplyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApplyResponse.Marshal(b, m, deterministic)
+}
+func (m *ApplyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApplyResponse.Merge(m, src)
+}
+func (m *ApplyResponse) XXX_Size() int {
+	return xxx_messageInfo_ApplyResponse.Size(m)
+}
+func (m *ApplyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApplyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApplyResponse proto.InternalMessageInfo
+
+func (m *ApplyResponse) GetChanges() []*ApplyResponse_Change {
+	if m != nil {
+		return m.Changes
+	}
+	return nil
+}
+
+func (m *ApplyResponse) GetApplied() bool {
+	if m != nil {
+		return m.Applied
+	}
+	return false
+}
+
+type ApplyResponse_Change struct {
+	Action               string   `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	Path                 string   `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Source               string   `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	Detail               string   `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ApplyResponse_Change) Reset()         { *m = ApplyResponse_Change{} }
+func (m *ApplyResponse_Change) String() string { return proto.CompactTextString(m) }
+func (*ApplyResponse_Change) ProtoMessage()    {}
+func (*ApplyResponse_Change) Descriptor() ([]byte, []int) {
+	return fileDescriptor_00212fb1f9d3bf1c, []int{51, 0}
+}
+
+func (m *ApplyResponse_Change) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ApplyResponse_Change.Unmarshal(m, b)
+}
+func (m *ApplyResponse_Change) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ApplyResponse_Change.Marshal(b, m, deterministic)
+}
+func (m *ApplyResponse_Change) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ApplyResponse_Change.Merge(m, src)
+}
+func (m *ApplyResponse_Change) XXX_Size() int {
+	return xxx_messageInfo_ApplyResponse_Change.Size(m)
+}
+func (m *ApplyResponse_Change) XXX_DiscardUnknown() {
+	xxx_messageInfo_ApplyResponse_Change.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ApplyResponse_Change proto.InternalMessageInfo
+
+func (m *ApplyResponse_Change) GetAction() string {
+	if m != nil {
+		return m.Action
+	}
+	return ""
+}
+
+func (m *ApplyResponse_Change) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *ApplyResponse_Change) GetSource() string {
+	if m != nil {
+		return m.Source
+	}
+	return ""
+}
+
+func (m *ApplyResponse_Change) GetDetail() string {
+	if m != nil {
+		return m.Detail
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*DirContentsRequest)(nil), "api.DirContentsRequest")
 	proto.RegisterType((*DirContentsResponse)(nil), "api.DirContentsResponse")
 	proto.RegisterType((*DirContentsResponse_Entry)(nil), "api.DirContentsResponse.Entry")
+	proto.RegisterType((*ServiceInfoRequest)(nil), "api.ServiceInfoRequest")
+	proto.RegisterType((*ServiceInfoResponse)(nil), "api.ServiceInfoResponse")
+	proto.RegisterType((*PushRequest)(nil), "api.PushRequest")
+	proto.RegisterType((*PushResponse)(nil), "api.PushResponse")
+	proto.RegisterType((*AddRemoteRequest)(nil), "api.AddRemoteRequest")
+	proto.RegisterType((*AddRemoteResponse)(nil), "api.AddRemoteResponse")
+	proto.RegisterType((*MkdirRequest)(nil), "api.MkdirRequest")
+	proto.RegisterType((*MkdirResponse)(nil), "api.MkdirResponse")
+	proto.RegisterType((*RemoveRequest)(nil), "api.RemoveRequest")
+	proto.RegisterType((*RemoveResponse)(nil), "api.RemoveResponse")
+	proto.RegisterType((*RenameRequest)(nil), "api.RenameRequest")
+	proto.RegisterType((*RenameResponse)(nil), "api.RenameResponse")
+	proto.RegisterType((*FreezeRequest)(nil), "api.FreezeRequest")
+	proto.RegisterType((*FreezeResponse)(nil), "api.FreezeResponse")
+	proto.RegisterType((*PrefetchRequest)(nil), "api.PrefetchRequest")
+	proto.RegisterType((*PrefetchResponse)(nil), "api.PrefetchResponse")
+	proto.RegisterType((*EvictRequest)(nil), "api.EvictRequest")
+	proto.RegisterType((*EvictResponse)(nil), "api.EvictResponse")
+	proto.RegisterType((*RefreshRequest)(nil), "api.RefreshRequest")
+	proto.RegisterType((*RefreshResponse)(nil), "api.RefreshResponse")
+	proto.RegisterType((*StatsRequest)(nil), "api.StatsRequest")
+	proto.RegisterType((*StatsResponse)(nil), "api.StatsResponse")
+	proto.RegisterType((*StatsResponse_Transfer)(nil), "api.StatsResponse.Transfer")
+	proto.RegisterType((*StatusRequest)(nil), "api.StatusRequest")
+	proto.RegisterType((*StatusResponse)(nil), "api.StatusResponse")
+	proto.RegisterType((*StatusResponse_Transfer)(nil), "api.StatusResponse.Transfer")
+	proto.RegisterType((*StatusResponse_Copy)(nil), "api.StatusResponse.Copy")
+	proto.RegisterType((*StatusResponse_Mount)(nil), "api.StatusResponse.Mount")
+	proto.RegisterType((*WatchEventsRequest)(nil), "api.WatchEventsRequest")
+	proto.RegisterType((*Event)(nil), "api.Event")
+	proto.RegisterType((*ListLabelsRequest)(nil), "api.ListLabelsRequest")
+	proto.RegisterType((*ListLabelsResponse)(nil), "api.ListLabelsResponse")
+	proto.RegisterType((*LabelHistoryRequest)(nil), "api.LabelHistoryRequest")
+	proto.RegisterType((*LabelHistoryResponse)(nil), "api.LabelHistoryResponse")
+	proto.RegisterType((*LabelHistoryResponse_Version)(nil), "api.LabelHistoryResponse.Version")
+	proto.RegisterType((*DiffRequest)(nil), "api.DiffRequest")
+	proto.RegisterType((*DiffResponse)(nil), "api.DiffResponse")
+	proto.RegisterType((*DiffResponse_Change)(nil), "api.DiffResponse.Change")
+	proto.RegisterType((*MergeRequest)(nil), "api.MergeRequest")
+	proto.RegisterType((*MergeResponse)(nil), "api.MergeResponse")
+	proto.RegisterType((*MergeResponse_Conflict)(nil), "api.MergeResponse.Conflict")
+	proto.RegisterType((*CreateSnapshotRequest)(nil), "api.CreateSnapshotRequest")
+	proto.RegisterType((*CreateSnapshotResponse)(nil), "api.CreateSnapshotResponse")
+	proto.RegisterType((*ListSnapshotsRequest)(nil), "api.ListSnapshotsRequest")
+	proto.RegisterType((*ListSnapshotsResponse)(nil), "api.ListSnapshotsResponse")
+	proto.RegisterType((*ListSnapshotsResponse_Snapshot)(nil), "api.ListSnapshotsResponse.Snapshot")
+	proto.RegisterType((*RestoreSnapshotRequest)(nil), "api.RestoreSnapshotRequest")
+	proto.RegisterType((*RestoreSnapshotResponse)(nil), "api.RestoreSnapshotResponse")
+	proto.RegisterType((*DeleteSnapshotRequest)(nil), "api.DeleteSnapshotRequest")
+	proto.RegisterType((*DeleteSnapshotResponse)(nil), "api.DeleteSnapshotResponse")
+	proto.RegisterType((*CheckoutRequest)(nil), "api.CheckoutRequest")
+	proto.RegisterType((*CheckoutResponse)(nil), "api.CheckoutResponse")
+	proto.RegisterType((*UnmountLabelRequest)(nil), "api.UnmountLabelRequest")
+	proto.RegisterType((*UnmountLabelResponse)(nil), "api.UnmountLabelResponse")
+	proto.RegisterType((*ListLeasesRequest)(nil), "api.ListLeasesRequest")
+	proto.RegisterType((*ListLeasesResponse)(nil), "api.ListLeasesResponse")
+	proto.RegisterType((*ListLeasesResponse_Lease)(nil), "api.ListLeasesResponse.Lease")
+	proto.RegisterType((*ApplyRequest)(nil), "api.ApplyRequest")
+	proto.RegisterType((*ApplyRequest_Link)(nil), "api.ApplyRequest.Link")
+	proto.RegisterType((*ApplyResponse)(nil), "api.ApplyResponse")
+	proto.RegisterType((*ApplyResponse_Change)(nil), "api.ApplyResponse.Change")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// PufsClient is the client API for Pufs service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PufsClient interface {
+	GetDirContents(ctx context.Context, in *DirContentsRequest, opts ...grpc.CallOption) (*DirContentsResponse, error)
+	GetServiceInfo(ctx context.Context, in *ServiceInfoRequest, opts ...grpc.CallOption) (*ServiceInfoResponse, error)
+	Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*PushResponse, error)
+	AddRemote(ctx context.Context, in *AddRemoteRequest, opts ...grpc.CallOption) (*AddRemoteResponse, error)
+	Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*MkdirResponse, error)
+	Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error)
+	Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*RenameResponse, error)
+	Freeze(ctx context.Context, in *FreezeRequest, opts ...grpc.CallOption) (*FreezeResponse, error)
+	Prefetch(ctx context.Context, in *PrefetchRequest, opts ...grpc.CallOption) (*PrefetchResponse, error)
+	Evict(ctx context.Context, in *EvictRequest, opts ...grpc.CallOption) (*EvictResponse, error)
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (Pufs_WatchEventsClient, error)
+	GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+	ListLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsResponse, error)
+	GetLabelHistory(ctx context.Context, in *LabelHistoryRequest, opts ...grpc.CallOption) (*LabelHistoryResponse, error)
+	Diff(ctx context.Context, in *DiffRequest, opts ...grpc.CallOption) (*DiffResponse, error)
+	Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error)
+	CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error)
+	ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error)
+	RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error)
+	DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error)
+	Checkout(ctx context.Context, in *CheckoutRequest, opts ...grpc.CallOption) (*CheckoutResponse, error)
+	UnmountLabel(ctx context.Context, in *UnmountLabelRequest, opts ...grpc.CallOption) (*UnmountLabelResponse, error)
+	ListLeases(ctx context.Context, in *ListLeasesRequest, opts ...grpc.CallOption) (*ListLeasesResponse, error)
+	Apply(ctx context.Context, in *ApplyRequest, opts ...grpc.CallOption) (*ApplyResponse, error)
+}
+
+type pufsClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPufsClient(cc *grpc.ClientConn) PufsClient {
+	return &pufsClient{cc}
+}
+
+func (c *pufsClient) GetDirContents(ctx context.Context, in *DirContentsRequest, opts ...grpc.CallOption) (*DirContentsResponse, error) {
+	out := new(DirContentsResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/GetDirContents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) GetServiceInfo(ctx context.Context, in *ServiceInfoRequest, opts ...grpc.CallOption) (*ServiceInfoResponse, error) {
+	out := new(ServiceInfoResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/GetServiceInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Push(ctx context.Context, in *PushRequest, opts ...grpc.CallOption) (*PushResponse, error) {
+	out := new(PushResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Push", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) AddRemote(ctx context.Context, in *AddRemoteRequest, opts ...grpc.CallOption) (*AddRemoteResponse, error) {
+	out := new(AddRemoteResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/AddRemote", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Mkdir(ctx context.Context, in *MkdirRequest, opts ...grpc.CallOption) (*MkdirResponse, error) {
+	out := new(MkdirResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Mkdir", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Remove(ctx context.Context, in *RemoveRequest, opts ...grpc.CallOption) (*RemoveResponse, error) {
+	out := new(RemoveResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Remove", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Rename(ctx context.Context, in *RenameRequest, opts ...grpc.CallOption) (*RenameResponse, error) {
+	out := new(RenameResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Rename", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Freeze(ctx context.Context, in *FreezeRequest, opts ...grpc.CallOption) (*FreezeResponse, error) {
+	out := new(FreezeResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Freeze", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Prefetch(ctx context.Context, in *PrefetchRequest, opts ...grpc.CallOption) (*PrefetchResponse, error) {
+	out := new(PrefetchResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Prefetch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Evict(ctx context.Context, in *EvictRequest, opts ...grpc.CallOption) (*EvictResponse, error) {
+	out := new(EvictResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Evict", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	out := new(RefreshResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Refresh", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/GetStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) WatchEvents(ctx context.Context, in *WatchEventsRequest, opts ...grpc.CallOption) (Pufs_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Pufs_serviceDesc.Streams[0], "/api.Pufs/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pufsWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Pufs_WatchEventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type pufsWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *pufsWatchEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pufsClient) GetStatus(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) ListLabels(ctx context.Context, in *ListLabelsRequest, opts ...grpc.CallOption) (*ListLabelsResponse, error) {
+	out := new(ListLabelsResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/ListLabels", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) GetLabelHistory(ctx context.Context, in *LabelHistoryRequest, opts ...grpc.CallOption) (*LabelHistoryResponse, error) {
+	out := new(LabelHistoryResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/GetLabelHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Diff(ctx context.Context, in *DiffRequest, opts ...grpc.CallOption) (*DiffResponse, error) {
+	out := new(DiffResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Diff", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Merge(ctx context.Context, in *MergeRequest, opts ...grpc.CallOption) (*MergeResponse, error) {
+	out := new(MergeResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Merge", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) CreateSnapshot(ctx context.Context, in *CreateSnapshotRequest, opts ...grpc.CallOption) (*CreateSnapshotResponse, error) {
+	out := new(CreateSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/CreateSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) ListSnapshots(ctx context.Context, in *ListSnapshotsRequest, opts ...grpc.CallOption) (*ListSnapshotsResponse, error) {
+	out := new(ListSnapshotsResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/ListSnapshots", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) RestoreSnapshot(ctx context.Context, in *RestoreSnapshotRequest, opts ...grpc.CallOption) (*RestoreSnapshotResponse, error) {
+	out := new(RestoreSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/RestoreSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) DeleteSnapshot(ctx context.Context, in *DeleteSnapshotRequest, opts ...grpc.CallOption) (*DeleteSnapshotResponse, error) {
+	out := new(DeleteSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/DeleteSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Checkout(ctx context.Context, in *CheckoutRequest, opts ...grpc.CallOption) (*CheckoutResponse, error) {
+	out := new(CheckoutResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Checkout", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) UnmountLabel(ctx context.Context, in *UnmountLabelRequest, opts ...grpc.CallOption) (*UnmountLabelResponse, error) {
+	out := new(UnmountLabelResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/UnmountLabel", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) ListLeases(ctx context.Context, in *ListLeasesRequest, opts ...grpc.CallOption) (*ListLeasesResponse, error) {
+	out := new(ListLeasesResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/ListLeases", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pufsClient) Apply(ctx context.Context, in *ApplyRequest, opts ...grpc.CallOption) (*ApplyResponse, error) {
+	out := new(ApplyResponse)
+	err := c.cc.Invoke(ctx, "/api.Pufs/Apply", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PufsServer is the server API for Pufs service.
+type PufsServer interface {
+	GetDirContents(context.Context, *DirContentsRequest) (*DirContentsResponse, error)
+	GetServiceInfo(context.Context, *ServiceInfoRequest) (*ServiceInfoResponse, error)
+	Push(context.Context, *PushRequest) (*PushResponse, error)
+	AddRemote(context.Context, *AddRemoteRequest) (*AddRemoteResponse, error)
+	Mkdir(context.Context, *MkdirRequest) (*MkdirResponse, error)
+	Remove(context.Context, *RemoveRequest) (*RemoveResponse, error)
+	Rename(context.Context, *RenameRequest) (*RenameResponse, error)
+	Freeze(context.Context, *FreezeRequest) (*FreezeResponse, error)
+	Prefetch(context.Context, *PrefetchRequest) (*PrefetchResponse, error)
+	Evict(context.Context, *EvictRequest) (*EvictResponse, error)
+	Refresh(context.Context, *RefreshRequest) (*RefreshResponse, error)
+	GetStats(context.Context, *StatsRequest) (*StatsResponse, error)
+	WatchEvents(*WatchEventsRequest, Pufs_WatchEventsServer) error
+	GetStatus(context.Context, *StatusRequest) (*StatusResponse, error)
+	ListLabels(context.Context, *ListLabelsRequest) (*ListLabelsResponse, error)
+	GetLabelHistory(context.Context, *LabelHistoryRequest) (*LabelHistoryResponse, error)
+	Diff(context.Context, *DiffRequest) (*DiffResponse, error)
+	Merge(context.Context, *MergeRequest) (*MergeResponse, error)
+	CreateSnapshot(context.Context, *CreateSnapshotRequest) (*CreateSnapshotResponse, error)
+	ListSnapshots(context.Context, *ListSnapshotsRequest) (*ListSnapshotsResponse, error)
+	RestoreSnapshot(context.Context, *RestoreSnapshotRequest) (*RestoreSnapshotResponse, error)
+	DeleteSnapshot(context.Context, *DeleteSnapshotRequest) (*DeleteSnapshotResponse, error)
+	Checkout(context.Context, *CheckoutRequest) (*CheckoutResponse, error)
+	UnmountLabel(context.Context, *UnmountLabelRequest) (*UnmountLabelResponse, error)
+	ListLeases(context.Context, *ListLeasesRequest) (*ListLeasesResponse, error)
+	Apply(context.Context, *ApplyRequest) (*ApplyResponse, error)
+}
+
+func RegisterPufsServer(s *grpc.Server, srv PufsServer) {
+	s.RegisterService(&_Pufs_serviceDesc, srv)
+}
+
+func _Pufs_GetDirContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DirContentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).GetDirContents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/GetDirContents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).GetDirContents(ctx, req.(*DirContentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_GetServiceInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ServiceInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).GetServiceInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/GetServiceInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).GetServiceInfo(ctx, req.(*ServiceInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Push_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Push(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Push",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Push(ctx, req.(*PushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_AddRemote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRemoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).AddRemote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/AddRemote",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).AddRemote(ctx, req.(*AddRemoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Mkdir_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MkdirRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Mkdir(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Mkdir",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Mkdir(ctx, req.(*MkdirRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Remove",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Remove(ctx, req.(*RemoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Rename_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Rename(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Rename",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Rename(ctx, req.(*RenameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Freeze_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreezeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Freeze(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Freeze",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Freeze(ctx, req.(*FreezeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Prefetch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrefetchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Prefetch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Prefetch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Prefetch(ctx, req.(*PrefetchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Evict_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EvictRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Evict(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Evict",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Evict(ctx, req.(*EvictRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func _Pufs_Refresh_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Refresh(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Refresh",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Refresh(ctx, req.(*RefreshRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func _Pufs_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/GetStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).GetStats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
-// PufsClient is the client API for Pufs service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type PufsClient interface {
-	GetDirContents(ctx context.Context, in *DirContentsRequest, opts ...grpc.CallOption) (*DirContentsResponse, error)
+func _Pufs_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PufsServer).WatchEvents(m, &pufsWatchEventsServer{stream})
 }
 
-type pufsClient struct {
-	cc *grpc.ClientConn
+type Pufs_WatchEventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
 }
 
-func NewPufsClient(cc *grpc.ClientConn) PufsClient {
-	return &pufsClient{cc}
+type pufsWatchEventsServer struct {
+	grpc.ServerStream
 }
 
-func (c *pufsClient) GetDirContents(ctx context.Context, in *DirContentsRequest, opts ...grpc.CallOption) (*DirContentsResponse, error) {
-	out := new(DirContentsResponse)
-	err := c.cc.Invoke(ctx, "/api.Pufs/GetDirContents", in, out, opts...)
-	if err != nil {
+func (x *pufsWatchEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Pufs_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(PufsServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).GetStatus(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// PufsServer is the server API for Pufs service.
-type PufsServer interface {
-	GetDirContents(context.Context, *DirContentsRequest) (*DirContentsResponse, error)
+func _Pufs_ListLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLabelsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).ListLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/ListLabels",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).ListLabels(ctx, req.(*ListLabelsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterPufsServer(s *grpc.Server, srv PufsServer) {
-	s.RegisterService(&_Pufs_serviceDesc, srv)
+func _Pufs_GetLabelHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LabelHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).GetLabelHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/GetLabelHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).GetLabelHistory(ctx, req.(*LabelHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Pufs_GetDirContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DirContentsRequest)
+func _Pufs_Diff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(PufsServer).GetDirContents(ctx, in)
+		return srv.(PufsServer).Diff(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/api.Pufs/GetDirContents",
+		FullMethod: "/api.Pufs/Diff",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(PufsServer).GetDirContents(ctx, req.(*DirContentsRequest))
+		return srv.(PufsServer).Diff(ctx, req.(*DiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Merge_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Merge(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Merge",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Merge(ctx, req.(*MergeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_CreateSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).CreateSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/CreateSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).CreateSnapshot(ctx, req.(*CreateSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_ListSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).ListSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/ListSnapshots",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).ListSnapshots(ctx, req.(*ListSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_RestoreSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).RestoreSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/RestoreSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).RestoreSnapshot(ctx, req.(*RestoreSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_DeleteSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).DeleteSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/DeleteSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).DeleteSnapshot(ctx, req.(*DeleteSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Checkout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Checkout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Checkout",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Checkout(ctx, req.(*CheckoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_UnmountLabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnmountLabelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).UnmountLabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/UnmountLabel",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).UnmountLabel(ctx, req.(*UnmountLabelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_ListLeases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLeasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).ListLeases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/ListLeases",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).ListLeases(ctx, req.(*ListLeasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pufs_Apply_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PufsServer).Apply(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/api.Pufs/Apply",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PufsServer).Apply(ctx, req.(*ApplyRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -287,34 +4238,264 @@ var _Pufs_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetDirContents",
 			Handler:    _Pufs_GetDirContents_Handler,
 		},
+		{
+			MethodName: "GetServiceInfo",
+			Handler:    _Pufs_GetServiceInfo_Handler,
+		},
+		{
+			MethodName: "Push",
+			Handler:    _Pufs_Push_Handler,
+		},
+		{
+			MethodName: "AddRemote",
+			Handler:    _Pufs_AddRemote_Handler,
+		},
+		{
+			MethodName: "Mkdir",
+			Handler:    _Pufs_Mkdir_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Pufs_Remove_Handler,
+		},
+		{
+			MethodName: "Rename",
+			Handler:    _Pufs_Rename_Handler,
+		},
+		{
+			MethodName: "Freeze",
+			Handler:    _Pufs_Freeze_Handler,
+		},
+		{
+			MethodName: "Prefetch",
+			Handler:    _Pufs_Prefetch_Handler,
+		},
+		{
+			MethodName: "Evict",
+			Handler:    _Pufs_Evict_Handler,
+		},
+		{
+			MethodName: "Refresh",
+			Handler:    _Pufs_Refresh_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Pufs_GetStats_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Pufs_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListLabels",
+			Handler:    _Pufs_ListLabels_Handler,
+		},
+		{
+			MethodName: "GetLabelHistory",
+			Handler:    _Pufs_GetLabelHistory_Handler,
+		},
+		{
+			MethodName: "Diff",
+			Handler:    _Pufs_Diff_Handler,
+		},
+		{
+			MethodName: "Merge",
+			Handler:    _Pufs_Merge_Handler,
+		},
+		{
+			MethodName: "CreateSnapshot",
+			Handler:    _Pufs_CreateSnapshot_Handler,
+		},
+		{
+			MethodName: "ListSnapshots",
+			Handler:    _Pufs_ListSnapshots_Handler,
+		},
+		{
+			MethodName: "RestoreSnapshot",
+			Handler:    _Pufs_RestoreSnapshot_Handler,
+		},
+		{
+			MethodName: "DeleteSnapshot",
+			Handler:    _Pufs_DeleteSnapshot_Handler,
+		},
+		{
+			MethodName: "Checkout",
+			Handler:    _Pufs_Checkout_Handler,
+		},
+		{
+			MethodName: "UnmountLabel",
+			Handler:    _Pufs_UnmountLabel_Handler,
+		},
+		{
+			MethodName: "ListLeases",
+			Handler:    _Pufs_ListLeases_Handler,
+		},
+		{
+			MethodName: "Apply",
+			Handler:    _Pufs_Apply_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Pufs_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
 
 func init() { proto.RegisterFile("api.proto", fileDescriptor_00212fb1f9d3bf1c) }
 
 var fileDescriptor_00212fb1f9d3bf1c = []byte{
-	// 321 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0x4f, 0x4f, 0x3a, 0x31,
-	0x10, 0xfd, 0xed, 0x2e, 0xcb, 0x9f, 0xe1, 0x27, 0x87, 0x91, 0xc4, 0x86, 0x83, 0xd9, 0x10, 0x63,
-	0xf6, 0xc4, 0x01, 0x2f, 0xde, 0x59, 0x62, 0x38, 0x90, 0x98, 0xe2, 0x17, 0x58, 0x60, 0xc4, 0x46,
-	0x68, 0x6b, 0xdb, 0x3d, 0xe0, 0x57, 0xf0, 0x13, 0x7b, 0x33, 0x2d, 0x59, 0x22, 0x8a, 0xb7, 0xf7,
-	0x5e, 0x5f, 0xe6, 0xbd, 0xcc, 0x14, 0x3a, 0xa5, 0x16, 0x23, 0x6d, 0x94, 0x53, 0x98, 0x94, 0x5a,
-	0x0c, 0x73, 0xc0, 0x42, 0x98, 0x89, 0x92, 0x8e, 0xa4, 0xb3, 0x9c, 0xde, 0x2a, 0xb2, 0x0e, 0x11,
-	0x1a, 0xba, 0x74, 0x2f, 0x2c, 0xca, 0xa2, 0xbc, 0xc3, 0x03, 0x1e, 0x7e, 0xc6, 0x70, 0x79, 0x62,
-	0xb5, 0x5a, 0x49, 0x4b, 0x78, 0x0f, 0x2d, 0x92, 0xce, 0x08, 0xb2, 0x0c, 0xb2, 0x24, 0xef, 0x8e,
-	0xaf, 0x47, 0x3e, 0xe3, 0x8c, 0x75, 0x34, 0x95, 0xce, 0xec, 0x79, 0x6d, 0xc7, 0x01, 0xb4, 0xc9,
-	0x18, 0x65, 0xe6, 0x76, 0xc3, 0xba, 0x21, 0xe9, 0xc8, 0x07, 0x1f, 0x31, 0xa4, 0xc1, 0x8e, 0x3d,
-	0x88, 0x67, 0x45, 0x68, 0x92, 0xf0, 0x78, 0x56, 0xf8, 0x6e, 0xb2, 0xdc, 0x11, 0x8b, 0x0f, 0xdd,
-	0x3c, 0x46, 0x06, 0x2d, 0x61, 0x0b, 0x61, 0xdc, 0x9e, 0x25, 0x59, 0x94, 0xb7, 0x79, 0x4d, 0xb1,
-	0x0f, 0x69, 0x80, 0xac, 0x11, 0xf4, 0x03, 0xf1, 0x33, 0xac, 0x78, 0x27, 0x96, 0x86, 0xa9, 0x01,
-	0xe3, 0x2d, 0xf4, 0x76, 0x6a, 0xfd, 0x24, 0x76, 0xb4, 0xa0, 0x95, 0x92, 0x6b, 0xcb, 0x9a, 0xe1,
-	0xf5, 0x87, 0xea, 0xb3, 0x96, 0x5b, 0xb5, 0x7a, 0x9d, 0x15, 0xac, 0x95, 0x45, 0xf9, 0x7f, 0x5e,
-	0x53, 0x1c, 0x43, 0x5f, 0x2b, 0x5d, 0x6d, 0x4b, 0x47, 0x6b, 0x4e, 0x1b, 0xa1, 0xe4, 0x44, 0x55,
-	0xd2, 0xb1, 0x76, 0x16, 0xe5, 0x29, 0x3f, 0xfb, 0x86, 0x37, 0x70, 0x71, 0xd4, 0x17, 0xbe, 0x52,
-	0x27, 0x84, 0x9e, 0x8a, 0xe3, 0x39, 0x34, 0x1e, 0xab, 0x67, 0x8b, 0x53, 0xe8, 0x3d, 0x90, 0xfb,
-	0xb6, 0x5a, 0xbc, 0xfa, 0xbd, 0xec, 0x70, 0xc2, 0x01, 0xfb, 0xeb, 0x0a, 0xc3, 0x7f, 0xcb, 0x66,
-	0xf8, 0x00, 0x77, 0x5f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x75, 0x69, 0x66, 0x28, 0x0d, 0x02, 0x00,
+	// 2369 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x59, 0x4f, 0x73, 0xdc, 0x48,
+	0x15, 0x8f, 0xe6, 0x8f, 0x67, 0xe6, 0x79, 0xc6, 0x76, 0xda, 0xf6, 0x44, 0x56, 0x96, 0x60, 0x94,
+	0xb0, 0xe5, 0xda, 0x0d, 0x43, 0xd6, 0xa9, 0xdd, 0x62, 0x29, 0xd8, 0x90, 0xd8, 0xd9, 0xdd, 0x14,
+	0x49, 0xf0, 0x2a, 0x59, 0x38, 0xcb, 0xa3, 0x1e, 0x5b, 0xe5, 0xb1, 0x24, 0xa4, 0x1e, 0x27, 0xde,
+	0x2b, 0x07, 0x0e, 0x14, 0x07, 0x0e, 0x54, 0x71, 0xe7, 0x0b, 0xc0, 0x81, 0x2a, 0xee, 0x70, 0xe0,
+	0xc0, 0xb7, 0xe0, 0xc2, 0x57, 0xe0, 0x40, 0x15, 0xf5, 0xba, 0x5f, 0x4b, 0xdd, 0x33, 0xf2, 0x24,
+	0x39, 0x72, 0xd3, 0xfb, 0xf5, 0xeb, 0xd7, 0xfd, 0x5e, 0xbf, 0x7e, 0xef, 0xf5, 0x13, 0xf4, 0xc2,
+	0x2c, 0x1e, 0x65, 0x79, 0x2a, 0x52, 0xd6, 0x0c, 0xb3, 0xd8, 0xdf, 0x03, 0x76, 0x18, 0xe7, 0x07,
+	0x69, 0x22, 0x78, 0x22, 0x8a, 0x80, 0xff, 0x72, 0xc6, 0x0b, 0xc1, 0x18, 0xb4, 0xb2, 0x50, 0x9c,
+	0xba, 0xce, 0xae, 0xb3, 0xd7, 0x0b, 0xe4, 0xb7, 0xff, 0x9f, 0x06, 0x6c, 0x5a, 0xac, 0x45, 0x96,
+	0x26, 0x05, 0x67, 0x3f, 0x80, 0x0e, 0x4f, 0x44, 0x1e, 0xf3, 0xc2, 0x85, 0xdd, 0xe6, 0xde, 0xea,
+	0xfe, 0xad, 0x11, 0xae, 0x51, 0xc3, 0x3a, 0x7a, 0x9c, 0x88, 0xfc, 0x32, 0xd0, 0xec, 0xcc, 0x83,
+	0x2e, 0xcf, 0xf3, 0x34, 0x7f, 0x56, 0x9c, 0xb8, 0xab, 0x72, 0xa5, 0x92, 0xf6, 0x7e, 0xd3, 0x80,
+	0xb6, 0x64, 0x67, 0x6b, 0xd0, 0x78, 0x72, 0x28, 0x77, 0xd2, 0x0c, 0x1a, 0x4f, 0x0e, 0x71, 0x6f,
+	0x49, 0x78, 0xce, 0xdd, 0x86, 0xda, 0x1b, 0x7e, 0x33, 0x17, 0x3a, 0x71, 0x71, 0x18, 0xe7, 0xe2,
+	0xd2, 0x6d, 0xee, 0x3a, 0x7b, 0xdd, 0x40, 0x93, 0x6c, 0x0b, 0xda, 0xf2, 0xd3, 0x6d, 0x49, 0x5c,
+	0x11, 0x28, 0xa3, 0x88, 0xbf, 0xe1, 0x6e, 0x5b, 0x4a, 0x95, 0xdf, 0xec, 0x7d, 0x58, 0x3b, 0x4f,
+	0xa3, 0x97, 0xf1, 0x39, 0x7f, 0xc1, 0xc7, 0x69, 0x12, 0x15, 0xee, 0x8a, 0x1c, 0x9d, 0x43, 0x71,
+	0xad, 0xe3, 0x69, 0x3a, 0x3e, 0x7b, 0x72, 0xe8, 0x76, 0x76, 0x9d, 0xbd, 0x7e, 0xa0, 0x49, 0xb6,
+	0x0f, 0x5b, 0x59, 0x9a, 0xcd, 0xa6, 0xa1, 0xe0, 0x51, 0xc0, 0x4f, 0xe2, 0x34, 0x39, 0x48, 0x67,
+	0x89, 0x70, 0xbb, 0xbb, 0xce, 0x5e, 0x3b, 0xa8, 0x1d, 0x63, 0x77, 0x60, 0x50, 0xe2, 0x2f, 0x70,
+	0x4b, 0x3d, 0xb9, 0xa8, 0x0d, 0xfa, 0x5b, 0xc0, 0x5e, 0xf0, 0xfc, 0x22, 0x1e, 0xf3, 0x27, 0xc9,
+	0x24, 0xa5, 0x53, 0xf2, 0xff, 0xe8, 0xc0, 0xa6, 0x05, 0xd3, 0x89, 0xb8, 0xd0, 0xb9, 0xe0, 0x79,
+	0x11, 0xa7, 0x09, 0x1d, 0xa0, 0x26, 0xd9, 0x2d, 0x80, 0x73, 0x5c, 0xf6, 0x28, 0x8d, 0x13, 0x41,
+	0x16, 0x34, 0x10, 0xdc, 0xcd, 0x2c, 0x13, 0x86, 0x09, 0x9a, 0x6a, 0x37, 0x16, 0xc8, 0x36, 0xa0,
+	0x99, 0xc5, 0x91, 0xb4, 0x68, 0x33, 0xc0, 0x4f, 0x3c, 0xc9, 0x9c, 0x67, 0xe9, 0x11, 0xfa, 0x4c,
+	0x5b, 0x9d, 0xa4, 0xa6, 0xfd, 0xdf, 0x39, 0xb0, 0x7a, 0x34, 0x2b, 0x4e, 0x97, 0xf8, 0x16, 0x9e,
+	0xd2, 0x34, 0x3c, 0xe6, 0x53, 0xda, 0x92, 0x22, 0x50, 0x8f, 0x73, 0x5e, 0x14, 0xe1, 0x09, 0x97,
+	0xfb, 0xe8, 0x05, 0x9a, 0x64, 0x7b, 0xb0, 0xce, 0x5f, 0x67, 0x7c, 0x2c, 0x78, 0xf4, 0x88, 0xce,
+	0xa2, 0x25, 0xcf, 0x62, 0x1e, 0x46, 0xc9, 0x93, 0x34, 0x1f, 0xab, 0xa3, 0xee, 0x06, 0x8a, 0xf0,
+	0xf7, 0xa0, 0xaf, 0xb6, 0x54, 0x59, 0x4c, 0x9f, 0xa9, 0x63, 0x9d, 0xa9, 0xff, 0x19, 0x6c, 0x3c,
+	0x8c, 0xa2, 0x80, 0x9f, 0xa7, 0x82, 0x2f, 0xd3, 0x60, 0x08, 0x2b, 0x45, 0x3a, 0xc3, 0x85, 0x94,
+	0x0a, 0x44, 0xf9, 0xb7, 0xe1, 0xba, 0x31, 0x9f, 0x96, 0x9b, 0x73, 0x69, 0xdf, 0x87, 0xfe, 0xb3,
+	0xb3, 0x28, 0xce, 0x97, 0x5d, 0xbf, 0x6f, 0xc3, 0x80, 0x78, 0xae, 0x10, 0x72, 0x1b, 0x06, 0xb8,
+	0xcc, 0xc5, 0xb2, 0x6d, 0xfa, 0x1b, 0xb0, 0xa6, 0x99, 0x94, 0x18, 0xff, 0x00, 0xa7, 0xe1, 0x25,
+	0xd2, 0xd3, 0x5c, 0xe8, 0x14, 0xf9, 0xf8, 0xa8, 0x9a, 0xa9, 0x49, 0x1c, 0x89, 0x0a, 0x21, 0x47,
+	0x94, 0x92, 0x9a, 0x54, 0x62, 0x95, 0x10, 0x12, 0x7b, 0x1b, 0x06, 0x9f, 0xe7, 0x9c, 0x7f, 0xb3,
+	0x74, 0x37, 0x1f, 0xc0, 0x9a, 0x66, 0x7a, 0xe3, 0x41, 0x7c, 0x17, 0xd6, 0x8f, 0x72, 0x3e, 0xe1,
+	0x62, 0xbc, 0xcc, 0x93, 0xfc, 0xf7, 0x61, 0xa3, 0x62, 0x23, 0xa1, 0xfa, 0xb6, 0x3b, 0xd5, 0x6d,
+	0x47, 0x93, 0x3f, 0xbe, 0x88, 0xc7, 0x62, 0x99, 0xac, 0xef, 0xc3, 0x80, 0x78, 0x48, 0xd0, 0x2d,
+	0x80, 0xe3, 0x4b, 0xc1, 0x0b, 0xdc, 0x74, 0x44, 0xe2, 0x0c, 0xc4, 0xbf, 0x83, 0x66, 0x98, 0xe4,
+	0x7c, 0xa9, 0xb3, 0xfb, 0x1f, 0xc3, 0x7a, 0xc9, 0x45, 0x82, 0x7d, 0xe8, 0xcf, 0xb2, 0x08, 0xaf,
+	0xbb, 0x8a, 0x18, 0x4a, 0xb4, 0x85, 0xf9, 0x6b, 0xd0, 0x7f, 0x21, 0xc2, 0x32, 0x46, 0xfb, 0xbf,
+	0x6e, 0xc1, 0x80, 0x80, 0x6a, 0x7b, 0xd3, 0x74, 0x1c, 0x4e, 0x5f, 0xa6, 0x22, 0x9c, 0x4a, 0x19,
+	0xad, 0xc0, 0x40, 0xd8, 0x7b, 0xd0, 0x93, 0x14, 0x6e, 0x56, 0x9e, 0x60, 0x2b, 0xa8, 0x80, 0x72,
+	0xf6, 0xc3, 0x8b, 0x30, 0x9e, 0xba, 0x4d, 0x63, 0xb6, 0x44, 0xd8, 0x2e, 0xac, 0x4e, 0xe4, 0x61,
+	0xe5, 0x5f, 0x17, 0x5c, 0xdf, 0x7e, 0x13, 0x42, 0x2d, 0x5e, 0xe5, 0xb1, 0x08, 0x8f, 0xa7, 0x5c,
+	0xb2, 0xa8, 0xe8, 0x6a, 0x61, 0xb8, 0xca, 0x38, 0x1c, 0x9f, 0xf2, 0xaf, 0x66, 0xa9, 0x08, 0x29,
+	0xc2, 0x1a, 0x08, 0x8e, 0xc7, 0x49, 0x1a, 0x71, 0x65, 0x07, 0x0c, 0xb0, 0x83, 0xc0, 0x40, 0x50,
+	0x87, 0xf3, 0xf0, 0xf5, 0x93, 0xe7, 0x69, 0xc4, 0x0b, 0x19, 0x58, 0x07, 0x41, 0x05, 0xb0, 0x4f,
+	0xa1, 0x27, 0xf2, 0x30, 0x29, 0x26, 0x3c, 0x2f, 0xdc, 0x9e, 0xcc, 0x46, 0x37, 0x65, 0x36, 0xb2,
+	0x0c, 0x35, 0x7a, 0x49, 0x3c, 0x41, 0xc5, 0xed, 0xfd, 0xd5, 0x81, 0xae, 0xc6, 0xaf, 0x76, 0x43,
+	0xf6, 0x01, 0x6c, 0x14, 0x22, 0xcc, 0x85, 0x99, 0x27, 0x1a, 0x52, 0x8b, 0x05, 0x1c, 0x63, 0x8f,
+	0xc4, 0x28, 0x8a, 0x2a, 0x02, 0x23, 0x45, 0x3a, 0x99, 0x14, 0x5c, 0x90, 0x09, 0x89, 0xc2, 0xa8,
+	0xca, 0x13, 0x6d, 0x34, 0xfc, 0xc4, 0x8c, 0x24, 0x9d, 0xeb, 0x88, 0xe7, 0x4a, 0xa4, 0xb4, 0x57,
+	0x23, 0x98, 0x43, 0xfd, 0x75, 0xe5, 0x08, 0xb3, 0xd2, 0x35, 0x7e, 0xd5, 0x85, 0x35, 0x8d, 0x90,
+	0x6f, 0xfc, 0xd0, 0xb4, 0x8c, 0x23, 0x2d, 0xf3, 0x5e, 0x69, 0x99, 0xd9, 0x52, 0xd3, 0xb0, 0x7b,
+	0xb0, 0x32, 0x4e, 0x33, 0x4c, 0xf0, 0x0d, 0x39, 0xd1, 0xad, 0x9b, 0x78, 0x90, 0x66, 0x97, 0x01,
+	0xf1, 0xcd, 0xfb, 0x4a, 0x73, 0xd1, 0x57, 0xee, 0xc0, 0x80, 0x48, 0x19, 0xa9, 0x0b, 0x32, 0x86,
+	0x0d, 0xa2, 0x05, 0x22, 0x4c, 0xe3, 0x9f, 0xc7, 0x53, 0xf2, 0x08, 0x65, 0x9e, 0x39, 0xd4, 0xe2,
+	0x7b, 0x84, 0xc6, 0xd1, 0xb9, 0xdb, 0x46, 0x17, 0x3c, 0xb4, 0x53, 0xe3, 0xa1, 0x1f, 0xc1, 0x8a,
+	0xcc, 0x88, 0xe8, 0x5e, 0xa8, 0xed, 0x4e, 0x9d, 0xb6, 0xcf, 0x90, 0x23, 0x20, 0xc6, 0xb9, 0xb4,
+	0xda, 0x5b, 0x48, 0xab, 0x66, 0x7a, 0x04, 0x3b, 0x3d, 0x2e, 0xa6, 0xdc, 0xd5, 0x9a, 0x94, 0xfb,
+	0x7f, 0xec, 0x9d, 0xde, 0x9f, 0x1c, 0x68, 0xa1, 0x73, 0x2c, 0xd9, 0x76, 0xb9, 0x95, 0x86, 0xb9,
+	0x15, 0x5a, 0xb2, 0x69, 0x2d, 0x59, 0xaa, 0xf1, 0x3c, 0x4c, 0x52, 0xed, 0x35, 0x73, 0x28, 0x1e,
+	0x33, 0x4f, 0xa2, 0x8a, 0x8b, 0x02, 0x91, 0x89, 0xe1, 0x99, 0x8c, 0xd3, 0xf3, 0x6c, 0xca, 0x05,
+	0x97, 0x1b, 0xef, 0x06, 0x25, 0xed, 0xfd, 0xcd, 0x81, 0xb6, 0x3c, 0xe1, 0xba, 0xe2, 0x33, 0xab,
+	0xf2, 0x9f, 0xfc, 0x36, 0xf5, 0x6a, 0xda, 0x7a, 0x61, 0xc0, 0xe5, 0x61, 0xc1, 0x9f, 0x63, 0xbd,
+	0xda, 0x92, 0x53, 0x2a, 0x80, 0x8d, 0x80, 0x4d, 0xc3, 0x42, 0x04, 0x3c, 0xe1, 0xaf, 0xc2, 0xa9,
+	0x3e, 0x2e, 0xb5, 0xd7, 0x9a, 0x11, 0xc9, 0x8f, 0x93, 0x1f, 0xbf, 0xce, 0xe2, 0xfc, 0xd2, 0x2e,
+	0x52, 0x6b, 0x46, 0xfc, 0xcf, 0x80, 0xfd, 0x22, 0x14, 0xe3, 0xd3, 0xc7, 0x17, 0x66, 0x69, 0xbf,
+	0x05, 0x6d, 0x71, 0x99, 0x71, 0x15, 0x04, 0x7a, 0x81, 0x22, 0xea, 0xf4, 0xf2, 0xff, 0x8b, 0x25,
+	0x38, 0xce, 0xc5, 0x51, 0x64, 0xd3, 0x59, 0x0c, 0xbf, 0x51, 0x37, 0x51, 0x1a, 0x58, 0x9d, 0x5b,
+	0x05, 0xe8, 0x12, 0xb1, 0x59, 0x95, 0x88, 0xca, 0x92, 0xad, 0x05, 0x4b, 0xb6, 0xeb, 0x2d, 0xb9,
+	0x62, 0x5b, 0xb2, 0x72, 0xcb, 0x8e, 0xe5, 0x96, 0x43, 0x58, 0x99, 0xf2, 0xe4, 0x44, 0x9c, 0xca,
+	0x5c, 0xd0, 0x0c, 0x88, 0x92, 0x01, 0x61, 0x96, 0x87, 0x22, 0x4e, 0x93, 0x67, 0xf1, 0x38, 0x4f,
+	0x0b, 0xaa, 0xab, 0xe7, 0xd0, 0xaa, 0xf0, 0x04, 0xb3, 0xf0, 0xf4, 0xa0, 0xab, 0x43, 0x82, 0xbc,
+	0x8e, 0xdd, 0xa0, 0xa4, 0x65, 0x0d, 0x80, 0x9b, 0x52, 0xe1, 0xa8, 0x4f, 0x35, 0x40, 0x89, 0xc8,
+	0x22, 0x29, 0x4f, 0xb3, 0x8c, 0x47, 0xee, 0x40, 0x0e, 0x6a, 0xd2, 0x28, 0x11, 0xd7, 0xcc, 0x12,
+	0x11, 0xf1, 0x2c, 0x4e, 0x12, 0x1e, 0xb9, 0xeb, 0x72, 0x2d, 0xa2, 0xfc, 0x4d, 0xb8, 0xfe, 0x34,
+	0x2e, 0xc4, 0x53, 0xdc, 0x52, 0x19, 0xda, 0xef, 0x02, 0x33, 0x41, 0x8a, 0xee, 0x68, 0x06, 0x89,
+	0xd0, 0xa9, 0x12, 0xe5, 0x7f, 0x08, 0x9b, 0x92, 0xf3, 0xcb, 0xb8, 0x10, 0x69, 0x7e, 0x69, 0xf8,
+	0x80, 0xd2, 0xda, 0x31, 0xb4, 0xf6, 0x7f, 0xdb, 0x80, 0x2d, 0x9b, 0x9b, 0xa4, 0xff, 0x18, 0xba,
+	0xf4, 0x80, 0xd0, 0xa9, 0xe3, 0x3b, 0x32, 0x26, 0xd6, 0x31, 0x8f, 0x7e, 0xae, 0x38, 0x83, 0x72,
+	0x8a, 0xf7, 0x17, 0x07, 0x3a, 0x84, 0xce, 0x3f, 0x4d, 0xda, 0xd5, 0xd3, 0x64, 0xb9, 0x3f, 0x31,
+	0x68, 0x9d, 0xa6, 0x85, 0xa0, 0x77, 0x80, 0xfc, 0x46, 0x6c, 0x56, 0xf0, 0x9c, 0x2e, 0x96, 0xfc,
+	0x36, 0x3d, 0xa8, 0xbd, 0xe0, 0x41, 0x59, 0x98, 0xf3, 0x44, 0x90, 0x6b, 0x11, 0x65, 0x3e, 0x32,
+	0x3a, 0xd6, 0x23, 0xc3, 0xff, 0x08, 0x56, 0x0f, 0xe3, 0xc9, 0xc4, 0x28, 0xe5, 0x26, 0x79, 0x7a,
+	0xae, 0x2f, 0x01, 0x7e, 0xa3, 0x53, 0x8b, 0x94, 0x2e, 0x4d, 0x43, 0xa4, 0x68, 0xc2, 0xbe, 0x9a,
+	0x43, 0xa6, 0xdb, 0x87, 0xce, 0xf8, 0x34, 0x4c, 0x4e, 0xb8, 0xb6, 0x9c, 0x4b, 0x8f, 0xe3, 0x8a,
+	0x67, 0x74, 0x20, 0x19, 0x02, 0xcd, 0xe8, 0xfd, 0xc3, 0x81, 0x15, 0x85, 0xe1, 0x9a, 0x67, 0x71,
+	0x12, 0xe9, 0x35, 0xf1, 0xfb, 0xaa, 0x10, 0x94, 0x4e, 0x23, 0x99, 0x5f, 0xe8, 0xa5, 0x44, 0xe4,
+	0x15, 0xef, 0xdf, 0x5b, 0x00, 0xe9, 0x34, 0x7a, 0x64, 0x59, 0xca, 0x40, 0x48, 0x9e, 0x7c, 0x8f,
+	0xaa, 0xf8, 0xa2, 0xc9, 0x25, 0xaf, 0x5f, 0x5d, 0x65, 0x77, 0x8d, 0x2a, 0x3b, 0x81, 0xfe, 0x33,
+	0x9e, 0x9f, 0x98, 0x8f, 0x80, 0xe3, 0xb0, 0x28, 0x03, 0x09, 0x7e, 0xb3, 0x3e, 0x38, 0x21, 0x29,
+	0xe3, 0x84, 0x48, 0x1d, 0x93, 0x0e, 0xce, 0x71, 0xe5, 0xa8, 0xad, 0x2b, 0xde, 0x85, 0x6d, 0xfb,
+	0xc8, 0xfe, 0xe0, 0xc0, 0x80, 0x16, 0x7c, 0xd3, 0x83, 0x02, 0x6b, 0xc5, 0x71, 0x9a, 0x4c, 0xa6,
+	0xf1, 0x58, 0xe8, 0xc2, 0x46, 0xd5, 0x8a, 0x96, 0x80, 0xd1, 0x01, 0xf1, 0x04, 0x15, 0xb7, 0xb7,
+	0x0f, 0x5d, 0x0d, 0xbf, 0xed, 0x11, 0xf9, 0x0f, 0x60, 0xfb, 0x20, 0xe7, 0xa1, 0xe0, 0x2f, 0x92,
+	0x30, 0x2b, 0x4e, 0xd3, 0x65, 0x2f, 0x8f, 0xba, 0x1e, 0x87, 0xbf, 0x0f, 0xc3, 0x79, 0x01, 0x6f,
+	0x7c, 0x34, 0x0d, 0x61, 0x0b, 0xa3, 0x85, 0x9e, 0x51, 0x46, 0x91, 0x7f, 0x3a, 0xb0, 0x3d, 0x37,
+	0x40, 0xb2, 0x1e, 0x42, 0xaf, 0xd0, 0x20, 0xb9, 0xec, 0x6d, 0x75, 0xd9, 0xeb, 0xd8, 0x47, 0xe5,
+	0x5e, 0xaa, 0x59, 0x5e, 0x06, 0x5d, 0x0d, 0x97, 0x8a, 0x38, 0x95, 0x22, 0xef, 0x98, 0x43, 0x7d,
+	0xe8, 0x8f, 0xa5, 0xda, 0x91, 0x99, 0xf1, 0x2d, 0xcc, 0xff, 0x09, 0x0c, 0x03, 0x8e, 0x61, 0xa8,
+	0xce, 0xb8, 0x6f, 0xb3, 0xbe, 0xbf, 0x03, 0x37, 0x16, 0x24, 0xd0, 0x4b, 0xf6, 0x43, 0xd8, 0x3e,
+	0xe4, 0x53, 0x2e, 0xde, 0x46, 0xb6, 0xef, 0xc2, 0x70, 0x9e, 0x99, 0xc4, 0x7c, 0x05, 0xeb, 0x07,
+	0xa7, 0x7c, 0x7c, 0x96, 0xce, 0xc4, 0xbb, 0x77, 0x42, 0xca, 0x2e, 0x46, 0xd3, 0xec, 0x62, 0xdc,
+	0x85, 0x8d, 0x4a, 0xe4, 0x1b, 0x7d, 0xe1, 0x01, 0x6c, 0x7e, 0x9d, 0xc8, 0xa2, 0x54, 0xc6, 0xed,
+	0x37, 0x6c, 0x42, 0x2d, 0xd7, 0x30, 0x97, 0x1b, 0xc2, 0x96, 0x2d, 0x80, 0x34, 0xd3, 0x79, 0x8a,
+	0x87, 0x05, 0x2f, 0x3d, 0xec, 0x5f, 0x0e, 0x30, 0x13, 0xa5, 0xed, 0x7d, 0x8c, 0xf9, 0x1a, 0x11,
+	0xf2, 0xad, 0x6f, 0x95, 0xbe, 0x65, 0x33, 0x8e, 0x24, 0x19, 0x10, 0xb3, 0xf7, 0x7b, 0x07, 0xda,
+	0x12, 0xa9, 0x3d, 0x50, 0x43, 0xe7, 0x86, 0xed, 0x3c, 0xbb, 0xb0, 0xca, 0x65, 0x4d, 0xa4, 0x7c,
+	0x87, 0xde, 0x21, 0x06, 0x54, 0x26, 0x96, 0x96, 0x9d, 0x58, 0xb0, 0x3c, 0xd7, 0xa5, 0x09, 0x7e,
+	0xab, 0x38, 0xfa, 0xb3, 0x57, 0x09, 0xd5, 0x8a, 0x8a, 0xf0, 0xff, 0xed, 0x40, 0xff, 0x61, 0x96,
+	0x4d, 0xcb, 0xcc, 0x7a, 0x17, 0xda, 0xd3, 0x38, 0x39, 0xd3, 0xea, 0x0d, 0xa5, 0x7a, 0x26, 0xc7,
+	0xe8, 0x69, 0x9c, 0x9c, 0x05, 0x8a, 0x09, 0x85, 0x66, 0xf9, 0x2c, 0x29, 0xed, 0x2c, 0x89, 0xfa,
+	0xc3, 0xc6, 0xfc, 0x15, 0xe5, 0x97, 0xc1, 0x2c, 0xa1, 0x48, 0x4e, 0x94, 0x37, 0x81, 0x16, 0x8a,
+	0x7c, 0x97, 0xa6, 0x14, 0x86, 0xff, 0x13, 0x9e, 0x70, 0x55, 0x09, 0x91, 0x55, 0x0c, 0x04, 0x65,
+	0x71, 0x11, 0x9e, 0x68, 0xa3, 0xe0, 0xb7, 0xff, 0x77, 0x07, 0x06, 0xa4, 0x08, 0x9d, 0xe5, 0xfd,
+	0xf9, 0xdc, 0xb6, 0x63, 0x6a, 0x5b, 0x9f, 0xdc, 0xf0, 0xac, 0xc2, 0x2c, 0x9b, 0xc6, 0x3c, 0x22,
+	0xa5, 0x35, 0xe9, 0x45, 0x65, 0xd6, 0x1b, 0xc2, 0x4a, 0x38, 0x16, 0x55, 0xfb, 0x92, 0xa8, 0xda,
+	0xc0, 0x51, 0xa9, 0xd8, 0x9c, 0x2f, 0xaa, 0x22, 0x2e, 0xb0, 0x93, 0xa1, 0x94, 0x20, 0x6a, 0xff,
+	0xcf, 0x7d, 0x68, 0x1d, 0xcd, 0x26, 0x05, 0x7b, 0x0c, 0x6b, 0x5f, 0x70, 0x61, 0x74, 0xa9, 0xd9,
+	0x8d, 0xc5, 0xbe, 0xb5, 0x3c, 0x32, 0xcf, 0xbd, 0xaa, 0xa1, 0xed, 0x5f, 0x23, 0x31, 0x46, 0x17,
+	0x96, 0xc4, 0x2c, 0xb6, 0x6b, 0x3d, 0x77, 0x71, 0xa0, 0x14, 0xf3, 0x3d, 0xdc, 0x55, 0x71, 0xca,
+	0x36, 0x24, 0x8f, 0xd1, 0x2e, 0xf5, 0xae, 0x1b, 0x48, 0xc9, 0xfe, 0x23, 0xe8, 0x95, 0x5d, 0x45,
+	0xb6, 0xad, 0xcc, 0x3e, 0xd7, 0xa5, 0xf4, 0x86, 0xf3, 0x70, 0x39, 0xfb, 0x1e, 0xb4, 0x65, 0x2b,
+	0x91, 0x29, 0xd9, 0x66, 0xeb, 0xd1, 0x63, 0x26, 0x54, 0xce, 0xb8, 0x0f, 0x2b, 0xaa, 0x6d, 0xc8,
+	0xd4, 0xb8, 0xd5, 0x68, 0xf4, 0x36, 0x2d, 0xcc, 0x9e, 0xa4, 0x22, 0x2e, 0x31, 0x18, 0x6d, 0x46,
+	0x6f, 0xd3, 0xc2, 0xcc, 0x49, 0xaa, 0x25, 0x48, 0x93, 0xac, 0x26, 0xa2, 0xb7, 0x69, 0x61, 0xe5,
+	0xa4, 0x4f, 0xa1, 0xab, 0x9b, 0x7e, 0x6c, 0x4b, 0xd9, 0xcb, 0x6e, 0x15, 0x7a, 0xdb, 0x73, 0xa8,
+	0x69, 0x0b, 0xd9, 0xe3, 0x23, 0x5b, 0x98, 0x3d, 0x41, 0x8f, 0x99, 0x50, 0x39, 0xe3, 0x13, 0xe8,
+	0x50, 0xfb, 0x8e, 0x69, 0x1d, 0xcc, 0x96, 0x9f, 0xb7, 0x65, 0x83, 0x86, 0x66, 0x5d, 0xf4, 0x14,
+	0x11, 0x8a, 0x82, 0x16, 0x33, 0xdb, 0x79, 0x1e, 0x33, 0x21, 0x63, 0xb1, 0x55, 0xe3, 0x0d, 0x47,
+	0xbe, 0xb5, 0xf8, 0xaa, 0xf3, 0x80, 0xb6, 0xca, 0x13, 0xe1, 0x5f, 0xbb, 0xe7, 0xb0, 0x4f, 0xa0,
+	0x47, 0x8b, 0xcd, 0x0a, 0xc6, 0xac, 0x0e, 0x86, 0x69, 0x49, 0xbb, 0xab, 0xe1, 0x5f, 0x63, 0x0f,
+	0x00, 0xaa, 0xe7, 0x05, 0x1b, 0x56, 0xd1, 0xd9, 0x7c, 0x84, 0x78, 0x37, 0x16, 0xf0, 0x52, 0xc0,
+	0x97, 0xb0, 0xfe, 0x05, 0x17, 0xe6, 0xcb, 0x80, 0xb9, 0x35, 0x8f, 0x05, 0x25, 0x67, 0xe7, 0xca,
+	0x67, 0x84, 0xba, 0x12, 0x58, 0x26, 0xd3, 0x95, 0x30, 0x2a, 0x71, 0xef, 0xba, 0x81, 0x58, 0x4e,
+	0x8d, 0x85, 0x9b, 0x76, 0x6a, 0xa3, 0xec, 0xf4, 0x98, 0x09, 0x95, 0x33, 0x7e, 0x0a, 0x6b, 0x76,
+	0x41, 0xc5, 0x3c, 0xc9, 0x57, 0x5b, 0xa6, 0x79, 0x37, 0x6b, 0xc7, 0x0c, 0xbd, 0x07, 0x56, 0x85,
+	0xc4, 0x76, 0xea, 0xaa, 0x26, 0x25, 0xca, 0xbb, 0xba, 0xa0, 0xf2, 0xaf, 0xb1, 0xe7, 0xd8, 0x1e,
+	0xb6, 0x4a, 0x11, 0x76, 0x93, 0x5c, 0xaa, 0xae, 0xc4, 0xf1, 0xde, 0xab, 0x1f, 0x34, 0xd5, 0xb4,
+	0x4b, 0x12, 0x52, 0xb3, 0xb6, 0xa8, 0xf1, 0x6e, 0xd6, 0x8e, 0x99, 0x37, 0x4d, 0x97, 0x1c, 0x74,
+	0xd3, 0xe6, 0x8a, 0x1a, 0x6f, 0x7b, 0x0e, 0x35, 0x22, 0x65, 0xdf, 0x2c, 0x1f, 0xc8, 0x2d, 0x6a,
+	0x4a, 0x12, 0x6f, 0xa7, 0x66, 0x64, 0xc1, 0x43, 0x65, 0x61, 0x60, 0x7a, 0xa8, 0x59, 0x7e, 0x78,
+	0x37, 0x16, 0x70, 0xd3, 0x51, 0x64, 0x8a, 0x22, 0x47, 0x31, 0x93, 0xb3, 0xc7, 0x4c, 0x48, 0xcf,
+	0x38, 0x5e, 0x91, 0xff, 0x4b, 0xef, 0xff, 0x6f, 0x00, 0x79, 0x91, 0x98, 0xc5, 0x3c, 0x1d, 0x00,
 	0x00,
 }
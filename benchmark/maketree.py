@@ -18,8 +18,14 @@ def write_tree(root, levels, dir_count, child_files_count, file_size):
         for i in range(dir_count):
             write_tree(os.path.join(root, str(i)), levels - 1, dir_count, child_files_count, file_size)
 
+def write_flat(root, file_count, file_size):
+    os.makedirs(root)
+    for i in range(file_count):
+        write_file(os.path.join(root, str(i)), file_size)
+
 def create_all(root):
     write_tree(os.path.join(root, "widedir"), 1, 500, 1, 4*KB)
+    write_flat(os.path.join(root, "flatdir"), 20000, 0)
     write_file(os.path.join(root, "1kb"), 1 * MB)
     write_file(os.path.join(root, "1mb"), 1 * MB)
     write_file(os.path.join(root, "50mb"), 50 * MB)
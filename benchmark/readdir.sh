@@ -0,0 +1,12 @@
+# Benchmark directory listing: populate a fresh repo with the maketree.py tree, then
+# walk it twice (the second pass runs against already loaded directories)
+rm -r ~/repo-3
+( cd ../pufs && go run main.go init ~/repo-3 && go run main.go mount ~/repo-3 ~/mount-3 > ../benchmark/mount.log 2>&1 & )
+sleep 8
+echo "creating tree"
+python maketree.py ~/mount-3/tree
+echo "starting benchmark"
+python walktree.py ~/mount-3/tree dirs
+echo "running 2nd pass"
+python walktree.py ~/mount-3/tree dirs
+umount ~/mount-3
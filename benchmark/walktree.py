@@ -12,6 +12,17 @@ def time_walk(root):
     assert count > 500
     return end-start
 
+def time_walk_stat(root):
+    start = time.time()
+    count = 0
+    for root, dirs, files in os.walk(root):
+        for name in dirs + files:
+            os.lstat(os.path.join(root, name))
+            count += 1
+    end = time.time()
+    assert count > 500
+    return end-start
+
 def time_read(filename, read_size):
     start = time.time()
     count = 0
@@ -21,11 +32,18 @@ def time_read(filename, read_size):
     end = time.time()
     return end-start
 
+def benchmark_dirs(root):
+    print("time to walk wide: {:.3e}".format( time_walk(os.path.join(root, "widedir")) ))
+    print("time to walk and stat wide: {:.3e}".format( time_walk_stat(os.path.join(root, "widedir")) ))
+    print("time to walk and stat flat: {:.3e}".format( time_walk_stat(os.path.join(root, "flatdir")) ))
+
 def benchmark(root, read_size):
-    #print("time to walk wide: {:.3e}".format( time_walk(os.path.join(root, "widedir")) ))
     print("time to read 1KB: {:.3e}".format( time_read(os.path.join(root, "1kb"), read_size)))
     print("time to read 1MB: {:.3e}".format( time_read(os.path.join(root, "1mb"), read_size)))
     print("time to read 50MB: {:.3e}".format( time_read(os.path.join(root, "50mb"), read_size)))
 
 if __name__ == "__main__":
-    benchmark(sys.argv[1], int(sys.argv[2]))
+    if sys.argv[2] == "dirs":
+        benchmark_dirs(sys.argv[1])
+    else:
+        benchmark(sys.argv[1], int(sys.argv[2]))
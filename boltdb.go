@@ -3,6 +3,7 @@ package sply2
 import (
 	"bytes"
 	"log"
+	"time"
 
 	bolt "github.com/coreos/bbolt"
 	"github.com/pgm/sply2/core"
@@ -38,10 +39,16 @@ type WrappedBucket struct {
 }
 
 func (b *WrappedBucket) Get(key []byte) []byte {
+	if b.bucket == nil {
+		return nil
+	}
 	return b.bucket.Get(key)
 }
 
 func (b *WrappedBucket) ForEachWithPrefix(prefix []byte, callback func(key []byte, value []byte) error) error {
+	if b.bucket == nil {
+		return nil
+	}
 	c := b.bucket.Cursor()
 	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
 		err := callback(k, v)
@@ -52,6 +59,23 @@ func (b *WrappedBucket) ForEachWithPrefix(prefix []byte, callback func(key []byt
 	return nil
 }
 
+func (b *WrappedBucket) ForEachWithPrefixFrom(prefix []byte, start []byte, callback func(key []byte, value []byte) error) error {
+	if bytes.Compare(start, prefix) < 0 {
+		start = prefix
+	}
+	if b.bucket == nil {
+		return nil
+	}
+	c := b.bucket.Cursor()
+	for k, v := c.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		err := callback(k, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (b *WrappedBucket) Put(key []byte, value []byte) error {
 	return b.bucket.Put(key, value)
 }
@@ -60,8 +84,14 @@ func (b *WrappedBucket) Delete(key []byte) error {
 	return b.bucket.Delete(key)
 }
 
+// how long to wait for another process to release its lock on a database before giving up
+const boltLockTimeout = 5 * time.Second
+
 func NewBoltDB(filename string, buckets [][]byte) *BoltKVStore {
-	db, err := bolt.Open(filename, 0600, nil)
+	db, err := bolt.Open(filename, 0600, &bolt.Options{Timeout: boltLockTimeout})
+	if err == bolt.ErrTimeout {
+		log.Fatalf("Could not open %s: it is locked by another process, which may have the repo mounted", filename)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -96,10 +126,16 @@ func (b *BoltKVStore) Close() error {
 	return b.db.Close()
 }
 
+// RBucket returns an empty bucket if name hasn't been created yet
 func (tx *BoltTx) RBucket(name []byte) core.RBucket {
 	return &WrappedBucket{tx.tx.Bucket(name)}
 }
 
+// WBucket creates name if it doesn't exist, so databases made before a bucket was added keep working
 func (tx *BoltTx) WBucket(name []byte) core.WBucket {
-	return &WrappedBucket{tx.tx.Bucket(name)}
+	bucket, err := tx.tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &WrappedBucket{bucket}
 }
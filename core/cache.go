@@ -0,0 +1,297 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/pgm/sply2/region"
+)
+
+// the size of the buffer used when reading files in order to prefetch them
+const prefetchBufferSize = 1024 * 1024
+
+// Prefetch reads everything under inode (or inode itself if it's a file) so that the data is copied into the
+// local cache. Returns the total size of the files which are now cached.
+func (d *DataStore) Prefetch(ctx context.Context, inode INode) (int64, error) {
+	node, err := d.GetAttr(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+
+	if !node.IsDir {
+		if node.BID == NABlock {
+			// writable files are already local
+			return 0, nil
+		}
+		return d.prefetchFile(ctx, inode, 0)
+	}
+
+	entries, err := d.GetDirContents(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		size, err := d.Prefetch(ctx, entry.ID)
+		if err != nil {
+			return total, err
+		}
+		total += size
+	}
+
+	return total, nil
+}
+
+// PrefetchHead copies up to the first maxBytes of the file inode into the local cache, returning the number of
+// bytes now cached
+func (d *DataStore) PrefetchHead(ctx context.Context, inode INode, maxBytes int64) (int64, error) {
+	node, err := d.GetAttr(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+	if node.IsDir {
+		return 0, IsDirErr
+	}
+	if node.BID == NABlock {
+		return 0, nil
+	}
+	return d.prefetchFile(ctx, inode, maxBytes)
+}
+
+// prefetchFile reads the file inode, stopping after maxBytes if maxBytes > 0
+func (d *DataStore) prefetchFile(ctx context.Context, inode INode, maxBytes int64) (int64, error) {
+	// nothing is blocked on this, so let reads from the filesystem go first
+	ctx = region.WithBackgroundPriority(ctx)
+
+	ref, err := d.GetReadRef(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+	defer ref.Release()
+
+	buffer := make([]byte, prefetchBufferSize)
+	var total int64
+	for maxBytes <= 0 || total < maxBytes {
+		if maxBytes > 0 && maxBytes-total < int64(len(buffer)) {
+			buffer = buffer[:maxBytes-total]
+		}
+		n, err := ref.Read(ctx, buffer)
+		total += int64(n)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	return total, nil
+}
+
+// Evict drops the cached copy of inode (or every file under inode if it's a directory), returning the number of
+// bytes freed. Only data which can be fetched again from a remote, and which isn't part of a snapshot, is dropped.
+// Directories which have not been listed yet are not loaded, as nothing under them can be cached.
+func (d *DataStore) Evict(ctx context.Context, inode INode) (int64, error) {
+	node, err := d.GetAttr(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+
+	protected, err := d.snapshotBlocks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if !node.IsDir {
+		if node.BID == NABlock {
+			return 0, NotEvictableErr
+		}
+		if protected[node.BID] {
+			return 0, InSnapshotErr
+		}
+		freed, err := d.freezer.Evict(node.BID)
+		if freed > 0 {
+			d.monitor.Evicted(ctx, inode, node.BID, freed)
+		}
+		return freed, err
+	}
+
+	var total int64
+	err = d.forEachLoadedDescendant(inode, func(id INode, node *NodeRepr) error {
+		if node.IsDir || node.BID == NABlock || protected[node.BID] {
+			return nil
+		}
+		freed, err := d.freezer.Evict(node.BID)
+		if err == NotEvictableErr || err == BlockInUseErr {
+			// when evicting a whole tree, skip what we can't evict instead of failing
+			return nil
+		}
+		if freed > 0 {
+			d.monitor.Evicted(ctx, id, node.BID, freed)
+		}
+		total += freed
+		return err
+	})
+
+	return total, err
+}
+
+// forEachLoadedDescendant calls callback for every node below inode, without fetching the children of any
+// directories which have not been listed yet.
+func (d *DataStore) forEachLoadedDescendant(inode INode, callback func(id INode, node *NodeRepr) error) error {
+	type child struct {
+		id   INode
+		node *NodeRepr
+	}
+
+	var children []child
+	err := d.db.view(func(tx RTx) error {
+		node, err := getNodeRepr(tx, inode)
+		if err != nil {
+			return err
+		}
+		if !node.IsDir || node.IsDeferredChildFetch {
+			return nil
+		}
+
+		entries, err := d.db.GetDirContents(tx, inode, false)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			childNode, err := getNodeRepr(tx, entry.ID)
+			if err != nil {
+				return err
+			}
+			children = append(children, child{entry.ID, childNode})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range children {
+		err = callback(c.id, c.node)
+		if err != nil {
+			return err
+		}
+		if c.node.IsDir {
+			err = d.forEachLoadedDescendant(c.id, callback)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Refresh checks whether the remote objects that inode (or any file under inode) was created from have changed,
+// and if so, re-points those files at the latest version. Returns the number of files which were updated.
+func (d *DataStore) Refresh(ctx context.Context, inode INode) (int, error) {
+	node, err := d.GetAttr(ctx, inode)
+	if err != nil {
+		return 0, err
+	}
+
+	protected, err := d.snapshotBlocks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if !node.IsDir {
+		updated, err := d.refreshFile(ctx, inode, node, protected)
+		if updated {
+			return 1, err
+		}
+		return 0, err
+	}
+
+	count := 0
+	err = d.forEachLoadedDescendant(inode, func(id INode, node *NodeRepr) error {
+		if node.IsDir {
+			return nil
+		}
+		updated, err := d.refreshFile(ctx, id, node, protected)
+		if updated {
+			count++
+		}
+		return err
+	})
+
+	return count, err
+}
+
+func (d *DataStore) refreshFile(ctx context.Context, inode INode, node *NodeRepr, protected map[BlockID]bool) (bool, error) {
+	if node.RemoteSource == nil {
+		return false, nil
+	}
+	if d.networkClient == nil {
+		return false, NoNetworkClientErr
+	}
+
+	var remoteSource interface{}
+	var BID BlockID
+	var size int64
+
+	switch source := node.RemoteSource.(type) {
+	case *GCSObjectSource:
+		attrs, err := d.networkClient.GetGCSAttr(ctx, source.Bucket, source.Key)
+		if err != nil {
+			return false, err
+		}
+		if attrs.Generation == source.Generation {
+			return false, nil
+		}
+		remoteSource = &GCSObjectSource{Bucket: source.Bucket, Key: source.Key, Generation: attrs.Generation, Size: attrs.Size}
+		BID = makeGSCHashBlockID(source.Bucket, source.Key, attrs.Generation)
+		size = attrs.Size
+	case *URLSource:
+		attrs, err := d.networkClient.GetHTTPAttr(ctx, source.URL)
+		if err != nil {
+			return false, err
+		}
+		if attrs.ETag == source.ETag {
+			return false, nil
+		}
+		remoteSource = &URLSource{URL: source.URL, ETag: attrs.ETag, Size: attrs.Size}
+		BID = makeURLHashBlockID(source.URL, attrs.ETag)
+		size = attrs.Size
+	default:
+		return false, nil
+	}
+
+	err := d.db.update(func(tx RWTx) error {
+		return d.db.UpdateRemoteSource(tx, inode, remoteSource, BID, size, time.Now())
+	})
+	if err != nil {
+		return false, err
+	}
+
+	// whatever was cached from the old version won't be read again, unless a snapshot still refers to it
+	if node.BID != NABlock && !protected[node.BID] {
+		freed, err := d.freezer.Evict(node.BID)
+		if err != nil {
+			log.Printf("Could not evict old version of inode %d: %s", inode, err)
+		} else if freed > 0 {
+			d.monitor.Evicted(ctx, inode, node.BID, freed)
+		}
+	}
+
+	return true, nil
+}
+
+// GetActiveTransfers returns the status of all copies from remotes which are currently in progress
+func (d *DataStore) GetActiveTransfers(timeUnit time.Duration) []*BlockTransferStatus {
+	return d.freezer.GetActiveTransferStatus(timeUnit)
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// GenerationPolicy decides what happens when a file is read after the remote object it was linked from has been
+// replaced
+type GenerationPolicy string
+
+const (
+	// reads of regions which aren't cached yet fail with a precondition error (reported as ESTALE) until the file is
+	// refreshed
+	StaleOnChange GenerationPolicy = "stale"
+	// keep reading the generation the file was linked from. Requires object versioning to be enabled on the bucket,
+	// and only applies to GCS objects.
+	PinGeneration GenerationPolicy = "pin"
+)
+
+func ParseGenerationPolicy(name string) (GenerationPolicy, error) {
+	switch GenerationPolicy(name) {
+	case StaleOnChange, PinGeneration:
+		return GenerationPolicy(name), nil
+	}
+	return "", InvalidGenerationPolicyErr
+}
+
+// PinnableRemoteRef is implemented by remote refs which can keep reading the version of an object they were created
+// from, after the object has been replaced
+type PinnableRemoteRef interface {
+	RemoteRef
+	Pin() RemoteRef
+}
+
+// RemoteChange records that a block was found to have been replaced in the remote while reading it
+type RemoteChange struct {
+	Time time.Time
+	BID  BlockID
+	// the object the block was linked from
+	Source string
+	// true if reads continued from the old generation, false if they failed
+	Pinned bool
+}
+
+type remoteChangeJSON struct {
+	Time   time.Time `json:"time"`
+	BID    string    `json:"block_id"`
+	Source string    `json:"source"`
+	Pinned bool      `json:"pinned"`
+}
+
+// remoteChangeLog is an append-only file of RemoteChanges, one JSON object per line
+type remoteChangeLog struct {
+	mutex sync.Mutex
+	path  string
+}
+
+func (l *remoteChangeLog) record(change *RemoteChange) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(&remoteChangeJSON{Time: change.Time,
+		BID:    base64.URLEncoding.EncodeToString(change.BID[:]),
+		Source: change.Source,
+		Pinned: change.Pinned})
+}
+
+func (l *remoteChangeLog) read() ([]*RemoteChange, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	changes := make([]*RemoteChange, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry remoteChangeJSON
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, err
+		}
+		change := &RemoteChange{Time: entry.Time, Source: entry.Source, Pinned: entry.Pinned}
+		BID, err := base64.URLEncoding.DecodeString(entry.BID)
+		if err != nil {
+			return nil, err
+		}
+		copy(change.BID[:], BID)
+		changes = append(changes, change)
+	}
+
+	return changes, scanner.Err()
+}
+
+// describeSource returns a readable description of the remote object source refers to
+func describeSource(source interface{}) string {
+	switch source := source.(type) {
+	case *GCSObjectSource:
+		return fmt.Sprintf("gs://%s/%s#%d", source.Bucket, source.Key, source.Generation)
+	case *URLSource:
+		return fmt.Sprintf("%s (ETag %s)", source.URL, source.ETag)
+	}
+	return fmt.Sprintf("%v", source)
+}
+
+// remoteChanged is called when a copy of BID from remote failed because the object has been replaced. The change is
+// logged the first time it's seen, and if the policy is to pin generations, a ref which reads the original generation
+// is returned. Otherwise returns nil.
+func (f *FreezerImp) remoteChanged(ctx context.Context, BID BlockID, remote RemoteRef) RemoteRef {
+	var pinned RemoteRef
+	if pinnable, ok := remote.(PinnableRemoteRef); ok && f.generationPolicy == PinGeneration {
+		pinned = pinnable.Pin()
+	}
+
+	f.mutex.Lock()
+	seen := f.changedBlocks[BID]
+	f.changedBlocks[BID] = true
+	f.mutex.Unlock()
+
+	if !seen {
+		source := describeSource(remote.GetSource())
+		f.monitor.RemoteChanged(ctx, BID, source, pinned != nil)
+		if f.changeLog != nil {
+			err := f.changeLog.record(&RemoteChange{Time: time.Now(), BID: BID, Source: source, Pinned: pinned != nil})
+			if err != nil {
+				log.Printf("Could not record change to %s: %s", source, err)
+			}
+		}
+	}
+
+	return pinned
+}
+
+// GetRemoteChanges returns every time a file was read after the object it was linked from had been replaced, oldest
+// first
+func (d *DataStore) GetRemoteChanges() ([]*RemoteChange, error) {
+	if d.changeLog == nil {
+		return nil, nil
+	}
+	return d.changeLog.read()
+}
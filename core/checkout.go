@@ -0,0 +1,127 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+func (d *DataStore) findMount(inode INode) *Mount {
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
+
+	for _, m := range d.mounts {
+		if m.mountPoint == inode {
+			return m
+		}
+	}
+	return nil
+}
+
+// checkMountUnchanged returns MountChangedErr if anything under the mount m differs from the root it was mounted
+// from (or last pushed as)
+func (d *DataStore) checkMountUnchanged(ctx context.Context, m *Mount) error {
+	node, err := d.GetAttr(ctx, m.mountPoint)
+	if err != nil {
+		return err
+	}
+	if node.IsDirty || node.BID != m.BID {
+		return MountChangedErr
+	}
+	return nil
+}
+
+// Checkout makes parent/name a copy of label (which may be label@N or label@<timestamp>). If a label is already
+// mounted there, its contents are replaced and its lease moved to the new root. Otherwise label is mounted as a
+// new directory. Replacing a mount which has changes fails with MountChangedErr, unless discardChanges is set.
+func (d *DataStore) Checkout(ctx context.Context, parent INode, name string, label string, discardChanges bool) (INode, BlockID, error) {
+	BID, err := d.ResolveLabel(ctx, label)
+	if err != nil {
+		return InvalidINode, NABlock, err
+	}
+
+	inode, err := d.GetNodeID(ctx, parent, name)
+	if err == NoSuchNodeErr {
+		inode, err = d.Mount(ctx, parent, name, BID)
+		return inode, BID, err
+	}
+	if err != nil {
+		return InvalidINode, NABlock, err
+	}
+
+	m := d.findMount(inode)
+	if m == nil {
+		return InvalidINode, NABlock, NoSuchMountErr
+	}
+	if !discardChanges {
+		err = d.checkMountUnchanged(ctx, m)
+		if err != nil {
+			return InvalidINode, NABlock, err
+		}
+	}
+
+	// keep the same lease, but protect the new root before anything refers to it. The old root is released as soon
+	// as the lease has moved.
+	leasedAt := time.Now()
+	oldBID, _, err := d.leaseMount(ctx, inode, BID)
+	if err != nil {
+		return InvalidINode, NABlock, err
+	}
+
+	var removed []INode
+	var writablePaths []string
+	err = d.db.update(func(tx RWTx) error {
+		removed, writablePaths, err = d.db.ReplaceWithBID(tx, inode, BID)
+		return err
+	})
+	if err != nil {
+		// the mount still refers to the old root, so it needs protecting again
+		_, _, leaseErr := d.leaseMount(ctx, inode, oldBID)
+		if leaseErr != nil {
+			log.Printf("Could not move lease back to %v: %s", oldBID, leaseErr)
+		}
+		return InvalidINode, NABlock, err
+	}
+	d.recordMountBID(inode, BID, leasedAt)
+
+	return inode, BID, d.releaseRemoved(ctx, removed, writablePaths)
+}
+
+// UnmountLabel removes the label mounted at parent/name from the tree and releases its lease. Fails with
+// MountChangedErr if the mount has changes, unless discardChanges is set.
+func (d *DataStore) UnmountLabel(ctx context.Context, parent INode, name string, discardChanges bool) error {
+	inode, err := d.GetNodeID(ctx, parent, name)
+	if err != nil {
+		return err
+	}
+
+	m := d.findMount(inode)
+	if m == nil {
+		return NoSuchMountErr
+	}
+	if !discardChanges {
+		err = d.checkMountUnchanged(ctx, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.RemoveTree(ctx, parent, name)
+}
+
+// RemoveTree removes parent/name and everything below it, including any labels mounted inside it and local changes
+// which were never pushed
+func (d *DataStore) RemoveTree(ctx context.Context, parent INode, name string) error {
+	var removed []INode
+	var writablePaths []string
+	err := d.updateAfterLoadLazyChildren(ctx, parent, func(tx RWTx) error {
+		var err error
+		removed, writablePaths, err = d.db.RemoveTree(tx, parent, name)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.releaseRemoved(ctx, removed, writablePaths)
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckout(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	newDataStore := func() *DataStore {
+		dir, err := ioutil.TempDir("", "test")
+		require.Nil(err)
+		ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f))
+		require.Nil(err)
+		return ds
+	}
+	readFile := func(ds *DataStore, parent INode, name string) string {
+		inode, err := ds.GetNodeID(ctx, parent, name)
+		require.Nil(err)
+		r, err := ds.GetReadRef(ctx, inode)
+		require.Nil(err)
+		buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+		require.Nil(err)
+		return string(buffer)
+	}
+
+	ds1 := newDataStore()
+	createFile(require, ds1, RootINode, "x", "version 1")
+	require.Nil(ds1.Push(ctx, RootINode, "ref"))
+	require.Nil(ds1.Remove(ctx, RootINode, "x"))
+	createFile(require, ds1, RootINode, "x", "version 2")
+	require.Nil(ds1.Push(ctx, RootINode, "ref"))
+	v1, err := ds1.ResolveLabel(ctx, "ref@1")
+	require.Nil(err)
+	v2, err := ds1.ResolveLabel(ctx, "ref")
+	require.Nil(err)
+
+	ds := newDataStore()
+
+	// checking out to a new path mounts the label
+	data, BID, err := ds.Checkout(ctx, RootINode, "data", "ref@1", false)
+	require.Nil(err)
+	require.Equal(v1, BID)
+	require.Equal("version 1", readFile(ds, data, "x"))
+
+	// and checking out again switches the same directory to the other version, moving the lease with it
+	inode, BID, err := ds.Checkout(ctx, RootINode, "data", "ref", false)
+	require.Nil(err)
+	require.Equal(data, inode)
+	require.Equal(v2, BID)
+	require.Equal("version 2", readFile(ds, data, "x"))
+	mounts := ds.GetMounts()
+	require.Len(mounts, 1)
+	require.Equal(v2, mounts[0].BID)
+	require.Equal(v2, f.leases[mounts[0].LeaseName].BID)
+
+	// changes aren't discarded unless asked
+	createFile(require, ds, data, "y", generateUniqueString())
+	_, _, err = ds.Checkout(ctx, RootINode, "data", "ref@1", false)
+	require.Equal(MountChangedErr, err)
+	require.Equal(MountChangedErr, ds.UnmountLabel(ctx, RootINode, "data", false))
+	_, _, err = ds.Checkout(ctx, RootINode, "data", "ref@1", true)
+	require.Nil(err)
+	require.Equal("version 1", readFile(ds, data, "x"))
+	_, err = ds.GetNodeID(ctx, data, "y")
+	require.Equal(NoSuchNodeErr, err)
+
+	// only mounted labels can be replaced or unmounted
+	_, err = ds.MakeDir(ctx, RootINode, "plain")
+	require.Nil(err)
+	_, _, err = ds.Checkout(ctx, RootINode, "plain", "ref", false)
+	require.Equal(NoSuchMountErr, err)
+	require.Equal(NoSuchMountErr, ds.UnmountLabel(ctx, RootINode, "plain", false))
+	_, _, err = ds.Checkout(ctx, RootINode, "other", "missing", false)
+	require.Equal(UndefinedRootErr, err)
+
+	require.Nil(ds.UnmountLabel(ctx, RootINode, "data", false))
+	_, err = ds.GetNodeID(ctx, RootINode, "data")
+	require.Equal(NoSuchNodeErr, err)
+	require.Empty(ds.GetMounts())
+	require.Equal(NoSuchNodeErr, ds.UnmountLabel(ctx, RootINode, "data", false))
+
+	// removing a directory takes any mounts inside it with it
+	outer, err := ds.MakeDir(ctx, RootINode, "outer")
+	require.Nil(err)
+	createFile(require, ds, outer, "local", "data")
+	_, _, err = ds.Checkout(ctx, outer, "data", "ref", false)
+	require.Nil(err)
+	require.Len(ds.GetMounts(), 1)
+	require.Nil(ds.RemoveTree(ctx, RootINode, "outer"))
+	_, err = ds.GetNodeID(ctx, RootINode, "outer")
+	require.Equal(NoSuchNodeErr, err)
+	require.Empty(ds.GetMounts())
+}
+
+// failingLeaseRemote fails to write leases while failLeases is set
+type failingLeaseRemote struct {
+	*RemoteRefFactoryMem
+	failLeases bool
+}
+
+func (r *failingLeaseRemote) SetLease(ctx context.Context, lease *Lease) error {
+	if r.failLeases {
+		return errors.New("lease write failed")
+	}
+	return r.RemoteRefFactoryMem.SetLease(ctx, lease)
+}
+
+func TestCheckoutLeasesBeforeReplacing(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	ds1 := newLeaseTestDataStore(require, f)
+	createFile(require, ds1, RootINode, "x", "version 1")
+	require.Nil(ds1.Push(ctx, RootINode, "ref"))
+	createFile(require, ds1, RootINode, "y", "version 2")
+	require.Nil(ds1.Push(ctx, RootINode, "ref"))
+	v1, err := ds1.ResolveLabel(ctx, "ref@1")
+	require.Nil(err)
+
+	remote := &failingLeaseRemote{RemoteRefFactoryMem: f}
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, remote, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	data, _, err := ds.Checkout(ctx, RootINode, "data", "ref@1", false)
+	require.Nil(err)
+
+	// if the new root can't be leased, the mount is left on the old one
+	remote.failLeases = true
+	_, _, err = ds.Checkout(ctx, RootINode, "data", "ref", false)
+	require.NotNil(err)
+	_, err = ds.GetNodeID(ctx, data, "y")
+	require.Equal(NoSuchNodeErr, err)
+	mounts := ds.GetMounts()
+	require.Len(mounts, 1)
+	require.Equal(v1, mounts[0].BID)
+	require.Equal(v1, f.leases[mounts[0].LeaseName].BID)
+}
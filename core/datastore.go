@@ -8,13 +8,17 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/pgm/sply2/region"
 )
 
 var NABlock BlockID = BlockID{}
@@ -35,13 +39,31 @@ type DataStore struct {
 	remoteRefFactory2 RemoteRefFactory2
 	writableStore     WriteableStore
 	remoteRefFactory  RemoteRefFactory
-	mounts            []*Mount
+	// guards mounts, which lease renewal reads in the background
+	mountsMutex sync.Mutex
+	mounts      []*Mount
 
 	// locker        *INodeLocker
 
 	networkClient NetworkClient
 
 	monitor Monitor
+
+	// shared by all copies from the remote
+	scheduler *region.FetchScheduler
+	// records reads of blocks whose remote object had been replaced
+	changeLog *remoteChangeLog
+
+	cacheQuota int64
+
+	// guards fsUsage, the space and inodes used as of fsUsageTime, which is slow to compute as it walks the repo
+	fsUsageMutex sync.Mutex
+	fsUsage      *FSStats
+	fsUsageTime  time.Time
+
+	// guards snapshotBlocksCache, the blocks protected by snapshots, which is nil until it's next needed
+	snapshotBlocksMutex sync.Mutex
+	snapshotBlocksCache map[BlockID]bool
 }
 
 // default expiry is 48 hours
@@ -50,6 +72,12 @@ const DEFAULT_EXPIRY = 2 * 24 * time.Hour
 // Renew leases every hour
 const STALE_LEASE_DURATION = 1 * time.Hour
 
+// How long the space and inodes used by the repo are reused for before being counted again
+const FSUsageTTL = 5 * time.Second
+
+// How often the daemon checks whether any leases need renewing
+const LEASE_RENEWAL_CHECK_INTERVAL = 5 * time.Minute
+
 ///////////////////////////
 
 func (d *DataStore) SetClients(networkClient NetworkClient) {
@@ -59,11 +87,21 @@ func (d *DataStore) SetClients(networkClient NetworkClient) {
 type DataStoreConfig struct {
 	chunkSize             int
 	rootBID               BlockID
+	rootLabel             string
 	rootBucket            string
 	rootKey               string
 	openExisting          bool
 	maxBackgroundTransfer int64
 	minUncommitted        int64
+	minReadahead          int64
+	readaheadBudget       int64
+	maxConcurrentFetches  int
+	fetchBandwidth        int64
+	retryLimit            int
+	retryDelay            time.Duration
+	generationPolicy      GenerationPolicy
+	cacheQuota            int64
+	monitor               Monitor
 }
 
 type DataStoreOption func(config *DataStoreConfig)
@@ -81,12 +119,20 @@ func DataStoreWithBIDRoot(BID BlockID) func(config *DataStoreConfig) {
 	}
 }
 
+// DataStoreWithLabelRoot creates the repo with the tree last pushed under label as its root
+func DataStoreWithLabelRoot(label string) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.rootLabel = label
+	}
+}
+
 func OpenExisting() func(config *DataStoreConfig) {
 	return func(config *DataStoreConfig) {
 		config.openExisting = true
 	}
 }
 
+// MaxBackgroundTransfer sets the largest readahead window, reached once a file has been read sequentially for a while
 func MaxBackgroundTransfer(length int64) func(config *DataStoreConfig) {
 	return func(config *DataStoreConfig) {
 		config.maxBackgroundTransfer = length
@@ -99,6 +145,70 @@ func MinUncommitted(length int64) func(config *DataStoreConfig) {
 	}
 }
 
+// MinReadahead sets the readahead window used when a file starts being read sequentially. The window doubles with
+// each sequential read up to MaxBackgroundTransfer.
+func MinReadahead(length int64) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.minReadahead = length
+	}
+}
+
+// ReadaheadBudget limits the rate (in bytes per second) at which data is fetched past what was asked for, across all
+// open files. Zero means unlimited.
+func ReadaheadBudget(bytesPerSecond int64) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.readaheadBudget = bytesPerSecond
+	}
+}
+
+// MaxConcurrentFetches sets the number of copies from the remote which can run at once, across all files. Zero means
+// unlimited.
+func MaxConcurrentFetches(count int) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.maxConcurrentFetches = count
+	}
+}
+
+// FetchBandwidth caps the rate (in bytes per second) at which data is copied from the remote, across all files.
+// Reads which are blocked on a copy take priority over readahead and prefetching. Zero means unlimited.
+func FetchBandwidth(bytesPerSecond int64) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.fetchBandwidth = bytesPerSecond
+	}
+}
+
+// RemoteRetries sets how many times a copy from the remote is retried after a transient failure, and the delay
+// before the first retry. The delay doubles with each retry.
+func RemoteRetries(limit int, delay time.Duration) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.retryLimit = limit
+		config.retryDelay = delay
+	}
+}
+
+// WithGenerationPolicy sets what happens when a file is read after the remote object it was linked from has been
+// replaced. Either way, the change is recorded in the repo's log of remote changes.
+func WithGenerationPolicy(policy GenerationPolicy) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.generationPolicy = policy
+	}
+}
+
+// CacheQuota sets the amount of local disk the freezer and writable area are expected to stay within.
+// Zero means unlimited.
+func CacheQuota(length int64) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.cacheQuota = length
+	}
+}
+
+// WithMonitor sets the Monitor which will be notified of opens, reads, fetches, etc
+func WithMonitor(monitor Monitor) func(config *DataStoreConfig) {
+	return func(config *DataStoreConfig) {
+		config.monitor = monitor
+	}
+}
+
 func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 	rrf2 RemoteRefFactory2, freezerKV KVStore,
 	nodeKV KVStore, options ...DataStoreOption) (*DataStore, error) {
@@ -106,7 +216,13 @@ func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 	config := DataStoreConfig{chunkSize: 200 * 1024,
 		rootBID:               NABlock,
 		minUncommitted:        DefaultMinUncommitted,
-		maxBackgroundTransfer: DefaultMaxBackgroundTransfer}
+		maxBackgroundTransfer: DefaultMaxBackgroundTransfer,
+		minReadahead:          DefaultMinReadahead,
+		maxConcurrentFetches:  DefaultMaxConcurrentFetches,
+		retryLimit:            DefaultRemoteRetries,
+		retryDelay:            DefaultRemoteRetryDelay,
+		generationPolicy:      StaleOnChange,
+		monitor:               &NullMonitor{}}
 	for _, option := range options {
 		option(&config)
 	}
@@ -122,6 +238,14 @@ func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 		if _, err := os.Stat(freezerPath); err == nil {
 			return nil, RepoExistsErr
 		}
+		if config.rootLabel != "" {
+			// resolve the label before creating anything, so a bad label doesn't leave a half created repo behind
+			BID, err := resolveLabel(context.Background(), remoteRefFactory, config.rootLabel)
+			if err != nil {
+				return nil, err
+			}
+			config.rootBID = BID
+		}
 		log.Printf("Creating new repo")
 		err := os.MkdirAll(freezerPath, 0700)
 		if err != nil {
@@ -131,18 +255,6 @@ func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 		if err != nil {
 			log.Fatalf("%s: Could not create %s\n", err, writablePath)
 		}
-
-		var mounts []*Mount
-		if _, err = os.Stat(mountTablePath); !os.IsNotExist(err) {
-			f, err := os.Open(mountTablePath)
-			defer f.Close()
-
-			dec := gob.NewDecoder(f)
-			err = dec.Decode(&mounts)
-			if err != nil {
-				log.Fatalf("%s: Could not read %s", err, mountTablePath)
-			}
-		}
 	}
 
 	db := NewINodeDB(10000000, nodeKV)
@@ -167,16 +279,43 @@ func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 		log.Fatalf("%s: Could not create root dir", err)
 	}
 
-	monitor := &NullMonitor{}
+	monitor := config.monitor
+
+	freezer := NewFreezer(freezerPath, freezerKV, rrf2, config.chunkSize, monitor)
+	freezer.maxBackgroundTransfer = config.maxBackgroundTransfer
+	freezer.minUncommitted = config.minUncommitted
+	freezer.minReadahead = config.minReadahead
+	freezer.retryLimit = config.retryLimit
+	freezer.retryDelay = config.retryDelay
+	freezer.generationPolicy = config.generationPolicy
+	changeLog := &remoteChangeLog{path: path.Join(storagePath, "remote-changes.log")}
+	freezer.changeLog = changeLog
+	if config.readaheadBudget > 0 {
+		freezer.readaheadBudget = newReadaheadBudget(config.readaheadBudget)
+	}
+	scheduler := region.NewFetchScheduler(region.MaxConcurrentCopies(config.maxConcurrentFetches),
+		region.MaxBytesPerSecond(config.fetchBandwidth))
+	freezer.scheduler = scheduler
 
 	ds := &DataStore{path: storagePath,
 		mountTablePath:    mountTablePath,
 		db:                db,
 		writableStore:     NewWritableStore(writablePath),
 		remoteRefFactory2: rrf2,
-		freezer:           NewFreezer(freezerPath, freezerKV, rrf2, config.chunkSize, monitor),
+		freezer:           freezer,
 		remoteRefFactory:  remoteRefFactory,
-		monitor:           monitor}
+		monitor:           monitor,
+		scheduler:         scheduler,
+		changeLog:         changeLog,
+		cacheQuota:        config.cacheQuota}
+
+	if config.openExisting {
+		ds.mounts, err = loadMountTable(mountTablePath)
+		if err != nil {
+			ds.Close()
+			return nil, err
+		}
+	}
 
 	if rootBID != NABlock {
 		// we created a root node which pointed to a remote BID, create the lease for it.
@@ -190,34 +329,19 @@ func NewDataStore(storagePath string, remoteRefFactory RemoteRefFactory,
 	return ds, nil
 }
 
-func (d *DataStore) persistMountTable() {
-	f, err := os.OpenFile(d.mountTablePath, os.O_CREATE|os.O_RDWR, 0660)
-	if err != nil {
-		log.Fatalf("%s: Could not open %s for writing", err, d.mountTablePath)
-	}
-	defer f.Close()
-	enc := gob.NewEncoder(f)
-	err = enc.Encode(d.mounts)
-	if err != nil {
-		log.Fatalf("%s: Could not write mount table", err)
-	}
-}
-
 func (d *DataStore) Close() {
 	d.db.Close()
+	d.freezer.Close()
 }
 
 ///////////////////////////
 
 // Mount
 
+// MountByLabel mounts the root that label points to at name. label can also be label@N or label@<timestamp> to
+// mount an earlier version.
 func (d *DataStore) MountByLabel(ctx context.Context, inode INode, name string, label string) error {
-	err := validateName(label)
-	if err != nil {
-		return err
-	}
-
-	BID, err := d.remoteRefFactory.GetRoot(ctx, label)
+	BID, err := d.ResolveLabel(ctx, label)
 	if err != nil {
 		return err
 	}
@@ -236,23 +360,10 @@ func genRandomString() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
-// need to call this periodically
-func (d *DataStore) renewLeases(ctx context.Context) error {
-	now := time.Now()
-	staleThreshold := now.Add(-STALE_LEASE_DURATION)
-	for _, mount := range d.mounts {
-		if mount.lastLeaseRenewal.Before(staleThreshold) {
-			err := d.remoteRefFactory.SetLease(ctx, mount.leaseName, now.Add(DEFAULT_EXPIRY), mount.BID)
-			if err != nil {
-				return err
-			}
-			mount.lastLeaseRenewal = now
-		}
-	}
-	return nil
-}
-
 func (d *DataStore) Unmount(ctx context.Context, inode INode) error {
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
+
 	var foundMount *Mount
 	newMounts := make([]*Mount, 0, len(d.mounts))
 
@@ -270,7 +381,7 @@ func (d *DataStore) Unmount(ctx context.Context, inode INode) error {
 	}
 
 	// record the lease is now expired
-	err := d.remoteRefFactory.SetLease(ctx, foundMount.leaseName, time.Now(), foundMount.BID)
+	err := d.remoteRefFactory.SetLease(ctx, d.newLease(foundMount.leaseName, foundMount.BID, time.Now()))
 	if err != nil {
 		return err
 	}
@@ -311,13 +422,47 @@ func (d *DataStore) Mount(ctx context.Context, parent INode, name string, BID Bl
 	return inode, nil
 }
 
+// releaseRemoved cleans up after the nodes removed were dropped from the tree: their unfrozen writes are deleted,
+// and the leases of any labels mounted among them are allowed to expire
+func (d *DataStore) releaseRemoved(ctx context.Context, removed []INode, writablePaths []string) error {
+	for _, writablePath := range writablePaths {
+		err := os.Remove(writablePath)
+		if err != nil {
+			log.Printf("Could not remove %s: %s", writablePath, err)
+		}
+	}
+
+	isRemoved := make(map[INode]bool)
+	for _, id := range removed {
+		isRemoved[id] = true
+	}
+	var removedMounts []INode
+	d.mountsMutex.Lock()
+	for _, m := range d.mounts {
+		if isRemoved[m.mountPoint] {
+			removedMounts = append(removedMounts, m.mountPoint)
+		}
+	}
+	d.mountsMutex.Unlock()
+	for _, mountPoint := range removedMounts {
+		err := d.Unmount(ctx, mountPoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *DataStore) CreateLeaseForMount(ctx context.Context, inode INode, BID BlockID) error {
 	mount := &Mount{mountPoint: inode, leaseName: genRandomString(), lastLeaseRenewal: time.Now(), BID: BID}
-	err := d.remoteRefFactory.SetLease(ctx, mount.leaseName, time.Now().Add(DEFAULT_EXPIRY), BID)
+	err := d.remoteRefFactory.SetLease(ctx, d.newLease(mount.leaseName, BID, time.Now().Add(DEFAULT_EXPIRY)))
 	if err != nil {
 		return err
 	}
 
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
 	d.mounts = append(d.mounts, mount)
 	d.persistMountTable()
 	return nil
@@ -391,7 +536,7 @@ type dirEntryCallback func(*DirEntryWithID) error
 func (d *DataStore) walkDirContents(ctx context.Context, id INode, callback dirEntryCallback) error {
 	var err error
 
-	err = d.readAfterLoadLazyChildren(ctx, id, func(tx RTx) error {
+	err = d.viewAfterLoadLazyChildren(ctx, id, func(tx RTx) error {
 
 		var names []NameINode
 		names, err = d.db.GetDirContents(tx, id, true)
@@ -400,20 +545,10 @@ func (d *DataStore) walkDirContents(ctx context.Context, id INode, callback dirE
 		}
 
 		for _, n := range names {
-			var node *NodeRepr
-			node, err = getNodeRepr(tx, n.ID)
-
-			size := node.Size
-			mtime := node.ModTime
-
-			if node.LocalWritablePath != "" {
-				fi, err := os.Stat(node.LocalWritablePath)
-				if err != nil {
-					return err
-				}
-
-				size = fi.Size()
-				mtime = fi.ModTime()
+			// getNodeRepr takes care of reporting the current size and mtime of writable files
+			node, err := getNodeRepr(tx, n.ID)
+			if err != nil {
+				return err
 			}
 
 			entry := &DirEntryWithID{ID: n.ID,
@@ -421,12 +556,12 @@ func (d *DataStore) walkDirContents(ctx context.Context, id INode, callback dirE
 					Name:         n.Name,
 					IsDirty:      node.IsDirty,
 					IsDir:        node.IsDir,
-					Size:         size,
-					ModTime:      mtime,
+					Size:         node.Size,
+					ModTime:      node.ModTime,
 					BID:          node.BID,
 					RemoteSource: node.RemoteSource}}
 
-			err := callback(entry)
+			err = callback(entry)
 			if err != nil {
 				return err
 			}
@@ -442,6 +577,45 @@ func (d *DataStore) walkDirContents(ctx context.Context, id INode, callback dirE
 	return nil
 }
 
+// ReadDirPlus returns up to maxEntries children of a directory, in name order, starting after the child
+// named "after" (or from the first child if after is ""). Each entry carries the child's attributes so
+// listing a directory doesn't require a separate GetAttr per child. "." and ".." are not included.
+func (d *DataStore) ReadDirPlus(ctx context.Context, id INode, after string, maxEntries int) ([]*DirEntryWithID, error) {
+	var entries []*DirEntryWithID
+
+	err := d.viewAfterLoadLazyChildren(ctx, id, func(tx RTx) error {
+		names, err := d.db.GetDirContentsAfter(tx, id, after, maxEntries)
+		if err != nil {
+			return err
+		}
+
+		entries = make([]*DirEntryWithID, 0, len(names))
+		for _, n := range names {
+			node, err := getNodeRepr(tx, n.ID)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, &DirEntryWithID{ID: n.ID,
+				DirEntry: DirEntry{
+					Name:         n.Name,
+					IsDirty:      node.IsDirty,
+					IsDir:        node.IsDir,
+					Size:         node.Size,
+					ModTime:      node.ModTime,
+					BID:          node.BID,
+					RemoteSource: node.RemoteSource}})
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
 func (d *DataStore) GetExtendedDirContents(ctx context.Context, id INode) ([]*ExtendedDirEntry, error) {
 	entries := make([]*ExtendedDirEntry, 0, 100)
 	callback := func(entry *DirEntryWithID) error {
@@ -510,47 +684,64 @@ func (d *DataStore) needsLoadLazyChildren(ctx context.Context, tx RTx, id INode)
 	return nil, nil
 }
 
-func (d *DataStore) loadLazyChildrenOutsideTransaction(ctx context.Context, id INode, node *NodeRepr) (func(tx RWTx) error, error) {
-	updateParent := func(tx RWTx) error {
-		node.IsDeferredChildFetch = false
-		return putNodeRepr(tx, id, node)
+// readDirBlock reads the frozen directory listing in block BID, fetching it from the remote unless it was frozen
+// locally or is already cached
+func (d *DataStore) readDirBlock(ctx context.Context, BID BlockID) (*Dir, error) {
+	fr, err := d.freezer.GetRef(BID)
+	if err != nil && err != UnknownBlockID {
+		return nil, err
 	}
 
-	if node.BID != NABlock {
-		// dir listing is stored in an immutable block
-		startTime := time.Now()
-
-		remoteSource, err := d.remoteRefFactory.GetBlockSource(ctx, node.BID)
+	if fr == nil {
+		remoteSource, err := d.remoteRefFactory.GetBlockSource(ctx, BID)
 		if err != nil {
 			return nil, err
 		}
 		if remoteSource == nil {
-			panic("got a nil source for block")
+			return nil, UnknownBlockID
 		}
-		fmt.Printf("remoteSource=%v\n", remoteSource)
 
 		remoteRef := d.remoteRefFactory2.GetRef(remoteSource)
+		err = d.freezer.AddBlock(ctx, BID, remoteRef)
 		if err != nil {
 			return nil, err
 		}
-		err = d.freezer.AddBlock(ctx, node.BID, remoteRef)
-		if err != nil {
-			return nil, err
-		}
-		fr, err := d.freezer.GetRef(node.BID)
+		fr, err = d.freezer.GetRef(BID)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		buffer, err := ioutil.ReadAll(makeReader(ctx, fr))
-		// buffer := make([]byte, node.Size)
-		// _, err = fr.Read(buffer)
-		dec := gob.NewDecoder(bytes.NewReader(buffer))
+	buffer, err := ioutil.ReadAll(makeReader(ctx, fr))
+	fr.Release()
+	if err != nil {
+		return nil, err
+	}
+	dec := gob.NewDecoder(bytes.NewReader(buffer))
 
-		var dir Dir
-		err = dec.Decode(&dir)
+	var dir Dir
+	err = dec.Decode(&dir)
+	if err != nil {
+		// the block is most likely a file's contents rather than a directory listing
+		log.Printf("Could not decode block %v as a directory: %v", BID, err)
+		return nil, NotDirErr
+	}
+	return &dir, nil
+}
+
+func (d *DataStore) loadLazyChildrenOutsideTransaction(ctx context.Context, id INode, node *NodeRepr) (func(tx RWTx) error, error) {
+	updateParent := func(tx RWTx) error {
+		node.IsDeferredChildFetch = false
+		return putNodeRepr(tx, id, node)
+	}
+
+	if node.BID != NABlock {
+		// dir listing is stored in an immutable block
+		startTime := time.Now()
+
+		dir, err := d.readDirBlock(ctx, node.BID)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		withinTransaction := func(tx RWTx) error {
@@ -569,7 +760,7 @@ func (d *DataStore) loadLazyChildrenOutsideTransaction(ctx context.Context, id I
 				updateParent(tx)
 
 				endTime := time.Now()
-				d.monitor.AddedLazyDirBlock(ctx, startTime, endTime)
+				d.monitor.AddedLazyDirBlock(ctx, id, startTime, endTime)
 			}
 
 			return nil
@@ -607,7 +798,7 @@ func (d *DataStore) loadLazyChildrenOutsideTransaction(ctx context.Context, id I
 				endTime := time.Now()
 				// elapsed := int(endTime.Sub(startTime) / time.Millisecond)
 
-				d.monitor.FetchedRemoteChildren(ctx, startTime, endTime)
+				d.monitor.FetchedRemoteChildren(ctx, id, startTime, endTime)
 			}
 			return nil
 		}
@@ -667,16 +858,43 @@ func (d *DataStore) readAfterLoadLazyChildren(ctx context.Context, parent INode,
 	updateWrapper := func(tx RWTx) error {
 		return update(tx)
 	}
-	return d.updateAfterMultiLoadLazyChildren(ctx, []INode{parent}, updateWrapper)
+	return d.loadLazyChildrenThen(ctx, []INode{parent}, d.db.loadUpdate, updateWrapper)
 }
 
 var NeedsLoadLazyChildrenError = errors.New("NeedsLoadLazyChildren")
 
+// viewAfterLoadLazyChildren is like readAfterLoadLazyChildren, but only uses a read-only transaction
+// when the children of parent have already been loaded.
+func (d *DataStore) viewAfterLoadLazyChildren(ctx context.Context, parent INode, read func(tx RTx) error) error {
+	err := d.db.view(func(tx RTx) error {
+		outsideTxCallback, err := d.needsLoadLazyChildren(ctx, tx, parent)
+		if err != nil {
+			return err
+		}
+		if outsideTxCallback != nil {
+			return NeedsLoadLazyChildrenError
+		}
+		return read(tx)
+	})
+
+	if err == NeedsLoadLazyChildrenError {
+		return d.readAfterLoadLazyChildren(ctx, parent, read)
+	}
+
+	return err
+}
+
 func (d *DataStore) updateAfterMultiLoadLazyChildren(ctx context.Context, parents []INode, update func(tx RWTx) error) error {
+	return d.loadLazyChildrenThen(ctx, parents, d.db.update, update)
+}
+
+// loadLazyChildrenThen runs update in a transaction started by runTx, once the children of each of parents have been
+// loaded
+func (d *DataStore) loadLazyChildrenThen(ctx context.Context, parents []INode, runTx func(func(tx RWTx) error) error, update func(tx RWTx) error) error {
 	var outsideTxCallbacks [2]func() (func(tx RWTx) error, error)
 	needsLoadCount := 0
 
-	err := d.db.update(func(tx RWTx) error {
+	err := runTx(func(tx RWTx) error {
 		for _, parent := range parents {
 			outsideTxCallback, err := d.needsLoadLazyChildren(ctx, tx, parent)
 			if err != nil {
@@ -714,7 +932,7 @@ func (d *DataStore) updateAfterMultiLoadLazyChildren(ctx context.Context, parent
 			}
 			insideTxCallbacks[i] = insideTxCallback
 		}
-		err = d.db.update(func(tx RWTx) error {
+		err = runTx(func(tx RWTx) error {
 			for i := 0; i < needsLoadCount; i++ {
 				err := insideTxCallbacks[i](tx)
 				if err != nil {
@@ -738,8 +956,36 @@ func (d *DataStore) updateAfterLoadLazyChildren(ctx context.Context, parent INod
 	return d.updateAfterMultiLoadLazyChildren(ctx, []INode{parent}, update)
 }
 
-func (d *DataStore) AddRemoteGCS(ctx context.Context, parent INode, name string, bucket string, key string) (INode, error) {
+// AddRemoteConfig holds the options for AddRemoteGCS and AddRemoteURL
+type AddRemoteConfig struct {
+	// the generation the GCS object must be at, or 0 for any
+	generation int64
+	// the ETag the URL must have, or "" for any
+	etag string
+}
+
+type AddRemoteOption func(config *AddRemoteConfig)
+
+// ExpectGeneration makes AddRemoteGCS fail with SourceChangedErr unless the object is at generation
+func ExpectGeneration(generation int64) AddRemoteOption {
+	return func(config *AddRemoteConfig) {
+		config.generation = generation
+	}
+}
+
+// ExpectETag makes AddRemoteURL fail with SourceChangedErr unless the URL has etag
+func ExpectETag(etag string) AddRemoteOption {
+	return func(config *AddRemoteConfig) {
+		config.etag = etag
+	}
+}
+
+func (d *DataStore) AddRemoteGCS(ctx context.Context, parent INode, name string, bucket string, key string, options ...AddRemoteOption) (INode, error) {
 	var inode INode
+	var config AddRemoteConfig
+	for _, option := range options {
+		option(&config)
+	}
 
 	err := validateName(name)
 	if err != nil {
@@ -750,6 +996,10 @@ func (d *DataStore) AddRemoteGCS(ctx context.Context, parent INode, name string,
 	if err != nil {
 		return InvalidINode, err
 	}
+	if config.generation != 0 && attrs.Generation != config.generation {
+		log.Printf("gs://%s/%s is at generation %d, not %d", bucket, key, attrs.Generation, config.generation)
+		return InvalidINode, SourceChangedErr
+	}
 
 	err = d.updateAfterLoadLazyChildren(ctx, parent, func(tx RWTx) error {
 		inode, err = d.db.AddRemoteGCS(tx, parent, name, bucket, key, attrs.Generation, attrs.Size, attrs.ModTime, attrs.IsDir)
@@ -909,9 +1159,13 @@ func (d *DataStore) AddImmutableBytes(ctx context.Context, parent INode, name st
 
 }
 
-func (d *DataStore) AddRemoteURL(ctx context.Context, parent INode, name string, URL string) (INode, error) {
+func (d *DataStore) AddRemoteURL(ctx context.Context, parent INode, name string, URL string, options ...AddRemoteOption) (INode, error) {
 	var inode INode
 	var err error
+	var config AddRemoteConfig
+	for _, option := range options {
+		option(&config)
+	}
 
 	err = validateName(name)
 	if err != nil {
@@ -922,6 +1176,10 @@ func (d *DataStore) AddRemoteURL(ctx context.Context, parent INode, name string,
 	if err != nil {
 		return InvalidINode, err
 	}
+	if config.etag != "" && attrs.ETag != config.etag {
+		log.Printf("%s has ETag %s, not %s", URL, attrs.ETag, config.etag)
+		return InvalidINode, SourceChangedErr
+	}
 
 	modTime := time.Now()
 
@@ -975,6 +1233,8 @@ func (d *DataStore) CreateWritable(ctx context.Context, parent INode, name strin
 		return InvalidINode, nil, err
 	}
 
+	d.monitor.FileOpened(ctx, inode, true)
+
 	return inode, &WritableRefImp{filename, 0}, err
 }
 
@@ -996,26 +1256,119 @@ func freezeDir(tempDir string, freezer Freezer, dir *Dir) (*NewBlock, error) {
 	return newBlock, err
 }
 
-func (ds *DataStore) Push(ctx context.Context, inode INode, name string) error {
-	err := validateName(name)
+// startPush checks that name can be pushed to, and returns where the label points before anything is uploaded, so
+// that a push which finishes first isn't overwritten
+func (ds *DataStore) startPush(ctx context.Context, name string, config *PushConfig) (BlockID, error) {
+	ref, err := ParseLabelRef(name)
 	if err != nil {
 		fmt.Printf("validateName error: %s", err)
+		return NABlock, err
+	}
+	if !ref.IsLatest() {
+		// only the latest version of a label can be pushed to
+		return NABlock, InvalidLabelVersionErr
+	}
+
+	parentBID, err := ds.remoteRefFactory.GetRoot(ctx, name)
+	if err == UndefinedRootErr {
+		parentBID = NABlock
+	} else if err != nil {
+		log.Printf("ds.remoteRefFactory.GetRoot error: %s", err)
+		return NABlock, err
+	}
+	if config.expected != nil && *config.expected != parentBID && !config.force {
+		return NABlock, LabelConflictErr
+	}
+	return parentBID, nil
+}
+
+// uploadBlocks pushes each block in blockList to the remote, returning the number of bytes uploaded
+func (ds *DataStore) uploadBlocks(ctx context.Context, blockList []BlockID) (int64, error) {
+	// Could do this in parallel instead of sequentially
+	var pushedBytes int64
+	for _, BID := range blockList {
+		frozen, err := ds.freezer.GetRef(BID)
+		if err != nil {
+			log.Printf("ds.freezer.GetRef error: %s", err)
+			return pushedBytes, err
+		}
+
+		size, err := frozen.Seek(0, io.SeekEnd)
+		if err != nil {
+			frozen.Release()
+			return pushedBytes, err
+		}
+		_, err = frozen.Seek(0, io.SeekStart)
+		if err != nil {
+			frozen.Release()
+			return pushedBytes, err
+		}
+		pushedBytes += size
+
+		err = ds.remoteRefFactory.Push(ctx, BID, frozen)
+		if err != nil {
+			log.Printf("ds.remoteRefFactory.Push error: %s", err)
+			return pushedBytes, err
+		}
+		frozen.Release()
+	}
+	return pushedBytes, nil
+}
+
+// moveLabel points name at rootBID, as long as it still points at parentBID (unless forced), and records the push in
+// the label's history
+func (ds *DataStore) moveLabel(ctx context.Context, name string, parentBID BlockID, rootBID BlockID, config *PushConfig) error {
+	if rootBID == NABlock {
+		panic("Cannot set root to invalid block")
+	}
+
+	var err error
+	if config.force {
+		parentBID, err = ds.remoteRefFactory.GetRoot(ctx, name)
+		if err == UndefinedRootErr {
+			parentBID = NABlock
+		} else if err != nil {
+			return err
+		}
+		err = ds.remoteRefFactory.SetRoot(ctx, name, rootBID)
+	} else {
+		err = ds.remoteRefFactory.CompareAndSetRoot(ctx, name, parentBID, rootBID)
+	}
+	if err != nil {
+		log.Printf("ds.remoteRefFactory.SetRoot error: %s", err)
 		return err
 	}
 
-	rootBID, err := ds.Freeze(inode)
+	// only record the push in the label's history once the label has moved, so that pushes which lost a race don't
+	// appear in it. By then the push has happened, so failing to record it is only logged.
+	err = ds.remoteRefFactory.AddLabelVersion(ctx, name, newLabelVersion(rootBID, parentBID, config.message))
+	if err != nil {
+		log.Printf("Pushed %s, but could not add it to the label's history: %s", name, err)
+	}
+	return nil
+}
+
+func (ds *DataStore) Push(ctx context.Context, inode INode, name string, options ...PushOption) error {
+	startTime := time.Now()
+	var config PushConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	parentBID, err := ds.startPush(ctx, name, &config)
 	if err != nil {
-		fmt.Printf("freeze error: %s", err)
 		return err
 	}
 
-	isPushed := func(BID BlockID) (bool, error) {
-		return ds.freezer.IsPushed(BID)
+	rootBID, err := ds.Freeze(inode)
+	if err != nil {
+		fmt.Printf("freeze error: %s", err)
+		return err
 	}
 
 	blockList := make([]BlockID, 0, 100)
 	err = ds.db.view(func(tx RTx) error {
-		err = collectUnpushed(ds.db, tx, inode, isPushed, &blockList)
+		err = collectUnpushed(ds.db, tx, inode, ds.isPushed, &blockList)
 		if err != nil {
 			fmt.Printf("collectUnpushed error: %s", err)
 			return err
@@ -1029,48 +1382,50 @@ func (ds *DataStore) Push(ctx context.Context, inode INode, name string) error {
 
 	log.Printf("Collected %d unpushed blocks", len(blockList))
 
-	// Could do this in parallel instead of sequentially
-	for _, BID := range blockList {
-		frozen, err := ds.freezer.GetRef(BID)
-		if err != nil {
-			log.Printf("ds.freezer.GetRef error: %s", err)
-			return err
-		}
-
-		err = ds.remoteRefFactory.Push(ctx, BID, frozen)
-		if err != nil {
-			log.Printf("ds.remoteRefFactory.Push error: %s", err)
-			return err
-		}
-		frozen.Release()
-	}
-
-	if rootBID == NABlock {
-		panic("Cannot set root to invalid block")
+	pushedBytes, err := ds.uploadBlocks(ctx, blockList)
+	if err != nil {
+		return err
 	}
 
-	err = ds.remoteRefFactory.SetRoot(ctx, name, rootBID)
+	err = ds.moveLabel(ctx, name, parentBID, rootBID, &config)
 	if err != nil {
-		log.Printf("ds.remoteRefFactory.SetRoot error: %s", err)
 		return err
 	}
 
 	// now that we've successfully pushed all data, update our lease to reflect the change if this was a mount point
-	for _, mount := range ds.mounts {
-		if mount.mountPoint == inode {
-			mount.BID = rootBID
-			err = ds.renewLeases(ctx)
-			if err != nil {
-				log.Printf("ds.remoteRefFactory.SetRoot error: %s", err)
-				return err
-			}
-			break
-		}
+	err = ds.moveLease(ctx, inode, rootBID)
+	if err != nil {
+		log.Printf("Could not move lease to %v: %s", rootBID, err)
+		return err
 	}
 
+	endTime := time.Now()
+	pushedBytesTotal.Add(float64(pushedBytes))
+	pushDuration.Observe(endTime.Sub(startTime).Seconds())
+	ds.monitor.Pushed(ctx, inode, name, rootBID, startTime, endTime, len(blockList), pushedBytes)
+
 	return nil
 }
 
+// isPushed is true if the remote already has a copy of BID. Blocks the freezer has never seen, such as those in
+// directories fetched from a label which were never read, can only have come from the remote.
+func (ds *DataStore) isPushed(BID BlockID) (bool, error) {
+	fr, err := ds.freezer.GetRef(BID)
+	if err == UnknownBlockID {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if fr == nil {
+		// only blocks with a remote copy can be evicted
+		return true, nil
+	}
+	fr.Release()
+
+	return ds.freezer.IsPushed(BID)
+}
+
 func collectUnpushed(db *INodeDB, tx RTx, inode INode, isPushed func(BlockID) (bool, error), blockList *[]BlockID) error {
 	node, err := getNodeRepr(tx, inode)
 	if err != nil {
@@ -1203,8 +1558,10 @@ func (d *DataStore) Freeze(inode INode) (BlockID, error) {
 	var BID BlockID
 
 	err = d.db.update(func(tx RWTx) error {
-		var newNode *NodeRepr
-		newNode, err = freeze(d.path, d.freezer, d.db, tx, inode)
+		newNode, err := freeze(d.path, d.freezer, d.db, tx, inode)
+		if err != nil {
+			return err
+		}
 		BID = newNode.BID
 		return nil
 	})
@@ -1248,6 +1605,8 @@ func (d *DataStore) GetWritableRef(ctx context.Context, inode INode, truncate bo
 		fp.Close()
 	}
 
+	d.monitor.FileOpened(ctx, inode, true)
+
 	return &WritableRefImp{node.LocalWritablePath, 0}, nil
 }
 
@@ -1272,6 +1631,7 @@ func (d *DataStore) GetReadRef(ctx context.Context, inode INode) (Reader, error)
 	}
 
 	if node.LocalWritablePath != "" {
+		d.monitor.FileOpened(ctx, inode, false)
 		return &WritableRefImp{node.LocalWritablePath, 0}, nil
 	}
 
@@ -1292,6 +1652,8 @@ func (d *DataStore) GetReadRef(ctx context.Context, inode INode) (Reader, error)
 		return nil, err
 	}
 
+	d.monitor.FileOpened(ctx, inode, false)
+
 	return ref, nil
 }
 
@@ -1352,6 +1714,38 @@ func (ds *DataStore) SplitPath(ctx context.Context, fullPath string) (INode, str
 	return parent, components[len(components)-1], nil
 }
 
+// GetPath returns the path of inode relative to the root (the reverse of GetINodeForPath). The root is "."
+func (ds *DataStore) GetPath(inode INode) (string, error) {
+	var names []string
+	err := ds.db.view(func(tx RTx) error {
+		for inode != RootINode {
+			node, err := getNodeRepr(tx, inode)
+			if err != nil {
+				return err
+			}
+			name, err := ds.db.GetChildName(tx, node.ParentINode, inode)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+			inode = node.ParentINode
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(names) == 0 {
+		return ".", nil
+	}
+
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/"), nil
+}
+
 func (ds *DataStore) GetINodeForPath(ctx context.Context, Path string) (INode, error) {
 	// log.Printf("GetINodeForPath: %s", Path)
 	inode := INode(RootINode)
@@ -1369,6 +1763,71 @@ func (ds *DataStore) GetINodeForPath(ctx context.Context, Path string) (INode, e
 	return inode, nil
 }
 
+// ChangeCount returns a number which goes up whenever anything in the repo is changed, so that callers which cache
+// attributes can tell when they may be out of date
+func (d *DataStore) ChangeCount() uint64 {
+	return d.db.changeCount()
+}
+
+// Monitor returns the Monitor which is notified of activity within this DataStore
+func (d *DataStore) Monitor() Monitor {
+	return d.monitor
+}
+
+// GetFSStats returns the capacity of the local filesystem and how much of it the repo is using. Counting the space
+// and inodes used means walking the whole repo, so those figures may be up to FSUsageTTL old.
+func (d *DataStore) GetFSStats() (*FSStats, error) {
+	stats := &FSStats{CacheQuota: d.cacheQuota, MaxINodes: d.db.MaxINodes()}
+
+	err := localFSStats(d.path, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := d.getFSUsage()
+	if err != nil {
+		return nil, err
+	}
+	stats.FreezerUsed = usage.FreezerUsed
+	stats.WritableUsed = usage.WritableUsed
+	stats.INodeCount = usage.INodeCount
+
+	return stats, nil
+}
+
+func (d *DataStore) getFSUsage() (*FSStats, error) {
+	d.fsUsageMutex.Lock()
+	defer d.fsUsageMutex.Unlock()
+
+	if d.fsUsage != nil && time.Now().Sub(d.fsUsageTime) < FSUsageTTL {
+		return d.fsUsage, nil
+	}
+
+	usage := &FSStats{}
+	var err error
+	usage.FreezerUsed, err = d.freezer.GetUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	usage.WritableUsed, err = d.writableStore.GetUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.db.view(func(tx RTx) error {
+		usage.INodeCount, err = d.db.GetINodeCount(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.fsUsage = usage
+	d.fsUsageTime = time.Now()
+	return usage, nil
+}
+
 func (ds *DataStore) PrintStats() {
 	fmt.Printf("PrintStats\n")
 	now := time.Now()
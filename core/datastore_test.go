@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"encoding/gob"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -29,25 +30,31 @@ func createFile(require *require.Assertions, d *DataStore, parent INode, name st
 }
 
 type LoggingMonitor struct {
+	NullMonitor
 	events []string
 }
 
-func (m *LoggingMonitor) AddedLazyDirBlock(ctx context.Context, startTime time.Time, endTime time.Time) {
+func (m *LoggingMonitor) AddedLazyDirBlock(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
 	m.events = append(m.events, "AddedLazyDirBlock")
 }
 
-func (m *LoggingMonitor) FetchedRemoteChildren(ctx context.Context, startTime time.Time, endTime time.Time) {
+func (m *LoggingMonitor) FetchedRemoteChildren(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
 	m.events = append(m.events, "FetchedRemoteChildren")
 }
 
-func (m *LoggingMonitor) RegionCopied(ctx context.Context, startTime time.Time, endTime time.Time, start int64, end int64) {
+func (m *LoggingMonitor) RegionCopied(ctx context.Context, BID BlockID, startTime time.Time, endTime time.Time, start int64, end int64) {
 	m.events = append(m.events, "RegionCopied")
 }
 
+// newMemDataStore creates a DataStore in dir which keeps its tables in memory
+func newMemDataStore(dir string, f RemoteRefFactory, rrf2 RemoteRefFactory2, options ...DataStoreOption) (*DataStore, error) {
+	return NewDataStore(dir, f, rrf2, NewMemStore([][]byte{ChunkStat}), NewMemStore([][]byte{ChildNodeBucket, NodeBucket}), options...)
+}
+
 func newDataStore(dir string) *DataStore {
 	repo := NewRemoteRefFactoryMem()
 	rrf2 := NewMemRemoteRefFactory2(repo)
-	ds, err := NewDataStore(dir, repo, rrf2, NewMemStore([][]byte{ChunkStat}), NewMemStore([][]byte{ChildNodeBucket, NodeBucket}))
+	ds, err := newMemDataStore(dir, repo, rrf2)
 	ds.monitor = &LoggingMonitor{}
 	if err != nil {
 		panic(err)
@@ -475,3 +482,89 @@ func (n *NetworkClientImp) GetHTTPAttr(ctx context.Context, url string) (*HTTPAt
 // 	// make sure our go routine which checked blocking behavior did everything right
 // 	require.True(blocksOkay)
 // }
+
+func TestReadDirPlus(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	d := testDataStore()
+
+	for _, name := range []string{"e", "b", "a", "d", "c"} {
+		createFile(require, d, RootINode, name, "data-"+name)
+	}
+
+	entries, err := d.ReadDirPlus(ctx, RootINode, "", 2)
+	require.Nil(err)
+	require.Equal([]string{"a", "b"}, extractNames(entries))
+	require.Equal(int64(6), entries[0].Size)
+
+	// entries added before the position we've read up to shouldn't cause anything to be repeated
+	createFile(require, d, RootINode, "aa", "data")
+
+	entries, err = d.ReadDirPlus(ctx, RootINode, "b", 2)
+	require.Nil(err)
+	require.Equal([]string{"c", "d"}, extractNames(entries))
+
+	// and removing the entry we resume after shouldn't cause anything to be skipped
+	err = d.Remove(ctx, RootINode, "d")
+	require.Nil(err)
+
+	entries, err = d.ReadDirPlus(ctx, RootINode, "d", 2)
+	require.Nil(err)
+	require.Equal([]string{"e"}, extractNames(entries))
+}
+
+func TestChangeCount(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	a, err := ds1.MakeDir(ctx, RootINode, "a")
+	require.Nil(err)
+	createFile(require, ds1, a, "b", "data")
+	require.Nil(ds1.Push(ctx, RootINode, "label"))
+
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f), DataStoreWithLabelRoot("label"))
+	require.Nil(err)
+
+	// listing a directory for the first time loads its children, which isn't a change
+	before := ds2.ChangeCount()
+	a, err = ds2.GetNodeID(ctx, RootINode, "a")
+	require.Nil(err)
+	_, err = ds2.GetDirContents(ctx, a)
+	require.Nil(err)
+	require.Equal(before, ds2.ChangeCount())
+
+	require.Nil(ds2.Remove(ctx, a, "b"))
+	require.True(ds2.ChangeCount() > before)
+}
+
+func TestStatusWithMissingWritableFile(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	ds := testDataStore()
+	createFile(require, ds, RootINode, "a", "data")
+	b := createFile(require, ds, RootINode, "b", "more data")
+
+	status, err := ds.GetStatus(time.Second)
+	require.Nil(err)
+	require.Equal(2, status.DirtyFileCount)
+	require.Equal(int64(len("data")+len("more data")), status.DirtyFileBytes)
+
+	// b's local copy disappears out from under us, which shouldn't stop status from being reported
+	node, err := ds.GetAttr(ctx, b)
+	require.Nil(err)
+	require.Nil(os.Remove(node.LocalWritablePath))
+
+	status, err = ds.GetStatus(time.Second)
+	require.Nil(err)
+	require.Equal(1, status.DirtyFileCount)
+	require.Equal(int64(len("data")), status.DirtyFileBytes)
+}
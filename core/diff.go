@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"path"
+	"sort"
+)
+
+type DiffKind string
+
+const (
+	Added    DiffKind = "added"
+	Removed  DiffKind = "removed"
+	Modified DiffKind = "modified"
+	// an entry which was removed from one path and added at another with the same block ID
+	Renamed DiffKind = "renamed"
+)
+
+// DiffEntry is one difference between two trees. Added and removed directories are reported once, rather than
+// listing everything within them, so a file moved into a new directory shows up as a removal and the new directory
+// rather than a rename.
+type DiffEntry struct {
+	Kind DiffKind
+	Path string
+	// where a renamed entry was in the old tree
+	OldPath string
+	IsDir   bool
+	// NABlock and 0 for added entries
+	OldBID  BlockID
+	OldSize int64
+	// NABlock and 0 for removed entries. NABlock also for files with changes which haven't been frozen.
+	BID  BlockID
+	Size int64
+}
+
+// TreeRef is one side of a diff: either a frozen tree, or a directory in this repo
+type TreeRef struct {
+	BID   BlockID
+	INode INode
+}
+
+func FrozenTree(BID BlockID) TreeRef {
+	return TreeRef{BID: BID, INode: InvalidINode}
+}
+
+func RepoTree(inode INode) TreeRef {
+	return TreeRef{BID: NABlock, INode: inode}
+}
+
+// diffNode is an entry on one side of a diff. Directories in the repo are listed from the node db, so that changes
+// which haven't been frozen yet are seen. Everything else is listed from frozen directory blocks.
+type diffNode struct {
+	DirEntry
+	inode INode
+}
+
+func (n *diffNode) isLive() bool {
+	return n.inode != InvalidINode
+}
+
+func (d *DataStore) listDiffNode(ctx context.Context, n *diffNode) (map[string]*diffNode, error) {
+	children := make(map[string]*diffNode)
+	if n.isLive() {
+		entries, err := d.GetDirContents(ctx, n.inode)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Name == "." || e.Name == ".." {
+				continue
+			}
+			children[e.Name] = &diffNode{DirEntry: e.DirEntry, inode: e.ID}
+		}
+	} else {
+		dir, err := d.readDirBlock(ctx, n.BID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range dir.Entries {
+			children[e.Name] = &diffNode{DirEntry: e, inode: InvalidINode}
+		}
+	}
+	return children, nil
+}
+
+// unchanged is true if a and b are known to have the same contents without looking inside them. Anything with changes
+// that haven't been frozen has no block ID yet, so is never considered unchanged.
+func unchanged(a *diffNode, b *diffNode) bool {
+	return a.BID != NABlock && a.BID == b.BID
+}
+
+func (d *DataStore) diffDirs(ctx context.Context, dirPath string, a *diffNode, b *diffNode, changes *[]*DiffEntry) error {
+	if unchanged(a, b) {
+		return nil
+	}
+
+	aChildren, err := d.listDiffNode(ctx, a)
+	if err != nil {
+		return err
+	}
+	bChildren, err := d.listDiffNode(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(aChildren)+len(bChildren))
+	for name := range aChildren {
+		names = append(names, name)
+	}
+	for name := range bChildren {
+		if _, ok := aChildren[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := path.Join(dirPath, name)
+		aChild, inA := aChildren[name]
+		bChild, inB := bChildren[name]
+		removed := &DiffEntry{Kind: Removed, Path: childPath}
+		added := &DiffEntry{Kind: Added, Path: childPath}
+		if inA {
+			removed.IsDir, removed.OldBID, removed.OldSize = aChild.IsDir, aChild.BID, aChild.Size
+		}
+		if inB {
+			added.IsDir, added.BID, added.Size = bChild.IsDir, bChild.BID, bChild.Size
+		}
+
+		if !inB {
+			*changes = append(*changes, removed)
+		} else if !inA {
+			*changes = append(*changes, added)
+		} else if aChild.IsDir != bChild.IsDir {
+			*changes = append(*changes, removed, added)
+		} else if aChild.IsDir {
+			err = d.diffDirs(ctx, childPath, aChild, bChild, changes)
+			if err != nil {
+				return err
+			}
+		} else if !unchanged(aChild, bChild) {
+			*changes = append(*changes, &DiffEntry{Kind: Modified, Path: childPath,
+				OldBID: aChild.BID, OldSize: aChild.Size,
+				BID: bChild.BID, Size: bChild.Size})
+		}
+	}
+
+	return nil
+}
+
+// pairRenames replaces removed and added entries with the same block ID with a single rename
+func pairRenames(changes []*DiffEntry) []*DiffEntry {
+	removedByBID := make(map[BlockID][]*DiffEntry)
+	for _, c := range changes {
+		if c.Kind == Removed && c.OldBID != NABlock {
+			removedByBID[c.OldBID] = append(removedByBID[c.OldBID], c)
+		}
+	}
+
+	renamedFrom := make(map[*DiffEntry]bool)
+	for _, c := range changes {
+		candidates := removedByBID[c.BID]
+		if c.Kind != Added || c.BID == NABlock || len(candidates) == 0 || candidates[0].IsDir != c.IsDir {
+			continue
+		}
+		removed := candidates[0]
+		removedByBID[c.BID] = candidates[1:]
+		renamedFrom[removed] = true
+
+		c.Kind = Renamed
+		c.OldPath = removed.Path
+		c.OldBID = removed.OldBID
+		c.OldSize = removed.OldSize
+	}
+
+	result := make([]*DiffEntry, 0, len(changes))
+	for _, c := range changes {
+		if !renamedFrom[c] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func (d *DataStore) diffRoot(ctx context.Context, tree TreeRef) (*diffNode, error) {
+	if tree.INode == InvalidINode {
+		return &diffNode{DirEntry: DirEntry{IsDir: true, BID: tree.BID}, inode: InvalidINode}, nil
+	}
+
+	node, err := d.GetAttr(ctx, tree.INode)
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsDir {
+		return nil, NotDirErr
+	}
+	return &diffNode{DirEntry: DirEntry{IsDir: true, IsDirty: node.IsDirty, BID: node.BID, Size: node.Size}, inode: tree.INode}, nil
+}
+
+// Diff reports how the tree new differs from old, ordered by path. Subtrees with the same block ID on both sides are
+// skipped without being read, so comparing trees which share most of their blocks is cheap.
+func (d *DataStore) Diff(ctx context.Context, old TreeRef, new TreeRef) ([]*DiffEntry, error) {
+	a, err := d.diffRoot(ctx, old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.diffRoot(ctx, new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]*DiffEntry, 0)
+	err = d.diffDirs(ctx, "", a, b, &changes)
+	if err != nil {
+		return nil, err
+	}
+	return pairRenames(changes), nil
+}
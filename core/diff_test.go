@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+
+	a, err := ds.MakeDir(ctx, RootINode, "a")
+	require.Nil(err)
+	createFile(require, ds, a, "x", generateUniqueString())
+	createFile(require, ds, a, "y", generateUniqueString())
+	b, err := ds.MakeDir(ctx, RootINode, "b")
+	require.Nil(err)
+	createFile(require, ds, b, "z", generateUniqueString())
+	e, err := ds.MakeDir(ctx, RootINode, "e")
+	require.Nil(err)
+	createFile(require, ds, e, "unchanged", generateUniqueString())
+	require.Nil(ds.Push(ctx, RootINode, "v1"))
+	v1, err := f.GetRoot(ctx, "v1")
+	require.Nil(err)
+
+	// a tree doesn't differ from itself
+	changes, err := ds.Diff(ctx, FrozenTree(v1), RepoTree(RootINode))
+	require.Nil(err)
+	require.Empty(changes)
+
+	require.Nil(ds.Remove(ctx, a, "x"))
+	createFile(require, ds, a, "x", "new content")
+	require.Nil(ds.Remove(ctx, a, "y"))
+	require.Nil(ds.Rename(ctx, b, "z", a, "z"))
+	require.Nil(ds.Rename(ctx, RootINode, "e", RootINode, "f"))
+	createFile(require, ds, RootINode, "d", "added")
+
+	requireChanges := func(changes []*DiffEntry) {
+		require.Len(changes, 5)
+		require.Equal(Modified, changes[0].Kind)
+		require.Equal("a/x", changes[0].Path)
+		require.Equal(int64(len("new content")), changes[0].Size)
+		require.Equal(Removed, changes[1].Kind)
+		require.Equal("a/y", changes[1].Path)
+		require.Equal(Renamed, changes[2].Kind)
+		require.Equal("b/z", changes[2].OldPath)
+		require.Equal("a/z", changes[2].Path)
+		require.Equal(changes[2].OldBID, changes[2].BID)
+		require.Equal(Added, changes[3].Kind)
+		require.Equal("d", changes[3].Path)
+		require.False(changes[3].IsDir)
+		require.Equal(Renamed, changes[4].Kind)
+		require.Equal("e", changes[4].OldPath)
+		require.Equal("f", changes[4].Path)
+		require.True(changes[4].IsDir)
+	}
+
+	// unfrozen changes in the repo are compared against the label
+	changes, err = ds.Diff(ctx, FrozenTree(v1), RepoTree(RootINode))
+	require.Nil(err)
+	requireChanges(changes)
+
+	// and once pushed, the labels can be compared
+	require.Nil(ds.Push(ctx, RootINode, "v2"))
+	v2, err := f.GetRoot(ctx, "v2")
+	require.Nil(err)
+
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	changes, err = ds2.Diff(ctx, FrozenTree(v1), FrozenTree(v2))
+	require.Nil(err)
+	requireChanges(changes)
+
+	// without reading the subtree which is the same in both
+	eNode, err := ds.GetAttr(ctx, e)
+	require.Nil(err)
+	require.NotEqual(NABlock, eNode.BID)
+	_, err = ds2.freezer.GetRef(eNode.BID)
+	require.Equal(UnknownBlockID, err)
+
+	// reversing the diff swaps additions and removals
+	changes, err = ds2.Diff(ctx, FrozenTree(v2), FrozenTree(v1))
+	require.Nil(err)
+	require.Len(changes, 5)
+	require.Equal(Added, changes[1].Kind)
+	require.Equal("a/y", changes[1].Path)
+	require.Equal(Renamed, changes[2].Kind)
+	require.Equal("a/z", changes[2].OldPath)
+	require.Equal("b/z", changes[2].Path)
+	require.Equal(Removed, changes[3].Kind)
+	require.Equal("d", changes[3].Path)
+
+	// only directories can be compared
+	x, err := ds.GetNodeID(ctx, a, "x")
+	require.Nil(err)
+	_, err = ds.Diff(ctx, FrozenTree(v1), RepoTree(x))
+	require.Equal(NotDirErr, err)
+
+	// including when the frozen side is a file's block
+	xNode, err := ds.GetAttr(ctx, x)
+	require.Nil(err)
+	require.NotEqual(NABlock, xNode.BID)
+	_, err = ds2.Diff(ctx, FrozenTree(xNode.BID), FrozenTree(v2))
+	require.Equal(NotDirErr, err)
+}
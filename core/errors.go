@@ -1,6 +1,9 @@
 package core
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var UnknownBlockID = errors.New("unknown block id")
 var INodesExhaustedErr = errors.New("INodes exhausted")
@@ -14,10 +17,82 @@ var DirNotEmptyErr = errors.New("Directory is not empty")
 var IsDirErr = errors.New("Is directory, not a normal file")
 var AlreadyMountPointErr = errors.New("This path is already mounted")
 var NoSuchMountErr = errors.New("Was not a valid mount")
+var MountChangedErr = errors.New("Mount has changes which haven't been pushed")
 var UndefinedRootErr = errors.New("No such root exists")
 var NotWritableErr = errors.New("File is not writable")
+var NotEvictableErr = errors.New("Block has no remote copy and cannot be evicted")
+var BlockInUseErr = errors.New("Block is in use")
+var NoNetworkClientErr = errors.New("No network client configured")
+var InvalidLabelVersionErr = errors.New("Label version must be a number from 1 or a timestamp, and cannot be pushed to")
+var LabelConflictErr = errors.New("Label was moved by another push")
+var UnknownLabelVersionErr = errors.New("Label has no such version")
+var NoSuchSnapshotErr = errors.New("No such snapshot")
+var SnapshotExistsErr = errors.New("A snapshot with that name already exists")
+var InSnapshotErr = errors.New("Block is part of a snapshot and cannot be evicted")
+var SourceChangedErr = errors.New("Source is not at the version it was pinned to")
+var InvalidGenerationPolicyErr = errors.New("Generation policy must be \"stale\" or \"pin\"")
 
 var InvalidRepoErr = errors.New("No such repo at that path")
 var RepoExistsErr = errors.New("Cannot create repo as directory already exists")
 
+type RemoteErrClass int
+
+const (
+	// a failure which may succeed if retried (ie: connection reset, HTTP 500)
+	RemoteTransient RemoteErrClass = iota
+	// the remote asked for requests to slow down (ie: HTTP 429)
+	RemoteThrottled
+	// the object no longer exists
+	RemoteNotFound
+	// the object has been replaced by a different generation since it was referenced
+	RemotePreconditionFailed
+	// the credentials don't allow reading the object
+	RemotePermissionDenied
+)
+
+func (c RemoteErrClass) String() string {
+	switch c {
+	case RemoteTransient:
+		return "transient"
+	case RemoteThrottled:
+		return "throttled"
+	case RemoteNotFound:
+		return "not found"
+	case RemotePreconditionFailed:
+		return "precondition failed"
+	case RemotePermissionDenied:
+		return "permission denied"
+	}
+	return fmt.Sprintf("RemoteErrClass(%d)", int(c))
+}
+
+// RemoteErr wraps an error returned by a remote with the kind of failure it was, which determines whether it's
+// retried and how it's reported
+type RemoteErr struct {
+	Class RemoteErrClass
+	Err   error
+}
+
+func (e *RemoteErr) Error() string {
+	return fmt.Sprintf("remote error (%s): %s", e.Class, e.Err)
+}
+
+func (e *RemoteErr) Throttled() bool {
+	return e.Class == RemoteThrottled
+}
+
+// Retryable returns true if the same request may succeed if tried again
+func (e *RemoteErr) Retryable() bool {
+	return e.Class == RemoteTransient || e.Class == RemoteThrottled
+}
+
+// GetRemoteErrClass returns the class of err if it came from a remote and was classified
+func GetRemoteErrClass(err error) (RemoteErrClass, bool) {
+	remoteErr, ok := err.(*RemoteErr)
+	if !ok {
+		return 0, false
+	}
+	return remoteErr.Class, true
+}
+
 //var NoSuchBlockErr = errors.New("Block does not have any caching info")
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	OpenEvent       EventType = "open"
+	ReadEvent       EventType = "read"
+	FetchEvent      EventType = "fetch"
+	ListBlockEvent  EventType = "list-block"
+	ListRemoteEvent EventType = "list-remote"
+	PushEvent       EventType = "push"
+	EvictEvent      EventType = "evict"
+	// the remote object a block was linked from was replaced
+	RemoteChangedEvent EventType = "remote-changed"
+)
+
+var AllEventTypes = []EventType{OpenEvent, ReadEvent, FetchEvent, ListBlockEvent, ListRemoteEvent, PushEvent, EvictEvent, RemoteChangedEvent}
+
+// Event is a single thing that happened within the DataStore, as reported to the Monitor. Which fields are
+// populated depends on the type of event.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// the pid of the process the operation was performed on behalf of, if known
+	Pid      uint32
+	INode    INode
+	BID      BlockID
+	Offset   int64
+	Length   int64
+	Duration time.Duration
+	Label    string
+	Writable bool
+	// the number of blocks uploaded by a push
+	BlockCount int
+	// the remote object which changed, and whether reads were pinned to the old generation
+	Source string
+	Pinned bool
+}
+
+// EventFilter selects which events a subscriber receives. An empty list of types means all types.
+type EventFilter struct {
+	Types []EventType
+}
+
+func (f *EventFilter) matches(event *Event) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+type EventSubscription struct {
+	broker  *EventBroker
+	filter  EventFilter
+	events  chan *Event
+	dropped int64
+}
+
+// Events returns the channel which events are delivered on. It is closed once the subscription is closed.
+func (s *EventSubscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Dropped returns the number of events which were discarded because the subscriber wasn't keeping up
+func (s *EventSubscription) Dropped() int64 {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+	return s.dropped
+}
+
+func (s *EventSubscription) Close() {
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+	if _, ok := s.broker.subscriptions[s]; ok {
+		delete(s.broker.subscriptions, s)
+		close(s.events)
+	}
+}
+
+// EventBroker is a Monitor which turns the callbacks it receives into Events and hands them to any subscribers.
+// Publishing never blocks: if a subscriber's buffer is full, the event is dropped for that subscriber.
+type EventBroker struct {
+	mutex         sync.Mutex
+	subscriptions map[*EventSubscription]bool
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{subscriptions: make(map[*EventSubscription]bool)}
+}
+
+func (b *EventBroker) Subscribe(filter EventFilter, bufferSize int) *EventSubscription {
+	s := &EventSubscription{broker: b, filter: filter, events: make(chan *Event, bufferSize)}
+
+	b.mutex.Lock()
+	b.subscriptions[s] = true
+	b.mutex.Unlock()
+
+	return s
+}
+
+func (b *EventBroker) publish(ctx context.Context, event *Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.subscriptions) == 0 {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Pid = CallerPid(ctx)
+
+	for s := range b.subscriptions {
+		if !s.filter.matches(event) {
+			continue
+		}
+		select {
+		case s.events <- event:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+func (b *EventBroker) AddedLazyDirBlock(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
+	b.publish(ctx, &Event{Type: ListBlockEvent, Time: endTime, INode: inode, Duration: endTime.Sub(startTime)})
+}
+
+func (b *EventBroker) FetchedRemoteChildren(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
+	b.publish(ctx, &Event{Type: ListRemoteEvent, Time: endTime, INode: inode, Duration: endTime.Sub(startTime)})
+}
+
+func (b *EventBroker) RegionCopied(ctx context.Context, BID BlockID, startTime time.Time, endTime time.Time, start int64, end int64) {
+	b.publish(ctx, &Event{Type: FetchEvent, Time: endTime, BID: BID, Offset: start, Length: end - start, Duration: endTime.Sub(startTime)})
+}
+
+func (b *EventBroker) FileOpened(ctx context.Context, inode INode, writable bool) {
+	b.publish(ctx, &Event{Type: OpenEvent, INode: inode, Writable: writable})
+}
+
+func (b *EventBroker) FileRead(ctx context.Context, inode INode, offset int64, length int64) {
+	b.publish(ctx, &Event{Type: ReadEvent, INode: inode, Offset: offset, Length: length})
+}
+
+func (b *EventBroker) Pushed(ctx context.Context, inode INode, label string, BID BlockID, startTime time.Time, endTime time.Time, blockCount int, bytes int64) {
+	b.publish(ctx, &Event{Type: PushEvent, Time: endTime, INode: inode, Label: label, BID: BID, Duration: endTime.Sub(startTime), BlockCount: blockCount, Length: bytes})
+}
+
+func (b *EventBroker) Evicted(ctx context.Context, inode INode, BID BlockID, bytes int64) {
+	b.publish(ctx, &Event{Type: EvictEvent, INode: inode, BID: BID, Length: bytes})
+}
+
+func (b *EventBroker) RemoteChanged(ctx context.Context, BID BlockID, source string, pinned bool) {
+	b.publish(ctx, &Event{Type: RemoteChangedEvent, Time: time.Now(), BID: BID, Source: source, Pinned: pinned})
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBroker(t *testing.T) {
+	require := require.New(t)
+	ctx := WithCallerPid(context.Background(), 123)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	broker := NewEventBroker()
+	repo := NewRemoteRefFactoryMem()
+	ds, err := NewDataStore(dir, repo, NewMemRemoteRefFactory2(repo), NewMemStore([][]byte{ChunkStat}),
+		NewMemStore([][]byte{ChildNodeBucket, NodeBucket}), WithMonitor(broker))
+	require.Nil(err)
+
+	all := broker.Subscribe(EventFilter{}, 100)
+	opens := broker.Subscribe(EventFilter{Types: []EventType{OpenEvent}}, 1)
+
+	inode, w, err := ds.CreateWritable(ctx, RootINode, "a")
+	require.Nil(err)
+	w.Release()
+
+	r, err := ds.GetReadRef(ctx, inode)
+	require.Nil(err)
+	r.Release()
+
+	ds.Monitor().FileRead(ctx, inode, 10, 20)
+
+	_, err = ds.Freeze(RootINode)
+	require.Nil(err)
+	require.Nil(ds.Push(ctx, RootINode, "label"))
+
+	event := <-all.Events()
+	require.Equal(OpenEvent, event.Type)
+	require.Equal(inode, event.INode)
+	require.True(event.Writable)
+	require.Equal(uint32(123), event.Pid)
+
+	event = <-all.Events()
+	require.Equal(OpenEvent, event.Type)
+	require.False(event.Writable)
+
+	event = <-all.Events()
+	require.Equal(ReadEvent, event.Type)
+	require.Equal(int64(10), event.Offset)
+	require.Equal(int64(20), event.Length)
+
+	event = <-all.Events()
+	require.Equal(PushEvent, event.Type)
+	require.Equal("label", event.Label)
+	require.True(event.BlockCount > 0)
+	require.Equal(int64(0), all.Dropped())
+
+	// the filtered subscription only gets opens, and drops what doesn't fit in its buffer
+	event = <-opens.Events()
+	require.Equal(OpenEvent, event.Type)
+	require.Equal(int64(1), opens.Dropped())
+
+	opens.Close()
+	_, ok := <-opens.Events()
+	require.False(ok)
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"os"
 	"runtime/trace"
 	"sync"
@@ -20,31 +21,28 @@ import (
 
 var ChunkStat []byte = []byte("ChunkStat")
 
-type recentReads struct {
-	index   int
-	offsets []int64
-}
-
-func (r *recentReads) recordReadEnd(offset int64) {
-	r.offsets[r.index] = offset
-	r.index = (r.index + 1) % cap(r.offsets)
-}
-
 type Regions struct {
-	populated   *region.Mask
-	pending     region.PendingReads
-	recentReads recentReads
-	size        int64
+	populated *region.Mask
+	pending   region.PendingReads
+	size      int64
 }
 
 type FrozenRefImp struct {
 	BID      BlockID
-	remote   RemoteRef
 	filename string
-	fp       *os.File
-	offset   int64
 	size     int64
 	owner    *FreezerImp
+
+	// guards the fields below, as the same ref can be read from several goroutines (ie: concurrent FUSE requests).
+	// It's not held while copying from the remote.
+	mutex sync.Mutex
+	// replaced with the pinned generation if the remote object changes while being read
+	remote   RemoteRef
+	fp       *os.File
+	offset   int64
+	released bool
+	// decides how far to fetch past each read, based on how this ref has been read so far
+	readahead *readahead
 	//	regionMap *RegionMAp
 }
 
@@ -67,19 +65,34 @@ type FreezerImp struct {
 
 	mutex   sync.Mutex
 	regions map[BlockID]*Regions
+	// the number of refs handed out by GetRef which have not been released, by block
+	openRefs map[BlockID]int
 
 	historyMutext   sync.Mutex
 	history         []*CopyHistory
 	nextHistorySlot int
 
-	requestLatency       *Population
-	requestLengthSamples *Population
-	monitor              Monitor
+	monitor Monitor
 
 	pendingReads region.PendingReads
 
+	// the largest readahead window, used once a file has been read sequentially for a while
 	maxBackgroundTransfer int64
 	minUncommitted        int64
+	minReadahead          int64
+	// limits readahead across all files. nil if unlimited.
+	readaheadBudget *readaheadBudget
+	// shared by all copies from the remote. nil if unlimited.
+	scheduler *region.FetchScheduler
+	// how many times to retry a copy which failed with a transient error, and the delay before the first retry
+	retryLimit int
+	retryDelay time.Duration
+
+	// what to do when a remote object has been replaced since it was linked
+	generationPolicy GenerationPolicy
+	// blocks whose remote object has been found to have changed
+	changedBlocks map[BlockID]bool
+	changeLog     *remoteChangeLog
 
 	// used for heuristic detection/warning for file handle exhaustion
 	maxFd uint
@@ -97,6 +110,9 @@ type CopyHistory struct {
 const MaxHistoryLength = 32
 
 func (w *FrozenRefImp) Seek(offset int64, whence int) (int64, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
 	if whence == os.SEEK_SET {
 		w.offset = offset
 	} else if whence == os.SEEK_CUR {
@@ -107,13 +123,6 @@ func (w *FrozenRefImp) Seek(offset int64, whence int) (int64, error) {
 		panic("unknown value of whence")
 	}
 
-	if w.fp != nil {
-		_, err := w.fp.Seek(w.offset, 0)
-		if err != nil {
-			return 0, err
-		}
-	}
-
 	return w.offset, nil
 }
 
@@ -122,7 +131,9 @@ func divideIntoChunks(chunkSize int, x []region.Region) []region.Region {
 	return x
 }
 
-func (w *FrozenRefImp) ensurePulled(ctx context.Context, start int64, end int64, size int64) error {
+// ensurePulled makes sure start-end has been copied from the remote, fetching up to readaheadSize bytes past end
+// in the background if anything needs to be copied
+func (w *FrozenRefImp) ensurePulled(ctx context.Context, start int64, end int64, size int64, readaheadSize int64) error {
 	// align the read region with ChunkSize
 	chunkSize := w.owner.chunkSize
 
@@ -142,12 +153,22 @@ func (w *FrozenRefImp) ensurePulled(ctx context.Context, start int64, end int64,
 		return err
 	}
 
+	if len(missingRegions) == 0 {
+		cacheReads.WithLabelValues("hit").Inc()
+	} else {
+		cacheReads.WithLabelValues("miss").Inc()
+	}
+
 	missingRegions = divideIntoChunks(chunkSize, missingRegions)
+	if len(missingRegions) > 0 {
+		readaheadSize = w.owner.readaheadBudget.take(readaheadSize)
+	}
 
 	f, err := os.OpenFile(w.filename, os.O_RDWR, 0755)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	// if len(missingRegions) > 0 {
 	// 	log.Printf("Freezer (%p): Check of %d-%d (orig: %d-%d) found %d missing regions", ctx, start, end, origStart, origEnd, len(missingRegions))
@@ -162,69 +183,167 @@ func (w *FrozenRefImp) ensurePulled(ctx context.Context, start int64, end int64,
 		startTime := time.Now()
 		id := w.owner.RemoteCopyStart(w.BID, r.Start, r.End, startTime)
 		//		log.Printf("Freezer (%p): Started copy of %d-%d (orig: %d-%d)", ctx, r.Start, r.End, origStart, origEnd)
-		err = w.owner.CopyFromRemote(ctx, w.BID, w.remote, r.Start, r.End, f)
+		err = w.copyWithRetries(ctx, f, r.Start, r.End, readaheadSize)
 		endTime := time.Now()
 		//log.Printf("Freezer (%p): Finished copy of %d-%d (orig: %d-%d)", ctx, r.Start, r.End, origStart, origEnd)
 		w.owner.RemoteCopyEnd(id, endTime)
-		w.owner.requestLengthSamples.Add(int(r.End - r.Start))
-		w.owner.requestLatency.Add(int(endTime.Sub(startTime) / time.Millisecond))
+		if err != nil {
+			remoteRequestErrors.Inc()
+			// don't mark the region as valid if the copy failed
+			return err
+		}
+		remoteRequestSize.Observe(float64(r.End - r.Start))
+		remoteRequestDuration.Observe(endTime.Sub(startTime).Seconds())
+		w.owner.monitor.RegionCopied(ctx, w.BID, startTime, endTime, r.Start, r.End)
 
 		w.owner.addValidRegion(w.BID, r.Start, r.End)
 		// copiedNewData = true
 	}
 
-	// if copiedNewData {
-	// only update the read end for new data that we were forced to pull
-	w.owner.recordReadEnd(w.BID, origEnd)
-	// log.Printf("Freezer (%p): updating recordReadEnd", ctx)
-	// }
-
 	return nil
 }
 
-func (w *FrozenRefImp) Read(ctx context.Context, dest []byte) (int, error) {
-	err := w.ensurePulled(ctx, w.offset, w.offset+int64(len(dest)), w.size)
-	if err != nil {
-		return 0, err
+// retryDelay returns how long to wait before retry number attempt (starting from 0). The delay doubles with each
+// attempt, and is jittered so that reads which failed together don't all retry at once.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base << uint(attempt)
+	if delay > maxRemoteRetryDelay || delay <= 0 {
+		delay = maxRemoteRetryDelay
 	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
 
-	if w.fp == nil {
-		w.fp, err = os.OpenFile(w.filename, os.O_RDONLY, 0755)
+// copyWithRetries copies start-end from the remote into f, retrying failures which the remote classified as transient
+// or throttled
+func (w *FrozenRefImp) copyWithRetries(ctx context.Context, f *os.File, start int64, end int64, readaheadSize int64) error {
+	w.mutex.Lock()
+	remote := w.remote
+	w.mutex.Unlock()
+
+	pinned := false
+	for attempt := 0; ; attempt++ {
+		_, err := f.Seek(start, 0)
 		if err != nil {
-			return 0, err
+			return err
 		}
 
-		// newFd := uint(w.fp.Fd())
-		// if newFd > w.owner.maxFd {
-		// 	log.Printf("Got new max FD: %u", newFd)
-		// 	w.owner.maxFd = newFd
-		// }
+		err = w.owner.CopyFromRemote(ctx, w.BID, remote, start, end, readaheadSize, f)
+		if err == nil {
+			return nil
+		}
+
+		remoteErr, ok := err.(*RemoteErr)
+		if ok && remoteErr.Class == RemotePreconditionFailed {
+			pinnedRemote := w.owner.remoteChanged(ctx, w.BID, remote)
+			if pinnedRemote == nil || pinned {
+				return err
+			}
+			// carry on reading the generation the file was linked from
+			remote = pinnedRemote
+			w.mutex.Lock()
+			w.remote = pinnedRemote
+			w.mutex.Unlock()
+			pinned = true
+			attempt--
+			continue
+		}
+
+		if !ok || !remoteErr.Retryable() || attempt >= w.owner.retryLimit {
+			return err
+		}
 
-		_, err = w.fp.Seek(w.offset, 0)
+		delay := retryDelay(w.owner.retryDelay, attempt)
+		log.Printf("Copy of %s %d-%d failed (%s), retrying in %s", base64.URLEncoding.EncodeToString(w.BID[:]), start, end, err, delay)
+		remoteRequestRetries.Inc()
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+
+		// some of the region may have been copied before the failure, so only retry what's still missing
+		missing, err := w.owner.getMissingRegions(w.BID, start, end, w.size)
 		if err != nil {
-			return 0, err
+			return err
+		}
+		if len(missing) == 0 {
+			return nil
 		}
-		// defer f.Close()
+		start = missing[0].Start
 	}
+}
 
-	n, err := w.fp.Read(dest)
+func (w *FrozenRefImp) Read(ctx context.Context, dest []byte) (int, error) {
+	// claim the range up front, so reads from several goroutines each get their own part of the file
+	w.mutex.Lock()
+	offset := w.offset
+	if offset+int64(len(dest)) > w.size {
+		w.offset = w.size
+	} else {
+		w.offset = offset + int64(len(dest))
+	}
+	w.mutex.Unlock()
+
+	return w.ReadAt(ctx, dest, offset)
+}
+
+// ReadAt reads into dest from offset, fetching anything which isn't cached yet. It doesn't use or move the ref's
+// offset, and concurrent calls don't wait on each other's copies from the remote.
+func (w *FrozenRefImp) ReadAt(ctx context.Context, dest []byte, offset int64) (int, error) {
+	if offset >= w.size {
+		return 0, io.EOF
+	}
+
+	w.mutex.Lock()
+	readaheadSize := w.readahead.update(offset, int64(len(dest)))
+	w.mutex.Unlock()
+
+	err := w.ensurePulled(ctx, offset, offset+int64(len(dest)), w.size, readaheadSize)
 	if err != nil {
-		return n, err
+		return 0, err
 	}
-	w.offset += int64(n)
 
-	return n, nil
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.fp == nil {
+		w.fp, err = os.OpenFile(w.filename, os.O_RDONLY, 0755)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.fp.ReadAt(dest, offset)
+	if err == io.EOF && n > 0 {
+		// a short read at the end of the file, which the next read reports as EOF
+		err = nil
+	}
+	return n, err
 }
 
 func (w *FrozenRefImp) Release() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
 	if w.fp != nil {
 		log.Printf("Closing...")
 		w.fp.Close()
+		w.fp = nil
+	}
+	if !w.released {
+		w.released = true
+		w.owner.releaseRef(w.BID)
 	}
 }
 
 const DefaultMaxBackgroundTransfer = 1024 * 1024 * 5
 const DefaultMinUncommitted = 1024 * 100
+const DefaultMaxConcurrentFetches = 16
+const DefaultRemoteRetries = 5
+const DefaultRemoteRetryDelay = 200 * time.Millisecond
+const maxRemoteRetryDelay = 30 * time.Second
 
 func NewFreezer(path string, db KVStore, refFactory RemoteRefFactory2, chunkSize int, monitor Monitor) *FreezerImp {
 	chunkPath := path + "/chunks"
@@ -237,13 +356,17 @@ func NewFreezer(path string, db KVStore, refFactory RemoteRefFactory2, chunkSize
 		db:                    db,
 		chunkSize:             chunkSize,
 		regions:               make(map[BlockID]*Regions),
+		openRefs:              make(map[BlockID]int),
+		changedBlocks:         make(map[BlockID]bool),
+		generationPolicy:      StaleOnChange,
 		refFactory:            refFactory,
-		requestLengthSamples:  NewPopulation(1000),
-		requestLatency:        NewPopulation(1000),
 		history:               make([]*CopyHistory, MaxHistoryLength),
 		monitor:               monitor,
 		maxBackgroundTransfer: DefaultMaxBackgroundTransfer,
-		minUncommitted:        DefaultMinUncommitted}
+		minUncommitted:        DefaultMinUncommitted,
+		minReadahead:          DefaultMinReadahead,
+		retryLimit:            DefaultRemoteRetries,
+		retryDelay:            DefaultRemoteRetryDelay}
 }
 
 func (f *FreezerImp) GetBlockStats(BID BlockID, Size int64) (*BlockStats, error) {
@@ -269,22 +392,12 @@ func (f *FreezerImp) PrintStats() {
 
 	fmt.Printf("Blocks with region maps cached: %d\n", blocksWithRegionsCached)
 
-	latencies, ok := f.requestLatency.Percentiles([]float32{50, 90, 99})
-	if ok {
-		fmt.Printf("50%%, 90%%, 99%% Remote read latency (in ms) (%d): %d, %d, %d\n", f.requestLatency.Count(), latencies[0],
-			latencies[1],
-			latencies[2])
+	count, latency := histogramTotals(remoteRequestDuration)
+	if count > 0 {
+		_, size := histogramTotals(remoteRequestSize)
+		fmt.Printf("Remote reads: %d, mean latency %.1f ms, mean size %.0f bytes\n", count, latency/float64(count)*1000, size/float64(count))
 	} else {
-		fmt.Printf("No latencies recorded\n")
-	}
-
-	sizes, ok := f.requestLengthSamples.Percentiles([]float32{50, 90, 99})
-	if ok {
-		fmt.Printf("50%%, 90%%, 99%% Remote read size (in bytes) (%d): %d, %d, %d\n", f.requestLengthSamples.Count(), sizes[0],
-			sizes[1],
-			sizes[2])
-	} else {
-		fmt.Printf("No read sizes recorded\n")
+		fmt.Printf("No remote reads recorded\n")
 	}
 
 	f.historyMutext.Lock()
@@ -304,6 +417,11 @@ func (f *FreezerImp) PrintStats() {
 	f.historyMutext.Unlock()
 }
 
+// GetUsage returns the number of bytes on disk used by blocks (and their region logs) in the freezer
+func (f *FreezerImp) GetUsage() (int64, error) {
+	return diskUsage(f.path)
+}
+
 func (f *FreezerImp) RemoteCopyStart(BID BlockID, Start int64, End int64, startTime time.Time) *CopyHistory {
 	entry := &CopyHistory{BID: BID, Start: Start, End: End, StartTime: startTime, Complete: false}
 	f.historyMutext.Lock()
@@ -316,8 +434,37 @@ func (f *FreezerImp) RemoteCopyStart(BID BlockID, Start int64, End int64, startT
 }
 
 func (f *FreezerImp) RemoteCopyEnd(id *CopyHistory, endTime time.Time) {
+	f.historyMutext.Lock()
 	id.EndTime = endTime
 	id.Complete = true
+	f.historyMutext.Unlock()
+}
+
+// GetCopyHistory returns the most recent copies from remotes (up to MaxHistoryLength), oldest first
+func (f *FreezerImp) GetCopyHistory() []CopyHistory {
+	f.historyMutext.Lock()
+	defer f.historyMutext.Unlock()
+
+	history := make([]CopyHistory, 0, MaxHistoryLength)
+	for i := 0; i < MaxHistoryLength; i++ {
+		e := f.history[(f.nextHistorySlot+i)%MaxHistoryLength]
+		if e != nil {
+			history = append(history, *e)
+		}
+	}
+	return history
+}
+
+// GetBlockCount returns the number of blocks known to the freezer, whether or not their contents have been fetched
+func (f *FreezerImp) GetBlockCount() (int, error) {
+	count := 0
+	err := f.db.View(func(tx RTx) error {
+		return tx.RBucket(ChunkStat).ForEachWithPrefix(nil, func(key []byte, value []byte) error {
+			count++
+			return nil
+		})
+	})
+	return count, err
 }
 
 func (f *FreezerImp) Close() error {
@@ -357,14 +504,21 @@ func (f *FreezerImp) GetRef(BID BlockID) (FrozenRef, error) {
 		panic("Cannot get ref for NA block")
 	}
 
+	// count the ref as open before looking at the block so that it can't be evicted out from under us
+	f.mutex.Lock()
+	f.openRefs[BID]++
+	f.mutex.Unlock()
+
 	filename := f.getPath(BID)
 	remote, err := f.getRemote(BID)
 	if err != nil {
+		f.releaseRef(BID)
 		return nil, err
 	}
 	st, err := os.Stat(filename)
 	if os.IsNotExist(err) {
 		// fmt.Printf("Path %s does not exists\n", filename)
+		f.releaseRef(BID)
 		return nil, nil
 	}
 	// fmt.Printf("Path %s exists\n", filename)
@@ -376,11 +530,82 @@ func (f *FreezerImp) GetRef(BID BlockID) (FrozenRef, error) {
 		size = st.Size()
 	}
 
+	f.mutex.Lock()
+	changed := f.changedBlocks[BID]
+	f.mutex.Unlock()
+	if pinnable, ok := remote.(PinnableRemoteRef); ok && changed && f.generationPolicy == PinGeneration {
+		remote = pinnable.Pin()
+	}
+
 	return &FrozenRefImp{BID: BID,
-		remote:   remote,
-		owner:    f,
-		filename: filename,
-		size:     size}, nil
+		remote:    remote,
+		owner:     f,
+		filename:  filename,
+		size:      size,
+		readahead: newReadahead(f.minReadahead, f.maxBackgroundTransfer)}, nil
+}
+
+func (f *FreezerImp) releaseRef(BID BlockID) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.openRefs[BID]--
+	if f.openRefs[BID] <= 0 {
+		delete(f.openRefs, BID)
+	}
+}
+
+// Evict deletes the local copy of a block which can be fetched again from its remote source, returning the
+// number of bytes freed. Blocks which only exist locally can't be evicted, nor can blocks which are currently
+// being read.
+func (f *FreezerImp) Evict(BID BlockID) (int64, error) {
+	remote, err := f.getRemote(BID)
+	if err == UnknownBlockID {
+		// nothing cached, so nothing to do
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if remote == nil {
+		return 0, NotEvictableErr
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.openRefs[BID] > 0 {
+		return 0, BlockInUseErr
+	}
+
+	// forget the block before deleting its files, so that the next read goes back to the remote
+	err = f.db.Update(func(tx RWTx) error {
+		chunkStat := tx.WBucket(ChunkStat)
+		return chunkStat.Delete(BID[:])
+	})
+	if err != nil {
+		return 0, err
+	}
+	delete(f.regions, BID)
+
+	var freed int64
+	filename := f.getPath(BID)
+	for _, path := range []string{filename, filename + ".regions"} {
+		fi, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return freed, err
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return freed, err
+		}
+		freed += allocatedSize(fi)
+	}
+
+	return freed, nil
 }
 
 func computeHash(path string) (BlockID, error) {
@@ -427,9 +652,7 @@ func (f *FreezerImp) loadRegions(BID BlockID, size int64) (*Regions, error) {
 		regionMap = &Regions{
 			size:      size,
 			populated: mask,
-			pending:   region.NewPendingReads(),
-			recentReads: recentReads{
-				offsets: make([]int64, 20)}}
+			pending:   region.NewPendingReads(f.scheduler)}
 
 		fp, err := os.Open(regionLog)
 
@@ -473,11 +696,6 @@ func (f *FreezerImp) getPopulatedRegions(BID BlockID) *region.Mask {
 	return regions.populated
 }
 
-func (f *FreezerImp) recordReadEnd(BID BlockID, end int64) {
-	regions := f.regions[BID]
-	regions.recentReads.recordReadEnd(end)
-}
-
 func (f *FreezerImp) addValidRegion(BID BlockID, start int64, end int64) error {
 	regionLog := f.getPath(BID) + ".regions"
 
@@ -516,21 +734,7 @@ type freezerMarker struct {
 
 func (f *freezerMarker) GetFirstMissingRegion(start int64, end int64) *region.Region {
 	f.owner.mutex.Lock()
-	// offsets := f.regions.recentReads.offsets
-	// maxReadPosition := int64(0)
-	// for _, offset := range offsets {
-	// 	if offset > maxReadPosition && offset <= regionStart {
-	// 		maxReadPosition = offset
-	// 	}
-	// }
-
 	missing := f.regions.populated.GetFirstMissingRegion(start, end)
-	// if len(missingRegionsAtStart) != 0 {
-	// 	log.Printf("GetPendingStats at %d, maxReadPosition=%v, missing: %d-%d", regionStart, offsets, missingRegionsAtStart[0].Start, missingRegionsAtStart[0].End)
-	// } else {
-	// 	log.Printf("GetPendingStats at %d, maxReadPosition=%v, no missing", regionStart, offsets)
-	// }
-
 	f.owner.mutex.Unlock()
 
 	return missing
@@ -560,10 +764,11 @@ func (f *FreezerImp) GetActiveTransferStatus(timeUnit time.Duration) []*BlockTra
 
 func (f *freezerMarker) AddRegion(start int64, end int64) {
 	//	log.Printf("AddRegion(%d, %d)", start, end)
+	remoteFetchedBytes.Add(float64(end - start))
 	f.owner.addValidRegion(f.BID, start, end)
 }
 
-func (f *FreezerImp) CopyFromRemote(ctx context.Context, BID BlockID, remote RemoteRef, start int64, end int64, writer io.Writer) error {
+func (f *FreezerImp) CopyFromRemote(ctx context.Context, BID BlockID, remote RemoteRef, start int64, end int64, readaheadSize int64, writer io.Writer) error {
 	defer trace.StartRegion(ctx, "CopyFromRemote").End()
 
 	f.mutex.Lock()
@@ -576,7 +781,7 @@ func (f *FreezerImp) CopyFromRemote(ctx context.Context, BID BlockID, remote Rem
 	retryCount := 0
 	for {
 		log.Printf("freezer op %p: StartBackgroundCopy %d-%d started", ctx, start, end)
-		callStatus := regions.pending.StartBackgroundCopy(ctx, marker, remote, start, end, maxEnd, f.minUncommitted, f.maxBackgroundTransfer, writer)
+		callStatus := regions.pending.StartBackgroundCopy(ctx, marker, remote, start, end, maxEnd, f.minUncommitted, readaheadSize, writer)
 		err := callStatus.Wait()
 		log.Printf("freezer op %p: StartBackgroundCopy %d-%d completed, err=%v", ctx, start, end, err)
 		if err == nil {
@@ -696,9 +901,11 @@ func (f *FreezerImp) AddFile(path string) (*NewBlock, error) {
 	}
 	f.mutex.Unlock()
 
-	err = f.addValidRegion(BID, 0, st.Size())
-	if err != nil {
-		panic("Could not mark file as fully valid")
+	if st.Size() > 0 {
+		err = f.addValidRegion(BID, 0, st.Size())
+		if err != nil {
+			panic("Could not mark file as fully valid")
+		}
 	}
 
 	// TODO: Change "status" to include remote definition and path to chunklist (?)
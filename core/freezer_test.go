@@ -2,10 +2,13 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -160,3 +163,241 @@ func TestPartialReads(t *testing.T) {
 
 	require.Equal([]byte{'x', 'x', 'x', 'x'}, dest)
 }
+
+func TestConcurrentReads(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	rf := &PullCountRefFactoryMock{}
+	f := NewFreezer(dir, NewMemStore([][]byte{ChunkStat}), rf, 2, &NullMonitor{})
+	BID := BlockID{3}
+	ctx := context.Background()
+	require.Nil(f.AddBlock(ctx, BID, rf.GetRef("y")))
+
+	fr, err := f.GetRef(BID)
+	require.Nil(err)
+	defer fr.Release()
+
+	// reads from several goroutines share the ref's offset, so between them they read each byte exactly once
+	const readers = 4
+	totals := make(chan int, readers)
+	for i := 0; i < readers; i++ {
+		go func() {
+			total := 0
+			dest := make([]byte, 7)
+			for {
+				n, err := fr.Read(ctx, dest)
+				total += n
+				if err != nil {
+					break
+				}
+			}
+			totals <- total
+		}()
+	}
+
+	total := 0
+	for i := 0; i < readers; i++ {
+		total += <-totals
+	}
+	require.Equal(2000, total)
+}
+
+// positionRef is a remote whose bytes each depend on their offset. Copies starting at or past gateOffset signal
+// waiting, and then wait until gate is closed.
+type positionRef struct {
+	PullCountRefFactoryMockRef
+	gateOffset int64
+	waiting    chan bool
+	gate       chan bool
+}
+
+type positionRefFactory struct {
+	ref *positionRef
+}
+
+func (rf *positionRefFactory) GetRef(source interface{}) RemoteRef {
+	return rf.ref
+}
+
+func positionByte(offset int64) byte {
+	return byte(offset % 251)
+}
+
+func (rr *positionRef) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
+	if offset >= rr.gateOffset {
+		rr.waiting <- true
+		<-rr.gate
+	}
+	buffer := make([]byte, len)
+	for i := range buffer {
+		buffer[i] = positionByte(offset + int64(i))
+	}
+	_, err := writer.Write(buffer)
+	return err
+}
+
+func TestConcurrentReadAt(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	ref := &positionRef{gateOffset: 1000, waiting: make(chan bool, 10), gate: make(chan bool)}
+	rf := &positionRefFactory{ref}
+	f := NewFreezer(dir, NewMemStore([][]byte{ChunkStat}), rf, 100, &NullMonitor{})
+	BID := BlockID{4}
+	ctx := context.Background()
+	require.Nil(f.AddBlock(ctx, BID, ref))
+
+	fr, err := f.GetRef(BID)
+	require.Nil(err)
+	defer fr.Release()
+
+	// a read which is stuck waiting on the remote
+	blocked := make(chan error)
+	go func() {
+		_, err := fr.ReadAt(ctx, make([]byte, 10), 1500)
+		blocked <- err
+	}()
+	<-ref.waiting
+
+	// doesn't hold up reads elsewhere in the file, which each get the bytes at their own offset
+	const readers = 4
+	errs := make(chan error, readers)
+	for i := 0; i < readers; i++ {
+		go func(i int) {
+			dest := make([]byte, 13)
+			for offset := int64(i); offset+int64(len(dest)) < 1000; offset += readers {
+				n, err := fr.ReadAt(ctx, dest, offset)
+				if err != nil {
+					errs <- err
+					return
+				}
+				for j := 0; j < n; j++ {
+					if dest[j] != positionByte(offset+int64(j)) {
+						errs <- fmt.Errorf("wrong byte at %d", offset+int64(j))
+						return
+					}
+				}
+			}
+			errs <- nil
+		}(i)
+	}
+	for i := 0; i < readers; i++ {
+		select {
+		case err := <-errs:
+			require.Nil(err)
+		case <-time.After(5 * time.Second):
+			close(ref.gate)
+			require.FailNow("reads waited on another read's copy")
+		}
+	}
+
+	close(ref.gate)
+	require.Nil(<-blocked)
+}
+
+// failOnceRef fails its first copy without writing anything, and copies normally after that
+type failOnceRef struct {
+	PullCountRefFactoryMockRef
+	failed bool
+}
+
+// failOnceRefFactory hands out the same failOnceRef for every source
+type failOnceRefFactory struct {
+	ref *failOnceRef
+}
+
+func (rf *failOnceRefFactory) GetRef(source interface{}) RemoteRef {
+	return rf.ref
+}
+
+func (rr *failOnceRef) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
+	if !rr.failed {
+		rr.failed = true
+		return errors.New("copy failed")
+	}
+	return rr.PullCountRefFactoryMockRef.Copy(ctx, offset, len, writer)
+}
+
+func TestFailedCopyIsNotMarkedValid(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	rf := &failOnceRefFactory{&failOnceRef{PullCountRefFactoryMockRef: PullCountRefFactoryMockRef{&PullCountRefFactoryMock{}, "z"}}}
+	f := NewFreezer(dir, NewMemStore([][]byte{ChunkStat}), rf, 2, &NullMonitor{})
+	BID := BlockID{4}
+	ctx := context.Background()
+	require.Nil(f.AddBlock(ctx, BID, rf.ref))
+
+	fr, err := f.GetRef(BID)
+	require.Nil(err)
+	defer fr.Release()
+
+	dest := make([]byte, 4)
+	_, err = fr.Read(ctx, dest)
+	require.NotNil(err)
+
+	// the region which failed to copy is fetched again, rather than read back as zeros
+	n, err := fr.Read(ctx, dest)
+	require.Nil(err)
+	require.Equal(4, n)
+	require.Equal([]byte("zzzz"), dest)
+}
+
+func TestReadsDontLeakFiles(t *testing.T) {
+	require := require.New(t)
+
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("can't count open files on this platform")
+	}
+	openBefore := len(fds)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	rf := &PullCountRefFactoryMock{}
+	f := NewFreezer(dir, NewMemStore([][]byte{ChunkStat}), rf, 2, &NullMonitor{})
+	BID := BlockID{5}
+	ctx := context.Background()
+	require.Nil(f.AddBlock(ctx, BID, rf.GetRef("w")))
+
+	// each read which copies from the remote opens the block's file to write to
+	fr, err := f.GetRef(BID)
+	require.Nil(err)
+	dest := make([]byte, 2)
+	for i := 0; i < 100; i++ {
+		_, err = fr.Read(ctx, dest)
+		require.Nil(err)
+	}
+	fr.Release()
+
+	fds, err = ioutil.ReadDir("/proc/self/fd")
+	require.Nil(err)
+	require.True(len(fds) <= openBefore+2, "%d files open before, %d after", openBefore, len(fds))
+}
+
+func TestReadEmptyFile(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	f := NewFreezer(dir, NewMemStore([][]byte{ChunkStat}), &PullCountRefFactoryMock{}, 2, &NullMonitor{})
+
+	empty := path.Join(dir, "empty")
+	require.Nil(ioutil.WriteFile(empty, nil, 0600))
+	block, err := f.AddFile(empty)
+	require.Nil(err)
+
+	fr, err := f.GetRef(block.BID)
+	require.Nil(err)
+	defer fr.Release()
+	_, err = fr.Read(context.Background(), make([]byte, 10))
+	require.Equal(io.EOF, err)
+}
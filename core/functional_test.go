@@ -21,7 +21,7 @@ func TestFreezePush(t *testing.T) {
 
 	f := NewRemoteRefFactoryMem()
 	f.objects["k"] = []byte{1}
-	ds1, err := NewDataStore(dir1, f, NewMemRemoteRefFactory2(f), NewMemStore([][]byte{ChunkStat}), NewMemStore([][]byte{ChildNodeBucket, NodeBucket}))
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
 	require.Nil(err)
 
 	aID := createFile(require, ds1, RootINode, "a", content)
@@ -31,7 +31,7 @@ func TestFreezePush(t *testing.T) {
 
 	dir2, err := ioutil.TempDir("", "test")
 	require.Nil(err)
-	ds2, err := NewDataStore(dir2, f, NewMemRemoteRefFactory2(f), NewMemStore([][]byte{ChunkStat}), NewMemStore([][]byte{ChildNodeBucket, NodeBucket}))
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f))
 	require.Nil(err)
 
 	err = ds2.MountByLabel(ctx, RootINode, "mount", "sample-label")
@@ -50,3 +50,49 @@ func TestFreezePush(t *testing.T) {
 
 	require.Equal(content, string(buffer))
 }
+
+func TestLabelRoot(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	content := generateUniqueString()
+
+	f := NewRemoteRefFactoryMem()
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	createFile(require, ds1, RootINode, "a", content)
+	require.Nil(ds1.Push(ctx, RootINode, "sample-label"))
+	ds1.Close()
+
+	// a label which was never pushed fails without creating the repo
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	_, err = newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f), DataStoreWithLabelRoot("missing"))
+	require.Equal(UndefinedRootErr, err)
+	_, err = ioutil.ReadDir(dir2 + "/freezer")
+	require.NotNil(err)
+
+	// otherwise the pushed tree becomes the root of the new repo
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f), DataStoreWithLabelRoot("sample-label"))
+	require.Nil(err)
+
+	aID, err := ds2.GetNodeID(ctx, RootINode, "a")
+	require.Nil(err)
+	r, err := ds2.GetReadRef(ctx, aID)
+	require.Nil(err)
+	buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+	require.Nil(err)
+	require.Equal(content, string(buffer))
+
+	// and is leased so that it isn't garbage collected from under the repo
+	BID, err := f.GetRoot(ctx, "sample-label")
+	require.Nil(err)
+	mounts := ds2.GetMounts()
+	require.Len(mounts, 1)
+	require.Equal(INode(RootINode), mounts[0].INode)
+	require.Equal(BID, mounts[0].BID)
+	require.Equal(BID, f.leases[mounts[0].LeaseName].BID)
+}
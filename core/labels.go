@@ -0,0 +1,234 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LabelVersion is one entry in the history of a label, recorded each time the label is pushed
+type LabelVersion struct {
+	// numbered from 1, in the order they were pushed
+	Version int
+	Time    time.Time
+	Host    string
+	User    string
+	BID     BlockID
+	// the root the label pointed to before this push, or NABlock if this was the first
+	Parent  BlockID
+	Message string
+}
+
+type labelVersionJSON struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Host    string    `json:"host"`
+	User    string    `json:"user"`
+	BID     string    `json:"block_id"`
+	Parent  string    `json:"parent,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+func (v *LabelVersion) MarshalJSON() ([]byte, error) {
+	entry := &labelVersionJSON{Version: v.Version, Time: v.Time, Host: v.Host, User: v.User,
+		BID:     base64.URLEncoding.EncodeToString(v.BID[:]),
+		Message: v.Message}
+	if v.Parent != NABlock {
+		entry.Parent = base64.URLEncoding.EncodeToString(v.Parent[:])
+	}
+	return json.Marshal(entry)
+}
+
+func (v *LabelVersion) UnmarshalJSON(buffer []byte) error {
+	var entry labelVersionJSON
+	err := json.Unmarshal(buffer, &entry)
+	if err != nil {
+		return err
+	}
+
+	*v = LabelVersion{Version: entry.Version, Time: entry.Time, Host: entry.Host, User: entry.User, Message: entry.Message}
+	BID, err := base64.URLEncoding.DecodeString(entry.BID)
+	if err != nil {
+		return err
+	}
+	copy(v.BID[:], BID)
+	if entry.Parent != "" {
+		parent, err := base64.URLEncoding.DecodeString(entry.Parent)
+		if err != nil {
+			return err
+		}
+		copy(v.Parent[:], parent)
+	}
+	return nil
+}
+
+// LabelRef names a label, optionally at a point in its history. Written as "label", "label@N" for the Nth version
+// pushed, or "label@<timestamp>" for whichever version was current at that time.
+type LabelRef struct {
+	Name string
+	// 0 if not given
+	Version int
+	// zero if not given
+	AsOf time.Time
+}
+
+// the formats accepted for label@<timestamp>. Times without a zone are taken as local time.
+var labelTimeFormats = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// ParseLabelRef splits label into a name and version. A suffix after the last @ which isn't a version number or
+// timestamp is treated as part of the name, so labels pushed with an @ in their name can still be referred to.
+func ParseLabelRef(label string) (*LabelRef, error) {
+	ref := &LabelRef{Name: label}
+	i := strings.LastIndex(label, "@")
+	if i > 0 {
+		suffix := label[i+1:]
+		if version, err := strconv.Atoi(suffix); err == nil {
+			if version < 1 {
+				return nil, InvalidLabelVersionErr
+			}
+			ref.Name = label[:i]
+			ref.Version = version
+		} else {
+			for _, format := range labelTimeFormats {
+				if t, err := time.ParseInLocation(format, suffix, time.Local); err == nil {
+					ref.Name = label[:i]
+					ref.AsOf = t
+					break
+				}
+			}
+		}
+	}
+
+	err := validateName(ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// IsLatest is true if ref refers to wherever the label currently points
+func (ref *LabelRef) IsLatest() bool {
+	return ref.Version == 0 && ref.AsOf.IsZero()
+}
+
+// findLabelVersion fetches the entry from the history of ref.Name that ref refers to. Only the entries needed are
+// fetched: the one asked for by number, or those a binary search for the time visits (as versions are numbered in the
+// order they were pushed).
+func findLabelVersion(ctx context.Context, remoteRefFactory RemoteRefFactory, ref *LabelRef) (*LabelVersion, error) {
+	if ref.Version != 0 {
+		return remoteRefFactory.GetLabelVersion(ctx, ref.Name, ref.Version)
+	}
+
+	count, err := remoteRefFactory.GetLabelVersionCount(ctx, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the last version pushed at or before ref.AsOf
+	var found *LabelVersion
+	low, high := 1, count
+	for low <= high {
+		mid := (low + high) / 2
+		v, err := remoteRefFactory.GetLabelVersion(ctx, ref.Name, mid)
+		if err != nil {
+			return nil, err
+		}
+		if v.Time.After(ref.AsOf) {
+			high = mid - 1
+		} else {
+			found = v
+			low = mid + 1
+		}
+	}
+	if found == nil {
+		return nil, UnknownLabelVersionErr
+	}
+	return found, nil
+}
+
+// resolveLabel returns the root label refers to, looking through the label's history if a version was given
+func resolveLabel(ctx context.Context, remoteRefFactory RemoteRefFactory, label string) (BlockID, error) {
+	ref, err := ParseLabelRef(label)
+	if err != nil {
+		return NABlock, err
+	}
+
+	if ref.IsLatest() {
+		return remoteRefFactory.GetRoot(ctx, ref.Name)
+	}
+
+	version, err := findLabelVersion(ctx, remoteRefFactory, ref)
+	if err != nil {
+		return NABlock, err
+	}
+	return version.BID, nil
+}
+
+// ResolveLabel returns the root that label (which may be label@N or label@<timestamp>) refers to
+func (d *DataStore) ResolveLabel(ctx context.Context, label string) (BlockID, error) {
+	return resolveLabel(ctx, d.remoteRefFactory, label)
+}
+
+// GetLabelHistory returns each version of label that was pushed, oldest first
+func (d *DataStore) GetLabelHistory(ctx context.Context, label string) ([]*LabelVersion, error) {
+	err := validateName(label)
+	if err != nil {
+		return nil, err
+	}
+	return d.remoteRefFactory.GetLabelHistory(ctx, label)
+}
+
+// ListLabels returns the names of all labels which have been pushed
+func (d *DataStore) ListLabels(ctx context.Context) ([]string, error) {
+	return d.remoteRefFactory.ListLabels(ctx)
+}
+
+type PushConfig struct {
+	message string
+	// the root the label must point to for the push to go ahead, or nil to use wherever it pointed when the push started
+	expected *BlockID
+	// move the label even if another push moved it first
+	force bool
+}
+
+type PushOption func(config *PushConfig)
+
+// PushMessage records message in the label's history along with the push
+func PushMessage(message string) PushOption {
+	return func(config *PushConfig) {
+		config.message = message
+	}
+}
+
+// PushExpecting only moves the label if it currently points to expected (or doesn't exist yet, if expected is
+// NABlock)
+func PushExpecting(expected BlockID) PushOption {
+	return func(config *PushConfig) {
+		config.expected = &expected
+	}
+}
+
+// PushForce moves the label regardless of where it points, overwriting any concurrent push
+func PushForce() PushOption {
+	return func(config *PushConfig) {
+		config.force = true
+	}
+}
+
+// newLabelVersion describes a push of BID by this host and user, which replaced parent
+func newLabelVersion(BID BlockID, parent BlockID, message string) *LabelVersion {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	return &LabelVersion{Time: time.Now(), Host: hostname, User: username, BID: BID, Parent: parent, Message: message}
+}
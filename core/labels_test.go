@@ -0,0 +1,265 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelRef(t *testing.T) {
+	require := require.New(t)
+
+	ref, err := ParseLabelRef("results")
+	require.Nil(err)
+	require.Equal(&LabelRef{Name: "results"}, ref)
+	require.True(ref.IsLatest())
+
+	ref, err = ParseLabelRef("results@3")
+	require.Nil(err)
+	require.Equal(&LabelRef{Name: "results", Version: 3}, ref)
+	require.False(ref.IsLatest())
+
+	ref, err = ParseLabelRef("results@2018-06-01T12:00:00Z")
+	require.Nil(err)
+	require.Equal("results", ref.Name)
+	require.Equal(time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC).Unix(), ref.AsOf.Unix())
+
+	ref, err = ParseLabelRef("results@2018-06-01")
+	require.Nil(err)
+	require.Equal(time.Date(2018, 6, 1, 0, 0, 0, 0, time.Local).Unix(), ref.AsOf.Unix())
+
+	// an @ followed by something else is part of the name
+	ref, err = ParseLabelRef("me@work")
+	require.Nil(err)
+	require.Equal(&LabelRef{Name: "me@work"}, ref)
+
+	_, err = ParseLabelRef("results@0")
+	require.Equal(InvalidLabelVersionErr, err)
+	_, err = ParseLabelRef("bad/label")
+	require.Equal(InvalidCharFilenameErr, err)
+}
+
+func TestLabelVersionJSON(t *testing.T) {
+	require := require.New(t)
+
+	v := &LabelVersion{Version: 2, Time: time.Now().Round(0), Host: "h", User: "u", BID: BlockID{1, 2}, Parent: BlockID{3}, Message: "m"}
+	buffer, err := json.Marshal(v)
+	require.Nil(err)
+	var decoded LabelVersion
+	require.Nil(json.Unmarshal(buffer, &decoded))
+	require.True(v.Time.Equal(decoded.Time))
+	decoded.Time = v.Time
+	require.Equal(v, &decoded)
+
+	// the first version has no parent
+	v.Parent = NABlock
+	buffer, err = json.Marshal(v)
+	require.Nil(err)
+	require.NotContains(string(buffer), "parent")
+	require.Nil(json.Unmarshal(buffer, &decoded))
+	require.Equal(NABlock, decoded.Parent)
+}
+
+func TestLabelHistory(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+
+	first := generateUniqueString()
+	createFile(require, ds, RootINode, "a", first)
+	require.Nil(ds.Push(ctx, RootINode, "results", PushMessage("first run")))
+	afterFirst := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	second := generateUniqueString()
+	createFile(require, ds, RootINode, "b", second)
+	require.Nil(ds.Push(ctx, RootINode, "results"))
+
+	// each push is recorded along with the root it replaced
+	history, err := ds.GetLabelHistory(ctx, "results")
+	require.Nil(err)
+	require.Len(history, 2)
+	require.Equal(1, history[0].Version)
+	require.Equal("first run", history[0].Message)
+	require.Equal(NABlock, history[0].Parent)
+	require.NotEqual("", history[0].Host)
+	require.Equal(2, history[1].Version)
+	require.Equal(history[0].BID, history[1].Parent)
+	latest, err := f.GetRoot(ctx, "results")
+	require.Nil(err)
+	require.Equal(latest, history[1].BID)
+
+	labels, err := ds.ListLabels(ctx)
+	require.Nil(err)
+	require.Equal([]string{"results"}, labels)
+
+	_, err = ds.GetLabelHistory(ctx, "missing")
+	require.Equal(UndefinedRootErr, err)
+
+	// earlier versions can be resolved by number or by time
+	BID, err := ds.ResolveLabel(ctx, "results@1")
+	require.Nil(err)
+	require.Equal(history[0].BID, BID)
+	BID, err = ds.ResolveLabel(ctx, "results@"+afterFirst.Format(time.RFC3339Nano))
+	require.Nil(err)
+	require.Equal(history[0].BID, BID)
+	BID, err = ds.ResolveLabel(ctx, "results")
+	require.Nil(err)
+	require.Equal(history[1].BID, BID)
+
+	_, err = ds.ResolveLabel(ctx, "results@3")
+	require.Equal(UnknownLabelVersionErr, err)
+	_, err = ds.ResolveLabel(ctx, "results@2000-01-01")
+	require.Equal(UnknownLabelVersionErr, err)
+
+	// and mounted
+	require.Nil(ds.MountByLabel(ctx, RootINode, "old", "results@1"))
+	old, err := ds.GetNodeID(ctx, RootINode, "old")
+	require.Nil(err)
+	_, err = ds.GetNodeID(ctx, old, "a")
+	require.Nil(err)
+	_, err = ds.GetNodeID(ctx, old, "b")
+	require.Equal(NoSuchNodeErr, err)
+
+	// but not pushed to
+	require.Equal(InvalidLabelVersionErr, ds.Push(ctx, RootINode, "results@1"))
+}
+
+// countingRemote counts the label versions read from it, and refuses to read a label's whole history
+type countingRemote struct {
+	*RemoteRefFactoryMem
+	versionsRead int
+}
+
+func (r *countingRemote) GetLabelHistory(ctx context.Context, name string) ([]*LabelVersion, error) {
+	panic("resolving a label shouldn't read its whole history")
+}
+
+func (r *countingRemote) GetLabelVersion(ctx context.Context, name string, version int) (*LabelVersion, error) {
+	r.versionsRead++
+	return r.RemoteRefFactoryMem.GetLabelVersion(ctx, name, version)
+}
+
+func TestResolveLabelReadsFewVersions(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	f := &countingRemote{RemoteRefFactoryMem: NewRemoteRefFactoryMem()}
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 100; i++ {
+		BID := BlockID{byte(i + 1)}
+		require.Nil(f.SetRoot(ctx, "results", BID))
+		require.Nil(f.AddLabelVersion(ctx, "results", &LabelVersion{Time: start.Add(time.Duration(i) * time.Hour), BID: BID}))
+	}
+
+	// a version number reads just that version
+	BID, err := resolveLabel(ctx, f, "results@42")
+	require.Nil(err)
+	require.Equal(BlockID{42}, BID)
+	require.Equal(1, f.versionsRead)
+
+	// and a time only reads the versions a binary search visits
+	f.versionsRead = 0
+	BID, err = resolveLabel(ctx, f, "results@"+start.Add(41*time.Hour+time.Minute).Format(time.RFC3339))
+	require.Nil(err)
+	require.Equal(BlockID{42}, BID)
+	require.True(f.versionsRead <= 7, "read %d versions", f.versionsRead)
+
+	_, err = resolveLabel(ctx, f, "results@"+start.Add(-time.Hour).Format(time.RFC3339))
+	require.Equal(UnknownLabelVersionErr, err)
+	_, err = resolveLabel(ctx, f, "results@101")
+	require.Equal(UnknownLabelVersionErr, err)
+	_, err = resolveLabel(ctx, f, "missing@1")
+	require.Equal(UndefinedRootErr, err)
+}
+
+// racingRemote moves a label to another root while blocks are being uploaded, as if another push finished first
+type racingRemote struct {
+	*RemoteRefFactoryMem
+	label string
+	BID   BlockID
+	// when set, recording pushes in the label's history fails
+	historyErr error
+}
+
+func (r *racingRemote) AddLabelVersion(ctx context.Context, name string, version *LabelVersion) error {
+	if r.historyErr != nil {
+		return r.historyErr
+	}
+	return r.RemoteRefFactoryMem.AddLabelVersion(ctx, name, version)
+}
+
+func (r *racingRemote) Push(ctx context.Context, BID BlockID, fr FrozenRef) error {
+	if r.label != "" {
+		r.roots[r.label] = r.BID
+		r.label = ""
+	}
+	return r.RemoteRefFactoryMem.Push(ctx, BID, fr)
+}
+
+func TestPushConflicts(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := &racingRemote{RemoteRefFactoryMem: NewRemoteRefFactoryMem()}
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f.RemoteRefFactoryMem))
+	require.Nil(err)
+
+	// the label must not exist yet
+	createFile(require, ds, RootINode, "a", generateUniqueString())
+	require.Nil(ds.Push(ctx, RootINode, "results", PushExpecting(NABlock)))
+	first, err := f.GetRoot(ctx, "results")
+	require.Nil(err)
+	require.Equal(LabelConflictErr, ds.Push(ctx, RootINode, "results", PushExpecting(NABlock)))
+
+	// or must point to the given root
+	createFile(require, ds, RootINode, "b", generateUniqueString())
+	require.Equal(LabelConflictErr, ds.Push(ctx, RootINode, "results", PushExpecting(BlockID{1})))
+	require.Nil(ds.Push(ctx, RootINode, "results", PushExpecting(first)))
+	second, err := f.GetRoot(ctx, "results")
+	require.Nil(err)
+
+	// another push moving the label while this one uploads makes it fail, rather than silently losing the other push
+	other := BlockID{2}
+	f.label, f.BID = "results", other
+	createFile(require, ds, RootINode, "c", generateUniqueString())
+	require.Equal(LabelConflictErr, ds.Push(ctx, RootINode, "results"))
+	current, err := f.GetRoot(ctx, "results")
+	require.Nil(err)
+	require.Equal(other, current)
+
+	history, err := ds.GetLabelHistory(ctx, "results")
+	require.Nil(err)
+	require.Len(history, 2)
+	require.Equal(second, history[1].BID)
+
+	// unless forced
+	require.Nil(ds.Push(ctx, RootINode, "results", PushExpecting(first), PushForce()))
+	history, err = ds.GetLabelHistory(ctx, "results")
+	require.Nil(err)
+	require.Len(history, 3)
+	require.Equal(other, history[2].Parent)
+
+	// and once the label has moved, the push has happened even if it can't be added to the history
+	f.historyErr = errors.New("history write failed")
+	createFile(require, ds, RootINode, "d", generateUniqueString())
+	require.Nil(ds.Push(ctx, RootINode, "results"))
+	current, err = f.GetRoot(ctx, "results")
+	require.Nil(err)
+	require.NotEqual(history[2].BID, current)
+}
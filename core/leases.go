@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lease records that a repo still refers to a root on the remote, so nothing reachable from it may be garbage
+// collected before the lease expires
+type Lease struct {
+	Name   string
+	Expiry time.Time
+	BID    BlockID
+	// the host and storage path of the repo which holds the lease. Empty for leases written by older versions.
+	Host string
+	Repo string
+}
+
+func (l *Lease) IsExpired(now time.Time) bool {
+	return !l.Expiry.After(now)
+}
+
+func (d *DataStore) newLease(name string, BID BlockID, expiry time.Time) *Lease {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = ""
+	}
+	repo, err := filepath.Abs(d.path)
+	if err != nil {
+		repo = d.path
+	}
+	return &Lease{Name: name, Expiry: expiry, BID: BID, Host: hostname, Repo: repo}
+}
+
+// mountRecord is how a Mount is written to the mount table
+type mountRecord struct {
+	MountPoint       INode
+	LeaseName        string
+	LastLeaseRenewal time.Time
+	BID              BlockID
+}
+
+func loadMountTable(mountTablePath string) ([]*Mount, error) {
+	f, err := os.Open(mountTablePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []mountRecord
+	err = gob.NewDecoder(f).Decode(&records)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %s", mountTablePath, err)
+	}
+
+	mounts := make([]*Mount, 0, len(records))
+	for _, r := range records {
+		if r.MountPoint == InvalidINode || r.LeaseName == "" {
+			// tables written by older versions only recorded each mount's BID, which isn't enough to renew its lease
+			log.Printf("Ignoring mount of %s in %s which has no mount point or lease", base64.URLEncoding.EncodeToString(r.BID[:]), mountTablePath)
+			continue
+		}
+		mounts = append(mounts, &Mount{mountPoint: r.MountPoint, leaseName: r.LeaseName, lastLeaseRenewal: r.LastLeaseRenewal, BID: r.BID})
+	}
+	return mounts, nil
+}
+
+// persistMountTable writes out the mount table. Must be called with mountsMutex held.
+func (d *DataStore) persistMountTable() {
+	records := make([]mountRecord, len(d.mounts))
+	for i, m := range d.mounts {
+		records[i] = mountRecord{MountPoint: m.mountPoint, LeaseName: m.leaseName, LastLeaseRenewal: m.lastLeaseRenewal, BID: m.BID}
+	}
+
+	// write a new copy and move it into place, so a crash can't leave a partially written table behind
+	tmpPath := d.mountTablePath + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0660)
+	if err != nil {
+		log.Fatalf("%s: Could not open %s for writing", err, tmpPath)
+	}
+	err = gob.NewEncoder(f).Encode(records)
+	if err != nil {
+		log.Fatalf("%s: Could not write mount table", err)
+	}
+	err = f.Close()
+	if err != nil {
+		log.Fatalf("%s: Could not write mount table", err)
+	}
+	err = os.Rename(tmpPath, d.mountTablePath)
+	if err != nil {
+		log.Fatalf("%s: Could not replace %s", err, d.mountTablePath)
+	}
+}
+
+// renewLeases renews every lease which was last renewed more than STALE_LEASE_DURATION ago. The leases are written
+// without holding mountsMutex, so a slow remote doesn't hold up mounting and unmounting.
+func (d *DataStore) renewLeases(ctx context.Context) error {
+	now := time.Now()
+	staleThreshold := now.Add(-STALE_LEASE_DURATION)
+
+	d.mountsMutex.Lock()
+	due := make([]Mount, 0)
+	for _, m := range d.mounts {
+		if m.lastLeaseRenewal.Before(staleThreshold) {
+			due = append(due, *m)
+		}
+	}
+	d.mountsMutex.Unlock()
+
+	renewed := make([]Mount, 0, len(due))
+	var firstErr error
+	for _, m := range due {
+		err := d.remoteRefFactory.SetLease(ctx, d.newLease(m.leaseName, m.BID, now.Add(DEFAULT_EXPIRY)))
+		if err != nil {
+			// keep going, so one bad lease doesn't stop the others being renewed
+			log.Printf("Could not renew lease %s: %s", m.leaseName, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		renewed = append(renewed, m)
+	}
+	if len(renewed) == 0 {
+		return firstErr
+	}
+
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
+	for _, r := range renewed {
+		for _, m := range d.mounts {
+			// skip mounts which were replaced or moved to another root while the lease was being written
+			if m.leaseName == r.leaseName && m.BID == r.BID {
+				m.lastLeaseRenewal = now
+			}
+		}
+	}
+	d.persistMountTable()
+	return firstErr
+}
+
+// leaseMount points the lease of the mount at inode at BID, without changing the mount table. Returns the BID the
+// mount's lease protected before, and false if there is no mount at inode.
+func (d *DataStore) leaseMount(ctx context.Context, inode INode, BID BlockID) (BlockID, bool, error) {
+	d.mountsMutex.Lock()
+	var leaseName string
+	var oldBID BlockID
+	found := false
+	for _, m := range d.mounts {
+		if m.mountPoint == inode {
+			leaseName, oldBID, found = m.leaseName, m.BID, true
+			break
+		}
+	}
+	d.mountsMutex.Unlock()
+	if !found {
+		return NABlock, false, nil
+	}
+
+	err := d.remoteRefFactory.SetLease(ctx, d.newLease(leaseName, BID, time.Now().Add(DEFAULT_EXPIRY)))
+	if err != nil {
+		return NABlock, true, err
+	}
+	return oldBID, true, nil
+}
+
+// recordMountBID updates the mount table once the mount at inode has been leased at BID and refers to it
+func (d *DataStore) recordMountBID(inode INode, BID BlockID, leasedAt time.Time) {
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
+
+	for _, m := range d.mounts {
+		if m.mountPoint == inode {
+			m.BID = BID
+			m.lastLeaseRenewal = leasedAt
+			d.persistMountTable()
+			return
+		}
+	}
+}
+
+// moveLease points the lease of the mount at inode (if there is one) at BID instead
+func (d *DataStore) moveLease(ctx context.Context, inode INode, BID BlockID) error {
+	leasedAt := time.Now()
+	_, found, err := d.leaseMount(ctx, inode, BID)
+	if err != nil || !found {
+		return err
+	}
+	d.recordMountBID(inode, BID, leasedAt)
+	return nil
+}
+
+// RenewLeasesPeriodically keeps the leases of this repo's mounts from expiring while it's in use, checking every
+// interval until ctx is cancelled. Leases are checked straight away, as they may have gone stale while the repo was
+// closed. Failures are logged, and retried at the next check.
+func (d *DataStore) RenewLeasesPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := d.renewLeases(ctx)
+		if err != nil {
+			log.Printf("Lease renewal failed: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListLeases returns every lease on the remote, from all repos, including those which have expired
+func (d *DataStore) ListLeases(ctx context.Context) ([]*Lease, error) {
+	return d.remoteRefFactory.ListLeases(ctx)
+}
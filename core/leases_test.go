@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newLeaseTestDataStore(require *require.Assertions, f *RemoteRefFactoryMem) *DataStore {
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	return ds
+}
+
+func TestMountsReloadedOnOpen(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	src := newLeaseTestDataStore(require, f)
+	createFile(require, src, RootINode, "x", "data")
+	require.Nil(src.Push(ctx, RootINode, "ref"))
+	BID, err := src.ResolveLabel(ctx, "ref")
+	require.Nil(err)
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	freezerStore := NewMemStore([][]byte{ChunkStat})
+	nodeStore := NewMemStore([][]byte{ChildNodeBucket, NodeBucket})
+	ds1, err := NewDataStore(dir, f, NewMemRemoteRefFactory2(f), freezerStore, nodeStore)
+	require.Nil(err)
+	inode, err := ds1.Mount(ctx, RootINode, "data", BID)
+	require.Nil(err)
+	mounts := ds1.GetMounts()
+	require.Len(mounts, 1)
+	ds1.Close()
+
+	ds2, err := NewDataStore(dir, f, NewMemRemoteRefFactory2(f), freezerStore, nodeStore, OpenExisting())
+	require.Nil(err)
+	reloaded := ds2.GetMounts()
+	require.Len(reloaded, 1)
+	require.Equal(inode, reloaded[0].INode)
+	require.Equal(BID, reloaded[0].BID)
+	require.Equal(mounts[0].LeaseName, reloaded[0].LeaseName)
+	require.True(mounts[0].LastLeaseRenewal.Equal(reloaded[0].LastLeaseRenewal))
+	ds2.Close()
+
+	// tables written by older versions only have each mount's BID, so their mounts are skipped rather than kept
+	// with no lease or mount point
+	type oldMount struct {
+		BID BlockID
+	}
+	table, err := os.Create(path.Join(dir, "mounts.gob"))
+	require.Nil(err)
+	require.Nil(gob.NewEncoder(table).Encode([]*oldMount{{BID: BID}}))
+	require.Nil(table.Close())
+	ds3, err := NewDataStore(dir, f, NewMemRemoteRefFactory2(f), freezerStore, nodeStore, OpenExisting())
+	require.Nil(err)
+	require.Empty(ds3.GetMounts())
+}
+
+// blockingLeaseRemote holds up each lease write until it's released
+type blockingLeaseRemote struct {
+	*RemoteRefFactoryMem
+	writing chan bool
+	release chan bool
+}
+
+func (r *blockingLeaseRemote) SetLease(ctx context.Context, lease *Lease) error {
+	r.writing <- true
+	<-r.release
+	return r.RemoteRefFactoryMem.SetLease(ctx, lease)
+}
+
+func TestRenewLeasesDoesNotBlockMounts(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	src := newLeaseTestDataStore(require, f)
+	createFile(require, src, RootINode, "x", "data")
+	require.Nil(src.Push(ctx, RootINode, "ref"))
+	BID, err := src.ResolveLabel(ctx, "ref")
+	require.Nil(err)
+
+	remote := &blockingLeaseRemote{RemoteRefFactoryMem: f, writing: make(chan bool), release: make(chan bool)}
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := newMemDataStore(dir, remote, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	go func() {
+		<-remote.writing
+		remote.release <- true
+	}()
+	_, err = ds.Mount(ctx, RootINode, "data", BID)
+	require.Nil(err)
+
+	stale := time.Now().Add(-2 * STALE_LEASE_DURATION)
+	ds.mounts[0].lastLeaseRenewal = stale
+	done := make(chan error)
+	go func() {
+		done <- ds.renewLeases(ctx)
+	}()
+
+	// while the lease is being written, the mount table can still be read
+	<-remote.writing
+	require.Len(ds.GetMounts(), 1)
+	remote.release <- true
+	require.Nil(<-done)
+	require.True(ds.GetMounts()[0].LastLeaseRenewal.After(stale))
+}
+
+func TestRenewLeases(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	src := newLeaseTestDataStore(require, f)
+	createFile(require, src, RootINode, "x", "data")
+	require.Nil(src.Push(ctx, RootINode, "ref"))
+	BID, err := src.ResolveLabel(ctx, "ref")
+	require.Nil(err)
+
+	ds := newLeaseTestDataStore(require, f)
+	_, err = ds.Mount(ctx, RootINode, "data", BID)
+	require.Nil(err)
+	leaseName := ds.GetMounts()[0].LeaseName
+
+	// a recently renewed lease is left alone
+	before := f.leases[leaseName].Expiry
+	require.Nil(ds.renewLeases(ctx))
+	require.Equal(before, f.leases[leaseName].Expiry)
+
+	// but a stale one is extended, and records who holds it
+	stale := time.Now().Add(-2 * STALE_LEASE_DURATION)
+	ds.mounts[0].lastLeaseRenewal = stale
+	require.Nil(ds.renewLeases(ctx))
+	require.True(ds.GetMounts()[0].LastLeaseRenewal.After(stale))
+	lease := f.leases[leaseName]
+	require.True(lease.Expiry.After(before))
+	require.Equal(BID, lease.BID)
+	require.NotEqual("", lease.Repo)
+
+	leases, err := ds.ListLeases(ctx)
+	require.Nil(err)
+	require.Len(leases, 1)
+	require.Equal(leaseName, leases[0].Name)
+	require.False(leases[0].IsExpired(time.Now()))
+	require.True(leases[0].IsExpired(leases[0].Expiry))
+
+	// the background loop renews straight away and stops when cancelled
+	ds.mounts[0].lastLeaseRenewal = stale
+	renewCtx, cancel := context.WithCancel(ctx)
+	done := make(chan bool)
+	go func() {
+		ds.RenewLeasesPeriodically(renewCtx, time.Hour)
+		done <- true
+	}()
+	for i := 0; i < 100 && !ds.GetMounts()[0].LastLeaseRenewal.After(stale); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.True(ds.GetMounts()[0].LastLeaseRenewal.After(stale))
+	cancel()
+	<-done
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// MemStore is an in-memory KVStore. Like bolt, it allows one update or many views at a time.
 type MemStore struct {
+	mutex     sync.RWMutex
 	perBucket map[string]map[string][]byte
 	rollback  []OldValue
 }
@@ -37,10 +41,18 @@ type Bucket struct {
 func (m *MemStore) RBucket(name []byte) RBucket {
 	return &Bucket{string(name), m}
 }
+
+// WBucket creates the bucket if it doesn't exist yet, so stores made before a bucket was added keep working
 func (m *MemStore) WBucket(name []byte) WBucket {
+	if _, ok := m.perBucket[string(name)]; !ok {
+		m.perBucket[string(name)] = make(map[string][]byte)
+	}
 	return &Bucket{string(name), m}
 }
 func (m *MemStore) Update(callback func(RWTx) error) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	m.rollback = nil
 	err := callback(m)
 	if err != nil {
@@ -52,6 +64,9 @@ func (m *MemStore) Update(callback func(RWTx) error) error {
 	return err
 }
 func (m *MemStore) View(callback func(RTx) error) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	return callback(m)
 }
 func (m *MemStore) Close() error {
@@ -85,6 +100,26 @@ func (m *Bucket) ForEachWithPrefix(prefix []byte, callback func(key []byte, valu
 	return nil
 }
 
+func (m *Bucket) ForEachWithPrefixFrom(prefix []byte, start []byte, callback func(key []byte, value []byte) error) error {
+	sprefix := string(prefix)
+	sstart := string(start)
+	keys := make([]string, 0, 100)
+	for k, v := range m.store.perBucket[m.name] {
+		if strings.HasPrefix(k, sprefix) && k >= sstart && v != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		err := callback([]byte(k), m.store.perBucket[m.name][k])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (m *Bucket) Put(key []byte, value []byte) error {
 	skey := string(key)
 	oldValue, okay := m.store.perBucket[m.name][skey]
@@ -102,10 +137,30 @@ func (m *Bucket) Delete(key []byte) error {
 }
 
 type RemoteRefFactoryMem struct {
-	leases  map[string]BlockID
+	leases  map[string]*Lease
 	roots   map[string]BlockID
+	history map[string][]*LabelVersion
 	objects map[string][]byte
 	prefix  string
+	// delay added to each copy, to simulate a slow remote
+	latency time.Duration
+}
+
+// SetLatency makes every subsequent copy from this remote take at least latency
+func (r *RemoteRefFactoryMem) SetLatency(latency time.Duration) {
+	r.latency = latency
+}
+
+func (r *RemoteRefFactoryMem) delay(ctx context.Context) error {
+	if r.latency == 0 {
+		return nil
+	}
+	select {
+	case <-time.After(r.latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 type MemCopy struct {
@@ -141,9 +196,10 @@ func (m *MemCopy) GetSource() interface{} {
 
 func NewRemoteRefFactoryMem() *RemoteRefFactoryMem {
 	return &RemoteRefFactoryMem{roots: make(map[string]BlockID),
+		history: make(map[string][]*LabelVersion),
 		objects: make(map[string][]byte),
 		prefix:  "blocks/",
-		leases:  make(map[string]BlockID)}
+		leases:  make(map[string]*Lease)}
 }
 
 func (r *RemoteRefFactoryMem) GetRef(ctx context.Context, node *NodeRepr) (RemoteRef, error) {
@@ -175,16 +231,81 @@ func (r *RemoteRefFactoryMem) Push(ctx context.Context, BID BlockID, fr FrozenRe
 	return nil
 }
 
-func (r *RemoteRefFactoryMem) SetLease(ctx context.Context, name string, expiry time.Time, BID BlockID) error {
-	r.leases[name] = BID
+func (r *RemoteRefFactoryMem) SetLease(ctx context.Context, lease *Lease) error {
+	copied := *lease
+	r.leases[lease.Name] = &copied
 	return nil
 }
 
+func (r *RemoteRefFactoryMem) ListLeases(ctx context.Context) ([]*Lease, error) {
+	leases := make([]*Lease, 0, len(r.leases))
+	for _, lease := range r.leases {
+		copied := *lease
+		leases = append(leases, &copied)
+	}
+	sort.Slice(leases, func(i, j int) bool { return leases[i].Name < leases[j].Name })
+	return leases, nil
+}
+
 func (r *RemoteRefFactoryMem) SetRoot(ctx context.Context, name string, BID BlockID) error {
 	r.roots[name] = BID
 	return nil
 }
 
+func (r *RemoteRefFactoryMem) CompareAndSetRoot(ctx context.Context, name string, expected BlockID, BID BlockID) error {
+	current, ok := r.roots[name]
+	if !ok {
+		current = NABlock
+	}
+	if current != expected {
+		return LabelConflictErr
+	}
+	r.roots[name] = BID
+	return nil
+}
+
+func (r *RemoteRefFactoryMem) AddLabelVersion(ctx context.Context, name string, version *LabelVersion) error {
+	added := *version
+	added.Version = len(r.history[name]) + 1
+	r.history[name] = append(r.history[name], &added)
+	return nil
+}
+
+func (r *RemoteRefFactoryMem) GetLabelHistory(ctx context.Context, name string) ([]*LabelVersion, error) {
+	history, ok := r.history[name]
+	if !ok {
+		if _, ok := r.roots[name]; !ok {
+			return nil, UndefinedRootErr
+		}
+	}
+	return history, nil
+}
+
+func (r *RemoteRefFactoryMem) GetLabelVersionCount(ctx context.Context, name string) (int, error) {
+	history, err := r.GetLabelHistory(ctx, name)
+	return len(history), err
+}
+
+func (r *RemoteRefFactoryMem) GetLabelVersion(ctx context.Context, name string, version int) (*LabelVersion, error) {
+	history, err := r.GetLabelHistory(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > len(history) {
+		return nil, UnknownLabelVersionErr
+	}
+	return history[version-1], nil
+}
+
+func (r *RemoteRefFactoryMem) ListLabels(ctx context.Context) ([]string, error) {
+	labels := make([]string, 0, len(r.roots))
+	for name := range r.roots {
+		labels = append(labels, name)
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
 func (r *RemoteRefFactoryMem) GetRoot(ctx context.Context, name string) (BlockID, error) {
 	BID, ok := r.roots[name]
 	if !ok {
@@ -269,12 +390,17 @@ func (m *MemRemoteRef) GetSize() int64 {
 }
 
 func (m *MemRemoteRef) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
+	err := m.repo.delay(ctx)
+	if err != nil {
+		return err
+	}
+
 	key := GetBlockKey(m.repo.prefix, m.BID)
 	data, ok := m.repo.objects[key]
 	if !ok {
 		panic("Attempted to get data of non-existant key")
 	}
-	_, err := writer.Write(data[offset : offset+len])
+	_, err = writer.Write(data[offset : offset+len])
 	if err != nil {
 		return err
 	}
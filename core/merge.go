@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"log"
+	"path"
+	"sort"
+	"time"
+)
+
+type MergeConflictKind string
+
+const (
+	// the path didn't exist in the base, and was added with different contents on each side
+	BothAdded MergeConflictKind = "both-added"
+	// the path was changed differently on each side
+	BothModified MergeConflictKind = "both-modified"
+	// the path was changed in a, and removed in b
+	ModifiedDeleted MergeConflictKind = "modified-deleted"
+	// the path was removed in a, and changed in b
+	DeletedModified MergeConflictKind = "deleted-modified"
+)
+
+type MergeConflict struct {
+	Kind MergeConflictKind
+	Path string
+}
+
+type MergeResult struct {
+	// the root of the merged tree, or NABlock if there were conflicts
+	BID       BlockID
+	Conflicts []*MergeConflict
+}
+
+// sameEntry is true if x and y are both missing, or have the same contents
+func sameEntry(x *DirEntry, y *DirEntry) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.IsDir == y.IsDir && x.BID != NABlock && x.BID == y.BID
+}
+
+func (d *DataStore) readDirEntries(ctx context.Context, e *DirEntry) (map[string]*DirEntry, error) {
+	entries := make(map[string]*DirEntry)
+	if e == nil {
+		return entries, nil
+	}
+	dir, err := d.readDirBlock(ctx, e.BID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range dir.Entries {
+		entries[dir.Entries[i].Name] = &dir.Entries[i]
+	}
+	return entries, nil
+}
+
+// mergeEntry merges the changes made to base in a and in b. Only directories changed on both sides are read, and
+// new directory blocks are only written for those. Returns nil if the merged entry should be removed.
+func (d *DataStore) mergeEntry(ctx context.Context, entryPath string, base *DirEntry, a *DirEntry, b *DirEntry, conflicts *[]*MergeConflict) (*DirEntry, error) {
+	if sameEntry(a, b) {
+		return a, nil
+	}
+	if sameEntry(base, a) {
+		return b, nil
+	}
+	if sameEntry(base, b) {
+		return a, nil
+	}
+
+	if a != nil && b != nil && a.IsDir && b.IsDir && (base == nil || base.IsDir) {
+		return d.mergeDirs(ctx, entryPath, base, a, b, conflicts)
+	}
+
+	kind := BothModified
+	if base == nil {
+		kind = BothAdded
+	} else if b == nil {
+		kind = ModifiedDeleted
+	} else if a == nil {
+		kind = DeletedModified
+	}
+	*conflicts = append(*conflicts, &MergeConflict{Kind: kind, Path: entryPath})
+	return a, nil
+}
+
+func (d *DataStore) mergeDirs(ctx context.Context, dirPath string, base *DirEntry, a *DirEntry, b *DirEntry, conflicts *[]*MergeConflict) (*DirEntry, error) {
+	baseEntries, err := d.readDirEntries(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+	aEntries, err := d.readDirEntries(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	bEntries, err := d.readDirEntries(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, entries := range []map[string]*DirEntry{baseEntries, aEntries, bEntries} {
+		for name := range entries {
+			names[name] = true
+		}
+	}
+	sortedNames := make([]string, 0, len(names))
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+
+	conflictCount := len(*conflicts)
+	merged := make([]DirEntry, 0, len(sortedNames))
+	for _, name := range sortedNames {
+		entry, err := d.mergeEntry(ctx, path.Join(dirPath, name), baseEntries[name], aEntries[name], bEntries[name], conflicts)
+		if err != nil {
+			return nil, err
+		}
+		if entry != nil {
+			merged = append(merged, *entry)
+		}
+	}
+
+	if len(*conflicts) > conflictCount {
+		// no point writing out a directory which can't be used
+		return a, nil
+	}
+
+	newBlock, err := freezeDir(d.path, d.freezer, &Dir{merged})
+	if err != nil {
+		return nil, err
+	}
+	return &DirEntry{Name: a.Name, IsDir: true, BID: newBlock.BID, Size: newBlock.Size, ModTime: newBlock.ModTime}, nil
+}
+
+// Merge combines the changes made to the tree base in both a and b, which must all be frozen trees. base can be
+// NABlock if a and b have no common ancestor. Paths which were changed differently in a and b are reported as
+// conflicts, in which case no merged tree is produced. The merged tree only exists locally until pushed with PushTree.
+func (d *DataStore) Merge(ctx context.Context, base BlockID, a BlockID, b BlockID) (*MergeResult, error) {
+	var baseEntry *DirEntry
+	if base != NABlock {
+		baseEntry = &DirEntry{IsDir: true, BID: base}
+	}
+
+	conflicts := make([]*MergeConflict, 0)
+	merged, err := d.mergeEntry(ctx, "", baseEntry, &DirEntry{IsDir: true, BID: a}, &DirEntry{IsDir: true, BID: b}, &conflicts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(conflicts) > 0 {
+		return &MergeResult{BID: NABlock, Conflicts: conflicts}, nil
+	}
+	if merged == nil {
+		// a and b both match a base which doesn't exist, which can only happen if base was NABlock and so were they
+		return nil, UnknownBlockID
+	}
+	return &MergeResult{BID: merged.BID, Conflicts: conflicts}, nil
+}
+
+// collectUnpushedTree finds the blocks in the frozen tree BID which only exist locally. Anything already pushed was
+// pushed along with everything below it, so isn't looked inside.
+func (d *DataStore) collectUnpushedTree(ctx context.Context, BID BlockID, isDir bool, blockList *[]BlockID) error {
+	pushed, err := d.isPushed(BID)
+	if err != nil || pushed {
+		return err
+	}
+
+	if isDir {
+		dir, err := d.readDirBlock(ctx, BID)
+		if err != nil {
+			return err
+		}
+		for _, e := range dir.Entries {
+			if e.RemoteSource != nil && !e.IsDir {
+				continue
+			}
+			err = d.collectUnpushedTree(ctx, e.BID, e.IsDir, blockList)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	*blockList = append(*blockList, BID)
+	return nil
+}
+
+// PushTree points the label name at the frozen tree BID, such as one produced by Merge, first uploading any of its
+// blocks which only exist locally
+func (d *DataStore) PushTree(ctx context.Context, BID BlockID, name string, options ...PushOption) error {
+	startTime := time.Now()
+	var config PushConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	parentBID, err := d.startPush(ctx, name, &config)
+	if err != nil {
+		return err
+	}
+
+	blockList := make([]BlockID, 0, 100)
+	err = d.collectUnpushedTree(ctx, BID, true, &blockList)
+	if err != nil {
+		return err
+	}
+	log.Printf("Collected %d unpushed blocks", len(blockList))
+
+	pushedBytes, err := d.uploadBlocks(ctx, blockList)
+	if err != nil {
+		return err
+	}
+
+	err = d.moveLabel(ctx, name, parentBID, BID, &config)
+	if err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	pushedBytesTotal.Add(float64(pushedBytes))
+	pushDuration.Observe(endTime.Sub(startTime).Seconds())
+	d.monitor.Pushed(ctx, InvalidINode, name, BID, startTime, endTime, len(blockList), pushedBytes)
+
+	return nil
+}
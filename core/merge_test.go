@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	newDataStore := func(options ...DataStoreOption) *DataStore {
+		dir, err := ioutil.TempDir("", "test")
+		require.Nil(err)
+		ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f), options...)
+		require.Nil(err)
+		return ds
+	}
+	lookup := func(ds *DataStore, parent INode, name string) INode {
+		inode, err := ds.GetNodeID(ctx, parent, name)
+		require.Nil(err)
+		return inode
+	}
+	getRoot := func(label string) BlockID {
+		BID, err := f.GetRoot(ctx, label)
+		require.Nil(err)
+		return BID
+	}
+
+	ds := newDataStore()
+	a, err := ds.MakeDir(ctx, RootINode, "a")
+	require.Nil(err)
+	createFile(require, ds, a, "x", generateUniqueString())
+	createFile(require, ds, a, "y", generateUniqueString())
+	b, err := ds.MakeDir(ctx, RootINode, "b")
+	require.Nil(err)
+	createFile(require, ds, b, "z", generateUniqueString())
+	createFile(require, ds, RootINode, "c", generateUniqueString())
+	require.Nil(ds.Push(ctx, RootINode, "base"))
+	base := getRoot("base")
+
+	// one side changes a/x and adds d
+	left := newDataStore(DataStoreWithLabelRoot("base"))
+	leftA := lookup(left, RootINode, "a")
+	require.Nil(left.Remove(ctx, leftA, "x"))
+	createFile(require, left, leftA, "x", "new x")
+	createFile(require, left, RootINode, "d", generateUniqueString())
+	require.Nil(left.Push(ctx, RootINode, "left"))
+
+	// the other removes a/y and adds b/w
+	right := newDataStore(DataStoreWithLabelRoot("base"))
+	require.Nil(right.Remove(ctx, lookup(right, RootINode, "a"), "y"))
+	createFile(require, right, lookup(right, RootINode, "b"), "w", generateUniqueString())
+	require.Nil(right.Push(ctx, RootINode, "right"))
+
+	// merging with an unchanged side is just the other side
+	result, err := ds.Merge(ctx, base, base, getRoot("right"))
+	require.Nil(err)
+	require.Empty(result.Conflicts)
+	require.Equal(getRoot("right"), result.BID)
+	result, err = ds.Merge(ctx, base, getRoot("left"), getRoot("left"))
+	require.Nil(err)
+	require.Equal(getRoot("left"), result.BID)
+
+	// otherwise both sets of changes are combined, in a repo which has neither side locally
+	merger := newDataStore()
+	result, err = merger.Merge(ctx, base, getRoot("left"), getRoot("right"))
+	require.Nil(err)
+	require.Empty(result.Conflicts)
+	require.Nil(merger.PushTree(ctx, result.BID, "merged", PushMessage("merged left and right")))
+	require.Equal(result.BID, getRoot("merged"))
+
+	history, err := merger.GetLabelHistory(ctx, "merged")
+	require.Nil(err)
+	require.Len(history, 1)
+	require.Equal("merged left and right", history[0].Message)
+
+	// the merged tree differs from each side by the other side's changes
+	changes, err := merger.Diff(ctx, FrozenTree(getRoot("right")), FrozenTree(result.BID))
+	require.Nil(err)
+	require.Len(changes, 2)
+	require.Equal(Modified, changes[0].Kind)
+	require.Equal("a/x", changes[0].Path)
+	require.Equal(Added, changes[1].Kind)
+	require.Equal("d", changes[1].Path)
+	changes, err = merger.Diff(ctx, FrozenTree(getRoot("left")), FrozenTree(result.BID))
+	require.Nil(err)
+	require.Len(changes, 2)
+	require.Equal(Removed, changes[0].Kind)
+	require.Equal("a/y", changes[0].Path)
+	require.Equal(Added, changes[1].Kind)
+	require.Equal("b/w", changes[1].Path)
+
+	// and can be used as the root of another repo
+	reader := newDataStore(DataStoreWithLabelRoot("merged"))
+	r, err := reader.GetReadRef(ctx, lookup(reader, lookup(reader, RootINode, "a"), "x"))
+	require.Nil(err)
+	buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+	require.Nil(err)
+	require.Equal("new x", string(buffer))
+
+	// changes to the same paths on both sides conflict
+	require.Nil(left.Remove(ctx, RootINode, "c"))
+	createFile(require, left, RootINode, "c", "changed")
+	createFile(require, left, RootINode, "e", "left e")
+	require.Nil(left.Remove(ctx, leftA, "y"))
+	createFile(require, left, leftA, "y", "left y")
+	require.Nil(left.Push(ctx, RootINode, "left"))
+	require.Nil(right.Remove(ctx, RootINode, "c"))
+	createFile(require, right, RootINode, "e", "right e")
+	createFile(require, right, lookup(right, RootINode, "a"), "y", "right y")
+	require.Nil(right.Push(ctx, RootINode, "right"))
+
+	result, err = merger.Merge(ctx, base, getRoot("left"), getRoot("right"))
+	require.Nil(err)
+	require.Equal(NABlock, result.BID)
+	require.Equal([]*MergeConflict{
+		{Kind: BothModified, Path: "a/y"},
+		{Kind: ModifiedDeleted, Path: "c"},
+		{Kind: BothAdded, Path: "e"}}, result.Conflicts)
+
+	// and without a base, anything that differs conflicts
+	result, err = merger.Merge(ctx, NABlock, getRoot("right"), getRoot("left"))
+	require.Nil(err)
+	require.Equal(BothAdded, result.Conflicts[0].Kind)
+	require.Equal("a/x", result.Conflicts[0].Path)
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var (
+	remoteFetchedBytes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "remote_fetched_bytes_total",
+		Help:      "Bytes copied from remote blocks into the local cache",
+	})
+
+	remoteRequestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "pufs",
+		Name:      "remote_request_duration_seconds",
+		Help:      "Time taken to copy a missing region of a block from the remote",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	})
+
+	remoteRequestSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "pufs",
+		Name:      "remote_request_size_bytes",
+		Help:      "Size of each missing region of a block copied from the remote",
+		Buckets:   prometheus.ExponentialBuckets(4096, 4, 10),
+	})
+
+	remoteRequestErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "remote_request_errors_total",
+		Help:      "Copies from the remote which failed",
+	})
+
+	remoteRequestRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "remote_request_retries_total",
+		Help:      "Copies from the remote which were retried after a transient failure",
+	})
+
+	// reads of frozen blocks, partitioned by whether the data was already in the local cache
+	cacheReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "cache_reads_total",
+		Help:      "Reads of frozen blocks, by whether they were served entirely from the local cache (hit) or required a fetch (miss)",
+	}, []string{"result"})
+
+	pushedBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "pushed_bytes_total",
+		Help:      "Bytes of blocks uploaded by pushes",
+	})
+
+	pushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "pufs",
+		Name:      "push_duration_seconds",
+		Help:      "Time taken by each push",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 4, 10),
+	})
+)
+
+func init() {
+	prometheus.MustRegister(remoteFetchedBytes, remoteRequestDuration, remoteRequestSize, remoteRequestErrors,
+		remoteRequestRetries, cacheReads, pushedBytesTotal, pushDuration)
+}
+
+var (
+	freezerUsedDesc = prometheus.NewDesc("pufs_freezer_used_bytes",
+		"Bytes on disk used by cached and frozen blocks", nil, nil)
+	writableUsedDesc = prometheus.NewDesc("pufs_writable_used_bytes",
+		"Bytes on disk used by files which have been written but not frozen", nil, nil)
+	inodeCountDesc = prometheus.NewDesc("pufs_inodes",
+		"Number of inodes allocated", nil, nil)
+	pendingReadsDesc = prometheus.NewDesc("pufs_pending_reads_in_flight",
+		"Background copies from the remote which are currently running", nil, nil)
+	fetchesQueuedDesc = prometheus.NewDesc("pufs_fetches_queued",
+		"Copies from the remote waiting for a free slot, by priority", []string{"priority"}, nil)
+	fetchThrottlesDesc = prometheus.NewDesc("pufs_fetch_throttles_total",
+		"Copies from the remote which were rejected because the remote asked for requests to slow down", nil, nil)
+)
+
+// dataStoreCollector reports metrics which are computed from the state of a DataStore at the time they are
+// collected, rather than accumulated as operations happen
+type dataStoreCollector struct {
+	ds *DataStore
+}
+
+// NewDataStoreCollector returns a prometheus Collector which reports disk usage and in-flight transfers for ds. The
+// counters and histograms for remote reads, pushes, etc are registered with the default registry automatically.
+func NewDataStoreCollector(ds *DataStore) prometheus.Collector {
+	return &dataStoreCollector{ds: ds}
+}
+
+func (c *dataStoreCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- freezerUsedDesc
+	ch <- writableUsedDesc
+	ch <- inodeCountDesc
+	ch <- pendingReadsDesc
+	ch <- fetchesQueuedDesc
+	ch <- fetchThrottlesDesc
+}
+
+func (c *dataStoreCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.ds.GetFSStats()
+	if err != nil {
+		log.Printf("Could not get stats for metrics: %s", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(freezerUsedDesc, prometheus.GaugeValue, float64(stats.FreezerUsed))
+	ch <- prometheus.MustNewConstMetric(writableUsedDesc, prometheus.GaugeValue, float64(stats.WritableUsed))
+	ch <- prometheus.MustNewConstMetric(inodeCountDesc, prometheus.GaugeValue, float64(stats.INodeCount))
+
+	inFlight := 0
+	for _, t := range c.ds.freezer.GetActiveTransferStatus(time.Second) {
+		inFlight += len(t.Transfers)
+	}
+	ch <- prometheus.MustNewConstMetric(pendingReadsDesc, prometheus.GaugeValue, float64(inFlight))
+
+	if c.ds.scheduler != nil {
+		schedulerStats := c.ds.scheduler.Stats()
+		ch <- prometheus.MustNewConstMetric(fetchesQueuedDesc, prometheus.GaugeValue, float64(schedulerStats.QueuedForeground), "foreground")
+		ch <- prometheus.MustNewConstMetric(fetchesQueuedDesc, prometheus.GaugeValue, float64(schedulerStats.QueuedBackground), "background")
+		ch <- prometheus.MustNewConstMetric(fetchThrottlesDesc, prometheus.CounterValue, float64(schedulerStats.Throttles))
+	}
+}
+
+// histogramTotals returns the number of observations recorded by h and their sum
+func histogramTotals(h prometheus.Histogram) (uint64, float64) {
+	var m dto.Metric
+	err := h.Write(&m)
+	if err != nil {
+		return 0, 0
+	}
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
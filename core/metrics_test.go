@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+func counterValue(require *require.Assertions, c prometheus.Counter) float64 {
+	var m dto.Metric
+	require.Nil(c.Write(&m))
+	return m.GetCounter().GetValue()
+}
+
+func TestMetrics(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+	content := generateUniqueString()
+
+	f := NewRemoteRefFactoryMem()
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+
+	createFile(require, ds1, RootINode, "a", content)
+	pushedBefore := counterValue(require, pushedBytesTotal)
+	pushesBefore, _ := histogramTotals(pushDuration)
+	require.Nil(ds1.Push(ctx, RootINode, "label"))
+	require.True(counterValue(require, pushedBytesTotal) >= pushedBefore+float64(len(content)))
+	pushesAfter, _ := histogramTotals(pushDuration)
+	require.Equal(pushesBefore+1, pushesAfter)
+
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	require.Nil(ds2.MountByLabel(ctx, RootINode, "mount", "label"))
+	mountInode, err := ds2.GetNodeID(ctx, RootINode, "mount")
+	require.Nil(err)
+	aID, err := ds2.GetNodeID(ctx, mountInode, "a")
+	require.Nil(err)
+
+	// the first read needs to fetch from the remote, the second is served from the cache
+	fetchedBefore := counterValue(require, remoteFetchedBytes)
+	missesBefore := counterValue(require, cacheReads.WithLabelValues("miss"))
+	hitsBefore := counterValue(require, cacheReads.WithLabelValues("hit"))
+	requestsBefore, _ := histogramTotals(remoteRequestDuration)
+
+	for i := 0; i < 2; i++ {
+		r, err := ds2.GetReadRef(ctx, aID)
+		require.Nil(err)
+		buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+		require.Nil(err)
+		require.Equal(content, string(buffer))
+		r.Release()
+	}
+
+	require.Equal(fetchedBefore+float64(len(content)), counterValue(require, remoteFetchedBytes))
+	require.True(counterValue(require, cacheReads.WithLabelValues("miss")) > missesBefore)
+	require.True(counterValue(require, cacheReads.WithLabelValues("hit")) > hitsBefore)
+	requestsAfter, _ := histogramTotals(remoteRequestDuration)
+	require.True(requestsAfter > requestsBefore)
+
+	// disk usage is reported by the collector when scraped
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewDataStoreCollector(ds2))
+	families, err := registry.Gather()
+	require.Nil(err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	require.True(values["pufs_freezer_used_bytes"] > 0)
+	require.True(values["pufs_inodes"] > 1)
+	require.Equal(float64(0), values["pufs_pending_reads_in_flight"])
+}
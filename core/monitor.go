@@ -6,19 +6,53 @@ import (
 )
 
 type Monitor interface {
-	AddedLazyDirBlock(ctx context.Context, startTime time.Time, endTime time.Time)
-	FetchedRemoteChildren(ctx context.Context, startTime time.Time, endTime time.Time)
-	RegionCopied(ctx context.Context, startTime time.Time, endTime time.Time, start int64, end int64)
+	AddedLazyDirBlock(ctx context.Context, inode INode, startTime time.Time, endTime time.Time)
+	FetchedRemoteChildren(ctx context.Context, inode INode, startTime time.Time, endTime time.Time)
+	RegionCopied(ctx context.Context, BID BlockID, startTime time.Time, endTime time.Time, start int64, end int64)
+	FileOpened(ctx context.Context, inode INode, writable bool)
+	FileRead(ctx context.Context, inode INode, offset int64, length int64)
+	Pushed(ctx context.Context, inode INode, label string, BID BlockID, startTime time.Time, endTime time.Time, blockCount int, bytes int64)
+	Evicted(ctx context.Context, inode INode, BID BlockID, bytes int64)
+	RemoteChanged(ctx context.Context, BID BlockID, source string, pinned bool)
 }
 
 type NullMonitor struct {
 }
 
-func (m *NullMonitor) AddedLazyDirBlock(ctx context.Context, startTime time.Time, endTime time.Time) {
+func (m *NullMonitor) AddedLazyDirBlock(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
 }
 
-func (m *NullMonitor) FetchedRemoteChildren(ctx context.Context, startTime time.Time, endTime time.Time) {
+func (m *NullMonitor) FetchedRemoteChildren(ctx context.Context, inode INode, startTime time.Time, endTime time.Time) {
 }
 
-func (m *NullMonitor) RegionCopied(ctx context.Context, startTime time.Time, endTime time.Time, start int64, end int64) {
+func (m *NullMonitor) RegionCopied(ctx context.Context, BID BlockID, startTime time.Time, endTime time.Time, start int64, end int64) {
+}
+
+func (m *NullMonitor) FileOpened(ctx context.Context, inode INode, writable bool) {
+}
+
+func (m *NullMonitor) FileRead(ctx context.Context, inode INode, offset int64, length int64) {
+}
+
+func (m *NullMonitor) Pushed(ctx context.Context, inode INode, label string, BID BlockID, startTime time.Time, endTime time.Time, blockCount int, bytes int64) {
+}
+
+func (m *NullMonitor) Evicted(ctx context.Context, inode INode, BID BlockID, bytes int64) {
+}
+
+func (m *NullMonitor) RemoteChanged(ctx context.Context, BID BlockID, source string, pinned bool) {
+}
+
+type callerPidKey struct{}
+
+// WithCallerPid records the pid of the process on whose behalf an operation is being performed (ie: the process
+// which made a FUSE request), so that it can be included in events reported to the Monitor
+func WithCallerPid(ctx context.Context, pid uint32) context.Context {
+	return context.WithValue(ctx, callerPidKey{}, pid)
+}
+
+// CallerPid returns the pid recorded by WithCallerPid, or 0 if there was none
+func CallerPid(ctx context.Context) uint32 {
+	pid, _ := ctx.Value(callerPidKey{}).(uint32)
+	return pid
 }
@@ -5,8 +5,11 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"log"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,6 +25,8 @@ type INodeDB struct {
 	db        KVStore
 	lastID    INode
 	maxINodes uint32
+	// the number of updates made so far. Accessed atomically.
+	changes uint64
 }
 
 type NodeRepr struct {
@@ -110,7 +115,7 @@ func NewINodeDB(maxINodes uint32, db KVStore) *INodeDB {
 }
 
 func (db *INodeDB) AddEmptyRootDir() error {
-	err := db.db.Update(func(tx RWTx) error {
+	err := db.update(func(tx RWTx) error {
 		err := addEmptyDir(tx, RootINode, RootINode)
 
 		return err
@@ -120,7 +125,7 @@ func (db *INodeDB) AddEmptyRootDir() error {
 }
 
 func (db *INodeDB) AddRemoteGCSRootDir(bucket string, key string) error {
-	err := db.db.Update(func(tx RWTx) error {
+	err := db.update(func(tx RWTx) error {
 		err := addRemoteGCS(tx, RootINode, RootINode, bucket, key, 0, 0, time.Now(), true)
 		return err
 	})
@@ -129,7 +134,7 @@ func (db *INodeDB) AddRemoteGCSRootDir(bucket string, key string) error {
 }
 
 func (db *INodeDB) AddBlockIDRootDir(BID BlockID) error {
-	err := db.db.Update(func(tx RWTx) error {
+	err := db.update(func(tx RWTx) error {
 		err := addBIDMount(tx, RootINode, RootINode, BID)
 		return err
 	})
@@ -138,9 +143,21 @@ func (db *INodeDB) AddBlockIDRootDir(BID BlockID) error {
 }
 
 func (db *INodeDB) update(fn func(tx RWTx) error) error {
+	// counted once the update is committed, so anything read before the count changes may be stale
+	defer atomic.AddUint64(&db.changes, 1)
 	return db.db.Update(fn)
 }
 
+// loadUpdate is like update, but for transactions which only fill in children loaded lazily from a directory block.
+// Those don't change anything which could have been read before, so aren't counted as changes.
+func (db *INodeDB) loadUpdate(fn func(tx RWTx) error) error {
+	return db.db.Update(fn)
+}
+
+func (db *INodeDB) changeCount() uint64 {
+	return atomic.LoadUint64(&db.changes)
+}
+
 func (db *INodeDB) view(fn func(tx RTx) error) error {
 	return db.db.View(fn)
 }
@@ -175,6 +192,50 @@ func (db *INodeDB) getNextFreeInode(tx RWTx) (INode, error) {
 	}
 }
 
+func (db *INodeDB) GetINodeCount(tx RTx) (uint32, error) {
+	count := uint32(0)
+	b := tx.RBucket(NodeBucket)
+	err := b.ForEachWithPrefix(nil, func(k []byte, v []byte) error {
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// GetDirtyFileStats returns the number of files which have changes which haven't been pushed, and their total size.
+// Writable files whose local copy is missing are left out, rather than failing, so that status can still be reported.
+func (db *INodeDB) GetDirtyFileStats(tx RTx) (int, int64, error) {
+	count := 0
+	size := int64(0)
+	b := tx.RBucket(NodeBucket)
+	err := b.ForEachWithPrefix(nil, func(k []byte, v []byte) error {
+		node := bytesToNode(v)
+		if node.IsDir || !node.IsDirty {
+			return nil
+		}
+		if node.LocalWritablePath != "" {
+			st, err := os.Stat(node.LocalWritablePath)
+			if os.IsNotExist(err) {
+				log.Printf("Warning: writable file for inode %d is missing (%s), so it's left out of the unpushed files", binary.LittleEndian.Uint32(k), node.LocalWritablePath)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			size += st.Size()
+		} else {
+			size += node.Size
+		}
+		count++
+		return nil
+	})
+	return count, size, err
+}
+
+func (db *INodeDB) MaxINodes() uint32 {
+	return db.maxINodes
+}
+
 func (db *INodeDB) releaseNode(tx RWTx, id INode) error {
 	b := tx.WBucket(NodeBucket)
 	idBytes := make([]byte, 4)
@@ -299,7 +360,7 @@ func assertValidDirWillMutate(tx RWTx, id INode) error {
 		node.BID = NABlock
 		putNodeRepr(tx, id, node)
 
-		if node.ParentINode != RootINode {
+		if id != RootINode {
 			err := assertValidDirWillMutate(tx, node.ParentINode)
 			if err != nil {
 				return err
@@ -554,6 +615,108 @@ func addBIDMount(tx RWTx, parentINode INode, inode INode, BID BlockID) error {
 		IsDeferredChildFetch: true})
 }
 
+// ReplaceWithBID discards everything below the directory inode and makes it a lazily loaded copy of the directory
+// block BID instead. Returns the inodes which were removed, along with the local files which held their writes.
+func (db *INodeDB) ReplaceWithBID(tx RWTx, inode INode, BID BlockID) ([]INode, []string, error) {
+	node, err := getNodeRepr(tx, inode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !node.IsDir {
+		return nil, nil, NotDirErr
+	}
+
+	var removed []INode
+	var writablePaths []string
+	err = db.removeDescendants(tx, inode, &removed, &writablePaths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if inode != RootINode {
+		err = assertValidDirWillMutate(tx, node.ParentINode)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = addBIDMount(tx, node.ParentINode, inode, BID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return removed, writablePaths, nil
+}
+
+// RemoveTree removes the node name from parent along with everything below it. Returns the inodes which were
+// removed, along with the local files which held their writes.
+func (db *INodeDB) RemoveTree(tx RWTx, parent INode, name string) ([]INode, []string, error) {
+	id, err := db.GetNodeID(tx, parent, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	node, err := getNodeRepr(tx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var removed []INode
+	var writablePaths []string
+	if node.IsDir {
+		err = db.removeDescendants(tx, id, &removed, &writablePaths)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if node.LocalWritablePath != "" {
+		writablePaths = append(writablePaths, node.LocalWritablePath)
+	}
+
+	err = db.RemoveNode(tx, parent, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(removed, id), writablePaths, nil
+}
+
+func (db *INodeDB) removeDescendants(tx RWTx, inode INode, removed *[]INode, writablePaths *[]string) error {
+	children, err := db.GetDirContents(tx, inode, false)
+	if err != nil {
+		return err
+	}
+
+	nb := tx.WBucket(NodeBucket)
+	inodeBytes := make([]byte, 4)
+	for _, child := range children {
+		childNode, err := getNodeRepr(tx, child.ID)
+		if err != nil {
+			return err
+		}
+		if childNode.IsDir {
+			err = db.removeDescendants(tx, child.ID, removed, writablePaths)
+			if err != nil {
+				return err
+			}
+		}
+		if childNode.LocalWritablePath != "" {
+			*writablePaths = append(*writablePaths, childNode.LocalWritablePath)
+		}
+
+		err = removeChild(tx, inode, child.Name)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(inodeBytes, uint32(child.ID))
+		err = nb.Delete(inodeBytes)
+		if err != nil {
+			return err
+		}
+		*removed = append(*removed, child.ID)
+	}
+
+	return nil
+}
+
 func makeGSCHashBlockID(bucket string, key string, generation int64) BlockID {
 
 	var BID BlockID
@@ -588,6 +751,29 @@ func (db *INodeDB) UpdateIsRemoteGCS(tx RWTx, inode INode, bucket string, key st
 	return putNodeRepr(tx, inode, node)
 }
 
+// UpdateRemoteSource re-points a file at a newer version of the remote object it was created from
+func (db *INodeDB) UpdateRemoteSource(tx RWTx, inode INode, remoteSource interface{}, BID BlockID, size int64, ModTime time.Time) error {
+	node, err := getNodeRepr(tx, inode)
+	if err != nil {
+		return err
+	}
+	if node.IsDir {
+		return IsDirErr
+	}
+
+	err = assertValidDirWillMutate(tx, node.ParentINode)
+	if err != nil {
+		return err
+	}
+
+	node.RemoteSource = remoteSource
+	node.BID = BID
+	node.Size = size
+	node.ModTime = ModTime
+
+	return putNodeRepr(tx, inode, node)
+}
+
 func (db *INodeDB) AddRemoteGCS(tx RWTx, parent INode, name string, bucket string, key string, generation int64, size int64, ModTime time.Time, isDir bool) (INode, error) {
 	err := assertValidDirWillMutate(tx, parent)
 	if err != nil {
@@ -690,10 +876,15 @@ func (db *INodeDB) AddRemoteURL(tx RWTx, parent INode, name string, url string,
 	return id, nil
 }
 
-func addRemoteURL(tx RWTx, parentINode INode, inode INode, url string, etag string, size int64, modTime time.Time) error {
+func makeURLHashBlockID(url string, etag string) BlockID {
 	hashID := sha256.Sum256([]byte(url + etag))
 	var BID BlockID
 	copy(BID[:], hashID[:])
+	return BID
+}
+
+func addRemoteURL(tx RWTx, parentINode INode, inode INode, url string, etag string, size int64, modTime time.Time) error {
+	BID := makeURLHashBlockID(url, etag)
 	return putNodeRepr(tx, inode, &NodeRepr{
 		ParentINode:  parentINode,
 		IsDirty:      false,
@@ -789,3 +980,64 @@ func (db *INodeDB) GetDirContents(tx RTx, id INode, includeDots bool) ([]NameINo
 
 	return names, nil
 }
+
+var stopIterationErr = errors.New("stop iteration")
+
+// GetChildName returns the name that id has within the directory parent. As children are only indexed by name, this
+// scans the directory.
+func (db *INodeDB) GetChildName(tx RTx, parent INode, id INode) (string, error) {
+	prefix := make([]byte, 4)
+	binary.LittleEndian.PutUint32(prefix, uint32(parent))
+
+	name := ""
+	found := false
+	c := tx.RBucket(ChildNodeBucket)
+	err := c.ForEachWithPrefix(prefix, func(k []byte, v []byte) error {
+		if INode(binary.LittleEndian.Uint32(v)) == id {
+			name = string(k[len(prefix):])
+			found = true
+			return stopIterationErr
+		}
+		return nil
+	})
+	if err != nil && err != stopIterationErr {
+		return "", err
+	}
+	if !found {
+		return "", NoSuchNodeErr
+	}
+
+	return name, nil
+}
+
+// GetDirContentsAfter returns up to maxEntries children of the directory, sorted by name, whose names
+// sort after the given name. Use after="" to start at the first child.
+func (db *INodeDB) GetDirContentsAfter(tx RTx, id INode, after string, maxEntries int) ([]NameINode, error) {
+	err := assertValidDir(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]NameINode, 0, maxEntries)
+	prefix := make([]byte, 4)
+	binary.LittleEndian.PutUint32(prefix, uint32(id))
+	start := makeChildKey(id, after)
+
+	c := tx.RBucket(ChildNodeBucket)
+	err = c.ForEachWithPrefixFrom(prefix, start, func(k []byte, v []byte) error {
+		name := string(k[len(prefix):])
+		if name == after {
+			return nil
+		}
+		if len(names) >= maxEntries {
+			return stopIterationErr
+		}
+		names = append(names, NameINode{Name: name, ID: INode(binary.LittleEndian.Uint32(v))})
+		return nil
+	})
+	if err != nil && err != stopIterationErr {
+		return nil, err
+	}
+
+	return names, nil
+}
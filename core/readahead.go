@@ -0,0 +1,86 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// the smallest readahead window used for a file which is being read sequentially
+const DefaultMinReadahead = 1024 * 128
+
+// readahead tracks the access pattern of a single open file to decide how much to fetch past the end of each read.
+// Each read which starts where the previous one ended doubles the window (up to max), and each read which doesn't
+// shrinks it to a quarter, dropping to no readahead at all once it falls below min.
+type readahead struct {
+	min      int64
+	max      int64
+	window   int64
+	nextRead int64
+}
+
+func newReadahead(min int64, max int64) *readahead {
+	if min > max {
+		min = max
+	}
+	return &readahead{min: min, max: max, window: min}
+}
+
+// update records a read at offset, returning the number of bytes to fetch past the end of it
+func (r *readahead) update(offset int64, length int64) int64 {
+	if offset == r.nextRead {
+		if r.window < r.min {
+			r.window = r.min
+		} else {
+			r.window *= 2
+		}
+		if r.window > r.max {
+			r.window = r.max
+		}
+	} else {
+		r.window /= 4
+		if r.window < r.min {
+			r.window = 0
+		}
+	}
+	r.nextRead = offset + length
+	return r.window
+}
+
+// readaheadBudget limits the rate at which bytes are fetched speculatively, across all open files. It's a token
+// bucket which holds up to one second's worth of bytes. Reads which were actually requested are never limited;
+// only the readahead past them is.
+type readaheadBudget struct {
+	mutex          sync.Mutex
+	bytesPerSecond int64
+	available      int64
+	lastRefill     time.Time
+}
+
+func newReadaheadBudget(bytesPerSecond int64) *readaheadBudget {
+	return &readaheadBudget{bytesPerSecond: bytesPerSecond, available: bytesPerSecond, lastRefill: time.Now()}
+}
+
+// take returns how much of the requested readahead can be fetched, and deducts it from the budget. A budget of zero
+// bytes per second means unlimited.
+func (b *readaheadBudget) take(requested int64) int64 {
+	if b == nil || b.bytesPerSecond <= 0 || requested <= 0 {
+		return requested
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+	b.available += int64(float64(b.bytesPerSecond) * now.Sub(b.lastRefill).Seconds())
+	if b.available > b.bytesPerSecond {
+		b.available = b.bytesPerSecond
+	}
+	b.lastRefill = now
+
+	granted := requested
+	if granted > b.available {
+		granted = b.available
+	}
+	b.available -= granted
+	return granted
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadaheadWindow(t *testing.T) {
+	require := require.New(t)
+
+	r := newReadahead(100, 1000)
+
+	// sequential reads double the window until it reaches the max
+	require.Equal(int64(200), r.update(0, 10))
+	require.Equal(int64(400), r.update(10, 10))
+	require.Equal(int64(800), r.update(20, 10))
+	require.Equal(int64(1000), r.update(30, 10))
+	require.Equal(int64(1000), r.update(40, 10))
+
+	// a seek shrinks it, and a second one turns readahead off
+	require.Equal(int64(250), r.update(5000, 10))
+	require.Equal(int64(0), r.update(100, 10))
+	require.Equal(int64(0), r.update(3000, 10))
+
+	// and reading sequentially again starts back at the min
+	require.Equal(int64(100), r.update(3010, 10))
+	require.Equal(int64(200), r.update(3020, 10))
+}
+
+func TestReadaheadBudget(t *testing.T) {
+	require := require.New(t)
+
+	var unlimited *readaheadBudget
+	require.Equal(int64(5000), unlimited.take(5000))
+
+	b := newReadaheadBudget(1000)
+	require.Equal(int64(600), b.take(600))
+	require.Equal(int64(400), b.take(600))
+	require.Equal(int64(0), b.take(600))
+
+	// the budget refills over time
+	time.Sleep(100 * time.Millisecond)
+	granted := b.take(600)
+	require.True(granted >= 100 && granted < 600, "granted %d", granted)
+}
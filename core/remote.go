@@ -34,9 +34,23 @@ type RemoteFile struct {
 type RemoteRefFactory interface {
 	GetBlockSource(ctx context.Context, BID BlockID) (interface{}, error)
 	Push(ctx context.Context, BID BlockID, rr FrozenRef) error
-	SetLease(ctx context.Context, name string, expiry time.Time, BID BlockID) error
+	SetLease(ctx context.Context, lease *Lease) error
+	// ListLeases returns every lease, including those which have expired
+	ListLeases(ctx context.Context) ([]*Lease, error)
 	SetRoot(ctx context.Context, name string, BID BlockID) error
+	// CompareAndSetRoot points the label name at BID only if it currently points at expected, or doesn't exist if
+	// expected is NABlock. Returns LabelConflictErr if the label had moved.
+	CompareAndSetRoot(ctx context.Context, name string, expected BlockID, BID BlockID) error
 	GetRoot(ctx context.Context, name string) (BlockID, error)
+	// AddLabelVersion appends version to the history of the label name, numbering it after the last version
+	AddLabelVersion(ctx context.Context, name string, version *LabelVersion) error
+	// GetLabelHistory returns the versions of the label name, oldest first
+	GetLabelHistory(ctx context.Context, name string) ([]*LabelVersion, error)
+	// GetLabelVersionCount returns the number of the latest version of the label name, or 0 if it has no history
+	GetLabelVersionCount(ctx context.Context, name string) (int, error)
+	// GetLabelVersion returns one version of the label name. Returns UnknownLabelVersionErr if there is no such version.
+	GetLabelVersion(ctx context.Context, name string, version int) (*LabelVersion, error)
+	ListLabels(ctx context.Context) ([]string, error)
 }
 
 type RemoteRefFactory2 interface {
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// faultyRemoteRefFactory wraps a RemoteRefFactory2 so that copies fail with each of the queued faults in turn
+// before succeeding
+type faultyRemoteRefFactory struct {
+	RemoteRefFactory2
+
+	mutex  sync.Mutex
+	faults []error
+	// how many bytes a failing copy writes before returning its error
+	partialWrite int64
+	copies       int
+}
+
+func (f *faultyRemoteRefFactory) inject(partialWrite int64, faults ...error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.faults = faults
+	f.partialWrite = partialWrite
+	f.copies = 0
+}
+
+func (f *faultyRemoteRefFactory) copyCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.copies
+}
+
+func (f *faultyRemoteRefFactory) faultsRemaining() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return len(f.faults)
+}
+
+func (f *faultyRemoteRefFactory) GetRef(source interface{}) RemoteRef {
+	return &faultyRemoteRef{RemoteRef: f.RemoteRefFactory2.GetRef(source), owner: f}
+}
+
+type faultyRemoteRef struct {
+	RemoteRef
+	owner *faultyRemoteRefFactory
+	// pinned refs read the original generation, which is unaffected by faults
+	pinned bool
+}
+
+func (r *faultyRemoteRef) Pin() RemoteRef {
+	return &faultyRemoteRef{RemoteRef: r.RemoteRef, owner: r.owner, pinned: true}
+}
+
+func (r *faultyRemoteRef) Copy(ctx context.Context, offset int64, length int64, writer io.Writer) error {
+	f := r.owner
+	f.mutex.Lock()
+	f.copies++
+	var fault error
+	if len(f.faults) > 0 && !r.pinned {
+		fault = f.faults[0]
+		f.faults = f.faults[1:]
+	}
+	partialWrite := f.partialWrite
+	f.mutex.Unlock()
+
+	if fault == nil {
+		return r.RemoteRef.Copy(ctx, offset, length, writer)
+	}
+
+	if partialWrite > 0 && partialWrite < length {
+		err := r.RemoteRef.Copy(ctx, offset, partialWrite, writer)
+		if err != nil {
+			return err
+		}
+	}
+	return fault
+}
+
+// newFaultyDataStore pushes files with the given names and returns a DataStore with them mounted under "mount",
+// reading from a remote which fails as instructed by the returned factory
+func newFaultyDataStore(require *require.Assertions, names []string, options ...DataStoreOption) (*DataStore, *faultyRemoteRefFactory, map[string]string, func(string) (string, error)) {
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+
+	contents := make(map[string]string)
+	for _, name := range names {
+		contents[name] = strings.Repeat(fmt.Sprintf("%s%s", name, generateUniqueString()), 1000)
+		createFile(require, ds1, RootINode, name, contents[name])
+	}
+	require.Nil(ds1.Push(ctx, RootINode, "label"))
+
+	faulty := &faultyRemoteRefFactory{RemoteRefFactory2: NewMemRemoteRefFactory2(f)}
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds2, err := newMemDataStore(dir2, f, faulty, options...)
+	require.Nil(err)
+	require.Nil(ds2.MountByLabel(ctx, RootINode, "mount", "label"))
+	mount, err := ds2.GetNodeID(ctx, RootINode, "mount")
+	require.Nil(err)
+	// list the mount up front, so that only reads of files see the faults
+	_, err = ds2.GetDirContents(ctx, mount)
+	require.Nil(err)
+
+	readFile := func(name string) (string, error) {
+		id, err := ds2.GetNodeID(ctx, mount, name)
+		require.Nil(err)
+		r, err := ds2.GetReadRef(ctx, id)
+		require.Nil(err)
+		defer r.Release()
+		buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+		return string(buffer), err
+	}
+
+	return ds2, faulty, contents, readFile
+}
+
+func TestRemoteRetries(t *testing.T) {
+	require := require.New(t)
+	_, faulty, contents, readFile := newFaultyDataStore(require, []string{"a", "b", "c", "d"}, RemoteRetries(3, time.Millisecond))
+
+	transient := &RemoteErr{Class: RemoteTransient, Err: errors.New("connection reset")}
+
+	// transient failures are retried until the copy succeeds, resuming after whatever was already copied
+	faulty.inject(100, transient, transient)
+	content, err := readFile("a")
+	require.Nil(err)
+	require.Equal(contents["a"], content)
+	require.Equal(0, faulty.faultsRemaining())
+	// (later reads may need further copies if they overtake the readahead)
+	require.True(faulty.copyCount() >= 3)
+
+	// throttling is retried too
+	faulty.inject(0, &RemoteErr{Class: RemoteThrottled, Err: errors.New("slow down")})
+	content, err = readFile("b")
+	require.Nil(err)
+	require.Equal(contents["b"], content)
+	require.Equal(0, faulty.faultsRemaining())
+
+	// failures which won't go away on their own are returned immediately
+	faulty.inject(0, &RemoteErr{Class: RemotePreconditionFailed, Err: errors.New("generation changed")})
+	_, err = readFile("c")
+	class, ok := GetRemoteErrClass(err)
+	require.True(ok)
+	require.Equal(RemotePreconditionFailed, class)
+	require.Equal(1, faulty.copyCount())
+
+	// and transient failures are returned once the retries are used up
+	faulty.inject(0, transient, transient, transient, transient, transient)
+	_, err = readFile("d")
+	class, ok = GetRemoteErrClass(err)
+	require.True(ok)
+	require.Equal(RemoteTransient, class)
+	require.Equal(4, faulty.copyCount())
+	require.Equal(1, faulty.faultsRemaining())
+}
+
+func TestGenerationPolicy(t *testing.T) {
+	require := require.New(t)
+	replaced := &RemoteErr{Class: RemotePreconditionFailed, Err: errors.New("generation changed")}
+
+	// by default, reads fail once the object has been replaced
+	ds, faulty, _, readFile := newFaultyDataStore(require, []string{"a"})
+	events := NewEventBroker()
+	ds.freezer.(*FreezerImp).monitor = events
+	sub := events.Subscribe(EventFilter{Types: []EventType{RemoteChangedEvent}}, 10)
+	defer sub.Close()
+
+	faulty.inject(0, replaced, replaced)
+	_, err := readFile("a")
+	class, ok := GetRemoteErrClass(err)
+	require.True(ok)
+	require.Equal(RemotePreconditionFailed, class)
+
+	event := <-sub.Events()
+	require.False(event.Pinned)
+	changes, err := ds.GetRemoteChanges()
+	require.Nil(err)
+	require.Len(changes, 1)
+	require.False(changes[0].Pinned)
+	require.Equal(event.BID, changes[0].BID)
+
+	// a second failure of the same block isn't logged again
+	_, err = readFile("a")
+	require.NotNil(err)
+	changes, err = ds.GetRemoteChanges()
+	require.Nil(err)
+	require.Len(changes, 1)
+
+	// but when pinning generations, reads carry on from the original generation
+	ds, faulty, contents, readFile := newFaultyDataStore(require, []string{"b"}, WithGenerationPolicy(PinGeneration))
+	faulty.inject(0, replaced, replaced, replaced)
+	content, err := readFile("b")
+	require.Nil(err)
+	require.Equal(contents["b"], content)
+
+	// and subsequent opens read the pinned generation without first trying the new one
+	copies := faulty.copyCount()
+	_, err = readFile("b")
+	require.Nil(err)
+	require.Equal(2, faulty.faultsRemaining())
+	require.Equal(copies, faulty.copyCount())
+
+	changes, err = ds.GetRemoteChanges()
+	require.Nil(err)
+	require.Len(changes, 1)
+	require.True(changes[0].Pinned)
+}
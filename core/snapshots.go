@@ -0,0 +1,239 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"sort"
+	"time"
+)
+
+var SnapshotBucket []byte = []byte("Snapshot")
+
+// Snapshot is a frozen copy of a directory in the repo, kept locally so the directory can be returned to it later
+type Snapshot struct {
+	Name string
+	// the directory the snapshot was taken of, relative to the root
+	Path    string
+	BID     BlockID
+	Created time.Time
+}
+
+func snapshotToBytes(snapshot *Snapshot) []byte {
+	buffer := bytes.NewBuffer(make([]byte, 0, 100))
+	enc := gob.NewEncoder(buffer)
+	err := enc.Encode(snapshot)
+	if err != nil {
+		panic(err)
+	}
+	return buffer.Bytes()
+}
+
+func bytesToSnapshot(b []byte) *Snapshot {
+	var snapshot Snapshot
+	dec := gob.NewDecoder(bytes.NewBuffer(b))
+	err := dec.Decode(&snapshot)
+	if err != nil {
+		panic(err)
+	}
+	return &snapshot
+}
+
+// CreateSnapshot freezes the directory inode and records its root under name
+func (d *DataStore) CreateSnapshot(ctx context.Context, inode INode, name string) (*Snapshot, error) {
+	err := validateName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := d.GetAttr(ctx, inode)
+	if err != nil {
+		return nil, err
+	}
+	if !node.IsDir {
+		return nil, NotDirErr
+	}
+
+	_, err = d.GetSnapshot(name)
+	if err == nil {
+		return nil, SnapshotExistsErr
+	}
+	if err != NoSuchSnapshotErr {
+		return nil, err
+	}
+
+	BID, err := d.Freeze(inode)
+	if err != nil {
+		return nil, err
+	}
+	snapshotPath, err := d.GetPath(inode)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Name: name, Path: snapshotPath, BID: BID, Created: time.Now()}
+	err = d.db.update(func(tx RWTx) error {
+		sb := tx.WBucket(SnapshotBucket)
+		if sb.Get([]byte(name)) != nil {
+			return SnapshotExistsErr
+		}
+		return sb.Put([]byte(name), snapshotToBytes(snapshot))
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.invalidateSnapshotBlocks()
+
+	return snapshot, nil
+}
+
+func (d *DataStore) GetSnapshot(name string) (*Snapshot, error) {
+	var snapshot *Snapshot
+	err := d.db.view(func(tx RTx) error {
+		value := tx.RBucket(SnapshotBucket).Get([]byte(name))
+		if value == nil {
+			return NoSuchSnapshotErr
+		}
+		snapshot = bytesToSnapshot(value)
+		return nil
+	})
+	return snapshot, err
+}
+
+// ListSnapshots returns all snapshots, oldest first
+func (d *DataStore) ListSnapshots() ([]*Snapshot, error) {
+	snapshots := make([]*Snapshot, 0)
+	err := d.db.view(func(tx RTx) error {
+		return tx.RBucket(SnapshotBucket).ForEachWithPrefix(nil, func(k []byte, v []byte) error {
+			snapshots = append(snapshots, bytesToSnapshot(v))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Created.Equal(snapshots[j].Created) {
+			return snapshots[i].Name < snapshots[j].Name
+		}
+		return snapshots[i].Created.Before(snapshots[j].Created)
+	})
+	return snapshots, nil
+}
+
+// DeleteSnapshot forgets the snapshot name, after which its blocks can be evicted again
+func (d *DataStore) DeleteSnapshot(name string) error {
+	err := d.db.update(func(tx RWTx) error {
+		sb := tx.WBucket(SnapshotBucket)
+		if sb.Get([]byte(name)) == nil {
+			return NoSuchSnapshotErr
+		}
+		return sb.Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+	d.invalidateSnapshotBlocks()
+	return nil
+}
+
+// RestoreSnapshot replaces the contents of the directory inode with the snapshot name. Everything under inode is
+// discarded, including changes which were never frozen, and the snapshot's contents are loaded lazily as they're
+// listed. The remote is not involved, other than to fetch blocks which aren't cached.
+func (d *DataStore) RestoreSnapshot(ctx context.Context, name string, inode INode) error {
+	snapshot, err := d.GetSnapshot(name)
+	if err != nil {
+		return err
+	}
+
+	var removed []INode
+	var writablePaths []string
+	err = d.db.update(func(tx RWTx) error {
+		removed, writablePaths, err = d.db.ReplaceWithBID(tx, inode, snapshot.BID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.releaseRemoved(ctx, removed, writablePaths)
+}
+
+// snapshotBlocks returns the blocks in any snapshot which are cached locally, so that they aren't evicted. Directories
+// which aren't cached aren't looked inside, as they're fetched from the remote if the snapshot is restored anyway.
+// Walking every snapshot is slow, so the result is kept until a snapshot is created or deleted. A block which wasn't
+// cached when the set was built can still be evicted, but only blocks which can be fetched again are ever missing.
+// The returned map is shared and must not be modified.
+func (d *DataStore) snapshotBlocks(ctx context.Context) (map[BlockID]bool, error) {
+	d.snapshotBlocksMutex.Lock()
+	defer d.snapshotBlocksMutex.Unlock()
+
+	if d.snapshotBlocksCache != nil {
+		return d.snapshotBlocksCache, nil
+	}
+
+	blocks, err := d.collectSnapshotBlocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d.snapshotBlocksCache = blocks
+	return blocks, nil
+}
+
+func (d *DataStore) invalidateSnapshotBlocks() {
+	d.snapshotBlocksMutex.Lock()
+	d.snapshotBlocksCache = nil
+	d.snapshotBlocksMutex.Unlock()
+}
+
+func (d *DataStore) collectSnapshotBlocks(ctx context.Context) (map[BlockID]bool, error) {
+	snapshots, err := d.ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := make(map[BlockID]bool)
+	for _, snapshot := range snapshots {
+		err = d.collectCachedBlocks(ctx, snapshot.BID, true, blocks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+func (d *DataStore) collectCachedBlocks(ctx context.Context, BID BlockID, isDir bool, blocks map[BlockID]bool) error {
+	if BID == NABlock || blocks[BID] {
+		// snapshots share most of their blocks, so don't walk the same tree twice
+		return nil
+	}
+
+	fr, err := d.freezer.GetRef(BID)
+	if err == UnknownBlockID {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fr == nil {
+		return nil
+	}
+	fr.Release()
+	blocks[BID] = true
+
+	if !isDir {
+		return nil
+	}
+	dir, err := d.readDirBlock(ctx, BID)
+	if err != nil {
+		return err
+	}
+	for _, e := range dir.Entries {
+		err = d.collectCachedBlocks(ctx, e.BID, e.IsDir, blocks)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshots(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	newDataStore := func(options ...DataStoreOption) *DataStore {
+		dir, err := ioutil.TempDir("", "test")
+		require.Nil(err)
+		ds, err := newMemDataStore(dir, f, NewMemRemoteRefFactory2(f), options...)
+		require.Nil(err)
+		return ds
+	}
+	readFile := func(ds *DataStore, parent INode, name string) string {
+		inode, err := ds.GetNodeID(ctx, parent, name)
+		require.Nil(err)
+		r, err := ds.GetReadRef(ctx, inode)
+		require.Nil(err)
+		buffer, err := ioutil.ReadAll(&FrozenReader{ctx, r})
+		require.Nil(err)
+		return string(buffer)
+	}
+
+	ds := newDataStore()
+	a, err := ds.MakeDir(ctx, RootINode, "a")
+	require.Nil(err)
+	createFile(require, ds, a, "x", "original x")
+	createFile(require, ds, RootINode, "y", "original y")
+
+	snapshot, err := ds.CreateSnapshot(ctx, a, "before")
+	require.Nil(err)
+	require.Equal("a", snapshot.Path)
+	_, err = ds.CreateSnapshot(ctx, a, "before")
+	require.Equal(SnapshotExistsErr, err)
+	_, err = ds.CreateSnapshot(ctx, RootINode, "bad/name")
+	require.NotNil(err)
+
+	// change everything under a, including a file which is still being written
+	require.Nil(ds.Remove(ctx, a, "x"))
+	createFile(require, ds, a, "x", "changed x")
+	b, err := ds.MakeDir(ctx, a, "b")
+	require.Nil(err)
+	createFile(require, ds, b, "z", generateUniqueString())
+	_, w, err := ds.CreateWritable(ctx, a, "w")
+	require.Nil(err)
+	_, err = w.Write([]byte("unfrozen"))
+	require.Nil(err)
+	writablePath := w.(*WritableRefImp).filename
+	w.Release()
+	createFile(require, ds, RootINode, "outside", generateUniqueString())
+
+	require.Nil(ds.RestoreSnapshot(ctx, "before", a))
+
+	// a is back as it was, keeping its inode, and nothing outside it changed
+	entries, err := ds.GetDirContents(ctx, a)
+	require.Nil(err)
+	names := make([]string, 0)
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	require.Equal([]string{".", "..", "x"}, names)
+	require.Equal("original x", readFile(ds, a, "x"))
+	require.Equal("original y", readFile(ds, RootINode, "y"))
+	_, err = ds.GetNodeID(ctx, RootINode, "outside")
+	require.Nil(err)
+	_, err = os.Stat(writablePath)
+	require.True(os.IsNotExist(err))
+
+	// and freezes to the same block as the snapshot
+	aBID, err := ds.Freeze(a)
+	require.Nil(err)
+	require.Equal(snapshot.BID, aBID)
+
+	_, err = ds.CreateSnapshot(ctx, RootINode, "everything")
+	require.Nil(err)
+	snapshots, err := ds.ListSnapshots()
+	require.Nil(err)
+	require.Len(snapshots, 2)
+	require.Equal("before", snapshots[0].Name)
+	require.Equal("everything", snapshots[1].Name)
+	require.Equal(".", snapshots[1].Path)
+
+	require.Nil(ds.DeleteSnapshot("before"))
+	require.Equal(NoSuchSnapshotErr, ds.DeleteSnapshot("before"))
+	require.Equal(NoSuchSnapshotErr, ds.RestoreSnapshot(ctx, "before", a))
+
+	// restoring the root replaces the whole tree
+	require.Nil(ds.Remove(ctx, RootINode, "y"))
+	require.Nil(ds.RestoreSnapshot(ctx, "everything", RootINode))
+	require.Equal("original y", readFile(ds, RootINode, "y"))
+}
+
+func TestSnapshotsProtectFromEviction(t *testing.T) {
+	require := require.New(t)
+	gob.Register(BlockID{})
+	ctx := context.Background()
+
+	f := NewRemoteRefFactoryMem()
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := newMemDataStore(dir1, f, NewMemRemoteRefFactory2(f))
+	require.Nil(err)
+	content := generateUniqueString()
+	createFile(require, ds1, RootINode, "x", content)
+	require.Nil(ds1.Push(ctx, RootINode, "v1"))
+
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds2, err := newMemDataStore(dir2, f, NewMemRemoteRefFactory2(f), DataStoreWithLabelRoot("v1"))
+	require.Nil(err)
+	x, err := ds2.GetNodeID(ctx, RootINode, "x")
+	require.Nil(err)
+	size, err := ds2.Prefetch(ctx, x)
+	require.Nil(err)
+	require.Equal(int64(len(content)), size)
+
+	// looks up the protected blocks before there are any snapshots
+	_, err = ds2.Refresh(ctx, RootINode)
+	require.Nil(err)
+
+	_, err = ds2.CreateSnapshot(ctx, RootINode, "s")
+	require.Nil(err)
+
+	// cached blocks in a snapshot stay put
+	_, err = ds2.Evict(ctx, x)
+	require.Equal(InSnapshotErr, err)
+	freed, err := ds2.Evict(ctx, RootINode)
+	require.Nil(err)
+	require.Equal(int64(0), freed)
+
+	// until the snapshot is deleted
+	require.Nil(ds2.DeleteSnapshot("s"))
+	freed, err = ds2.Evict(ctx, RootINode)
+	require.Nil(err)
+	require.True(freed > 0)
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// FSStats summarizes how much space and how many inodes a repo is using, along with the
+// capacity of the local filesystem the repo lives on. Used to answer statfs requests.
+type FSStats struct {
+	// capacity of the local filesystem holding the repo (in bytes)
+	LocalTotal uint64
+	LocalFree  uint64
+	LocalAvail uint64
+	BlockSize  uint32
+
+	// bytes consumed by cached blocks in the freezer and by files in the writable area
+	FreezerUsed  int64
+	WritableUsed int64
+
+	// the configured limit on space used by the freezer and writable area. Zero means no limit.
+	CacheQuota int64
+
+	INodeCount uint32
+	MaxINodes  uint32
+}
+
+// allocatedSize returns the number of bytes actually allocated on disk for the file, which for sparse,
+// partially populated blocks can be far less than the file's size.
+func allocatedSize(fi os.FileInfo) int64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return fi.Size()
+}
+
+func diskUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// files can disappear out from under us (ie: writable files being frozen), so ignore those
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !fi.IsDir() {
+			total += allocatedSize(fi)
+		}
+		return nil
+	})
+	return total, err
+}
+
+func localFSStats(dir string, stats *FSStats) error {
+	var st syscall.Statfs_t
+	err := syscall.Statfs(dir, &st)
+	if err != nil {
+		return err
+	}
+
+	bsize := uint64(st.Bsize)
+	stats.BlockSize = uint32(st.Bsize)
+	stats.LocalTotal = uint64(st.Blocks) * bsize
+	stats.LocalFree = uint64(st.Bfree) * bsize
+	stats.LocalAvail = uint64(st.Bavail) * bsize
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSStats(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	repo := NewRemoteRefFactoryMem()
+	ds, err := NewDataStore(dir, repo, NewMemRemoteRefFactory2(repo), NewMemStore([][]byte{ChunkStat}),
+		NewMemStore([][]byte{ChildNodeBucket, NodeBucket}), CacheQuota(1000000))
+	require.Nil(err)
+
+	before, err := ds.GetFSStats()
+	require.Nil(err)
+	require.Equal(int64(1000000), before.CacheQuota)
+	require.Equal(uint32(1), before.INodeCount)
+	require.True(before.MaxINodes > before.INodeCount)
+	require.True(before.LocalTotal > 0)
+	require.True(before.BlockSize > 0)
+
+	createFile(require, ds, RootINode, "a", strings.Repeat("x", 10000))
+	_, err = ds.AddImmutableBytes(ctx, RootINode, "b", []byte(strings.Repeat("y", 10000)))
+	require.Nil(err)
+
+	// the usage is counted at most once per FSUsageTTL
+	cached, err := ds.GetFSStats()
+	require.Nil(err)
+	require.Equal(before.INodeCount, cached.INodeCount)
+	require.Equal(before.WritableUsed, cached.WritableUsed)
+
+	ds.fsUsageTime = time.Now().Add(-FSUsageTTL)
+	after, err := ds.GetFSStats()
+	require.Nil(err)
+	require.Equal(uint32(3), after.INodeCount)
+	require.True(after.WritableUsed >= 10000)
+	require.True(after.FreezerUsed >= 10000)
+}
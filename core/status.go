@@ -0,0 +1,83 @@
+package core
+
+import (
+	"time"
+)
+
+// MountStatus describes a block which was mounted into the repo, and the lease which keeps it from being garbage
+// collected from the remote
+type MountStatus struct {
+	INode INode
+	// the path of the mount point within the repo, or "" if it could not be determined
+	Path             string
+	BID              BlockID
+	LeaseName        string
+	LastLeaseRenewal time.Time
+	LeaseExpiry      time.Time
+}
+
+// Status is a snapshot of what a DataStore is doing and how much it's holding
+type Status struct {
+	Transfers      []*BlockTransferStatus
+	CopyHistory    []CopyHistory
+	FreezerUsed    int64
+	FreezerBlocks  int
+	DirtyFileCount int
+	DirtyFileBytes int64
+	WritableUsed   int64
+	Mounts         []*MountStatus
+}
+
+// GetMounts returns the blocks mounted into the repo along with the state of their leases
+func (d *DataStore) GetMounts() []*MountStatus {
+	d.mountsMutex.Lock()
+	defer d.mountsMutex.Unlock()
+
+	mounts := make([]*MountStatus, 0, len(d.mounts))
+	for _, m := range d.mounts {
+		p, err := d.GetPath(m.mountPoint)
+		if err != nil {
+			p = ""
+		}
+		mounts = append(mounts, &MountStatus{INode: m.mountPoint,
+			Path:             p,
+			BID:              m.BID,
+			LeaseName:        m.leaseName,
+			LastLeaseRenewal: m.lastLeaseRenewal,
+			LeaseExpiry:      m.lastLeaseRenewal.Add(DEFAULT_EXPIRY)})
+	}
+	return mounts
+}
+
+// GetStatus collects active and recent transfers, cache usage, unpushed changes and mounts
+func (d *DataStore) GetStatus(timeUnit time.Duration) (*Status, error) {
+	var err error
+	status := &Status{Transfers: d.freezer.GetActiveTransferStatus(timeUnit),
+		CopyHistory: d.freezer.GetCopyHistory(),
+		Mounts:      d.GetMounts()}
+
+	status.FreezerUsed, err = d.freezer.GetUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	status.FreezerBlocks, err = d.freezer.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+
+	status.WritableUsed, err = d.writableStore.GetUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.db.view(func(tx RTx) error {
+		status.DirtyFileCount, status.DirtyFileBytes, err = d.db.GetDirtyFileStats(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
@@ -16,6 +16,11 @@ type Freezer interface {
 	IsPushed(BID BlockID) (bool, error)
 	GetBlockStats(BID BlockID, Size int64) (*BlockStats, error)
 	GetActiveTransferStatus(timeUnit time.Duration) []*BlockTransferStatus
+	GetUsage() (int64, error)
+	GetCopyHistory() []CopyHistory
+	GetBlockCount() (int, error)
+	Evict(BID BlockID) (int64, error)
+	Close() error
 }
 
 type Releasable interface {
@@ -26,6 +31,8 @@ type Reader interface {
 	io.Seeker
 	Releasable
 	Read(ctx context.Context, p []byte) (n int, err error)
+	// ReadAt reads from offset, without using or moving the offset which Seek and Read share
+	ReadAt(ctx context.Context, p []byte, offset int64) (n int, err error)
 }
 
 type FrozenRef interface {
@@ -101,6 +108,8 @@ type KVStore interface {
 type RBucket interface {
 	Get(key []byte) []byte
 	ForEachWithPrefix(prefix []byte, callback func(key []byte, value []byte) error) error
+	// ForEachWithPrefixFrom visits keys with the given prefix in sorted order, starting at the first key >= start
+	ForEachWithPrefixFrom(prefix []byte, start []byte, callback func(key []byte, value []byte) error) error
 }
 
 type WBucket interface {
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -11,6 +12,7 @@ import (
 type WriteableStore interface {
 	NewWriteRef() (WritableRef, error)
 	NewFile() (string, error)
+	GetUsage() (int64, error)
 }
 
 type WritableStoreImp struct {
@@ -55,6 +57,20 @@ func (w *WritableRefImp) Read(ctx context.Context, dest []byte) (int, error) {
 	return n, err
 }
 
+func (w *WritableRefImp) ReadAt(ctx context.Context, dest []byte, offset int64) (int, error) {
+	f, err := os.OpenFile(w.filename, os.O_RDONLY, 0755)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	n, err := f.ReadAt(dest, offset)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
 func (w *WritableRefImp) Write(buffer []byte) (int, error) {
 	log.Printf("Writing %d bytes to %s:%d", len(buffer), w.filename, w.offset)
 	f, err := os.OpenFile(w.filename, os.O_RDWR, 0755)
@@ -95,6 +111,11 @@ func (w *WritableStoreImp) NewFile() (string, error) {
 	return name, nil
 }
 
+// GetUsage returns the number of bytes on disk used by writable files
+func (w *WritableStoreImp) GetUsage() (int64, error) {
+	return diskUsage(w.path)
+}
+
 func NewWritableStore(path string) WriteableStore {
 	return &WritableStoreImp{path}
 }
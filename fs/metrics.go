@@ -0,0 +1,37 @@
+package fs
+
+import (
+	"reflect"
+	"strings"
+
+	"bazil.org/fuse"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	fuseRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "pufs",
+		Name:      "fuse_request_duration_seconds",
+		Help:      "Time taken to handle FUSE requests, by type of request",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+	}, []string{"op"})
+
+	fuseRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "pufs",
+		Name:      "fuse_request_errors_total",
+		Help:      "FUSE requests which returned an error, by type of request",
+	}, []string{"op"})
+)
+
+func init() {
+	prometheus.MustRegister(fuseRequestDuration, fuseRequestErrors)
+}
+
+// opName returns the name of the operation a request is for (ie: "Read" for a *fuse.ReadRequest)
+func opName(r fuse.Request) string {
+	t := reflect.TypeOf(r)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return strings.TrimSuffix(t.Name(), "Request")
+}
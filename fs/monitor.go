@@ -2,61 +2,91 @@ package fs
 
 import (
 	"context"
+	"log"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/pgm/sply2/core"
 )
 
-type AddedLazyDirBlockEvent struct {
-	ctx       context.Context
-	startTime time.Time
-	endTime   time.Time
+type PrefetchConfig struct {
+	// how many sibling directories must be listed within siblingWindow before the remaining siblings are listed
+	siblingListThreshold int
+	siblingWindow        time.Duration
+	// the number of directories listed in parallel when prefetching listings
+	listConcurrency int
+	// the maximum number of directories listed each time sibling listing is triggered
+	listBudget int
+
+	// how many sibling files must be read to the end, one after the other, before the next file is fetched
+	sequentialFileThreshold int
+	// the number of files which can be fetched in the background at once
+	fetchConcurrency int
+	// the maximum number of bytes fetched from the start of each file
+	fetchBudget int64
 }
 
-type FetchedRemoteChildrenEvent struct {
-	ctx       context.Context
-	startTime time.Time
-	endTime   time.Time
+type PrefetchOption func(config *PrefetchConfig)
+
+func newPrefetchConfig(options []PrefetchOption) *PrefetchConfig {
+	config := &PrefetchConfig{siblingListThreshold: 3,
+		siblingWindow:           time.Minute,
+		listConcurrency:         20,
+		listBudget:              1000,
+		sequentialFileThreshold: 2,
+		fetchConcurrency:        2,
+		fetchBudget:             100 * 1024 * 1024}
+	for _, option := range options {
+		option(config)
+	}
+	return config
 }
 
-type RegionCopiedEvent struct {
-	ctx       context.Context
-	startTime time.Time
-	endTime   time.Time
-	start     int64
-	end       int64
+// SiblingListThreshold sets how many sibling directories must be listed within window before the rest of the
+// siblings are listed in the background
+func SiblingListThreshold(count int, window time.Duration) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.siblingListThreshold = count
+		config.siblingWindow = window
+	}
 }
 
-type MonitorToChan struct {
-	events chan interface{}
+// ListConcurrency sets how many directories are listed in parallel when prefetching listings
+func ListConcurrency(count int) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.listConcurrency = count
+	}
 }
 
-func hasFuseRequest(ctx context.Context) bool {
-	return ctx.Value(FUSE_REQUEST) != nil
+// ListBudget sets the maximum number of directories listed each time sibling listing is triggered
+func ListBudget(count int) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.listBudget = count
+	}
 }
 
-func (m *MonitorToChan) AddedLazyDirBlock(ctx context.Context, startTime time.Time, endTime time.Time) {
-	if !hasFuseRequest(ctx) {
-		return
+// SequentialFileThreshold sets how many sibling files must be read to the end, in name order, before the next file
+// is fetched in the background
+func SequentialFileThreshold(count int) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.sequentialFileThreshold = count
 	}
-
-	m.events <- &AddedLazyDirBlockEvent{ctx, startTime, endTime}
 }
 
-func (m *MonitorToChan) FetchedRemoteChildren(ctx context.Context, startTime time.Time, endTime time.Time) {
-	if !hasFuseRequest(ctx) {
-		return
+// FetchConcurrency sets how many files can be fetched in the background at once. Prefetches beyond this are
+// skipped rather than queued.
+func FetchConcurrency(count int) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.fetchConcurrency = count
 	}
-
-	m.events <- &FetchedRemoteChildrenEvent{ctx, startTime, endTime}
 }
 
-func (m *MonitorToChan) RegionCopied(ctx context.Context, startTime time.Time, endTime time.Time, start int64, end int64) {
-	if !hasFuseRequest(ctx) {
-		return
+// FetchBudget sets the maximum number of bytes fetched from the start of each file which is prefetched
+func FetchBudget(bytes int64) PrefetchOption {
+	return func(config *PrefetchConfig) {
+		config.fetchBudget = bytes
 	}
-
-	m.events <- &RegionCopiedEvent{ctx, startTime, endTime, start, end}
 }
 
 type ListDirCount struct {
@@ -64,27 +94,78 @@ type ListDirCount struct {
 	timestamp time.Time
 }
 
-// type OpHistory struct {
-// 	childListDirCount map[INode]*ListDirCount
-// }
+// the files most recently read to the end within a directory
+type sequentialRun struct {
+	lastFinished core.INode
+	length       int
+}
 
-func listChildrenInParallel(ds *core.DataStore, id core.INode, concurrentReqs int) error {
-	ctx := context.Background()
+// the most directories or files each of the Prefetcher's maps tracks. A map which reaches this is cleared, which at
+// worst means a directory's siblings are listed again, or a file is fetched again, if the same pattern repeats.
+const maxPrefetchTracked = 10000
+
+// Prefetcher watches the events from a DataStore and, based on the access pattern, lists directories and fetches
+// files in the background before they are asked for
+type Prefetcher struct {
+	ds     *core.DataStore
+	config *PrefetchConfig
+	sub    *core.EventSubscription
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// recent listings of child directories, by parent
+	childListDirCount map[core.INode]*ListDirCount
+	// directories whose children have already been listed in the background
+	listedParents map[core.INode]bool
+	// runs of files read to the end, by parent
+	runs map[core.INode]*sequentialRun
+	// files which have already been fetched in the background
+	fetched map[core.INode]bool
+
+	fetchSlots chan bool
+	wg         sync.WaitGroup
+
+	mutex          sync.Mutex
+	dirsListed     int
+	filesFetched   int
+	bytesFetched   int64
+	fetchesSkipped int
+}
 
+type PrefetchStats struct {
+	DirsListed   int
+	FilesFetched int
+	BytesFetched int64
+	// prefetches which were skipped because fetchConcurrency fetches were already running
+	FetchesSkipped int
+}
+
+func listChildrenInParallel(ctx context.Context, ds *core.DataStore, id core.INode, concurrentReqs int, maxDirs int) (int, error) {
 	entries, err := ds.GetDirContents(ctx, id)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	c := make(chan core.INode, 100)
 
-	if concurrentReqs > len(entries) {
-		concurrentReqs = len(entries)
+	// list in name order, so that when the budget runs out it's the later directories which are skipped
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	dirs := make([]core.INode, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir && entry.Name != "." && entry.Name != ".." && len(dirs) < maxDirs {
+			dirs = append(dirs, entry.ID)
+		}
+	}
+
+	if concurrentReqs > len(dirs) {
+		concurrentReqs = len(dirs)
 	}
 
 	// worker which does a get dir contents on given INode. Don't worry about errors here.
 	// exits once the channel is closed
+	var wg sync.WaitGroup
 	requestDir := func() {
+		defer wg.Done()
 		for {
 			id, ok := <-c
 			if !ok {
@@ -95,83 +176,226 @@ func listChildrenInParallel(ds *core.DataStore, id core.INode, concurrentReqs in
 	}
 
 	// spawn goroutines
+	wg.Add(concurrentReqs)
 	for i := 0; i < concurrentReqs; i++ {
 		go requestDir()
 	}
 
 	// queue up all of the child inodes
-	for _, entry := range entries {
-		c <- entry.ID
+	for _, id := range dirs {
+		c <- id
 	}
 
 	close(c)
-	return nil
+	wg.Wait()
+	return len(dirs), nil
 }
 
-// go through the list of recent reads. attempt read of end-fileLength
+// siblingFiles returns the files in the directory parent, ordered by name
+func siblingFiles(ctx context.Context, ds *core.DataStore, parent core.INode) ([]*core.DirEntryWithID, error) {
+	entries, err := ds.GetDirContents(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
 
-func StartMonitor(ds *core.DataStore) chan interface{} {
-	// concurrentReqs := 20
-	c := make(chan interface{}, 2000)
-	// ctx := context.Background()
-	// var history OpHistory
+	files := make([]*core.DirEntryWithID, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir {
+			files = append(files, entry)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
 
-	handleFetchedRemoteChildren := func(event *FetchedRemoteChildrenEvent) {
-		// parentId := ds.GetParent(listOp.id)
-		// count, ok := history.childListDirCount[parentId]
-		// if count.expiry < now {
-		// 	ok := false
-		// }
+	return files, nil
+}
 
-		// if !ok {
-		// 	count = &ListDirCount{count: 0}
-		// 	history.childListDirCount[parentId] = count
-		// }
+// handleListing is called when the children of a directory were fetched. If enough of its siblings have been listed
+// recently, the remaining siblings are listed in the background.
+func (p *Prefetcher) handleListing(event *core.Event) {
+	parent, err := p.ds.GetParent(event.INode)
+	if err != nil || parent == core.InvalidINode || p.listedParents[parent] {
+		return
+	}
 
-		// count.count += 1
-		// count.expiry = now + 1*time.Minute
-		// if history.childListDirCount[parentId].count > 5 {
-		// 	listChildrenInParallel(ds, parentId, concurrentReqs)
-		// 	delete(history.childListDirCount[parentId])
-		// }
+	now := time.Now()
+	count, ok := p.childListDirCount[parent]
+	if !ok || now.Sub(count.timestamp) > p.config.siblingWindow {
+		if len(p.childListDirCount) >= maxPrefetchTracked {
+			p.childListDirCount = make(map[core.INode]*ListDirCount)
+		}
+		count = &ListDirCount{count: 0}
+		p.childListDirCount[parent] = count
 	}
+	count.count++
+	count.timestamp = now
 
-	handleRegionCopied := func(event *RegionCopiedEvent) {
-		// // add to queue of pending reads
+	if count.count < p.config.siblingListThreshold {
+		return
+	}
 
-		// // get first pending read
-		// readOp := getFirstOp()
-		// id := readOp.ctx.GetValue()
-		// ds.EnsureReadable(id, readOp.start, readOp.Length)
-		// // reader := ds.GetReadRef(ctx, id)
-		// // buffer := new([]byte, 500000, 500000)
-		// // reader.Seek(readOp.end, 0)
-		// // reader.Read(ctx, buffer)
-		// // reader.Release()
+	delete(p.childListDirCount, parent)
+	if len(p.listedParents) >= maxPrefetchTracked {
+		p.listedParents = make(map[core.INode]bool)
 	}
+	p.listedParents[parent] = true
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		listed, err := listChildrenInParallel(p.ctx, p.ds, parent, p.config.listConcurrency, p.config.listBudget)
+		if err != nil {
+			log.Printf("Could not prefetch listings under inode %d: %s", parent, err)
+		}
+		p.mutex.Lock()
+		p.dirsListed += listed
+		p.mutex.Unlock()
+	}()
+}
 
-	loop := func() {
-		for {
-			event, ok := <-c
-			if !ok {
-				break
-			}
+// handleRead is called after each read. Once enough sibling files have been read to the end in name order, the
+// start of the next file is fetched in the background.
+func (p *Prefetcher) handleRead(event *core.Event) {
+	node, err := p.ds.GetAttr(p.ctx, event.INode)
+	if err != nil || node.IsDir || node.Size == 0 || event.Offset+event.Length < node.Size {
+		return
+	}
 
-			if castEvent, ok := event.(*FetchedRemoteChildrenEvent); ok {
-				handleFetchedRemoteChildren(castEvent)
-			} else if castEvent, ok := event.(*RegionCopiedEvent); ok {
-				handleRegionCopied(castEvent)
-			} else {
-				// ignore
-			}
+	parent := node.ParentINode
+	files, err := siblingFiles(p.ctx, p.ds, parent)
+	if err != nil {
+		return
+	}
 
-			//		r, err := ds.GetReadRef(ctx, op.inode)
+	index := -1
+	for i, f := range files {
+		if f.ID == event.INode {
+			index = i
+			break
 		}
 	}
+	if index < 0 {
+		return
+	}
 
-	go loop()
+	run, ok := p.runs[parent]
+	if !ok {
+		if len(p.runs) >= maxPrefetchTracked {
+			p.runs = make(map[core.INode]*sequentialRun)
+		}
+		run = &sequentialRun{}
+		p.runs[parent] = run
+	}
+	if run.lastFinished == event.INode {
+		// the same file was read to the end again
+		return
+	}
+	if index > 0 && files[index-1].ID == run.lastFinished {
+		run.length++
+	} else {
+		run.length = 1
+	}
+	run.lastFinished = event.INode
+
+	if run.length < p.config.sequentialFileThreshold || index+1 >= len(files) {
+		return
+	}
 
-	return c
+	next := files[index+1].ID
+	if p.fetched[next] {
+		return
+	}
+
+	select {
+	case p.fetchSlots <- true:
+	default:
+		p.mutex.Lock()
+		p.fetchesSkipped++
+		p.mutex.Unlock()
+		return
+	}
+	if len(p.fetched) >= maxPrefetchTracked {
+		p.fetched = make(map[core.INode]bool)
+	}
+	p.fetched[next] = true
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.fetchSlots }()
+
+		n, err := p.ds.PrefetchHead(p.ctx, next, p.config.fetchBudget)
+		if err != nil {
+			log.Printf("Could not prefetch inode %d: %s", next, err)
+		}
+		p.mutex.Lock()
+		p.filesFetched++
+		p.bytesFetched += n
+		p.mutex.Unlock()
+	}()
+}
+
+func (p *Prefetcher) loop() {
+	for event := range p.sub.Events() {
+		if event.Type == core.EvictEvent {
+			// the file was evicted, or refreshed to a new version, so it can be fetched in the background again
+			delete(p.fetched, event.INode)
+			continue
+		}
+
+		// only react to activity on behalf of a process. Anything else (such as the listings and reads performed
+		// by the prefetcher itself) has no pid.
+		if event.Pid == 0 {
+			continue
+		}
+
+		switch event.Type {
+		case core.ListBlockEvent, core.ListRemoteEvent:
+			p.handleListing(event)
+		case core.ReadEvent:
+			p.handleRead(event)
+		}
+	}
 }
 
-//monitor.FetchedRemoteChildren(id, startTime, endTime)
+// Stats returns how much work the prefetcher has done so far
+func (p *Prefetcher) Stats() PrefetchStats {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return PrefetchStats{DirsListed: p.dirsListed,
+		FilesFetched:   p.filesFetched,
+		BytesFetched:   p.bytesFetched,
+		FetchesSkipped: p.fetchesSkipped}
+}
+
+// Stop stops watching for events, cancels any prefetches in progress and waits for them to exit
+func (p *Prefetcher) Stop() {
+	p.sub.Close()
+	p.cancel()
+	p.wg.Wait()
+}
+
+// StartMonitor starts prefetching data for ds based on the events published by events, which should be the
+// DataStore's monitor
+func StartMonitor(ds *core.DataStore, events *core.EventBroker, options ...PrefetchOption) *Prefetcher {
+	config := newPrefetchConfig(options)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Prefetcher{ds: ds,
+		config:            config,
+		sub:               events.Subscribe(core.EventFilter{Types: []core.EventType{core.ListBlockEvent, core.ListRemoteEvent, core.ReadEvent, core.EvictEvent}}, 2000),
+		ctx:               ctx,
+		cancel:            cancel,
+		childListDirCount: make(map[core.INode]*ListDirCount),
+		listedParents:     make(map[core.INode]bool),
+		runs:              make(map[core.INode]*sequentialRun),
+		fetched:           make(map[core.INode]bool),
+		fetchSlots:        make(chan bool, config.fetchConcurrency)}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.loop()
+	}()
+
+	return p
+}
@@ -0,0 +1,181 @@
+package fs
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgm/sply2/core"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFor polls until condition returns true, failing the test if it takes too long
+func waitFor(require *require.Assertions, condition func() bool) {
+	for start := time.Now(); !condition(); time.Sleep(5 * time.Millisecond) {
+		require.True(time.Now().Sub(start) < 5*time.Second, "timed out waiting")
+	}
+}
+
+// newPrefetchTestDataStore pushes a tree with the given directories (and one file in each) and files under
+// "files", then mounts it in a new DataStore which reads from a remote with the given latency
+func newPrefetchTestDataStore(require *require.Assertions, latency time.Duration, dirCount int, fileCount int, fileSize int) (*core.DataStore, *core.EventBroker, core.INode) {
+	gob.Register(core.BlockID{})
+	ctx := context.Background()
+	repo := core.NewRemoteRefFactoryMem()
+
+	dir1, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds1, err := core.NewDataStore(dir1, repo, core.NewMemRemoteRefFactory2(repo),
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}))
+	require.Nil(err)
+
+	dirs, err := ds1.MakeDir(ctx, core.RootINode, "dirs")
+	require.Nil(err)
+	for i := 0; i < dirCount; i++ {
+		d, err := ds1.MakeDir(ctx, dirs, fmt.Sprintf("d%d", i))
+		require.Nil(err)
+		_, err = ds1.AddImmutableBytes(ctx, d, "x", []byte("x"))
+		require.Nil(err)
+	}
+
+	files, err := ds1.MakeDir(ctx, core.RootINode, "files")
+	require.Nil(err)
+	for i := 0; i < fileCount; i++ {
+		// each file has distinct content so they're stored as separate blocks
+		_, err = ds1.AddImmutableBytes(ctx, files, fmt.Sprintf("f%d", i), []byte(strings.Repeat(fmt.Sprintf("%d", i), fileSize)))
+		require.Nil(err)
+	}
+	require.Nil(ds1.Push(ctx, core.RootINode, "label"))
+
+	dir2, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	events := core.NewEventBroker()
+	ds2, err := core.NewDataStore(dir2, repo, core.NewMemRemoteRefFactory2(repo),
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}), core.WithMonitor(events))
+	require.Nil(err)
+	require.Nil(ds2.MountByLabel(ctx, core.RootINode, "m", "label"))
+	mount, err := ds2.GetNodeID(ctx, core.RootINode, "m")
+	require.Nil(err)
+
+	repo.SetLatency(latency)
+
+	return ds2, events, mount
+}
+
+func TestPrefetchSiblingListings(t *testing.T) {
+	require := require.New(t)
+	// only activity on behalf of a process triggers prefetching, so pretend these requests came from FUSE
+	ctx := core.WithCallerPid(context.Background(), 100)
+
+	ds, events, mount := newPrefetchTestDataStore(require, 20*time.Millisecond, 8, 0, 0)
+	p := StartMonitor(ds, events, SiblingListThreshold(2, time.Minute), ListConcurrency(4), ListBudget(6))
+	defer p.Stop()
+
+	dirs, err := ds.GetNodeID(ctx, mount, "dirs")
+	require.Nil(err)
+
+	// listing one directory doesn't trigger anything
+	d0, err := ds.GetNodeID(ctx, dirs, "d0")
+	require.Nil(err)
+	_, err = ds.GetDirContents(ctx, d0)
+	require.Nil(err)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(0, p.Stats().DirsListed)
+
+	// but a second sibling does
+	d1, err := ds.GetNodeID(ctx, dirs, "d1")
+	require.Nil(err)
+	_, err = ds.GetDirContents(ctx, d1)
+	require.Nil(err)
+	waitFor(require, func() bool { return p.Stats().DirsListed == 6 })
+
+	// the listings were done in the background, so listing d5 now doesn't fetch anything. d7 is beyond the budget.
+	sub := events.Subscribe(core.EventFilter{Types: []core.EventType{core.ListBlockEvent}}, 10)
+	defer sub.Close()
+
+	d5, err := ds.GetNodeID(ctx, dirs, "d5")
+	require.Nil(err)
+	_, err = ds.GetDirContents(ctx, d5)
+	require.Nil(err)
+	require.Len(sub.Events(), 0)
+
+	d7, err := ds.GetNodeID(ctx, dirs, "d7")
+	require.Nil(err)
+	_, err = ds.GetDirContents(ctx, d7)
+	require.Nil(err)
+	require.Len(sub.Events(), 1)
+}
+
+func TestPrefetchSequentialFiles(t *testing.T) {
+	require := require.New(t)
+	ctx := core.WithCallerPid(context.Background(), 100)
+	fileSize := 10000
+
+	ds, events, mount := newPrefetchTestDataStore(require, 20*time.Millisecond, 0, 5, fileSize)
+	p := StartMonitor(ds, events, SequentialFileThreshold(2), FetchConcurrency(1), FetchBudget(4000))
+	defer p.Stop()
+
+	files, err := ds.GetNodeID(ctx, mount, "files")
+	require.Nil(err)
+
+	readFile := func(name string) {
+		id, err := ds.GetNodeID(ctx, files, name)
+		require.Nil(err)
+		r, err := ds.GetReadRef(ctx, id)
+		require.Nil(err)
+		buffer, err := ioutil.ReadAll(&core.FrozenReader{Ctx: ctx, Fr: r})
+		require.Nil(err)
+		r.Release()
+		ds.Monitor().FileRead(ctx, id, 0, int64(len(buffer)))
+	}
+
+	populated := func(name string) int64 {
+		entries, err := ds.GetExtendedDirContents(ctx, files)
+		require.Nil(err)
+		for _, e := range entries {
+			if e.Name == name {
+				return e.PopulatedSize
+			}
+		}
+		require.Fail("missing " + name)
+		return 0
+	}
+
+	// reading files out of order doesn't trigger anything
+	readFile("f3")
+	readFile("f0")
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(0, p.Stats().FilesFetched)
+
+	// reading f0 then f1 starts a fetch of the beginning of f2
+	readFile("f1")
+	waitFor(require, func() bool { return p.Stats().FilesFetched == 1 })
+	require.Equal(int64(4000), p.Stats().BytesFetched)
+	require.True(populated("f2") >= 4000)
+	require.Equal(int64(0), populated("f4"))
+
+	// continuing the run fetches f3, which is already cached, and then f4
+	readFile("f2")
+	waitFor(require, func() bool { return p.Stats().FilesFetched == 2 })
+	readFile("f3")
+	waitFor(require, func() bool { return p.Stats().FilesFetched == 3 })
+	require.True(populated("f4") >= 4000)
+
+	// once f4 is evicted, the same run fetches it again
+	f4, err := ds.GetNodeID(ctx, files, "f4")
+	require.Nil(err)
+	_, err = ds.Evict(ctx, f4)
+	require.Nil(err)
+	require.Equal(int64(0), populated("f4"))
+	time.Sleep(50 * time.Millisecond)
+	readFile("f2")
+	readFile("f3")
+	waitFor(require, func() bool { return p.Stats().FilesFetched == 4 })
+	require.True(populated("f4") >= 4000)
+}
@@ -10,35 +10,130 @@ import (
 	"runtime"
 	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"runtime/trace"
 
 	"bazil.org/fuse"
-	"bazil.org/fuse/fuseutil"
 	"github.com/pgm/sply2/core"
 )
 
-func Mount(dir string, ds *core.DataStore) {
-	c, err := fuse.Mount(dir)
+type ServerConfig struct {
+	reportCacheQuota bool
+	readOnly         bool
+	allowOther       bool
+	uid              *uint32
+	gid              *uint32
+	umask            os.FileMode
+}
+
+// the umask applied when none is specified. Results in the historical modes of 0775 for
+// directories and 0664 for writable files.
+const DefaultUmask = 0002
+
+type ServerOption func(config *ServerConfig)
+
+// ReportCacheQuota makes statfs report the repo's configured cache quota as the size of the filesystem
+// instead of the size of the local filesystem the repo lives on.
+func ReportCacheQuota() func(config *ServerConfig) {
+	return func(config *ServerConfig) {
+		config.reportCacheQuota = true
+	}
+}
+
+// ReadOnly rejects all requests which would modify the filesystem with EROFS
+func ReadOnly() func(config *ServerConfig) {
+	return func(config *ServerConfig) {
+		config.readOnly = true
+	}
+}
+
+// AllowOther lets users other than the one who mounted the filesystem access it. When set, the kernel
+// is also asked to enforce permissions based on the mode, uid and gid reported for each file.
+func AllowOther() func(config *ServerConfig) {
+	return func(config *ServerConfig) {
+		config.allowOther = true
+	}
+}
+
+// Owner reports all files as owned by the given uid and gid instead of the user running the server
+func Owner(uid uint32, gid uint32) func(config *ServerConfig) {
+	return func(config *ServerConfig) {
+		config.uid = &uid
+		config.gid = &gid
+	}
+}
+
+// Umask sets the permission bits which are cleared from the mode reported for each file
+func Umask(umask os.FileMode) func(config *ServerConfig) {
+	return func(config *ServerConfig) {
+		config.umask = umask
+	}
+}
+
+func newServerConfig(options []ServerOption) *ServerConfig {
+	config := &ServerConfig{umask: DefaultUmask}
+	for _, option := range options {
+		option(config)
+	}
+	return config
+}
+
+// Mount mounts the filesystem at dir. Call Serve on the returned Server to start handling requests.
+func Mount(dir string, ds *core.DataStore, options ...ServerOption) (*Server, error) {
+	config := newServerConfig(options)
+
+	mountOptions := []fuse.MountOption{fuse.FSName("pufs")}
+	if config.readOnly {
+		mountOptions = append(mountOptions, fuse.ReadOnly())
+	}
+	if config.allowOther {
+		mountOptions = append(mountOptions, fuse.AllowOther(), fuse.DefaultPermissions())
+	}
+
+	c, err := fuse.Mount(dir, mountOptions...)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	s := New(c, ds)
+	s := New(c, ds, options...)
+	s.mountPoint = dir
+	return s, nil
+}
+
+// Serve handles requests until the filesystem is unmounted, either externally or via Shutdown. Before returning
+// it waits for all in-flight requests to complete. The DataStore is left open for the caller to close.
+func (s *Server) Serve() error {
+	c := s.conn
+	defer c.Close()
+	// wait until all go routines have completed before exiting, whichever way we exit. This runs before the
+	// connection is closed, as they still need it to respond.
+	defer s.wg.Wait()
 
 	for {
 		req, err := c.ReadRequest()
 		if err != nil {
 			if err == io.EOF {
-				break
+				return nil
 			}
-			panic(err)
+			return err
+		}
+
+		s.meta.Lock()
+		closing := s.closing
+		if !closing {
+			s.wg.Add(1)
+		}
+		s.meta.Unlock()
+
+		if closing {
+			rejectDuringShutdown(req)
+			continue
 		}
 
 		//		fmt.Printf("(%d) Req start: %v\n", s.reqsInFlight, req)
 		rID := req.Hdr().ID
-		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
 			err := s.serve(req)
@@ -53,12 +148,47 @@ func Mount(dir string, ds *core.DataStore) {
 			}
 		}()
 	}
+}
+
+// rejectDuringShutdown responds to requests which arrive after Shutdown was called. Requests which are
+// part of tearing down the mount are acknowledged, everything else fails.
+func rejectDuringShutdown(req fuse.Request) {
+	switch r := req.(type) {
+	case *fuse.ForgetRequest:
+		r.Respond()
+	case *fuse.DestroyRequest:
+		r.Respond()
+	case *fuse.InterruptRequest:
+		r.Respond()
+	case *fuse.ReleaseRequest:
+		r.Respond()
+	default:
+		req.RespondError(fuse.Errno(syscall.ENOTCONN))
+	}
+}
+
+// Shutdown stops accepting new requests, waits for those in-flight to finish and then unmounts the filesystem,
+// which causes Serve to return. If the mount point is busy, it is lazily unmounted instead.
+func (s *Server) Shutdown() error {
+	s.meta.Lock()
+	if s.closing {
+		s.meta.Unlock()
+		return nil
+	}
+	s.closing = true
+	s.meta.Unlock()
 
-	// wait until all go routines have completed before exiting
+	log.Printf("Shutting down: waiting for in-flight requests to complete")
 	s.wg.Wait()
 
-	// shut it down
-	s.ds.Close()
+	log.Printf("Unmounting %s", s.mountPoint)
+	err := fuse.Unmount(s.mountPoint)
+	if err != nil {
+		log.Printf("Unmount of %s failed (%s), attempting lazy unmount", s.mountPoint, err)
+		err = lazyUnmount(s.mountPoint)
+	}
+
+	return err
 }
 
 type sRequest struct {
@@ -97,6 +227,7 @@ func (c *Server) serve(r fuse.Request) error {
 	defer cancel()
 
 	ctx = context.WithValue(ctx, FUSE_REQUEST, r)
+	ctx = core.WithCallerPid(ctx, r.Hdr().Pid)
 
 	req := &sRequest{Request: r, cancel: cancel}
 
@@ -179,8 +310,12 @@ func (c *Server) serve(r fuse.Request) error {
 		}
 	}()
 
+	op := opName(r)
+	startTime := time.Now()
 	err := c.handleRequest(ctx, r)
+	fuseRequestDuration.WithLabelValues(op).Observe(time.Now().Sub(startTime).Seconds())
 	if err != nil {
+		fuseRequestErrors.WithLabelValues(op).Inc()
 		if err == context.Canceled {
 			err = fuse.EINTR
 		}
@@ -219,7 +354,9 @@ func (c *Server) serve(r fuse.Request) error {
 	return err
 }
 
-func New(c *fuse.Conn, ds *core.DataStore) *Server {
+func New(c *fuse.Conn, ds *core.DataStore, options ...ServerOption) *Server {
+	config := newServerConfig(options)
+
 	user, err := user.Current()
 	if err != nil {
 		panic("Could not determine current user")
@@ -232,19 +369,38 @@ func New(c *fuse.Conn, ds *core.DataStore) *Server {
 	if err != nil {
 		panic("Could not determine GID")
 	}
+
+	uid := uint32(defaultUID)
+	if config.uid != nil {
+		uid = *config.uid
+	}
+	gid := uint32(defaultGID)
+	if config.gid != nil {
+		gid = *config.gid
+	}
+
 	return &Server{conn: c, ds: ds, reqs: make(map[fuse.RequestID]*sRequest),
-		handles:        make(map[fuse.HandleID]*sHandle),
-		lastHandleID:   1,
-		maxHandles:     100,
-		defaultUserID:  uint32(defaultUID),
-		defaultGroupID: uint32(defaultGID)}
+		handles:          make(map[fuse.HandleID]*sHandle),
+		attrs:            newAttrCache(defaultAttrCacheTTL, ds.ChangeCount),
+		lastHandleID:     1,
+		maxHandles:       100,
+		defaultUserID:    uid,
+		defaultGroupID:   gid,
+		umask:            config.umask,
+		readOnly:         config.readOnly,
+		reportCacheQuota: config.reportCacheQuota}
 }
 
 type Server struct {
-	conn *fuse.Conn
+	conn       *fuse.Conn
+	mountPoint string
 
 	defaultUserID  uint32
 	defaultGroupID uint32
+	umask          os.FileMode
+	readOnly       bool
+
+	reportCacheQuota bool
 
 	// state, protected by meta
 	meta         sync.Mutex
@@ -253,17 +409,26 @@ type Server struct {
 	lastHandleID int
 	maxHandles   int
 	reqsInFlight int
+	closing      bool
 
 	// Used to ensure worker goroutines finish before Serve returns
 	wg sync.WaitGroup
 
+	attrs *attrCache
+
 	ds *core.DataStore
 }
 
 type sHandle struct {
-	inode    core.INode
-	readData []byte
-	ref      core.Reader
+	inode core.INode
+	ref   core.Reader
+
+	// where readdir on this handle got to: the offset the last response started after, the name of the entry at that
+	// offset, and the names in the last response. See readDir.
+	dirMutex  sync.Mutex
+	dirOffset int64
+	dirPrev   string
+	dirNames  []string
 }
 
 func (h *sHandle) Read(ctx context.Context, req *fuse.ReadRequest, res *fuse.ReadResponse) error {
@@ -277,13 +442,9 @@ func (h *sHandle) Read(ctx context.Context, req *fuse.ReadRequest, res *fuse.Rea
 		return fuse.EIO
 	}
 
-	_, err := reader.Seek(req.Offset, 0)
-	if err != nil {
-		return err
-	}
-
-	n, err := reader.Read(ctx, res.Data[:req.Size])
-	if err != nil {
+	// the kernel can send several reads on the same handle at once, so don't share the handle's offset between them
+	n, err := reader.ReadAt(ctx, res.Data[:req.Size], req.Offset)
+	if err != nil && err != io.EOF {
 		return err
 	}
 
@@ -353,7 +514,26 @@ func (h *sHandle) Release() error {
 // 	return inode, nil
 // }
 
+// isMutating returns true if the request could change the contents or attributes of the filesystem
+func isMutating(r fuse.Request) bool {
+	switch r := r.(type) {
+	case *fuse.SetattrRequest, *fuse.RemoveRequest, *fuse.MkdirRequest, *fuse.CreateRequest,
+		*fuse.RenameRequest, *fuse.WriteRequest:
+		return true
+	case *fuse.OpenRequest:
+		return !r.Dir && !r.Flags.IsReadOnly()
+	}
+	return false
+}
+
 func (c *Server) handleRequest(ctx context.Context, r fuse.Request) error {
+	if isMutating(r) {
+		if c.readOnly {
+			return fuse.Errno(syscall.EROFS)
+		}
+		c.attrs.clear()
+	}
+
 	switch r := r.(type) {
 	default:
 		// Note: To FUSE, ENOSYS means "this server never implements this request."
@@ -534,16 +714,63 @@ func (c *Server) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
 	return nil
 }
 
+// the bits of an access request's mask
+const (
+	accessExecute = 1
+	accessWrite   = 2
+	accessRead    = 4
+)
+
 func (c *Server) Access(ctx context.Context, r *fuse.AccessRequest) error {
+	var attr fuse.Attr
+	err := c.getattr(ctx, core.INode(r.Node), &attr)
+	if err != nil {
+		return err
+	}
+
+	if r.Mask&accessWrite != 0 && c.readOnly {
+		return fuse.Errno(syscall.EROFS)
+	}
+
+	// select the permission bits which apply to the caller. Only the caller's primary group is considered.
+	var perms uint32
+	mode := uint32(attr.Mode.Perm())
+	if r.Uid == 0 {
+		// root can read and write anything, but can only execute if someone can
+		perms = accessRead | accessWrite
+		if mode&0111 != 0 {
+			perms |= accessExecute
+		}
+	} else if r.Uid == attr.Uid {
+		perms = (mode >> 6) & 7
+	} else if r.Gid == attr.Gid {
+		perms = (mode >> 3) & 7
+	} else {
+		perms = mode & 7
+	}
+
+	if r.Mask&^perms != 0 {
+		return fuse.Errno(syscall.EACCES)
+	}
+
 	return nil
 }
 
 func (c *Server) getattr(ctx context.Context, inode core.INode, attr *fuse.Attr) error {
-	nattr, err := c.ds.GetAttr(ctx, inode)
-	if err != nil {
-		return err
+	nattr := c.attrs.getAttr(inode)
+	if nattr == nil {
+		var err error
+		nattr, err = c.ds.GetAttr(ctx, inode)
+		if err != nil {
+			return err
+		}
 	}
 
+	c.fillAttr(inode, nattr, attr)
+	return nil
+}
+
+func (c *Server) fillAttr(inode core.INode, nattr *core.NodeRepr, attr *fuse.Attr) {
 	attr.Valid = 0 * time.Second
 	attr.Inode = uint64(inode)
 	attr.Size = uint64(nattr.Size)           // size in bytes
@@ -553,22 +780,24 @@ func (c *Server) getattr(ctx context.Context, inode core.INode, attr *fuse.Attr)
 	attr.Ctime = nattr.ModTime               // time of last inode change
 	attr.Crtime = nattr.ModTime              // time of creation (OS X only)
 	if nattr.IsDir {
-		attr.Mode = 0775 | os.ModeDir // all dirs are read/write
+		attr.Mode = 0777 | os.ModeDir // all dirs are read/write
 	} else {
 		if nattr.BID != core.NABlock {
 			attr.Mode = 0444 // read-only
 		} else {
-			attr.Mode = 0664 // read/write if not frozen
+			attr.Mode = 0666 // read/write if not frozen
 		}
 	}
+	attr.Mode &^= c.umask
+	if c.readOnly {
+		attr.Mode &^= 0222
+	}
 	attr.Nlink = 1              // number of links (usually 1)
 	attr.Uid = c.defaultUserID  // owner uid
 	attr.Gid = c.defaultGroupID // group gid
 	// resp.Attr.Rdev = 0     // device numbers
 	// resp.Attr.Flags     uint32      // chflags(2) flags (OS X only)
 	attr.BlockSize = 4 * 1024 // preferred blocksize for filesystem I/O. I don't know the implication of setting this
-
-	return nil
 }
 
 func mapError(err error) error {
@@ -580,6 +809,20 @@ func mapError(err error) error {
 		return fuse.ENOENT
 	}
 
+	// failures reading from the remote which survived any retries
+	if class, ok := core.GetRemoteErrClass(err); ok {
+		switch class {
+		case core.RemoteTransient, core.RemoteThrottled:
+			return fuse.Errno(syscall.EAGAIN)
+		case core.RemoteNotFound:
+			return fuse.ENOENT
+		case core.RemotePreconditionFailed:
+			return fuse.ESTALE
+		case core.RemotePermissionDenied:
+			return fuse.Errno(syscall.EACCES)
+		}
+	}
+
 	return fuse.EIO
 }
 
@@ -587,14 +830,20 @@ func (c *Server) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse
 	if req.Name == "Contents" {
 		return fuse.ENOENT
 	} else {
-		inode, err := c.ds.GetNodeID(ctx, core.INode(req.Node), req.Name)
-		if err != nil {
-			return err
-		}
+		inode, nattr := c.attrs.lookup(core.INode(req.Node), req.Name)
+		if nattr != nil {
+			c.fillAttr(inode, nattr, &resp.Attr)
+		} else {
+			var err error
+			inode, err = c.ds.GetNodeID(ctx, core.INode(req.Node), req.Name)
+			if err != nil {
+				return err
+			}
 
-		err = c.getattr(ctx, inode, &resp.Attr)
-		if err != nil {
-			return err
+			err = c.getattr(ctx, inode, &resp.Attr)
+			if err != nil {
+				return err
+			}
 		}
 
 		// TODO: Not sure about these
@@ -652,7 +901,7 @@ func (c *Server) Open(ctx context.Context, req *fuse.OpenRequest, res *fuse.Open
 			return err
 		}
 
-		res.Handle = c.bindHandle(&sHandle{ref: ref})
+		res.Handle = c.bindHandle(&sHandle{inode: core.INode(req.Node), ref: ref})
 		log.Printf("opened file for writing: handle=%v", res.Handle)
 		return nil
 	}
@@ -662,7 +911,7 @@ func (c *Server) Open(ctx context.Context, req *fuse.OpenRequest, res *fuse.Open
 	if err != nil {
 		return err
 	}
-	res.Handle = c.bindHandle(&sHandle{ref: ref})
+	res.Handle = c.bindHandle(&sHandle{inode: core.INode(req.Node), ref: ref})
 	log.Printf("opened file for reading: handle=%v", res.Handle)
 	return nil
 }
@@ -707,7 +956,7 @@ func (c *Server) Create(ctx context.Context, req *fuse.CreateRequest, res *fuse.
 	res.LookupResponse.Generation = 0
 	res.LookupResponse.Node = fuse.NodeID(inode)
 
-	handle := c.bindHandle(&sHandle{ref: ref})
+	handle := c.bindHandle(&sHandle{inode: inode, ref: ref})
 	res.OpenResponse.Handle = handle
 
 	log.Printf("Handle = %v", handle)
@@ -738,37 +987,15 @@ func (c *Server) Read(ctx context.Context, req *fuse.ReadRequest, res *fuse.Read
 	}
 
 	if req.Dir {
-		// detect rewinddir(3) or similar seek and refresh
-		// contents
-		if req.Offset == 0 {
-			h.readData = nil
-		}
-
-		if h.readData == nil {
-			dirs, err := c.ds.GetDirContents(ctx, h.inode)
-			if err != nil {
-				return err
-			}
-
-			var data []byte
-			for _, dir := range dirs {
-				entryType := fuse.DT_File
-				if dir.IsDir {
-					entryType = fuse.DT_Dir
-				}
-				data = fuse.AppendDirent(data, fuse.Dirent{Inode: uint64(dir.ID), Type: entryType, Name: dir.Name})
-			}
+		return c.readDir(ctx, h, req, res)
+	}
 
-			h.readData = data
-		}
-		fuseutil.HandleRead(req, res, h.readData)
-		return nil
-	} else {
-		if err := h.Read(ctx, req, res); err != nil {
-			return err
-		}
+	if err := h.Read(ctx, req, res); err != nil {
+		return err
 	}
 
+	c.ds.Monitor().FileRead(ctx, h.inode, req.Offset, int64(len(res.Data)))
+
 	return nil
 }
 
@@ -811,15 +1038,44 @@ func (c *Server) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
 }
 
 func (c *Server) Statfs(ctx context.Context, req *fuse.StatfsRequest, res *fuse.StatfsResponse) error {
-	// TODO: Review these
-	res.Blocks = 1000000
-	res.Bfree = 1000000
-	res.Bavail = 1000000
-	res.Files = 1000000
-	res.Ffree = 1000000
-	res.Bsize = 1024
-	res.Namelen = 4 * 1024
-	res.Ffree = 1
+	stats, err := c.ds.GetFSStats()
+	if err != nil {
+		return err
+	}
+
+	total := stats.LocalTotal
+	free := stats.LocalFree
+	avail := stats.LocalAvail
+
+	if stats.CacheQuota > 0 {
+		// never report more space than the quota has left, even if the local disk has more
+		quota := uint64(stats.CacheQuota)
+		used := uint64(stats.FreezerUsed + stats.WritableUsed)
+		remaining := uint64(0)
+		if used < quota {
+			remaining = quota - used
+		}
+
+		if c.reportCacheQuota {
+			total = quota
+		}
+		if free > remaining {
+			free = remaining
+		}
+		if avail > remaining {
+			avail = remaining
+		}
+	}
+
+	bsize := uint64(stats.BlockSize)
+	res.Bsize = stats.BlockSize
+	res.Frsize = stats.BlockSize
+	res.Blocks = total / bsize
+	res.Bfree = free / bsize
+	res.Bavail = avail / bsize
+	res.Files = uint64(stats.MaxINodes)
+	res.Ffree = uint64(stats.MaxINodes - stats.INodeCount)
+	res.Namelen = 255
 	return nil
 }
 
@@ -0,0 +1,155 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"bazil.org/fuse"
+	"github.com/pgm/sply2/core"
+	"github.com/stretchr/testify/require"
+)
+
+func testServer(require *require.Assertions, options ...ServerOption) *Server {
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds, err := core.NewDataStore(dir, repo, core.NewMemRemoteRefFactory2(repo),
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}))
+	require.Nil(err)
+
+	return New(nil, ds, options...)
+}
+
+func accessRequest(inode core.INode, uid uint32, gid uint32, mask uint32) *fuse.AccessRequest {
+	return &fuse.AccessRequest{Header: fuse.Header{Node: fuse.NodeID(inode), Uid: uid, Gid: gid}, Mask: mask}
+}
+
+func TestAccess(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := testServer(require, Owner(1000, 100), Umask(0022))
+	inode, _, err := s.ds.CreateWritable(ctx, core.RootINode, "a")
+	require.Nil(err)
+
+	var attr fuse.Attr
+	require.Nil(s.getattr(ctx, inode, &attr))
+	require.Equal(uint32(1000), attr.Uid)
+	require.Equal(uint32(100), attr.Gid)
+	require.Equal("-rw-r--r--", attr.Mode.String())
+
+	// owner can read and write, but not execute
+	require.Nil(s.Access(ctx, accessRequest(inode, 1000, 100, accessRead|accessWrite)))
+	require.Equal(fuse.Errno(syscall.EACCES), s.Access(ctx, accessRequest(inode, 1000, 100, accessExecute)))
+
+	// others in the group and everyone else can only read
+	require.Nil(s.Access(ctx, accessRequest(inode, 1001, 100, accessRead)))
+	require.Equal(fuse.Errno(syscall.EACCES), s.Access(ctx, accessRequest(inode, 1001, 100, accessWrite)))
+	require.Equal(fuse.Errno(syscall.EACCES), s.Access(ctx, accessRequest(inode, 1001, 101, accessWrite)))
+
+	// root can write to anything
+	require.Nil(s.Access(ctx, accessRequest(inode, 0, 0, accessWrite)))
+}
+
+func TestReadOnly(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := testServer(require, ReadOnly())
+	_, _, err := s.ds.CreateWritable(ctx, core.RootINode, "a")
+	require.Nil(err)
+
+	var attr fuse.Attr
+	require.Nil(s.getattr(ctx, core.RootINode, &attr))
+	require.Equal("dr-xr-xr-x", attr.Mode.String())
+
+	require.Equal(fuse.Errno(syscall.EROFS), s.Access(ctx, accessRequest(core.RootINode, 0, 0, accessWrite)))
+
+	err = s.handleRequest(ctx, &fuse.MkdirRequest{Header: fuse.Header{Node: core.RootINode}, Name: "b"})
+	require.Equal(fuse.Errno(syscall.EROFS), err)
+	err = s.handleRequest(ctx, &fuse.OpenRequest{Header: fuse.Header{Node: core.RootINode}, Flags: fuse.OpenWriteOnly})
+	require.Equal(fuse.Errno(syscall.EROFS), err)
+}
+
+func TestMapError(t *testing.T) {
+	require := require.New(t)
+
+	remoteErr := func(class core.RemoteErrClass) error {
+		return &core.RemoteErr{Class: class, Err: errors.New("failed")}
+	}
+
+	require.Equal(fuse.ENOENT, mapError(core.NoSuchNodeErr))
+	require.Equal(fuse.Errno(syscall.EAGAIN), mapError(remoteErr(core.RemoteTransient)))
+	require.Equal(fuse.Errno(syscall.EAGAIN), mapError(remoteErr(core.RemoteThrottled)))
+	require.Equal(fuse.ENOENT, mapError(remoteErr(core.RemoteNotFound)))
+	require.Equal(fuse.ESTALE, mapError(remoteErr(core.RemotePreconditionFailed)))
+	require.Equal(fuse.Errno(syscall.EACCES), mapError(remoteErr(core.RemotePermissionDenied)))
+	require.Equal(fuse.EIO, mapError(errors.New("unknown")))
+}
+
+// readDirNames returns the names and offsets of the entries in a readdir response
+func readDirNames(data []byte) ([]string, []uint64) {
+	var names []string
+	var offsets []uint64
+	for len(data) > 0 {
+		de := (*dirent)(unsafe.Pointer(&data[0]))
+		name := string(data[direntSize : direntSize+de.Namelen])
+		names = append(names, name)
+		offsets = append(offsets, de.Off)
+		data = data[direntLen(name):]
+	}
+	return names, offsets
+}
+
+func TestReadDir(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	s := testServer(require)
+	a, err := s.ds.MakeDir(ctx, core.RootINode, "a")
+	require.Nil(err)
+	for _, name := range []string{"b", "c"} {
+		_, err = s.ds.MakeDir(ctx, core.RootINode, name)
+		require.Nil(err)
+	}
+
+	h := &sHandle{inode: core.RootINode}
+	readDir := func(offset int64) ([]string, []uint64) {
+		// room for three entries
+		size := 3 * direntLen("a")
+		res := &fuse.ReadResponse{Data: make([]byte, 0, size)}
+		require.Nil(s.readDir(ctx, h, &fuse.ReadRequest{Offset: offset, Size: size}, res))
+		return readDirNames(res.Data)
+	}
+
+	names, offsets := readDir(0)
+	require.Equal([]string{".", "..", "a"}, names)
+	require.Equal([]uint64{1, 2, 3}, offsets)
+
+	// the kernel may not use every entry it was given, so listing can resume from any offset in the last response
+	names, offsets = readDir(2)
+	require.Equal([]string{"a", "b", "c"}, names)
+	require.Equal([]uint64{3, 4, 5}, offsets)
+	names, _ = readDir(4)
+	require.Equal([]string{"c"}, names)
+	require.Len(h.dirNames, 1)
+
+	// but earlier responses are forgotten
+	names, _ = readDir(1)
+	require.Nil(names)
+
+	// and rewinding starts again
+	names, _ = readDir(0)
+	require.Equal([]string{".", "..", "a"}, names)
+
+	// the listing cached the attributes of the entries, until the repo changes, even when not through the mount
+	require.NotNil(s.attrs.getAttr(a))
+	require.Nil(s.ds.Remove(ctx, core.RootINode, "b"))
+	require.Nil(s.attrs.getAttr(a))
+}
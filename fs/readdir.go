@@ -0,0 +1,260 @@
+package fs
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unsafe"
+
+	"bazil.org/fuse"
+	"github.com/pgm/sply2/core"
+)
+
+// dirent mirrors the kernel's fuse_dirent struct
+type dirent struct {
+	Ino     uint64
+	Off     uint64
+	Namelen uint32
+	Type    uint32
+}
+
+const direntSize = 8 + 8 + 4 + 4
+
+// the number of children to fetch from the DataStore at a time while filling a readdir buffer
+const readDirBatchSize = 256
+
+func direntLen(name string) int {
+	return (direntSize + len(name) + 7) &^ 7
+}
+
+// appendDirent is like fuse.AppendDirent except the caller chooses the offset reported for the entry.
+// The kernel passes this offset back when it wants the listing to continue after this entry.
+func appendDirent(data []byte, off uint64, dir fuse.Dirent) []byte {
+	de := dirent{
+		Ino:     dir.Inode,
+		Off:     off,
+		Namelen: uint32(len(dir.Name)),
+		Type:    uint32(dir.Type),
+	}
+	data = append(data, (*[direntSize]byte)(unsafe.Pointer(&de))[:]...)
+	data = append(data, dir.Name...)
+	padding := direntLen(dir.Name) - direntSize - len(dir.Name)
+	if padding > 0 {
+		var pad [8]byte
+		data = append(data, pad[:padding]...)
+	}
+	return data
+}
+
+// readDir fills the response with as many entries as fit, starting from the offset requested.
+//
+// The offsets we hand out are cookies: the entry returned with offset N is the Nth entry of the listing, and a read at
+// offset N resumes with the first child whose name sorts after that entry. Because we resume by name rather than by
+// position, entries created or removed while a listing is in progress don't cause other entries to be skipped or
+// repeated, and we never need to build the whole listing up front. Only the names in the last response are kept, as
+// the kernel may not have consumed all of them, so seekdir(3) to an offset from any earlier response isn't supported.
+func (c *Server) readDir(ctx context.Context, h *sHandle, req *fuse.ReadRequest, res *fuse.ReadResponse) error {
+	h.dirMutex.Lock()
+	defer h.dirMutex.Unlock()
+
+	if req.Offset == 0 {
+		// a rewinddir(3)
+		h.dirOffset = 0
+		h.dirPrev = ""
+		h.dirNames = h.dirNames[:0]
+	}
+	if req.Offset < h.dirOffset || req.Offset > h.dirOffset+int64(len(h.dirNames)) {
+		// not an offset from the last response. Treat as end of directory.
+		res.Data = res.Data[:0]
+		return nil
+	}
+
+	// forget the last response, other than the entry we're resuming after
+	if req.Offset > h.dirOffset {
+		h.dirPrev = h.dirNames[req.Offset-h.dirOffset-1]
+	}
+	h.dirOffset = req.Offset
+	h.dirNames = h.dirNames[:0]
+	position := func() int64 {
+		return h.dirOffset + int64(len(h.dirNames))
+	}
+
+	data := res.Data[:0]
+	add := func(inode core.INode, name string, isDir bool) bool {
+		if len(data)+direntLen(name) > req.Size {
+			return false
+		}
+		entryType := fuse.DT_File
+		if isDir {
+			entryType = fuse.DT_Dir
+		}
+		h.dirNames = append(h.dirNames, name)
+		data = appendDirent(data, uint64(position()), fuse.Dirent{Inode: uint64(inode), Type: entryType, Name: name})
+		return true
+	}
+
+	full := false
+	if position() == 0 {
+		full = !add(h.inode, ".", true)
+	}
+	if !full && position() == 1 {
+		parent, err := c.ds.GetParent(h.inode)
+		if err != nil {
+			return err
+		}
+		full = !add(parent, "..", true)
+	}
+
+	after := ""
+	if position() > 2 {
+		after = h.dirPrev
+	}
+
+	batchSize := req.Size/direntSize + 1
+	if batchSize > readDirBatchSize {
+		batchSize = readDirBatchSize
+	}
+
+	for !full {
+		// anything changed after this point may not be reflected in the entries, so don't cache them
+		changes := c.ds.ChangeCount()
+		entries, err := c.ds.ReadDirPlus(ctx, h.inode, after, batchSize)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, entry := range entries {
+			if !add(entry.ID, entry.Name, entry.IsDir) {
+				full = true
+				break
+			}
+			c.attrs.put(h.inode, entry, now, changes)
+		}
+
+		if len(entries) < batchSize {
+			break
+		}
+		after = entries[len(entries)-1].Name
+	}
+
+	res.Data = data
+	return nil
+}
+
+type childKey struct {
+	parent core.INode
+	name   string
+}
+
+type cachedAttr struct {
+	key     childKey
+	inode   core.INode
+	node    *core.NodeRepr
+	expires time.Time
+}
+
+// the cache is dropped if it grows beyond this many entries
+const maxAttrCacheEntries = 100000
+
+const defaultAttrCacheTTL = time.Second
+
+// attrCache holds the attributes fetched while listing a directory so that the lookups and getattrs which
+// typically follow a readdir (ie: "ls -l" or os.walk) can be answered without going back to the DataStore.
+// Entries are short-lived, and the whole cache is dropped whenever the repo is modified, whether through the mount
+// or not. The kernel would avoid those lookups entirely with READDIRPLUS, but bazil.org/fuse doesn't support it.
+type attrCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	byINode map[core.INode]*cachedAttr
+	byName  map[childKey]*cachedAttr
+
+	// returns the DataStore's change count, and the count as of when the entries were cached
+	changeCount func() uint64
+	changes     uint64
+}
+
+func newAttrCache(ttl time.Duration, changeCount func() uint64) *attrCache {
+	return &attrCache{ttl: ttl,
+		byINode:     make(map[core.INode]*cachedAttr),
+		byName:      make(map[childKey]*cachedAttr),
+		changeCount: changeCount,
+		changes:     changeCount()}
+}
+
+// put caches entry, which was read from the DataStore when its change count was changes
+func (a *attrCache) put(parent core.INode, entry *core.DirEntryWithID, now time.Time, changes uint64) {
+	if !entry.IsDir && entry.BID == core.NABlock {
+		// writable files can change size at any time, so don't cache them
+		return
+	}
+
+	node := &core.NodeRepr{ParentINode: parent,
+		IsDir:        entry.IsDir,
+		Size:         entry.Size,
+		ModTime:      entry.ModTime,
+		IsDirty:      entry.IsDirty,
+		BID:          entry.BID,
+		RemoteSource: entry.RemoteSource}
+	key := childKey{parent, entry.Name}
+	cached := &cachedAttr{key: key, inode: entry.ID, node: node, expires: now.Add(a.ttl)}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.dropIfChangedLocked()
+	if changes != a.changes {
+		return
+	}
+	if len(a.byINode) >= maxAttrCacheEntries {
+		a.clearLocked()
+	}
+	a.byINode[entry.ID] = cached
+	a.byName[key] = cached
+}
+
+func (a *attrCache) getAttr(inode core.INode) *core.NodeRepr {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.dropIfChangedLocked()
+
+	cached := a.byINode[inode]
+	if cached == nil || time.Now().After(cached.expires) {
+		return nil
+	}
+	return cached.node
+}
+
+func (a *attrCache) lookup(parent core.INode, name string) (core.INode, *core.NodeRepr) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.dropIfChangedLocked()
+
+	cached := a.byName[childKey{parent, name}]
+	if cached == nil || time.Now().After(cached.expires) {
+		return core.InvalidINode, nil
+	}
+	return cached.inode, cached.node
+}
+
+// dropIfChangedLocked clears the cache if the repo has been modified since the entries were cached
+func (a *attrCache) dropIfChangedLocked() {
+	changes := a.changeCount()
+	if changes != a.changes {
+		a.clearLocked()
+		a.changes = changes
+	}
+}
+
+func (a *attrCache) clear() {
+	a.mutex.Lock()
+	a.clearLocked()
+	a.mutex.Unlock()
+}
+
+func (a *attrCache) clearLocked() {
+	if len(a.byINode) == 0 {
+		return
+	}
+	a.byINode = make(map[core.INode]*cachedAttr)
+	a.byName = make(map[childKey]*cachedAttr)
+}
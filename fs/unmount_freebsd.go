@@ -0,0 +1,11 @@
+package fs
+
+import "syscall"
+
+// MNT_FORCE from <sys/mount.h>, which the syscall package doesn't define
+const mntForce = 0x80000
+
+// lazyUnmount forcibly unmounts the mount point, even if files within it are still open
+func lazyUnmount(dir string) error {
+	return syscall.Unmount(dir, mntForce)
+}
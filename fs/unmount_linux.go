@@ -0,0 +1,15 @@
+package fs
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// lazyUnmount detaches the mount point immediately, even if files within it are still open
+func lazyUnmount(dir string) error {
+	output, err := exec.Command("fusermount", "-u", "-z", dir).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("fusermount -u -z %s failed: %s: %s", dir, err, output)
+	}
+	return nil
+}
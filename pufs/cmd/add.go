@@ -16,11 +16,12 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"regexp"
 	"strings"
 
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
 	"github.com/spf13/cobra"
 )
 
@@ -34,37 +35,44 @@ func parseGCS(url string) (bucket string, key string, ok bool) {
 	return "", "", false
 }
 
+// addRemoteSource links source (a gs:// path, an http(s) URL, or a label, optionally written as pufs:///label) to
+// parent/name. options can pin a gs:// object or URL to the version expected.
+func addRemoteSource(ctx context.Context, ds *core.DataStore, parent core.INode, name string, source string, options ...core.AddRemoteOption) (core.INode, error) {
+	if bucket, key, ok := parseGCS(source); ok {
+		return ds.AddRemoteGCS(ctx, parent, name, bucket, key, options...)
+	}
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return ds.AddRemoteURL(ctx, parent, name, source, options...)
+	}
+
+	label := source
+	if pufsmatch := PUFSUrlExp.FindStringSubmatch(label); pufsmatch != nil {
+		label = pufsmatch[1]
+	}
+	err := ds.MountByLabel(ctx, parent, name, label)
+	if err != nil {
+		return core.InvalidINode, err
+	}
+	return ds.GetNodeID(ctx, parent, name)
+}
+
 // addCmd represents the add command
 var addCmd = &cobra.Command{
-	Use:   "add [repo] [url]",
-	Short: "Link a GCS path to a path within the repo",
+	Use:   "add [repo] [url] [path]",
+	Short: "Link a GCS path, URL or label to a path within the repo",
 	Args:  cobra.ExactArgs(3),
 	Run: func(cmd *cobra.Command, args []string) {
 		repoPath := args[0]
 		url := args[1]
 		newFilePath := args[2]
 
-		ds, _ := openDataStore(repoPath)
+		client, closeClient := getRepoClient(repoPath)
+		defer closeClient()
 
 		ctx := context.Background()
-		parent, name, err := ds.SplitPath(ctx, newFilePath)
-		if err != nil {
-			log.Fatalf("Error: %s", err)
-		}
-
-		gcsmatch := GCSUrlExp.FindStringSubmatch(url)
-		if gcsmatch != nil {
-			bucket := gcsmatch[1]
-			key := gcsmatch[2]
-			fmt.Printf("parent: %v, name: %v, bucket: %v, key: %v\n", parent, name, bucket, key)
-			_, err = ds.AddRemoteGCS(ctx, parent, name, bucket, key)
-		} else if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") {
-			_, err = ds.AddRemoteURL(ctx, parent, name, url)
-		} else {
-			err = ds.MountByLabel(ctx, parent, name, url)
-		}
+		_, err := client.AddRemote(ctx, &api.AddRemoteRequest{Path: newFilePath, Source: url})
 		if err != nil {
-			log.Fatalf("Error: %s", err)
+			log.Fatalf("Could not add %s: %s", url, errorMessage(err))
 		}
 	},
 }
@@ -0,0 +1,140 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+func printMapChanges(w io.Writer, changes []*api.ApplyResponse_Change) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, c := range changes {
+		switch c.Action {
+		case addChange:
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Action, c.Path, c.Source)
+		case updateChange:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t(was %s)\n", c.Action, c.Path, c.Source, c.Detail)
+		case removeChange:
+			fmt.Fprintf(tw, "%s\t%s\t\t(was %s)\n", c.Action, c.Path, c.Detail)
+		default:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.Action, c.Path, c.Source, c.Detail)
+		}
+	}
+	tw.Flush()
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply [repo] [map]",
+	Short: "Change an existing repo to match a map",
+	Long: `Change an existing repo to match a map, in the same format as pufs init --map. Links which are
+missing are added, and paths whose source has changed are relinked, keeping the cache. With --prune, links which
+an earlier map created but which aren't in this one are removed. Local files and directories, and mounted labels with changes, are listed as conflicts
+rather than being replaced, unless --force is given. If there are any conflicts, nothing is changed. The repo can be
+mounted or not.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		repoPath := args[0]
+		mapPath := args[1]
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			panic(err)
+		}
+		prune, err := cmd.Flags().GetBool("prune")
+		if err != nil {
+			panic(err)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			panic(err)
+		}
+
+		if getInfoType(repoPath) == "mount" {
+			log.Fatalf("%s is a mount point. Give the path of the repo instead.", repoPath)
+		}
+
+		mapping, err := parseMap(mapPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = validateMap(mapping)
+		if err != nil {
+			log.Fatalf("Invalid map %s:\n%s", mapPath, err)
+		}
+		if mapping.Root != "" {
+			log.Printf("Ignoring root %s, which can only be set by pufs init", mapping.Root)
+		}
+
+		bucketOptions, err := loadBucketOptions(repoPath)
+		if err != nil {
+			log.Fatalf("Could not load bucket options: %s", err)
+		}
+		mapBucketOptions, err := collectBucketOptions(mapping)
+		if err != nil {
+			log.Fatalf("Invalid map %s: %s", mapPath, err)
+		}
+		err = checkBucketOptionsUnchanged(bucketOptions, mapBucketOptions)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ctx := context.Background()
+		mapClient, err := newMapClient(ctx, loadRepoInfo(repoPath).credentialsPath, bucketOptions)
+		if err != nil {
+			log.Fatalf("Failed to create client: %s", err)
+		}
+		links, err := planMap(ctx, mapping, mapClient)
+		if err != nil {
+			log.Fatalf("Could not use map %s:\n%s", mapPath, err)
+		}
+
+		req := &api.ApplyRequest{Prune: prune, Force: force, DryRun: dryRun}
+		for _, link := range links {
+			req.Links = append(req.Links, &api.ApplyRequest_Link{Path: link.Path, Source: link.Source, Generation: link.Generation, Etag: link.ETag})
+		}
+
+		client, closeClient := getRepoClient(repoPath)
+		defer closeClient()
+
+		resp, err := client.Apply(ctx, req)
+		if err != nil {
+			log.Fatalf("Could not apply %s: %s", mapPath, errorMessage(err))
+		}
+		printMapChanges(os.Stdout, resp.Changes)
+
+		if len(resp.Changes) == 0 {
+			fmt.Printf("%s already matches %s\n", repoPath, mapPath)
+		} else if !resp.Applied && !dryRun {
+			fmt.Fprintf(os.Stderr, "There were conflicts, so nothing was changed\n")
+			closeClient()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().Bool("dry-run", false, "only list what would change")
+	applyCmd.Flags().Bool("prune", false, "remove links created by an earlier map which aren't in this one")
+	applyCmd.Flags().Bool("force", false, "replace local files and directories, and discard changes made to mounted labels")
+}
@@ -0,0 +1,93 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+
+	"github.com/pgm/sply2/remote"
+)
+
+// the file within a repo which holds the BucketOptions for each bucket which needs them
+const BucketOptionsFilename = ".pufs/buckets.json"
+
+func loadBucketOptions(dir string) (map[string]*remote.BucketOptions, error) {
+	buffer, err := ioutil.ReadFile(path.Join(dir, BucketOptionsFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var options map[string]*remote.BucketOptions
+	err = json.Unmarshal(buffer, &options)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", BucketOptionsFilename, err)
+	}
+	return options, nil
+}
+
+func saveBucketOptions(dir string, options map[string]*remote.BucketOptions) error {
+	buffer, err := json.MarshalIndent(options, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, BucketOptionsFilename), buffer, 0600)
+}
+
+// collectBucketOptions merges the buckets section of a map with the options given on individual links. All links to
+// the same bucket must agree, as the options apply to the whole bucket.
+func collectBucketOptions(mm *MountMap) (map[string]*remote.BucketOptions, error) {
+	options := make(map[string]*remote.BucketOptions)
+	for bucket, o := range mm.Buckets {
+		options[bucket] = o
+	}
+
+	for _, link := range mm.Links {
+		if link.UserProject == "" && link.Credentials == "" && !link.Anonymous {
+			continue
+		}
+
+		bucket, _, ok := parseGCS(link.Source)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a GCS path, so cannot have bucket options", link.Source)
+		}
+
+		linkOptions := &remote.BucketOptions{UserProject: link.UserProject, Credentials: link.Credentials, Anonymous: link.Anonymous}
+		if existing, ok := options[bucket]; ok && !reflect.DeepEqual(existing, linkOptions) {
+			return nil, fmt.Errorf("Links to gs://%s have conflicting options", bucket)
+		}
+		options[bucket] = linkOptions
+	}
+
+	return options, nil
+}
+
+// checkBucketOptionsUnchanged returns an error if options has anything for a bucket which differs from, or isn't in,
+// existing. Used when applying a map to an existing repo, whose bucket options can only be set when it's created.
+func checkBucketOptionsUnchanged(existing map[string]*remote.BucketOptions, options map[string]*remote.BucketOptions) error {
+	for bucket, o := range options {
+		if e, ok := existing[bucket]; !ok || !reflect.DeepEqual(e, o) {
+			return fmt.Errorf("Options for gs://%s differ from the repo's, and can only be set by pufs init", bucket)
+		}
+	}
+	return nil
+}
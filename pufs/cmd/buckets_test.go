@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pgm/sply2/remote"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectBucketOptions(t *testing.T) {
+	require := require.New(t)
+
+	mm := &MountMap{
+		Links: []*Link{
+			{Source: "gs://public/a", Path: "a", Anonymous: true},
+			{Source: "gs://public/b", Path: "b", Anonymous: true},
+			{Source: "gs://plain/c", Path: "c"},
+			{Source: "https://example.com/d", Path: "d"},
+		},
+		Buckets: map[string]*remote.BucketOptions{"pays": {UserProject: "billing"}},
+	}
+	options, err := collectBucketOptions(mm)
+	require.Nil(err)
+	require.Equal(map[string]*remote.BucketOptions{
+		"public": {Anonymous: true},
+		"pays":   {UserProject: "billing"}}, options)
+
+	// the options are saved with the repo
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	loaded, err := loadBucketOptions(dir)
+	require.Nil(err)
+	require.Nil(loaded)
+	require.Nil(os.MkdirAll(path.Join(dir, ".pufs"), 0700))
+	require.Nil(saveBucketOptions(dir, options))
+	loaded, err = loadBucketOptions(dir)
+	require.Nil(err)
+	require.Equal(options, loaded)
+
+	// links to the same bucket can't disagree
+	mm.Links = append(mm.Links, &Link{Source: "gs://pays/e", Path: "e", UserProject: "other"})
+	_, err = collectBucketOptions(mm)
+	require.NotNil(err)
+
+	// and only GCS links can have options
+	mm.Links = []*Link{{Source: "https://example.com/d", Path: "d", Anonymous: true}}
+	_, err = collectBucketOptions(mm)
+	require.NotNil(err)
+}
+
+func TestCheckBucketOptionsUnchanged(t *testing.T) {
+	require := require.New(t)
+
+	existing := map[string]*remote.BucketOptions{"public": {Anonymous: true}}
+	require.Nil(checkBucketOptionsUnchanged(existing, nil))
+	require.Nil(checkBucketOptionsUnchanged(existing, map[string]*remote.BucketOptions{"public": {Anonymous: true}}))
+	require.NotNil(checkBucketOptionsUnchanged(existing, map[string]*remote.BucketOptions{"public": {UserProject: "billing"}}))
+	require.NotNil(checkBucketOptionsUnchanged(nil, map[string]*remote.BucketOptions{"pays": {UserProject: "billing"}}))
+}
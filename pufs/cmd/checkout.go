@@ -0,0 +1,78 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+var checkoutCmd = &cobra.Command{
+	Use:   "checkout [label] [path]",
+	Short: "Mount a version of a label at a path, replacing whatever version was mounted there",
+	Long: `Mount a version of a label (label, label@N or label@<timestamp>) at a path within a repo or mount. If a
+label is already mounted at that path, its contents are replaced with the new version and its lease moves along
+with it. Fails if the mounted directory has changes which haven't been pushed, unless --force is given.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			panic(err)
+		}
+		label := args[0]
+
+		client, remainingPath, closeClient := findRepoClient(args[1])
+		defer closeClient()
+
+		resp, err := client.Checkout(context.Background(), &api.CheckoutRequest{Path: remainingPath, Label: label, Force: force})
+		if err != nil {
+			log.Fatalf("Could not check out %s to %s: %s", label, args[1], errorMessage(err))
+		}
+		fmt.Printf("%s\n", base64x(toBID(resp.BlockID)))
+	},
+}
+
+var unmountLabelCmd = &cobra.Command{
+	Use:   "unmount-label [path]",
+	Short: "Remove a mounted label from a repo or mount, and release its lease",
+	Long: `Remove a mounted label from a repo or mount, and release its lease. Fails if the mounted directory has
+changes which haven't been pushed, unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			panic(err)
+		}
+
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		_, err = client.UnmountLabel(context.Background(), &api.UnmountLabelRequest{Path: remainingPath, Force: force})
+		if err != nil {
+			log.Fatalf("Could not unmount %s: %s", args[0], errorMessage(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkoutCmd)
+	checkoutCmd.Flags().Bool("force", false, "Discard any changes under the mount which haven't been pushed")
+	rootCmd.AddCommand(unmountLabelCmd)
+	unmountLabelCmd.Flags().Bool("force", false, "Discard any changes under the mount which haven't been pushed")
+}
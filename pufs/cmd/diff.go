@@ -0,0 +1,133 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+// printDiff writes one line per change, in the style of git's --name-status. Sizes are only shown for files, as the
+// size of a directory is just that of its listing.
+func printDiff(w io.Writer, changes []*api.DiffResponse_Change) {
+	for _, c := range changes {
+		p := c.Path
+		size := ""
+		if c.IsDir {
+			p += "/"
+		} else if c.Kind == "modified" {
+			size = fmt.Sprintf(" (%s -> %s)", fmtNum(c.OldSize), fmtNum(c.Size))
+		} else if c.Kind == "removed" {
+			size = fmt.Sprintf(" (%s)", fmtNum(c.OldSize))
+		} else {
+			size = fmt.Sprintf(" (%s)", fmtNum(c.Size))
+		}
+
+		switch c.Kind {
+		case "added":
+			fmt.Fprintf(w, "A %s%s\n", p, size)
+		case "removed":
+			fmt.Fprintf(w, "D %s%s\n", p, size)
+		case "modified":
+			fmt.Fprintf(w, "M %s%s\n", p, size)
+		case "renamed":
+			fmt.Fprintf(w, "R %s -> %s%s\n", c.OldPath, p, size)
+		}
+	}
+}
+
+// diffChangeJSON is how each change is written with --json
+type diffChangeJSON struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"`
+	IsDir   bool   `json:"is_dir"`
+	OldBID  string `json:"old_block_id,omitempty"`
+	OldSize int64  `json:"old_size"`
+	BID     string `json:"block_id,omitempty"`
+	Size    int64  `json:"size"`
+}
+
+func printDiffJSON(w io.Writer, changes []*api.DiffResponse_Change) error {
+	entries := make([]*diffChangeJSON, len(changes))
+	for i, c := range changes {
+		entry := &diffChangeJSON{Kind: c.Kind, Path: c.Path, OldPath: c.OldPath, IsDir: c.IsDir, OldSize: c.OldSize, Size: c.Size}
+		if len(c.OldBlockID) > 0 {
+			entry.OldBID = base64x(toBID(c.OldBlockID))
+		}
+		if len(c.BlockID) > 0 {
+			entry.BID = base64x(toBID(c.BlockID))
+		}
+		entries[i] = entry
+	}
+
+	buffer, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", buffer)
+	return err
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [repo] [from] [to]",
+	Short: "Show what changed between two trees",
+	Long: `Show what changed between two trees. Each tree can be a label (optionally label@N or label@<timestamp>),
+a block ID, or "." for the repo including changes which haven't been pushed yet. A path starting with "./" compares
+just that directory. If to is omitted, compares against the repo, so "pufs diff repo label" shows what a push to
+label would change.`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			panic(err)
+		}
+
+		from := args[1]
+		to := "."
+		if len(args) > 2 {
+			to = args[2]
+		}
+
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.Diff(context.Background(), &api.DiffRequest{From: from, To: to})
+		if err != nil {
+			log.Fatalf("Could not compare %s and %s: %s", from, to, errorMessage(err))
+		}
+
+		if asJSON {
+			err = printDiffJSON(os.Stdout, resp.Changes)
+			if err != nil {
+				log.Fatalf("Could not write diff: %s", err)
+			}
+		} else {
+			printDiff(os.Stdout, resp.Changes)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().Bool("json", false, "Write the changes as JSON")
+}
@@ -35,6 +35,7 @@ to quickly create a Cobra application.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		repoPath := args[0]
+		requireNotMounted(repoPath)
 		ds, _ := openDataStore(repoPath)
 		defer ds.Close()
 
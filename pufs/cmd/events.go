@@ -0,0 +1,104 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"github.com/spf13/cobra"
+)
+
+var eventTypes []string
+
+func formatEvent(e *api.Event) string {
+	t := time.Unix(0, e.TimeNanos).Format("15:04:05.000")
+	var BID core.BlockID
+	copy(BID[:], e.BlockID)
+
+	var details string
+	switch core.EventType(e.Type) {
+	case core.OpenEvent:
+		mode := "r"
+		if e.Writable {
+			mode = "rw"
+		}
+		details = fmt.Sprintf("%s %s", e.Path, mode)
+	case core.ReadEvent:
+		details = fmt.Sprintf("%s @ %d (%s)", e.Path, e.Offset, fmtNum(e.Length))
+	case core.FetchEvent:
+		details = fmt.Sprintf("%s %d-%d (%s) in %s", base64x(BID), e.Offset, e.Offset+e.Length, fmtNum(e.Length), time.Duration(e.DurationMicros)*time.Microsecond)
+	case core.ListBlockEvent, core.ListRemoteEvent:
+		details = fmt.Sprintf("%s in %s", e.Path, time.Duration(e.DurationMicros)*time.Microsecond)
+	case core.PushEvent:
+		details = fmt.Sprintf("%s as %s: %d blocks (%s) in %s", e.Path, e.Label, e.BlockCount, fmtNum(e.Length), time.Duration(e.DurationMicros)*time.Microsecond)
+	case core.EvictEvent:
+		details = fmt.Sprintf("%s (%s)", e.Path, fmtNum(e.Length))
+	case core.RemoteChangedEvent:
+		action := "reads will fail until refreshed"
+		if e.Pinned {
+			action = "pinned to old generation"
+		}
+		details = fmt.Sprintf("%s %s: %s", base64x(BID), e.Source, action)
+	default:
+		details = e.Path
+	}
+
+	return fmt.Sprintf("%s pid=%d %s %s", t, e.Pid, e.Type, details)
+}
+
+// eventsCmd represents the events command
+var eventsCmd = &cobra.Command{
+	Use:   "events [mount-or-path]",
+	Short: "Print a live feed of opens, reads, fetches, listings, pushes and evictions from a mounted repo",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		stream, err := client.WatchEvents(ctx, &api.WatchEventsRequest{Types: eventTypes, Path: remainingPath})
+		if err != nil {
+			log.Fatalf("Could not watch events for %s: %s", args[0], errorMessage(err))
+		}
+
+		var lastDropped int64
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				log.Fatalf("Stopped watching events: %s", errorMessage(err))
+			}
+
+			if e.Dropped > lastDropped {
+				fmt.Printf("(%d events dropped)\n", e.Dropped-lastDropped)
+				lastDropped = e.Dropped
+			}
+			fmt.Println(formatEvent(e))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().StringSliceVar(&eventTypes, "type", nil, "Only show events of these types (open, read, fetch, list-block, list-remote, push, evict)")
+}
@@ -0,0 +1,46 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+// evictCmd represents the evict command
+var evictCmd = &cobra.Command{
+	Use:   "evict [path]",
+	Short: "Drop cached data under a path which can be fetched again from its remote",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		resp, err := client.Evict(ctx, &api.EvictRequest{Path: remainingPath})
+		if err != nil {
+			log.Fatalf("Could not evict %s: %s", args[0], errorMessage(err))
+		}
+		fmt.Printf("%s freed\n", fmtNum(resp.BytesFreed))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(evictCmd)
+}
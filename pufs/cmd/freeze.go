@@ -0,0 +1,50 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"github.com/spf13/cobra"
+)
+
+// freezeCmd represents the freeze command
+var freezeCmd = &cobra.Command{
+	Use:   "freeze [path]",
+	Short: "Freeze a file or directory, printing the ID of the resulting block",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		resp, err := client.Freeze(ctx, &api.FreezeRequest{Path: remainingPath})
+		if err != nil {
+			log.Fatalf("Could not freeze %s: %s", args[0], errorMessage(err))
+		}
+
+		var BID core.BlockID
+		copy(BID[:], resp.BlockID)
+		fmt.Println(base64x(BID))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(freezeCmd)
+}
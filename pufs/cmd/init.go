@@ -16,44 +16,34 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"path"
-	"strings"
+	"path/filepath"
 
 	"github.com/pgm/sply2/core"
+	"github.com/pgm/sply2/remote"
 	"github.com/spf13/cobra"
 )
 
-type Link struct {
-	Source string `json:"source"`
-	Path   string `json:"path"`
-}
-
-type MountMap struct {
-	Root  string  `json:"root"`
-	Links []*Link `json:"links"`
-}
-
-func parseMap(filename string) *MountMap {
-	fp, err := os.Open(filename)
+// newMapClient creates the client used to look up the sources in a map before the repo exists
+func newMapClient(ctx context.Context, credentialsPath string, bucketOptions map[string]*remote.BucketOptions) (*remote.RemoteRefFactoryImp, error) {
+	client, err := remote.NewGCSClient(ctx, credentialsPath)
 	if err != nil {
-		log.Fatalf("Could not open %s: %s", filename, err)
+		return nil, err
 	}
 
-	defer fp.Close()
-
-	dec := json.NewDecoder(fp)
-	var mm MountMap
-	err = dec.Decode(&mm)
-	if err != nil {
-		log.Fatalf("Could not parse %s: %s", filename, err)
+	rrf := remote.NewRemoteRefFactory(client, "", "")
+	for bucket, options := range bucketOptions {
+		err = rrf.SetBucketOptions(ctx, bucket, options)
+		if err != nil {
+			return nil, fmt.Errorf("Could not configure access to bucket %s: %s", bucket, err)
+		}
 	}
-
-	return &mm
+	return rrf, nil
 }
 
 var initCmd = &cobra.Command{
@@ -83,47 +73,87 @@ var initCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		bucketName := ""
-		keyPrefix := ""
+		cacheQuota, err := cmd.Flags().GetInt64("cache-quota")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		generationPolicy, err := cmd.Flags().GetString("generation-policy")
+		if err != nil {
+			log.Fatal(err)
+		}
+		_, err = core.ParseGenerationPolicy(generationPolicy)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		bucketName, err := cmd.Flags().GetString("bucket")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		keyPrefix, err := cmd.Flags().GetString("prefix")
+		if err != nil {
+			log.Fatal(err)
+		}
 
-		var mapping *MountMap
+		ctx := context.Background()
+		var links []*plannedLink
+		var bucketOptions map[string]*remote.BucketOptions
 		if root != "" {
 			if map_ != "" {
 				log.Fatal("Cannot specify both --map and --root parameters")
 			}
 		} else if map_ != "" {
-			mapping = parseMap(map_)
+			// check the whole map before creating anything, so a mistake doesn't leave a partially populated repo
+			mapping, err := parseMap(map_)
+			if err != nil {
+				log.Fatal(err)
+			}
+			err = validateMap(mapping)
+			if err != nil {
+				log.Fatalf("Invalid map %s:\n%s", map_, err)
+			}
 			if mapping.Root != "" {
 				root = mapping.Root
 			}
+			// the command line takes precedence over the map
+			if bucketName == "" {
+				bucketName, keyPrefix = mapping.LabelBucket, mapping.LabelPrefix
+			}
+			if bucketName == "" && mapUsesLabels(mapping) {
+				log.Fatalf("Map %s links to labels, so needs a label_bucket (or --bucket) to find them in", map_)
+			}
+			bucketOptions, err = collectBucketOptions(mapping)
+			if err != nil {
+				log.Fatalf("Invalid map %s: %s", map_, err)
+			}
+
+			client, err := newMapClient(ctx, credentialsPath, bucketOptions)
+			if err != nil {
+				log.Fatalf("Failed to create client: %s", err)
+			}
+			links, err = planMap(ctx, mapping, client)
+			if err != nil {
+				log.Fatalf("Could not use map %s:\n%s", map_, err)
+			}
+		}
+
+		if PUFSUrlExp.MatchString(root) && bucketName == "" {
+			log.Fatalf("--root %s needs --bucket, to find the label in", root)
 		}
 
-		ds := createDataStore(repoPath, root, credentialsPath, bucketName, keyPrefix, readahead)
-		if mapping != nil {
-			ctx := context.Background()
-			inodex := core.INode(core.RootINode)
-			for _, link := range mapping.Links {
-				pathComponents := strings.Split(link.Path, "/")
-				for i := 0; i < len(pathComponents)-1; i++ {
-					name := pathComponents[i]
-					nextNode, err := ds.GetNodeID(ctx, inodex, name)
-					if err == core.NoSuchNodeErr {
-						nextNode, err = ds.MakeDir(ctx, inodex, name)
-					}
-					if err != nil {
-						log.Fatalf("Could not map %s -> %s: %v", link.Source, link.Path, err)
-					}
-					inodex = nextNode
-				}
-				bucket, key, ok := parseGCS(link.Source)
-				if !ok {
-					log.Fatalf("The GCS path \"%s\" is not valid", link.Source)
-				}
-				_, err := ds.AddRemoteGCS(ctx, inodex, pathComponents[len(pathComponents)-1], bucket, key)
-				if err != nil {
-					log.Fatalf("Problem adding %s -> %s: %v", link.Source, link.Path, err)
-				}
+		_, err = os.Stat(repoPath)
+		repoIsNew := os.IsNotExist(err)
+
+		ds := createDataStore(repoPath, root, credentialsPath, bucketName, keyPrefix, readahead, cacheQuota, generationPolicy, bucketOptions)
+		err = applyMap(ctx, ds, repoPath, links)
+		if err != nil {
+			ds.Close()
+			if repoIsNew {
+				os.RemoveAll(repoPath)
 			}
+			log.Fatalf("Could not populate %s: %s", repoPath, err)
 		}
 
 		ds.Close()
@@ -133,25 +163,41 @@ var initCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(initCmd)
 	initCmd.Flags().String("root", "", "remote path to use for the root (ie: gs://bucket/path/ or pufs:///label )")
-	initCmd.Flags().String("map", "", "json file which describes how to prepopulate the filesystem")
-	initCmd.Flags().String("creds", "", "path to json credentials file for service account to use")
+	initCmd.Flags().String("map", "", "json or yaml file which describes how to prepopulate the filesystem")
+	initCmd.Flags().String("bucket", "", "GCS bucket which holds labels, pushed blocks and leases (needed for pufs:/// roots and links, and to push)")
+	initCmd.Flags().String("prefix", "", "prefix for the keys pufs writes within --bucket")
+	initCmd.Flags().String("creds", "", "path to json credentials file for service account to use (defaults to Application Default Credentials)")
 	initCmd.Flags().Int("readahead", core.DefaultMaxBackgroundTransfer, "How much streaming in background to perform")
+	initCmd.Flags().Int64("cache-quota", 0, "Max bytes of local disk to use for cached and written data (0 for no limit)")
+	initCmd.Flags().String("generation-policy", string(core.StaleOnChange), "What to do when a linked GCS object is overwritten: \"stale\" fails reads of uncached data until refreshed, \"pin\" keeps reading the old generation (requires object versioning)")
 }
 
-func createDataStore(dir string, mountAsRoot string, credentialsPath string, bucketName string, keyPrefix string, maxBackgroundTransfer int) *core.DataStore {
-	// log.Printf("mountAsRoot=%s", mountAsRoot)
-	socketFile, err := ioutil.TempFile("", "pufs-"+path.Base(dir))
+// the longest path which can be used for a unix socket (104 bytes on OS X including the terminating null, 108 on linux)
+const maxSocketPathLen = 103
+
+// defaultSocketAddress returns the path the service for the repo in dir will listen on. This is inside the repo's
+// .pufs directory if that path is short enough to be used for a unix socket. Otherwise it's in the temp dir, named
+// after a hash of the repo's path.
+func defaultSocketAddress(dir string) string {
+	absDir, err := filepath.Abs(dir)
 	if err != nil {
-		log.Fatalf("Could not create socket in temp dir: %s", err)
+		log.Fatalf("Could not get absolute path of %s: %s", dir, err)
 	}
-	socketAddress := socketFile.Name()
-	socketFile.Close()
-	err = os.Remove(socketFile.Name())
-	if err != nil {
-		log.Fatalf("Could not remove in temp dir: %s", err)
+
+	socketAddress := path.Join(absDir, path.Dir(PufsInfoFilename), "socket")
+	if len(socketAddress) <= maxSocketPathLen {
+		return socketAddress
 	}
 
-	if _, err = os.Stat(dir); os.IsNotExist(err) {
+	hash := sha256.Sum256([]byte(absDir))
+	return path.Join(os.TempDir(), fmt.Sprintf("pufs-%d-%s.sock", os.Getuid(), hex.EncodeToString(hash[:8])))
+}
+
+func createDataStore(dir string, mountAsRoot string, credentialsPath string, bucketName string, keyPrefix string, maxBackgroundTransfer int, cacheQuota int64, generationPolicy string, bucketOptions map[string]*remote.BucketOptions) *core.DataStore {
+	// log.Printf("mountAsRoot=%s", mountAsRoot)
+	socketAddress := defaultSocketAddress(dir)
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		err = os.MkdirAll(dir, 0700)
 		if err != nil {
 			log.Fatalf("Could not create %s: %s", dir, err)
@@ -174,17 +220,28 @@ func createDataStore(dir string, mountAsRoot string, credentialsPath string, buc
 			"credentialsPath=%s\n"+
 			"bucketName=%s\n"+
 			"keyPrefix=%s\n"+
-			"socketAddress=%s\n",
+			"socketAddress=%s\n"+
+			"cacheQuota=%d\n"+
+			"generationPolicy=%s\n",
 			maxBackgroundTransfer,
 			credentialsPath,
 			bucketName,
 			keyPrefix,
-			socketAddress)
+			socketAddress,
+			cacheQuota,
+			generationPolicy)
 		_, err = f.WriteString(configStr)
 		if err != nil {
 			log.Fatalf("Could not write %s: %s", pufsInfoPath, err)
 		}
 		defer f.Close()
+
+		if len(bucketOptions) > 0 {
+			err = saveBucketOptions(dir, bucketOptions)
+			if err != nil {
+				log.Fatalf("Could not write %s: %s", BucketOptionsFilename, err)
+			}
+		}
 	}
 
 	dsOptions := make([]core.DataStoreOption, 0)
@@ -197,16 +254,7 @@ func createDataStore(dir string, mountAsRoot string, credentialsPath string, buc
 			key := gcsmatch[2]
 			dsOptions = append(dsOptions, core.DataStoreWithGCSRoot(bucket, key))
 		} else if pufsmatch := PUFSUrlExp.FindStringSubmatch(mountAsRoot); pufsmatch != nil {
-			panic("unimplemented")
-			// log.Printf("pufs:%v", pufsmatch)
-			// label := pufsmatch[1]
-
-			// BID, err := remoteRefFactory.GetRoot(ctx, label)
-			// if err != nil {
-			// 	log.Fatalf("Could not get root %s: %v", label, err)
-			// }
-
-			// dsOptions = append(dsOptions, core.DataStoreWithBIDRoot(BID))
+			dsOptions = append(dsOptions, core.DataStoreWithLabelRoot(pufsmatch[1]))
 		} else {
 			log.Fatalf("Root was not parsable: %s", mountAsRoot)
 		}
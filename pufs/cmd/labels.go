@@ -0,0 +1,91 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+var labelsCmd = &cobra.Command{
+	Use:   "labels [repo]",
+	Short: "List the labels which have been pushed to the repo's remote",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.ListLabels(context.Background(), &api.ListLabelsRequest{})
+		if err != nil {
+			log.Fatalf("Could not list labels: %s", errorMessage(err))
+		}
+		for _, label := range resp.Labels {
+			fmt.Println(label)
+		}
+	},
+}
+
+func printLabelHistory(w io.Writer, label string, versions []*api.LabelHistoryResponse_Version) {
+	if len(versions) == 0 {
+		fmt.Fprintf(w, "%s has no recorded history\n", label)
+		return
+	}
+
+	// newest first, like git log
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		fmt.Fprintf(w, "%s@%d %s\n", label, v.Version, base64x(toBID(v.BlockID)))
+		fmt.Fprintf(w, "Pushed: %s by %s@%s\n", time.Unix(0, v.TimeNanos).Format(time.RFC3339), v.User, v.Host)
+		if len(v.Parent) > 0 {
+			fmt.Fprintf(w, "Parent: %s\n", base64x(toBID(v.Parent)))
+		}
+		if v.Message != "" {
+			fmt.Fprintf(w, "\n    %s\n", v.Message)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+var logCmd = &cobra.Command{
+	Use:   "log [repo] [label]",
+	Short: "Show each version of a label, newest first",
+	Long: `Show each version of a label, newest first. Any version can be referred to as label@N, or as
+label@<timestamp> for whichever version was current at that time, wherever a label is accepted.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		label := args[1]
+
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.GetLabelHistory(context.Background(), &api.LabelHistoryRequest{Label: label})
+		if err != nil {
+			log.Fatalf("Could not get history of %s: %s", label, errorMessage(err))
+		}
+		printLabelHistory(os.Stdout, label, resp.Versions)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(labelsCmd)
+	rootCmd.AddCommand(logCmd)
+}
@@ -0,0 +1,70 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+func printLeases(w io.Writer, leases []*api.ListLeasesResponse_Lease, now time.Time) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, l := range leases {
+		expiry := time.Unix(0, l.ExpiryNanos)
+		state := ""
+		if !expiry.After(now) {
+			state = "expired"
+		}
+		owner := "-"
+		if l.Host != "" || l.Repo != "" {
+			owner = fmt.Sprintf("%s:%s", l.Host, l.Repo)
+		}
+		if l.IsOwn {
+			owner += " (this repo)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", l.Name, base64x(toBID(l.BlockID)), expiry.Format(time.RFC3339), state, owner)
+	}
+	tw.Flush()
+}
+
+var leasesCmd = &cobra.Command{
+	Use:   "leases [repo]",
+	Short: "List the leases on the repo's remote",
+	Long: `List every lease on the repo's remote, including those held by other repos, along with when each
+expires and which repo owns it. Roots with an unexpired lease are never garbage collected.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.ListLeases(context.Background(), &api.ListLeasesRequest{})
+		if err != nil {
+			log.Fatalf("Could not list leases: %s", errorMessage(err))
+		}
+		printLeases(os.Stdout, resp.Leases, time.Now())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(leasesCmd)
+}
@@ -31,6 +31,8 @@ import (
 	"github.com/pgm/sply2/core"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func boolToStr(b bool, t string, f string) string {
@@ -45,6 +47,20 @@ func base64x(BID core.BlockID) string {
 	return base64.RawStdEncoding.EncodeToString(BID[:])
 }
 
+// parseBID reads a block ID as printed by base64x
+func parseBID(s string) (core.BlockID, error) {
+	var BID core.BlockID
+	b, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil {
+		return BID, err
+	}
+	if len(b) != len(BID) {
+		return BID, fmt.Errorf("Block ID %s should be %d bytes, but was %d", s, len(BID), len(b))
+	}
+	copy(BID[:], b)
+	return BID, nil
+}
+
 func fmtNum(v int64) string {
 	if v <= 1024 {
 		return fmt.Sprintf("%d", v)
@@ -101,36 +117,211 @@ func (c *ClientWrapper) GetDirContents(ctx context.Context, in *api.DirContentsR
 	return c.s.GetDirContents(ctx, in)
 }
 
-func getRepoClient(repoPath string) api.PufsClient {
+func (c *ClientWrapper) GetServiceInfo(ctx context.Context, in *api.ServiceInfoRequest, opts ...grpc.CallOption) (*api.ServiceInfoResponse, error) {
+	return c.s.GetServiceInfo(ctx, in)
+}
+
+func (c *ClientWrapper) Push(ctx context.Context, in *api.PushRequest, opts ...grpc.CallOption) (*api.PushResponse, error) {
+	return c.s.Push(ctx, in)
+}
+
+func (c *ClientWrapper) AddRemote(ctx context.Context, in *api.AddRemoteRequest, opts ...grpc.CallOption) (*api.AddRemoteResponse, error) {
+	return c.s.AddRemote(ctx, in)
+}
+
+func (c *ClientWrapper) Mkdir(ctx context.Context, in *api.MkdirRequest, opts ...grpc.CallOption) (*api.MkdirResponse, error) {
+	return c.s.Mkdir(ctx, in)
+}
+
+func (c *ClientWrapper) Remove(ctx context.Context, in *api.RemoveRequest, opts ...grpc.CallOption) (*api.RemoveResponse, error) {
+	return c.s.Remove(ctx, in)
+}
+
+func (c *ClientWrapper) Rename(ctx context.Context, in *api.RenameRequest, opts ...grpc.CallOption) (*api.RenameResponse, error) {
+	return c.s.Rename(ctx, in)
+}
+
+func (c *ClientWrapper) Freeze(ctx context.Context, in *api.FreezeRequest, opts ...grpc.CallOption) (*api.FreezeResponse, error) {
+	return c.s.Freeze(ctx, in)
+}
+
+func (c *ClientWrapper) Prefetch(ctx context.Context, in *api.PrefetchRequest, opts ...grpc.CallOption) (*api.PrefetchResponse, error) {
+	return c.s.Prefetch(ctx, in)
+}
+
+func (c *ClientWrapper) Evict(ctx context.Context, in *api.EvictRequest, opts ...grpc.CallOption) (*api.EvictResponse, error) {
+	return c.s.Evict(ctx, in)
+}
+
+func (c *ClientWrapper) Refresh(ctx context.Context, in *api.RefreshRequest, opts ...grpc.CallOption) (*api.RefreshResponse, error) {
+	return c.s.Refresh(ctx, in)
+}
+
+func (c *ClientWrapper) GetStats(ctx context.Context, in *api.StatsRequest, opts ...grpc.CallOption) (*api.StatsResponse, error) {
+	return c.s.GetStats(ctx, in)
+}
+
+func (c *ClientWrapper) GetStatus(ctx context.Context, in *api.StatusRequest, opts ...grpc.CallOption) (*api.StatusResponse, error) {
+	return c.s.GetStatus(ctx, in)
+}
+
+func (c *ClientWrapper) ListLabels(ctx context.Context, in *api.ListLabelsRequest, opts ...grpc.CallOption) (*api.ListLabelsResponse, error) {
+	return c.s.ListLabels(ctx, in)
+}
+
+func (c *ClientWrapper) GetLabelHistory(ctx context.Context, in *api.LabelHistoryRequest, opts ...grpc.CallOption) (*api.LabelHistoryResponse, error) {
+	return c.s.GetLabelHistory(ctx, in)
+}
+
+func (c *ClientWrapper) Diff(ctx context.Context, in *api.DiffRequest, opts ...grpc.CallOption) (*api.DiffResponse, error) {
+	return c.s.Diff(ctx, in)
+}
+
+func (c *ClientWrapper) Merge(ctx context.Context, in *api.MergeRequest, opts ...grpc.CallOption) (*api.MergeResponse, error) {
+	return c.s.Merge(ctx, in)
+}
+
+func (c *ClientWrapper) CreateSnapshot(ctx context.Context, in *api.CreateSnapshotRequest, opts ...grpc.CallOption) (*api.CreateSnapshotResponse, error) {
+	return c.s.CreateSnapshot(ctx, in)
+}
+
+func (c *ClientWrapper) ListSnapshots(ctx context.Context, in *api.ListSnapshotsRequest, opts ...grpc.CallOption) (*api.ListSnapshotsResponse, error) {
+	return c.s.ListSnapshots(ctx, in)
+}
+
+func (c *ClientWrapper) RestoreSnapshot(ctx context.Context, in *api.RestoreSnapshotRequest, opts ...grpc.CallOption) (*api.RestoreSnapshotResponse, error) {
+	return c.s.RestoreSnapshot(ctx, in)
+}
+
+func (c *ClientWrapper) DeleteSnapshot(ctx context.Context, in *api.DeleteSnapshotRequest, opts ...grpc.CallOption) (*api.DeleteSnapshotResponse, error) {
+	return c.s.DeleteSnapshot(ctx, in)
+}
+
+func (c *ClientWrapper) Checkout(ctx context.Context, in *api.CheckoutRequest, opts ...grpc.CallOption) (*api.CheckoutResponse, error) {
+	return c.s.Checkout(ctx, in)
+}
+
+func (c *ClientWrapper) UnmountLabel(ctx context.Context, in *api.UnmountLabelRequest, opts ...grpc.CallOption) (*api.UnmountLabelResponse, error) {
+	return c.s.UnmountLabel(ctx, in)
+}
+
+func (c *ClientWrapper) ListLeases(ctx context.Context, in *api.ListLeasesRequest, opts ...grpc.CallOption) (*api.ListLeasesResponse, error) {
+	return c.s.ListLeases(ctx, in)
+}
+
+func (c *ClientWrapper) Apply(ctx context.Context, in *api.ApplyRequest, opts ...grpc.CallOption) (*api.ApplyResponse, error) {
+	return c.s.Apply(ctx, in)
+}
+
+func (c *ClientWrapper) WatchEvents(ctx context.Context, in *api.WatchEventsRequest, opts ...grpc.CallOption) (api.Pufs_WatchEventsClient, error) {
+	// streaming needs a real connection, and there are no events to watch without a running mount anyway
+	return nil, status.Error(codes.FailedPrecondition, "Events are only available from a mounted repo")
+}
+
+// how long to wait for a pufs service to respond before deciding it's unresponsive
+const pingTimeout = 5 * time.Second
+
+var ServiceNotRunningErr = errors.New("pufs service is not running")
+
+func dialService(socketAddress string) (*grpc.ClientConn, error) {
+	return grpc.Dial(socketAddress, grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", addr, timeout)
+	}))
+}
+
+// pingService asks the pufs service listening on socketAddress to describe itself. If there's a socket, but nothing
+// is listening on it, the service must have died without cleaning up, so the stale socket is removed. In that case, or
+// if there is no socket at all, ServiceNotRunningErr is returned.
+func pingService(socketAddress string) (*api.ServiceInfoResponse, error) {
+	_, err := os.Stat(socketAddress)
+	if err != nil {
+		return nil, ServiceNotRunningErr
+	}
+
+	rawConn, err := net.DialTimeout("unix", socketAddress, pingTimeout)
+	if err != nil {
+		log.Printf("Removing stale socket %s (%s)", socketAddress, err)
+		os.Remove(socketAddress)
+		return nil, ServiceNotRunningErr
+	}
+	rawConn.Close()
+
+	conn, err := dialService(socketAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	return api.NewPufsClient(conn).GetServiceInfo(ctx, &api.ServiceInfoRequest{})
+}
+
+// getRepoClient returns a client for the repo (or mount) at repoPath, along with a function to call once done with
+// it. If the repo is mounted, requests go to the pufs service managing the mount. Otherwise the repo is opened
+// directly.
+func getRepoClient(repoPath string) (api.PufsClient, func()) {
 	socketAddress := getSocketAddress(repoPath)
 	return attemptConnect(socketAddress, repoPath)
 }
 
-func attemptConnect(socketAddress string, repoPath string) api.PufsClient {
-	_, err := os.Stat(socketAddress)
+func attemptConnect(socketAddress string, repoPath string) (api.PufsClient, func()) {
+	info, err := pingService(socketAddress)
 	if err == nil {
-		// the socket exists, so connect and use that as the client
-		log.Printf("socketAddress=%s", socketAddress)
-		conn, err := grpc.Dial(socketAddress, grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
-			return net.DialTimeout("unix", addr, timeout)
-		}))
+		// the service is up, so connect and use that as the client
+		log.Printf("Using pufs service (pid %d) for %s", info.Pid, info.MountPoint)
+		conn, err := dialService(socketAddress)
 		if err != nil {
 			log.Fatalf("Could not connect to pufs service: %s", err)
 		}
-		client := api.NewPufsClient(conn)
+		return api.NewPufsClient(conn), func() { conn.Close() }
+	}
 
-		// TODO: try sending a ping to make sure service is up.
-		// if not, shut down the client and delete the stale socket
-		return client
-	} else {
-		// okay, open directly
-		log.Printf("opening data store directly")
-		ds, _ := openExistingDataStore(repoPath)
-		localService := &apiService{ds}
-		return &ClientWrapper{localService}
+	if err != ServiceNotRunningErr {
+		log.Fatalf("pufs service at %s is not responding: %s", socketAddress, err)
+	}
+
+	if getInfoType(repoPath) == "mount" {
+		log.Fatalf("The pufs service for %s is not running", repoPath)
+	}
+
+	// okay, open directly
+	log.Printf("opening data store directly")
+	ds, _ := openExistingDataStore(repoPath)
+	localService := newAPIService(ds, repoPath, "", nil)
+	return &ClientWrapper{localService}, ds.Close
+}
+
+// requireNotMounted exits if the repo is in use by a running pufs service. Used by commands which can only run
+// against a repo that isn't mounted.
+func requireNotMounted(repoPath string) {
+	info, err := pingService(getSocketAddress(repoPath))
+	if err == nil {
+		log.Fatalf("%s is mounted at %s by pid %d. Unmount it first.", repoPath, info.MountPoint, info.Pid)
 	}
 }
 
+// errorMessage returns the message from an error returned by a pufs client, without the grpc decoration
+func errorMessage(err error) string {
+	if s, ok := status.FromError(err); ok {
+		return s.Message()
+	}
+	return err.Error()
+}
+
+// findRepoClient finds the repo (or mount) containing filePath and returns a client for it, along with the path of
+// filePath within the repo
+func findRepoClient(filePath string) (api.PufsClient, string, func()) {
+	repoPath, remainingPath, err := findPufsRoot(filePath)
+	if err != nil {
+		log.Fatalf("Could not find pufs repo: %s", err)
+	}
+
+	client, closeClient := getRepoClient(repoPath)
+	return client, remainingPath, closeClient
+}
+
 // lsCmd represents the ls command
 var lsCmd = &cobra.Command{
 	Use:   "ls",
@@ -145,17 +336,13 @@ var lsCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		dirPath := args[0]
 
-		repoPath, remainingPath, err := findPufsRoot(dirPath)
-		if err != nil {
-			log.Fatalf("Could not find pufs repo: %s", err)
-		}
-
-		client := getRepoClient(repoPath)
+		client, remainingPath, closeClient := findRepoClient(dirPath)
+		defer closeClient()
 
 		ctx := context.Background()
 		resp, err := client.GetDirContents(ctx, &api.DirContentsRequest{Path: remainingPath})
 		if err != nil {
-			log.Fatalf("Error calling client: %s", err)
+			log.Fatalf("Could not list %s: %s", dirPath, errorMessage(err))
 		}
 		//		fmt.Println("ls called")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0) //tabwriter.AlignRight)
@@ -210,9 +397,6 @@ var lsCmd = &cobra.Command{
 			return fmtNum(e.PopulatedSize)
 		}
 
-		if resp.ErrorMsg != "" {
-			log.Fatalf("Got error: %s", resp.ErrorMsg)
-		}
 		for _, e := range resp.Entries {
 			row := make([]string, len(columns))
 
@@ -0,0 +1,680 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pgm/sply2/core"
+	"github.com/pgm/sply2/remote"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type Link struct {
+	// a gs:// object, a gs:// prefix ending in "/" (linked as a directory which is listed lazily), a gs:// glob
+	// pattern, an http(s) URL, or pufs:///label
+	Source string `json:"source" yaml:"source"`
+	// where to put the link, relative to the root of the repo. For globs, the directory the matches are put in.
+	Path string `json:"path" yaml:"path"`
+
+	// pins the link to a particular version of Source. Linking fails if the gs:// object is at any other generation, or
+	// if the URL has any other ETag.
+	Generation int64  `json:"generation,omitempty" yaml:"generation,omitempty"`
+	ETag       string `json:"etag,omitempty" yaml:"etag,omitempty"`
+
+	// how to access the bucket Source is in, if it needs something other than the repo's credentials. These apply to
+	// every link to the same bucket.
+	UserProject string `json:"user_project,omitempty" yaml:"user_project,omitempty"`
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	Anonymous   bool   `json:"anonymous,omitempty" yaml:"anonymous,omitempty"`
+}
+
+type MountMap struct {
+	Root  string  `json:"root" yaml:"root"`
+	Links []*Link `json:"links" yaml:"links"`
+	// where labels, pushed blocks and leases are stored. Needed for a pufs:/// root or links, and to push.
+	LabelBucket string `json:"label_bucket,omitempty" yaml:"label_bucket,omitempty"`
+	LabelPrefix string `json:"label_prefix,omitempty" yaml:"label_prefix,omitempty"`
+	// options for accessing buckets, by bucket name
+	Buckets map[string]*remote.BucketOptions `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+}
+
+// parseMap reads a MountMap from filename, which is YAML if it ends in .yaml or .yml and JSON otherwise. Unknown
+// fields are rejected, so a misspelt option isn't silently ignored.
+func parseMap(filename string) (*MountMap, error) {
+	buffer, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var mm MountMap
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == ".yaml" || ext == ".yml" {
+		err = yaml.UnmarshalStrict(buffer, &mm)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(buffer))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&mm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", filename, err)
+	}
+
+	return &mm, nil
+}
+
+type linkKind string
+
+const (
+	gcsObjectLink linkKind = "gcs-object"
+	gcsPrefixLink linkKind = "gcs-prefix"
+	gcsGlobLink   linkKind = "gcs-glob"
+	urlLink       linkKind = "url"
+	labelLink     linkKind = "label"
+)
+
+func (k linkKind) isFile() bool {
+	return k == gcsObjectLink || k == urlLink
+}
+
+func classifySource(source string) (linkKind, error) {
+	if bucket, key, ok := parseGCS(source); ok {
+		if strings.ContainsAny(bucket, "*?[") {
+			return "", fmt.Errorf("bucket names cannot contain wildcards")
+		}
+		if strings.ContainsAny(key, "*?[") {
+			if _, err := path.Match(key, ""); err != nil {
+				return "", fmt.Errorf("invalid glob pattern: %s", err)
+			}
+			return gcsGlobLink, nil
+		}
+		if key == "" || strings.HasSuffix(key, "/") {
+			return gcsPrefixLink, nil
+		}
+		return gcsObjectLink, nil
+	}
+	if strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://") {
+		return urlLink, nil
+	}
+	if pufsmatch := PUFSUrlExp.FindStringSubmatch(source); pufsmatch != nil && pufsmatch[1] != "" {
+		return labelLink, nil
+	}
+	return "", fmt.Errorf("source must be a gs:// path, an http(s) URL or pufs:///label")
+}
+
+// mapUsesLabels is true if mm's root or any of its links is a pufs:/// label, which needs the label bucket to resolve
+func mapUsesLabels(mm *MountMap) bool {
+	if PUFSUrlExp.MatchString(mm.Root) {
+		return true
+	}
+	for _, link := range mm.Links {
+		if kind, err := classifySource(link.Source); err == nil && kind == labelLink {
+			return true
+		}
+	}
+	return false
+}
+
+func validateLinkPath(linkPath string) error {
+	if linkPath == "" {
+		return fmt.Errorf("path is empty")
+	}
+	if strings.HasPrefix(linkPath, "/") {
+		return fmt.Errorf("path must be relative to the root of the repo")
+	}
+	if path.Clean(linkPath) != linkPath {
+		return fmt.Errorf("path should be written as %s", path.Clean(linkPath))
+	}
+	components := strings.Split(linkPath, "/")
+	for _, c := range components {
+		if c == ".." || c == "." {
+			return fmt.Errorf("path cannot contain %s", c)
+		}
+	}
+	if components[0] == ".pufs" {
+		return fmt.Errorf(".pufs is reserved for the repo's own use")
+	}
+	return nil
+}
+
+// validateMap checks everything in mm which can be checked without the network, and reports every problem found
+// rather than just the first
+func validateMap(mm *MountMap) error {
+	problems := make([]string, 0)
+
+	if mm.Root != "" && !GCSUrlExp.MatchString(mm.Root) && !PUFSUrlExp.MatchString(mm.Root) {
+		problems = append(problems, fmt.Sprintf("root %s must be a gs:// path or pufs:///label", mm.Root))
+	}
+
+	kinds := make(map[string]linkKind)
+	validPaths := make([]string, 0, len(mm.Links))
+	for i, link := range mm.Links {
+		describe := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("link %d (%s -> %s): %s", i+1, link.Source, link.Path, fmt.Sprintf(format, args...)))
+		}
+
+		kind, err := classifySource(link.Source)
+		if err != nil {
+			describe("%s", err)
+		}
+		err = validateLinkPath(link.Path)
+		if err != nil {
+			describe("%s", err)
+			continue
+		}
+
+		if link.Generation != 0 && kind != gcsObjectLink {
+			describe("generation can only be given for a gs:// object")
+		}
+		if link.ETag != "" && kind != urlLink {
+			describe("etag can only be given for an http(s) URL")
+		}
+
+		// matches from several globs can be put in the same directory, but otherwise each path is linked once
+		if existing, ok := kinds[link.Path]; ok && !(existing == gcsGlobLink && kind == gcsGlobLink) {
+			describe("%s is already linked", link.Path)
+		}
+		kinds[link.Path] = kind
+		validPaths = append(validPaths, link.Path)
+	}
+
+	// nothing can be linked inside a file
+	for _, linkPath := range validPaths {
+		for dir := path.Dir(linkPath); dir != "."; dir = path.Dir(dir) {
+			if kinds[dir].isFile() {
+				problems = append(problems, fmt.Sprintf("%s is inside %s, which is linked to a file", linkPath, dir))
+				break
+			}
+		}
+	}
+
+	_, err := collectBucketOptions(mm)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// mapClient is what's needed to look up the sources in a map
+type mapClient interface {
+	GetGCSAttr(ctx context.Context, bucket string, key string) (*core.GCSAttrs, error)
+	GetHTTPAttr(ctx context.Context, url string) (*core.HTTPAttrs, error)
+	ListObjects(ctx context.Context, bucket string, prefix string) ([]string, error)
+}
+
+// plannedLink is a single link to create, after globs have been expanded
+type plannedLink struct {
+	Path   string
+	Source string
+	// pins from the map, which are checked again when the link is created
+	Generation int64
+	ETag       string
+}
+
+// pins returns the options which make linking fail if the source isn't at the version the link is pinned to
+func (l *plannedLink) pins() []core.AddRemoteOption {
+	options := make([]core.AddRemoteOption, 0)
+	if l.Generation != 0 {
+		options = append(options, core.ExpectGeneration(l.Generation))
+	}
+	if l.ETag != "" {
+		options = append(options, core.ExpectETag(l.ETag))
+	}
+	return options
+}
+
+// isPinnedTo is true if the node linked from source is at the version the link is pinned to, or the link isn't pinned
+func (l *plannedLink) isPinnedTo(node *core.NodeRepr) bool {
+	switch remoteSource := node.RemoteSource.(type) {
+	case *core.GCSObjectSource:
+		return l.Generation == 0 || remoteSource.Generation == l.Generation
+	case *core.URLSource:
+		return l.ETag == "" || remoteSource.ETag == l.ETag
+	}
+	return true
+}
+
+// planMap turns the links in mm, which must be valid, into the links to create. Globs are expanded into a link for
+// each object they match, placed under the link's path by their name relative to the last directory in the pattern
+// without wildcards. Pinned links are checked against the current version of their source. As with validateMap,
+// every problem found is reported.
+func planMap(ctx context.Context, mm *MountMap, client mapClient) ([]*plannedLink, error) {
+	problems := make([]string, 0)
+	planned := make([]*plannedLink, 0, len(mm.Links))
+	for i, link := range mm.Links {
+		describe := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("link %d (%s -> %s): %s", i+1, link.Source, link.Path, fmt.Sprintf(format, args...)))
+		}
+
+		kind, _ := classifySource(link.Source)
+		switch kind {
+		case gcsObjectLink:
+			bucket, key, _ := parseGCS(link.Source)
+			attrs, err := client.GetGCSAttr(ctx, bucket, key)
+			if err != nil {
+				describe("%s", err)
+				continue
+			}
+			if link.Generation != 0 && attrs.Generation != link.Generation {
+				describe("object is at generation %d, not %d", attrs.Generation, link.Generation)
+				continue
+			}
+		case urlLink:
+			attrs, err := client.GetHTTPAttr(ctx, link.Source)
+			if err != nil {
+				describe("%s", err)
+				continue
+			}
+			if link.ETag != "" && attrs.ETag != link.ETag {
+				describe("URL has ETag %s, not %s", attrs.ETag, link.ETag)
+				continue
+			}
+		case gcsGlobLink:
+			bucket, pattern, _ := parseGCS(link.Source)
+			dirPrefix := pattern[:strings.LastIndex(pattern[:strings.IndexAny(pattern, "*?[")], "/")+1]
+			names, err := client.ListObjects(ctx, bucket, dirPrefix)
+			if err != nil {
+				describe("%s", err)
+				continue
+			}
+			matched := 0
+			for _, name := range names {
+				if ok, _ := path.Match(pattern, name); ok && !strings.HasSuffix(name, "/") {
+					planned = append(planned, &plannedLink{Path: path.Join(link.Path, name[len(dirPrefix):]),
+						Source: fmt.Sprintf("gs://%s/%s", bucket, name)})
+					matched++
+				}
+			}
+			if matched == 0 {
+				describe("pattern matched nothing")
+			}
+			continue
+		}
+
+		planned = append(planned, &plannedLink{Path: link.Path, Source: link.Source, Generation: link.Generation, ETag: link.ETag})
+	}
+
+	seen := make(map[string]string)
+	for _, p := range planned {
+		if other, ok := seen[p.Path]; ok {
+			problems = append(problems, fmt.Sprintf("%s and %s would both be linked at %s", other, p.Source, p.Path))
+		}
+		seen[p.Path] = p.Source
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+
+	// create shallower links first, so that nothing is linked inside a directory which is then replaced
+	sort.SliceStable(planned, func(i, j int) bool {
+		return strings.Count(planned[i].Path, "/") < strings.Count(planned[j].Path, "/")
+	})
+	return planned, nil
+}
+
+// makeParentDirs creates any directories missing from the path to linkPath, and returns the directory which holds
+// it along with its name
+func makeParentDirs(ctx context.Context, ds *core.DataStore, linkPath string) (core.INode, string, error) {
+	parent := core.INode(core.RootINode)
+	pathComponents := strings.Split(linkPath, "/")
+	for _, name := range pathComponents[:len(pathComponents)-1] {
+		next, err := ds.GetNodeID(ctx, parent, name)
+		if err == core.NoSuchNodeErr {
+			next, err = ds.MakeDir(ctx, parent, name)
+		}
+		if err != nil {
+			return core.InvalidINode, "", fmt.Errorf("Could not create directory for %s: %s", linkPath, err)
+		}
+		parent = next
+	}
+	return parent, pathComponents[len(pathComponents)-1], nil
+}
+
+// the file within a repo which lists the paths of the links created from a map. Only these are removed when pruning, as
+// anything else with a remote source (such as the contents of a gs:// or pufs:// root) wasn't linked by a map.
+const MapLinksFilename = ".pufs/map-links.json"
+
+func loadMapLinks(dir string) ([]string, error) {
+	buffer, err := ioutil.ReadFile(path.Join(dir, MapLinksFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	err = json.Unmarshal(buffer, &paths)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", MapLinksFilename, err)
+	}
+	return paths, nil
+}
+
+func saveMapLinks(dir string, paths []string) error {
+	sort.Strings(paths)
+	buffer, err := json.MarshalIndent(paths, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, MapLinksFilename), buffer, 0600)
+}
+
+// applyMap creates the planned links in ds, creating any directories needed to hold them, and records them in the
+// repo at dir
+func applyMap(ctx context.Context, ds *core.DataStore, dir string, links []*plannedLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(links))
+	for _, link := range links {
+		parent, name, err := makeParentDirs(ctx, ds, link.Path)
+		if err != nil {
+			return err
+		}
+
+		_, err = addRemoteSource(ctx, ds, parent, name, link.Source, link.pins()...)
+		if err != nil {
+			return fmt.Errorf("Could not link %s -> %s: %s", link.Source, link.Path, err)
+		}
+		paths = append(paths, link.Path)
+	}
+	return saveMapLinks(dir, paths)
+}
+
+const (
+	addChange      = "add"
+	updateChange   = "update"
+	removeChange   = "remove"
+	conflictChange = "conflict"
+)
+
+// mapChange is a change needed to make a repo match a map
+type mapChange struct {
+	Action string
+	Path   string
+	// what Path will be linked to. Empty for removals.
+	Source string
+	// for updates and removals, what Path is currently linked to. For conflicts, why Path can't be changed.
+	Detail string
+
+	parent  core.INode
+	name    string
+	isMount bool
+	// for additions and updates, the link being made
+	link *plannedLink
+}
+
+type reconcileOptions struct {
+	// remove links which aren't in the map
+	Prune bool
+	// replace entries which aren't links, and discard changes made to mounted labels
+	Force bool
+	// only work out what would change
+	DryRun bool
+}
+
+// existingLink describes the entry at inode: a mount, a link (in which case the source it was linked from is
+// returned), or neither
+func existingLink(ctx context.Context, ds *core.DataStore, mounts map[core.INode]*core.MountStatus, inode core.INode) (source string, mount *core.MountStatus, node *core.NodeRepr, err error) {
+	node, err = ds.GetAttr(ctx, inode)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if m, ok := mounts[inode]; ok {
+		return "pufs:///" + base64x(m.BID), m, node, nil
+	}
+	switch remoteSource := node.RemoteSource.(type) {
+	case *core.GCSObjectSource:
+		return fmt.Sprintf("gs://%s/%s", remoteSource.Bucket, remoteSource.Key), nil, node, nil
+	case *core.URLSource:
+		return remoteSource.URL, nil, node, nil
+	}
+	return "", nil, node, nil
+}
+
+// lookupMapPath finds linkPath in ds. If it doesn't exist, returns core.InvalidINode, along with the deepest directory
+// which does exist if that's its parent.
+func lookupMapPath(ctx context.Context, ds *core.DataStore, linkPath string) (parent core.INode, name string, inode core.INode, err error) {
+	parent = core.RootINode
+	pathComponents := strings.Split(linkPath, "/")
+	for i, component := range pathComponents {
+		next, err := ds.GetNodeID(ctx, parent, component)
+		if err == core.NoSuchNodeErr {
+			if i < len(pathComponents)-1 {
+				return core.InvalidINode, "", core.InvalidINode, nil
+			}
+			return parent, component, core.InvalidINode, nil
+		}
+		if err != nil {
+			return core.InvalidINode, "", core.InvalidINode, err
+		}
+		if i == len(pathComponents)-1 {
+			return parent, component, next, nil
+		}
+		parent = next
+	}
+	panic("unreachable")
+}
+
+func mountIsUnchanged(node *core.NodeRepr, m *core.MountStatus) bool {
+	return !node.IsDirty && node.BID == m.BID
+}
+
+func labelOf(source string) string {
+	if pufsmatch := PUFSUrlExp.FindStringSubmatch(source); pufsmatch != nil {
+		return pufsmatch[1]
+	}
+	return source
+}
+
+// planChanges works out what has to change to make ds match links, without changing anything. previous are the paths
+// of the links created by earlier maps, which are the only ones considered for pruning.
+func planChanges(ctx context.Context, ds *core.DataStore, links []*plannedLink, previous []string, options reconcileOptions) ([]*mapChange, error) {
+	mounts := make(map[core.INode]*core.MountStatus)
+	for _, m := range ds.GetMounts() {
+		mounts[m.INode] = m
+	}
+
+	changes := make([]*mapChange, 0)
+	mapped := make(map[string]bool)
+	for _, link := range links {
+		mapped[link.Path] = true
+
+		parent, name, inode, err := lookupMapPath(ctx, ds, link.Path)
+		if err != nil {
+			return nil, err
+		}
+		if inode == core.InvalidINode {
+			changes = append(changes, &mapChange{Action: addChange, Path: link.Path, Source: link.Source, link: link})
+			continue
+		}
+
+		current, mount, node, err := existingLink(ctx, ds, mounts, inode)
+		if err != nil {
+			return nil, err
+		}
+		change := &mapChange{Action: updateChange, Path: link.Path, Source: link.Source, Detail: current, parent: parent, name: name, isMount: mount != nil, link: link}
+
+		kind, _ := classifySource(link.Source)
+		if kind == labelLink {
+			BID, err := ds.ResolveLabel(ctx, labelOf(link.Source))
+			if err != nil {
+				return nil, fmt.Errorf("Could not resolve %s: %s", link.Source, err)
+			}
+			if mount != nil && mount.BID == BID {
+				continue
+			}
+		} else if current == link.Source && link.isPinnedTo(node) {
+			continue
+		}
+
+		if current == "" && !options.Force {
+			change.Action = conflictChange
+			change.Detail = "not a link, so would be replaced"
+		} else if mount != nil && !mountIsUnchanged(node, mount) && !options.Force {
+			change.Action = conflictChange
+			change.Detail = "mounted label has changes which would be lost"
+		}
+		changes = append(changes, change)
+	}
+
+	if options.Prune {
+		for _, linkPath := range previous {
+			if mapped[linkPath] {
+				continue
+			}
+			change, err := planPrune(ctx, ds, linkPath, mounts, options)
+			if err != nil {
+				return nil, err
+			}
+			if change != nil {
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// planPrune works out how to remove the link a previous map created at linkPath. Returns nil if it has already gone, or
+// has since been replaced by something which isn't a link.
+func planPrune(ctx context.Context, ds *core.DataStore, linkPath string, mounts map[core.INode]*core.MountStatus, options reconcileOptions) (*mapChange, error) {
+	parent, name, inode, err := lookupMapPath(ctx, ds, linkPath)
+	if err != nil {
+		return nil, err
+	}
+	if inode == core.InvalidINode {
+		return nil, nil
+	}
+
+	current, mount, node, err := existingLink(ctx, ds, mounts, inode)
+	if err != nil {
+		return nil, err
+	}
+	if current == "" {
+		return nil, nil
+	}
+
+	change := &mapChange{Action: removeChange, Path: linkPath, Detail: current, parent: parent, name: name, isMount: mount != nil}
+	if mount != nil && !mountIsUnchanged(node, mount) && !options.Force {
+		change.Action = conflictChange
+		change.Detail = "mounted label has changes which would be lost"
+	}
+	return change, nil
+}
+
+// reconcileMap makes ds (the repo at dir) match links, adding links which are missing, relinking paths whose source
+// has changed and, if options.Prune is set, removing links an earlier map created which aren't in this one. Local files
+// and directories are left alone unless options.Force is set. If any change conflicts, nothing is changed. Returns the
+// changes, and whether they were made.
+func reconcileMap(ctx context.Context, ds *core.DataStore, dir string, links []*plannedLink, options reconcileOptions) ([]*mapChange, bool, error) {
+	previous, err := loadMapLinks(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	changes, err := planChanges(ctx, ds, links, previous, options)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, change := range changes {
+		if change.Action == conflictChange {
+			return changes, false, nil
+		}
+	}
+	if options.DryRun {
+		return changes, false, nil
+	}
+
+	// removals first, so a removed link can't be in the way of a new one
+	for _, change := range changes {
+		if change.Action != removeChange {
+			continue
+		}
+		if change.isMount {
+			err = ds.UnmountLabel(ctx, change.parent, change.name, true)
+		} else {
+			err = ds.RemoveTree(ctx, change.parent, change.name)
+		}
+		if err != nil {
+			return changes, false, fmt.Errorf("Could not remove %s: %s", change.Path, err)
+		}
+	}
+
+	for _, change := range changes {
+		if change.Action == updateChange {
+			kind, _ := classifySource(change.Source)
+			if change.isMount && kind == labelLink {
+				// keeps the mount's lease, moving it to the new root
+				_, _, err = ds.Checkout(ctx, change.parent, change.name, labelOf(change.Source), true)
+				if err != nil {
+					return changes, false, fmt.Errorf("Could not update %s: %s", change.Path, err)
+				}
+				continue
+			}
+			err = ds.RemoveTree(ctx, change.parent, change.name)
+			if err != nil {
+				return changes, false, fmt.Errorf("Could not update %s: %s", change.Path, err)
+			}
+		} else if change.Action != addChange {
+			continue
+		}
+
+		parent, name, err := makeParentDirs(ctx, ds, change.Path)
+		if err != nil {
+			return changes, false, err
+		}
+		_, err = addRemoteSource(ctx, ds, parent, name, change.Source, change.link.pins()...)
+		if err != nil {
+			return changes, false, fmt.Errorf("Could not link %s -> %s: %s", change.Source, change.Path, err)
+		}
+	}
+
+	// links which weren't pruned still came from a map
+	recorded := make(map[string]bool)
+	for _, link := range links {
+		recorded[link.Path] = true
+	}
+	if !options.Prune {
+		for _, linkPath := range previous {
+			recorded[linkPath] = true
+		}
+	}
+	paths := make([]string, 0, len(recorded))
+	for linkPath := range recorded {
+		paths = append(paths, linkPath)
+	}
+	err = saveMapLinks(dir, paths)
+	if err != nil {
+		return changes, true, fmt.Errorf("Could not write %s: %s", MapLinksFilename, err)
+	}
+
+	return changes, true, nil
+}
@@ -0,0 +1,387 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pgm/sply2/core"
+	"github.com/pgm/sply2/remote"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMapClient serves the objects in a single listing, all at generation 1
+type fakeMapClient struct {
+	fakeNetworkClient
+	objects []string
+}
+
+func (c *fakeMapClient) ListObjects(ctx context.Context, bucket string, prefix string) ([]string, error) {
+	names := make([]string, 0)
+	for _, name := range c.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func TestParseMap(t *testing.T) {
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	expected := &MountMap{
+		Links: []*Link{
+			{Source: "gs://bucket/a", Path: "a", Generation: 5},
+			{Source: "gs://public/*.txt", Path: "txt", Anonymous: true},
+		},
+		Buckets:     map[string]*remote.BucketOptions{"pays": {UserProject: "billing"}},
+		LabelBucket: "labels",
+		LabelPrefix: "team",
+	}
+
+	jsonPath := path.Join(dir, "map.json")
+	require.Nil(ioutil.WriteFile(jsonPath, []byte(`{"links": [
+		{"source": "gs://bucket/a", "path": "a", "generation": 5},
+		{"source": "gs://public/*.txt", "path": "txt", "anonymous": true}],
+	"buckets": {"pays": {"user_project": "billing"}},
+	"label_bucket": "labels", "label_prefix": "team"}`), 0600))
+	mm, err := parseMap(jsonPath)
+	require.Nil(err)
+	require.Equal(expected, mm)
+
+	yamlPath := path.Join(dir, "map.yaml")
+	require.Nil(ioutil.WriteFile(yamlPath, []byte(`links:
+  - source: gs://bucket/a
+    path: a
+    generation: 5
+  - source: gs://public/*.txt
+    path: txt
+    anonymous: true
+buckets:
+  pays:
+    user_project: billing
+label_bucket: labels
+label_prefix: team
+`), 0600))
+	mm, err = parseMap(yamlPath)
+	require.Nil(err)
+	require.Equal(expected, mm)
+
+	// only maps with labels need the label bucket
+	require.False(mapUsesLabels(mm))
+	require.True(mapUsesLabels(&MountMap{Root: "pufs:///ref"}))
+	require.True(mapUsesLabels(&MountMap{Links: []*Link{{Source: "pufs:///ref@2", Path: "r"}}}))
+
+	// misspelt options are errors rather than being ignored
+	require.Nil(ioutil.WriteFile(jsonPath, []byte(`{"links": [{"source": "gs://bucket/a", "path": "a", "generaton": 5}]}`), 0600))
+	_, err = parseMap(jsonPath)
+	require.NotNil(err)
+	require.Nil(ioutil.WriteFile(yamlPath, []byte("links:\n  - source: gs://bucket/a\n    pth: a\n"), 0600))
+	_, err = parseMap(yamlPath)
+	require.NotNil(err)
+}
+
+func TestValidateMap(t *testing.T) {
+	require := require.New(t)
+
+	valid := &MountMap{Links: []*Link{
+		{Source: "gs://bucket/a", Path: "files/a", Generation: 3},
+		{Source: "gs://bucket/dir/", Path: "dir"},
+		{Source: "gs://bucket/*.txt", Path: "files"},
+		{Source: "gs://bucket/other/*.txt", Path: "files"},
+		{Source: "https://example.com/b", Path: "b", ETag: "abc"},
+		{Source: "pufs:///label@2", Path: "data"},
+	}}
+	require.Nil(validateMap(valid))
+
+	invalid := &MountMap{Root: "/tmp/x", Links: []*Link{
+		{Source: "s3://bucket/a", Path: "a"},
+		{Source: "gs://bucket/b", Path: "/b"},
+		{Source: "gs://bucket/c", Path: "x/../c"},
+		{Source: "gs://bucket/d/", Path: "d", Generation: 1},
+		{Source: "gs://bucket/e", Path: "e", ETag: "abc"},
+		{Source: "gs://bucket/f[", Path: "f"},
+		{Source: "gs://bucket/g", Path: "g"},
+		{Source: "gs://bucket/g2", Path: "g"},
+		{Source: "gs://bucket/h", Path: "g/h"},
+		{Source: "gs://bucket/i", Path: ".pufs/i"},
+		{Source: "https://example.com/j", Path: "j", Anonymous: true},
+	}}
+	err := validateMap(invalid)
+	require.NotNil(err)
+	// every problem is reported, not just the first
+	problems := strings.Split(err.Error(), "\n")
+	require.Len(problems, 11, err.Error())
+	require.Contains(problems[0], "root /tmp/x")
+	require.Contains(err.Error(), "g/h is inside g")
+}
+
+func TestPlanAndApplyMap(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	client := &fakeMapClient{fakeNetworkClient: fakeNetworkClient{generation: 1},
+		objects: []string{"data/1/x.txt", "data/1/y.csv", "data/2/x.txt", "data/2/", "other/x.txt"}}
+
+	mm := &MountMap{Links: []*Link{
+		{Source: "gs://bucket/data/*/x.txt", Path: "in/x"},
+		{Source: "gs://bucket/a", Path: "in/a", Generation: 1},
+		{Source: "https://example.com/b", Path: "b"},
+		{Source: "gs://bucket/dir/", Path: "dir"},
+	}}
+	require.Nil(validateMap(mm))
+	links, err := planMap(ctx, mm, client)
+	require.Nil(err)
+	require.Equal([]*plannedLink{
+		{Path: "b", Source: "https://example.com/b"},
+		{Path: "dir", Source: "gs://bucket/dir/"},
+		{Path: "in/a", Source: "gs://bucket/a", Generation: 1},
+		{Path: "in/x/1/x.txt", Source: "gs://bucket/data/1/x.txt"},
+		{Path: "in/x/2/x.txt", Source: "gs://bucket/data/2/x.txt"},
+	}, links)
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	ds.SetClients(&client.fakeNetworkClient)
+	dir := newTestRepoDir(require)
+	require.Nil(applyMap(ctx, ds, dir, links))
+	in, err := ds.GetNodeID(ctx, core.RootINode, "in")
+	require.Nil(err)
+	x, err := ds.GetNodeID(ctx, in, "x")
+	require.Nil(err)
+	two, err := ds.GetNodeID(ctx, x, "2")
+	require.Nil(err)
+	_, err = ds.GetNodeID(ctx, two, "x.txt")
+	require.Nil(err)
+
+	// pins which don't match, globs which match nothing, and globs which collide are all reported
+	mm = &MountMap{Links: []*Link{
+		{Source: "gs://bucket/a", Path: "a", Generation: 2},
+		{Source: "https://example.com/b", Path: "b", ETag: "y"},
+		{Source: "gs://bucket/missing/*", Path: "c"},
+		{Source: "gs://bucket/data/1/*.txt", Path: "d"},
+		{Source: "gs://bucket/other/*.txt", Path: "d"},
+	}}
+	require.Nil(validateMap(mm))
+	_, err = planMap(ctx, mm, client)
+	require.NotNil(err)
+	problems := strings.Split(err.Error(), "\n")
+	require.Len(problems, 4, err.Error())
+	require.Contains(problems[0], "not 2")
+	require.Contains(problems[1], "not y")
+	require.Contains(problems[2], "matched nothing")
+	require.Contains(problems[3], "d/x.txt")
+}
+
+func TestReconcileMap(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	pusher := newTestDataStore(require, repo)
+	_, err := pusher.MakeDir(ctx, core.RootINode, "v1")
+	require.Nil(err)
+	require.Nil(pusher.Push(ctx, core.RootINode, "ref"))
+	_, err = pusher.MakeDir(ctx, core.RootINode, "v2")
+	require.Nil(err)
+	require.Nil(pusher.Push(ctx, core.RootINode, "ref"))
+	v2, err := pusher.ResolveLabel(ctx, "ref")
+	require.Nil(err)
+
+	ds := newTestDataStore(require, repo)
+	ds.SetClients(&fakeNetworkClient{generation: 1})
+	dir := newTestRepoDir(require)
+	require.Nil(applyMap(ctx, ds, dir, []*plannedLink{
+		{Path: "a", Source: "gs://bucket/a"},
+		{Path: "dir/u", Source: "https://example.com/u"},
+		{Path: "label", Source: "pufs:///ref@1"},
+		{Path: "old", Source: "gs://bucket/old"},
+	}))
+	_, err = ds.MakeDir(ctx, core.RootINode, "local")
+	require.Nil(err)
+	// links which weren't made by a map are never pruned
+	_, err = addRemoteSource(ctx, ds, core.RootINode, "manual", "gs://bucket/manual")
+	require.Nil(err)
+
+	links := []*plannedLink{
+		{Path: "a", Source: "gs://bucket/a2"},
+		{Path: "dir/u", Source: "https://example.com/u"},
+		{Path: "label", Source: "pufs:///ref"},
+		{Path: "new/n", Source: "gs://bucket/n"},
+		{Path: "local", Source: "gs://bucket/l"},
+	}
+	actions := func(changes []*mapChange) map[string]string {
+		byPath := make(map[string]string)
+		for _, c := range changes {
+			byPath[c.Path] = c.Action
+		}
+		return byPath
+	}
+
+	// local directories aren't replaced, and a conflict stops anything changing
+	changes, applied, err := reconcileMap(ctx, ds, dir, links, reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.False(applied)
+	require.Equal(map[string]string{"a": updateChange, "label": updateChange, "new/n": addChange,
+		"local": conflictChange, "old": removeChange}, actions(changes))
+	_, err = ds.GetNodeID(ctx, core.RootINode, "old")
+	require.Nil(err)
+
+	// a dry run lists the changes without making them
+	links = links[:4]
+	changes, applied, err = reconcileMap(ctx, ds, dir, links, reconcileOptions{Prune: true, DryRun: true})
+	require.Nil(err)
+	require.False(applied)
+	require.Len(changes, 4)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "old")
+	require.Nil(err)
+
+	_, applied, err = reconcileMap(ctx, ds, dir, links, reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.True(applied)
+	a, err := ds.GetNodeID(ctx, core.RootINode, "a")
+	require.Nil(err)
+	node, err := ds.GetAttr(ctx, a)
+	require.Nil(err)
+	require.Equal("a2", node.RemoteSource.(*core.GCSObjectSource).Key)
+	mounts := ds.GetMounts()
+	require.Len(mounts, 1)
+	require.Equal("label", mounts[0].Path)
+	require.Equal(v2, mounts[0].BID)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "old")
+	require.Equal(core.NoSuchNodeErr, err)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "local")
+	require.Nil(err)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "manual")
+	require.Nil(err)
+
+	// applying again changes nothing
+	changes, _, err = reconcileMap(ctx, ds, dir, links, reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.Empty(changes)
+
+	// changes made to a mounted label are kept while the label is still in the map, but aren't discarded unless forced
+	label, err := ds.GetNodeID(ctx, core.RootINode, "label")
+	require.Nil(err)
+	_, err = ds.MakeDir(ctx, label, "scratch")
+	require.Nil(err)
+	changes, _, err = reconcileMap(ctx, ds, dir, links, reconcileOptions{Prune: true, DryRun: true})
+	require.Nil(err)
+	require.Empty(changes)
+	changes, applied, err = reconcileMap(ctx, ds, dir, links[:2], reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.False(applied)
+	require.Equal(map[string]string{"label": conflictChange, "new/n": removeChange}, actions(changes))
+	_, applied, err = reconcileMap(ctx, ds, dir, links[:2], reconcileOptions{Prune: true, Force: true})
+	require.Nil(err)
+	require.True(applied)
+	require.Empty(ds.GetMounts())
+}
+
+// gcsRootRefFactory lists a fixed set of files for any gs:// directory, and otherwise reads blocks from the repo
+type gcsRootRefFactory struct {
+	core.RemoteRefFactory2
+	files []*core.RemoteFile
+}
+
+type gcsRootRef struct {
+	core.RemoteRef
+	files []*core.RemoteFile
+}
+
+func (r *gcsRootRef) GetChildNodes(ctx context.Context) ([]*core.RemoteFile, error) {
+	return r.files, nil
+}
+
+func (f *gcsRootRefFactory) GetRef(source interface{}) core.RemoteRef {
+	if _, ok := source.(*core.GCSObjectSource); ok {
+		return &gcsRootRef{files: f.files}
+	}
+	return f.RemoteRefFactory2.GetRef(source)
+}
+
+func TestPruneGCSRoot(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	// every entry in a repo with a gs:// root is lazily listed with a remote source
+	repo := core.NewRemoteRefFactoryMem()
+	files := []*core.RemoteFile{
+		{Name: "data.csv", Size: 10, RemoteSource: &core.GCSObjectSource{Bucket: "bucket", Key: "root/data.csv", Generation: 1}},
+		{Name: "sub", IsDir: true, RemoteSource: &core.GCSObjectSource{Bucket: "bucket", Key: "root/sub"}},
+	}
+	storage, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := core.NewDataStore(storage, repo, &gcsRootRefFactory{core.NewMemRemoteRefFactory2(repo), files},
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}),
+		core.DataStoreWithGCSRoot("bucket", "root"))
+	require.Nil(err)
+	ds.SetClients(&fakeNetworkClient{generation: 1})
+
+	dir := newTestRepoDir(require)
+	require.Nil(applyMap(ctx, ds, dir, []*plannedLink{{Path: "linked", Source: "gs://other/x"}}))
+
+	// pruning with an empty map only removes what the map linked, not the root's own contents
+	changes, applied, err := reconcileMap(ctx, ds, dir, nil, reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.True(applied)
+	require.Len(changes, 1)
+	require.Equal(removeChange, changes[0].Action)
+	require.Equal("linked", changes[0].Path)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "data.csv")
+	require.Nil(err)
+	_, err = ds.GetNodeID(ctx, core.RootINode, "sub")
+	require.Nil(err)
+
+	// and having pruned it, there's nothing left to prune
+	changes, _, err = reconcileMap(ctx, ds, dir, nil, reconcileOptions{Prune: true})
+	require.Nil(err)
+	require.Empty(changes)
+}
+
+func TestMapPinsCheckedWhenLinking(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	network := &fakeNetworkClient{generation: 1}
+	ds := newTestDataStore(require, core.NewRemoteRefFactoryMem())
+	ds.SetClients(network)
+	dir := newTestRepoDir(require)
+
+	// the object may have changed between planning and linking, so the pin is checked again when the link is made
+	err := applyMap(ctx, ds, dir, []*plannedLink{{Path: "p", Source: "gs://bucket/p", Generation: 2}})
+	require.NotNil(err)
+	require.Contains(err.Error(), core.SourceChangedErr.Error())
+	err = applyMap(ctx, ds, dir, []*plannedLink{{Path: "u", Source: "https://example.com/u", ETag: "y"}})
+	require.NotNil(err)
+
+	links := []*plannedLink{{Path: "p", Source: "gs://bucket/p", Generation: 1}}
+	require.Nil(applyMap(ctx, ds, dir, links))
+
+	// and a link whose pin has moved on is relinked at the new version
+	network.generation = 2
+	links[0].Generation = 2
+	changes, applied, err := reconcileMap(ctx, ds, dir, links, reconcileOptions{})
+	require.Nil(err)
+	require.True(applied)
+	require.Len(changes, 1)
+	require.Equal(updateChange, changes[0].Action)
+	p, err := ds.GetNodeID(ctx, core.RootINode, "p")
+	require.Nil(err)
+	node, err := ds.GetAttr(ctx, p)
+	require.Nil(err)
+	require.Equal(int64(2), node.RemoteSource.(*core.GCSObjectSource).Generation)
+
+	changes, _, err = reconcileMap(ctx, ds, dir, links, reconcileOptions{})
+	require.Nil(err)
+	require.Empty(changes)
+}
@@ -0,0 +1,78 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge [repo] [base] [a] [b]",
+	Short: "Combine the changes made to two trees since a common base",
+	Long: `Combine the changes made since base in a and in b, where each can be a label (optionally label@N or
+label@<timestamp>) or a block ID. Use "none" for base if a and b have no common ancestor. Paths which were changed
+differently on each side are listed as conflicts and nothing is merged. Otherwise the block ID of the merged tree is
+printed, and if --label is given, that label is pushed to point at it.`,
+	Args: cobra.ExactArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		label, err := cmd.Flags().GetString("label")
+		if err != nil {
+			panic(err)
+		}
+		message, err := cmd.Flags().GetString("message")
+		if err != nil {
+			panic(err)
+		}
+
+		base := args[1]
+		if base == "none" {
+			base = ""
+		}
+
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.Merge(context.Background(), &api.MergeRequest{Base: base, A: args[2], B: args[3], Label: label, Message: message})
+		if err != nil {
+			log.Fatalf("Could not merge %s and %s: %s", args[2], args[3], errorMessage(err))
+		}
+
+		if len(resp.Conflicts) > 0 {
+			for _, c := range resp.Conflicts {
+				fmt.Printf("%s %s\n", c.Kind, c.Path)
+			}
+			fmt.Fprintf(os.Stderr, "%d conflicts, nothing was merged\n", len(resp.Conflicts))
+			closeClient()
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s\n", base64x(toBID(resp.BlockID)))
+		if label != "" {
+			log.Printf("Pushed merged tree to %s", label)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().String("label", "", "Push the merged tree to this label")
+	mergeCmd.Flags().StringP("message", "m", "", "Message to record in the label's history")
+}
@@ -0,0 +1,44 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+// mkdirCmd represents the mkdir command
+var mkdirCmd = &cobra.Command{
+	Use:   "mkdir [path]",
+	Short: "Create a directory in a repo or mount",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		_, err := client.Mkdir(ctx, &api.MkdirRequest{Path: remainingPath})
+		if err != nil {
+			log.Fatalf("Could not create %s: %s", args[0], errorMessage(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mkdirCmd)
+}
@@ -3,25 +3,28 @@ package cmd
 import (
 	"context"
 	"encoding/gob"
-	"fmt"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path"
 	"regexp"
 	"runtime/trace"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/magiconair/properties"
 
-	"cloud.google.com/go/storage"
 	"github.com/pgm/sply2"
 	"github.com/pgm/sply2/api"
 	"github.com/pgm/sply2/core"
 	"github.com/pgm/sply2/fs"
 	"github.com/pgm/sply2/remote"
-	"google.golang.org/api/option"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
 
 	"github.com/spf13/cobra"
@@ -60,7 +63,102 @@ var mountCmd = &cobra.Command{
 			}
 		}
 
-		ds, repoInfo := openExistingDataStore(repoPath)
+		reportQuota, err := cmd.Flags().GetBool("report-quota")
+		if err != nil {
+			panic(err)
+		}
+
+		var serverOptions []fs.ServerOption
+		if reportQuota {
+			serverOptions = append(serverOptions, fs.ReportCacheQuota())
+		}
+
+		readOnly, err := cmd.Flags().GetBool("read-only")
+		if err != nil {
+			panic(err)
+		}
+		if readOnly {
+			serverOptions = append(serverOptions, fs.ReadOnly())
+		}
+
+		allowOther, err := cmd.Flags().GetBool("allow-other")
+		if err != nil {
+			panic(err)
+		}
+		if allowOther {
+			serverOptions = append(serverOptions, fs.AllowOther())
+		}
+
+		uid, err := cmd.Flags().GetInt("uid")
+		if err != nil {
+			panic(err)
+		}
+		gid, err := cmd.Flags().GetInt("gid")
+		if err != nil {
+			panic(err)
+		}
+		if uid >= 0 || gid >= 0 {
+			if uid < 0 || gid < 0 {
+				log.Fatalf("--uid and --gid must be specified together")
+			}
+			serverOptions = append(serverOptions, fs.Owner(uint32(uid), uint32(gid)))
+		}
+
+		umaskStr, err := cmd.Flags().GetString("umask")
+		if err != nil {
+			panic(err)
+		}
+		umask, err := strconv.ParseUint(umaskStr, 8, 32)
+		if err != nil || umask > 0777 {
+			log.Fatalf("Invalid umask: %s", umaskStr)
+		}
+		serverOptions = append(serverOptions, fs.Umask(os.FileMode(umask)))
+
+		pushOnExit, err := cmd.Flags().GetString("push-on-exit")
+		if err != nil {
+			panic(err)
+		}
+
+		metricsAddr, err := cmd.Flags().GetString("metrics-addr")
+		if err != nil {
+			panic(err)
+		}
+
+		prefetch, err := cmd.Flags().GetBool("prefetch")
+		if err != nil {
+			panic(err)
+		}
+		prefetchConcurrency, err := cmd.Flags().GetInt("prefetch-concurrency")
+		if err != nil {
+			panic(err)
+		}
+		prefetchBudget, err := cmd.Flags().GetInt64("prefetch-budget")
+		if err != nil {
+			panic(err)
+		}
+
+		readaheadBudget, err := cmd.Flags().GetInt64("readahead-budget")
+		if err != nil {
+			panic(err)
+		}
+		maxFetches, err := cmd.Flags().GetInt("max-fetches")
+		if err != nil {
+			panic(err)
+		}
+		fetchBandwidth, err := cmd.Flags().GetInt64("fetch-bandwidth")
+		if err != nil {
+			panic(err)
+		}
+
+		// check before opening the repo, as its databases can't be opened while another process has them open
+		info, err := pingService(getSocketAddress(repoPath))
+		if err == nil {
+			log.Fatalf("%s is already mounted at %s by pid %d", repoPath, info.MountPoint, info.Pid)
+		}
+
+		events := core.NewEventBroker()
+		ds, repoInfo := openDataStore(repoPath, core.OpenExisting(), core.WithMonitor(events),
+			core.ReadaheadBudget(readaheadBudget), core.MaxConcurrentFetches(maxFetches), core.FetchBandwidth(fetchBandwidth))
 
 		ticker := time.NewTicker(5 * time.Second)
 
@@ -80,22 +178,122 @@ var mountCmd = &cobra.Command{
 			log.Fatalf("failed to listen: %v", err)
 		}
 
+		if metricsAddr != "" {
+			serveMetrics(metricsAddr, ds)
+		}
+
 		grpcServer := grpc.NewServer()
-		api.RegisterPufsServer(grpcServer, &apiService{ds: ds})
+		api.RegisterPufsServer(grpcServer, newAPIService(ds, repoPath, mountPoint, events))
 		go grpcServer.Serve(lis)
 
-		fs.Mount(mountPoint, ds)
+		// keep the leases on whatever is mounted from expiring while the repo is in use
+		leaseCtx, stopLeaseRenewal := context.WithCancel(context.Background())
+		go ds.RenewLeasesPeriodically(leaseCtx, core.LEASE_RENEWAL_CHECK_INTERVAL)
+
+		server, err := fs.Mount(mountPoint, ds, serverOptions...)
+		if err != nil {
+			log.Fatalf("Could not mount %s: %s", mountPoint, err)
+		}
+
+		var prefetcher *fs.Prefetcher
+		if prefetch {
+			prefetcher = fs.StartMonitor(ds, events, fs.ListConcurrency(prefetchConcurrency),
+				fs.FetchConcurrency(prefetchConcurrency), fs.FetchBudget(prefetchBudget))
+		}
+
+		go shutdownOnSignal(server)
+
+		serveErr := server.Serve()
+		if serveErr != nil {
+			log.Printf("Error serving %s: %s", mountPoint, serveErr)
+		}
+
 		ticker.Stop()
+		stopLeaseRenewal()
+		if prefetcher != nil {
+			prefetcher.Stop()
+		}
+		grpcServer.Stop()
+		os.Remove(repoInfo.socketAddress)
+
+		var pushErr error
+		if pushOnExit != "" {
+			log.Printf("Pushing to %s", pushOnExit)
+			pushErr = ds.Push(context.Background(), core.RootINode, pushOnExit)
+			if pushErr != nil {
+				log.Printf("Push to %s failed: %s", pushOnExit, pushErr)
+			}
+		}
+
+		ds.Close()
+
 		trace.Stop()
 		if traceFd != nil {
 			traceFd.Close()
 		}
+
+		// exit with an error if serving or the final push failed
+		if serveErr != nil || pushErr != nil {
+			os.Exit(1)
+		}
 	},
 }
 
+// shutdownOnSignal waits for SIGINT or SIGTERM and then cleanly unmounts. A second signal exits immediately.
+func shutdownOnSignal(server *fs.Server) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	sig := <-signals
+	log.Printf("Received %s, shutting down", sig)
+
+	go func() {
+		sig := <-signals
+		log.Fatalf("Received %s while shutting down, exiting immediately", sig)
+	}()
+
+	err := server.Shutdown()
+	if err != nil {
+		log.Printf("Could not unmount: %s", err)
+	}
+}
+
+// serveMetrics exports prometheus metrics for ds (along with those collected by the core and fs packages) over
+// http at addr, in the background
+func serveMetrics(addr string, ds *core.DataStore) {
+	prometheus.MustRegister(core.NewDataStoreCollector(ds))
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("Could not listen for metrics requests on %s: %s", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		err := http.Serve(lis, mux)
+		log.Printf("Metrics server stopped: %s", err)
+	}()
+	log.Printf("Serving metrics at http://%s/metrics", lis.Addr())
+}
+
 func init() {
 	rootCmd.AddCommand(mountCmd)
 	mountCmd.Flags().String("trace", "", "Write execution trace to specified file")
+	mountCmd.Flags().String("push-on-exit", "", "After unmounting, freeze and push the repo to this label")
+	mountCmd.Flags().Bool("read-only", false, "Reject all changes to the filesystem")
+	mountCmd.Flags().Bool("allow-other", false, "Allow users other than the one mounting to access the filesystem (permissions are enforced by the kernel)")
+	mountCmd.Flags().Int("uid", -1, "Report files as owned by this uid (defaults to the current user)")
+	mountCmd.Flags().Int("gid", -1, "Report files as owned by this gid (defaults to the current user's group)")
+	mountCmd.Flags().String("umask", "0002", "Permission bits (in octal) to clear from the mode of all files")
+	mountCmd.Flags().Bool("prefetch", false, "List directories and fetch files in the background when the access pattern suggests they'll be needed")
+	mountCmd.Flags().Int("prefetch-concurrency", 4, "The number of listings or files to fetch at once with --prefetch")
+	mountCmd.Flags().Int64("prefetch-budget", 100*1024*1024, "The maximum number of bytes to fetch from each file with --prefetch")
+	mountCmd.Flags().Int64("readahead-budget", 0, "The maximum rate (in bytes per second) to fetch data ahead of reads, shared by all open files (0 for unlimited)")
+	mountCmd.Flags().Int("max-fetches", core.DefaultMaxConcurrentFetches, "The maximum number of requests to the remote to run at once (0 for unlimited)")
+	mountCmd.Flags().Int64("fetch-bandwidth", 0, "The maximum rate (in bytes per second) to copy data from the remote (0 for unlimited). Reads which are blocked take priority over readahead and prefetching.")
+	mountCmd.Flags().String("metrics-addr", "", "Serve prometheus metrics at /metrics on this address (ie: localhost:9100)")
+	mountCmd.Flags().Bool("report-quota", false, "Report the repo's cache quota as the size of the filesystem (ie: in df) instead of the size of the underlying disk")
 
 	// Here you will define your flags and configuration settings.
 
@@ -107,39 +305,6 @@ func init() {
 	// mountCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
-type apiService struct {
-	ds *core.DataStore
-}
-
-func (s *apiService) GetDirContents(ctx context.Context, req *api.DirContentsRequest) (*api.DirContentsResponse, error) {
-	fmt.Printf("GetDirContents\n")
-	inode, err := s.ds.GetINodeForPath(ctx, req.Path)
-	if err != nil {
-		return &api.DirContentsResponse{ErrorMsg: err.Error()}, nil
-	}
-
-	srcEntries, err := s.ds.GetExtendedDirContents(ctx, inode)
-	if err != nil {
-		return &api.DirContentsResponse{ErrorMsg: err.Error()}, nil
-	}
-
-	dstEntries := make([]*api.DirContentsResponse_Entry, len(srcEntries))
-	for i, src := range srcEntries {
-		dstEntries[i] = &api.DirContentsResponse_Entry{
-			ID:                   int64(src.ID),
-			Name:                 src.Name,
-			IsDirty:              src.IsDirty,
-			IsDir:                src.IsDir,
-			Size:                 src.Size,
-			ModTimeSeconds:       src.ModTime.Unix(),
-			BlockID:              src.BID[:],
-			PopulatedRegionCount: int32(src.PopulatedRegionCount),
-			PopulatedSize:        src.PopulatedSize}
-	}
-
-	return &api.DirContentsResponse{Entries: dstEntries}, nil
-}
-
 // type NewDataStoreOptions struct {
 // 	mountAsRoot           string
 // 	dsOptions             []core.DataStoreOption
@@ -172,6 +337,8 @@ type repoInfo struct {
 	bucketName            string
 	keyPrefix             string
 	maxBackgroundTransfer int
+	cacheQuota            int64
+	generationPolicy      core.GenerationPolicy
 }
 
 func getSocketAddress(dir string) string {
@@ -180,6 +347,13 @@ func getSocketAddress(dir string) string {
 	return p.MustGetString("socketAddress")
 }
 
+// getInfoType returns whether dir is the root of a "repo" or a "mount"
+func getInfoType(dir string) string {
+	pufsInfoPath := path.Join(dir, PufsInfoFilename)
+	p := properties.MustLoadFile(pufsInfoPath, properties.UTF8)
+	return p.MustGetString("type")
+}
+
 func loadRepoInfo(dir string) *repoInfo {
 	pufsInfoPath := path.Join(dir, PufsInfoFilename)
 	p := properties.MustLoadFile(pufsInfoPath, properties.UTF8)
@@ -187,7 +361,9 @@ func loadRepoInfo(dir string) *repoInfo {
 		bucketName:            p.MustGetString("bucketName"),
 		keyPrefix:             p.MustGetString("keyPrefix"),
 		maxBackgroundTransfer: p.MustGetInt("maxBackgroundTransfer"),
-		socketAddress:         p.MustGetString("socketAddress")}
+		socketAddress:         p.MustGetString("socketAddress"),
+		cacheQuota:            p.GetInt64("cacheQuota", 0),
+		generationPolicy:      core.GenerationPolicy(p.GetString("generationPolicy", string(core.StaleOnChange)))}
 	// read config to use from info file
 	// f, err := os.Open(pufsInfoPath)
 	// if err != nil {
@@ -203,17 +379,31 @@ func openExistingDataStore(dir string) (*core.DataStore, *repoInfo) {
 func openDataStore(dir string, dsOptions ...core.DataStoreOption) (*core.DataStore, *repoInfo) {
 
 	repoInfo := loadRepoInfo(dir)
+	dsOptions = append(dsOptions, core.CacheQuota(repoInfo.cacheQuota),
+		core.MaxBackgroundTransfer(int64(repoInfo.maxBackgroundTransfer)),
+		core.WithGenerationPolicy(repoInfo.generationPolicy))
 
 	ctx := context.Background()
 
 	// Creates a client.
-	client, err := storage.NewClient(ctx, option.WithServiceAccountFile(repoInfo.credentialsPath))
+	client, err := remote.NewGCSClient(ctx, repoInfo.credentialsPath)
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
 
 	remoteRefFactory := remote.NewRemoteRefFactory(client, repoInfo.bucketName, repoInfo.keyPrefix)
 
+	bucketOptions, err := loadBucketOptions(dir)
+	if err != nil {
+		log.Fatalf("Could not load bucket options: %v", err)
+	}
+	for bucket, options := range bucketOptions {
+		err = remoteRefFactory.SetBucketOptions(ctx, bucket, options)
+		if err != nil {
+			log.Fatalf("Could not configure access to bucket %s: %v", bucket, err)
+		}
+	}
+
 	ds, err := core.NewDataStore(dir, remoteRefFactory, remoteRefFactory,
 		sply2.NewBoltDB(path.Join(dir, "freezer.db"),
 			[][]byte{core.ChunkStat}),
@@ -234,6 +424,8 @@ func openDataStore(dir string, dsOptions ...core.DataStoreOption) (*core.DataSto
 
 func GobRegisterTypes() {
 	var x *core.GCSObjectSource
+	var u *core.URLSource
 	gob.Register(core.BlockID{})
 	gob.Register(x)
+	gob.Register(u)
 }
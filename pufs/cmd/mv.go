@@ -0,0 +1,56 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+// mvCmd represents the mv command
+var mvCmd = &cobra.Command{
+	Use:   "mv [src] [dst]",
+	Short: "Rename a file or directory within a repo or mount",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		srcRepoPath, srcPath, err := findPufsRoot(args[0])
+		if err != nil {
+			log.Fatalf("Could not find pufs repo: %s", err)
+		}
+		dstRepoPath, dstPath, err := findPufsRoot(args[1])
+		if err != nil {
+			log.Fatalf("Could not find pufs repo: %s", err)
+		}
+		if srcRepoPath != dstRepoPath {
+			log.Fatalf("%s and %s are not in the same repo", args[0], args[1])
+		}
+
+		client, closeClient := getRepoClient(srcRepoPath)
+		defer closeClient()
+
+		ctx := context.Background()
+		_, err = client.Rename(ctx, &api.RenameRequest{SrcPath: srcPath, DstPath: dstPath})
+		if err != nil {
+			log.Fatalf("Could not rename %s: %s", args[0], errorMessage(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
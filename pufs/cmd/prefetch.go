@@ -0,0 +1,46 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+// prefetchCmd represents the prefetch command
+var prefetchCmd = &cobra.Command{
+	Use:   "prefetch [path]",
+	Short: "Copy everything under a path into the local cache",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, remainingPath, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		resp, err := client.Prefetch(ctx, &api.PrefetchRequest{Path: remainingPath})
+		if err != nil {
+			log.Fatalf("Could not prefetch %s: %s", args[0], errorMessage(err))
+		}
+		fmt.Printf("%s cached\n", fmtNum(resp.Size))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(prefetchCmd)
+}
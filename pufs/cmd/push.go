@@ -16,39 +16,71 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"log"
 
+	"github.com/pgm/sply2/api"
 	"github.com/pgm/sply2/core"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // pushCmd represents the push command
 var pushCmd = &cobra.Command{
-	Use:   "push",
-	Short: "A brief description of your command",
-	Long: `A longer description that spans multiple lines and likely contains examples
-and usage of using your command. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	Args: cobra.ExactArgs(2),
+	Use:   "push [repo] [label]",
+	Short: "Freeze the repo, upload any new blocks and point the label at the result",
+	Args:  cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		repoPath := args[0]
 		label := args[1]
+		message, err := cmd.Flags().GetString("message")
+		if err != nil {
+			panic(err)
+		}
+		expect, err := cmd.Flags().GetString("expect")
+		if err != nil {
+			panic(err)
+		}
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			panic(err)
+		}
+
+		req := &api.PushRequest{Path: ".", Label: label, Message: message, Force: force}
+		if expect == "none" {
+			req.ExpectedBlockID = core.NABlock[:]
+		} else if expect != "" {
+			BID, err := parseBID(expect)
+			if err != nil {
+				log.Fatalf("Invalid --expect: %s", err)
+			}
+			req.ExpectedBlockID = BID[:]
+		}
+
+		client, closeClient := getRepoClient(repoPath)
+		defer closeClient()
 
-		ds, _ := openDataStore(repoPath)
 		ctx := context.Background()
-		err := ds.Push(ctx, core.RootINode, label)
+		resp, err := client.Push(ctx, req)
+		if s, ok := status.FromError(err); ok && s.Code() == codes.Aborted {
+			log.Fatalf("Could not push to %s: %s. Check pufs log, then retry with --expect or --force.", label, s.Message())
+		}
 		if err != nil {
-			log.Fatal(err)
+			log.Fatalf("Could not push to %s: %s", label, errorMessage(err))
 		}
-		ds.Close()
+
+		var BID core.BlockID
+		copy(BID[:], resp.BlockID)
+		fmt.Printf("Pushed %s as %s\n", base64x(BID), label)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(pushCmd)
+	pushCmd.Flags().StringP("message", "m", "", "Message to record in the label's history")
+	pushCmd.Flags().String("expect", "", "Only push if the label currently points to this block ID (or \"none\" if the label must not exist yet)")
+	pushCmd.Flags().Bool("force", false, "Move the label even if another push moved it since this one started")
 
 	// Here you will define your flags and configuration settings.
 
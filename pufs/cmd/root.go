@@ -23,6 +23,10 @@ import (
 
 // var cfgFile string
 
+// Version is reported by the pufs service. Set at build time via
+// -ldflags "-X github.com/pgm/sply2/pufs/cmd.Version=..."
+var Version = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "pufs",
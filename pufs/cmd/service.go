@@ -0,0 +1,840 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type apiService struct {
+	ds         *core.DataStore
+	repoPath   string
+	mountPoint string
+	startTime  time.Time
+	// the source of events for WatchEvents. nil if the repo isn't mounted.
+	events *core.EventBroker
+}
+
+// the number of events which can be queued up for a WatchEvents stream before events start being dropped
+const watchEventsBufferSize = 10000
+
+func newAPIService(ds *core.DataStore, repoPath string, mountPoint string, events *core.EventBroker) *apiService {
+	absRepoPath, err := filepath.Abs(repoPath)
+	if err == nil {
+		repoPath = absRepoPath
+	}
+	if mountPoint != "" {
+		absMountPoint, err := filepath.Abs(mountPoint)
+		if err == nil {
+			mountPoint = absMountPoint
+		}
+	}
+	return &apiService{ds: ds, repoPath: repoPath, mountPoint: mountPoint, startTime: time.Now(), events: events}
+}
+
+// toStatusError converts errors from the DataStore into grpc errors with a code describing what went wrong, so
+// that clients can tell (for example) a missing file from an internal failure
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := codes.Internal
+	switch err {
+	case core.NoSuchNodeErr, core.NoSuchMountErr, core.UndefinedRootErr, core.ParentMissingErr, core.UnknownBlockID,
+		core.UnknownLabelVersionErr, core.NoSuchSnapshotErr:
+		code = codes.NotFound
+	case core.ExistsErr, core.AlreadyMountPointErr, core.SnapshotExistsErr:
+		code = codes.AlreadyExists
+	case core.InvalidFilenameErr, core.InvalidCharFilenameErr, core.InvalidLabelVersionErr:
+		code = codes.InvalidArgument
+	case core.NotDirErr, core.IsDirErr, core.DirNotEmptyErr, core.NotWritableErr, core.NotEvictableErr,
+		core.InSnapshotErr, core.MountChangedErr, core.SourceChangedErr:
+		code = codes.FailedPrecondition
+	case core.BlockInUseErr:
+		code = codes.Unavailable
+	case core.LabelConflictErr:
+		code = codes.Aborted
+	case core.INodesExhaustedErr:
+		code = codes.ResourceExhausted
+	case core.NoNetworkClientErr:
+		code = codes.Unimplemented
+	case context.Canceled:
+		code = codes.Canceled
+	case context.DeadlineExceeded:
+		code = codes.DeadlineExceeded
+	}
+
+	if class, ok := core.GetRemoteErrClass(err); ok {
+		switch class {
+		case core.RemoteTransient, core.RemoteThrottled:
+			code = codes.Unavailable
+		case core.RemoteNotFound:
+			code = codes.NotFound
+		case core.RemotePreconditionFailed:
+			code = codes.FailedPrecondition
+		case core.RemotePermissionDenied:
+			code = codes.PermissionDenied
+		}
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// cleanRepoPath normalizes a path within the repo (ie: "a/b", "/a/b" or "./a/b") to the form used by
+// DataStore.GetINodeForPath
+func cleanRepoPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+func (s *apiService) lookup(ctx context.Context, p string) (core.INode, error) {
+	inode, err := s.ds.GetINodeForPath(ctx, cleanRepoPath(p))
+	if err != nil {
+		return core.InvalidINode, toStatusError(err)
+	}
+	return inode, nil
+}
+
+// lookupParent finds the directory which contains p, and the name of p within that directory
+func (s *apiService) lookupParent(ctx context.Context, p string) (core.INode, string, error) {
+	p = cleanRepoPath(p)
+	if p == "." {
+		return core.InvalidINode, "", status.Error(codes.InvalidArgument, "Path cannot be the root of the repo")
+	}
+
+	parent, err := s.lookup(ctx, path.Dir(p))
+	if err != nil {
+		return core.InvalidINode, "", err
+	}
+	return parent, path.Base(p), nil
+}
+
+func (s *apiService) GetServiceInfo(ctx context.Context, req *api.ServiceInfoRequest) (*api.ServiceInfoResponse, error) {
+	return &api.ServiceInfoResponse{Version: Version,
+		MountPoint:    s.mountPoint,
+		UptimeSeconds: int64(time.Now().Sub(s.startTime) / time.Second),
+		Pid:           int64(os.Getpid()),
+		RepoPath:      s.repoPath}, nil
+}
+
+func (s *apiService) GetDirContents(ctx context.Context, req *api.DirContentsRequest) (*api.DirContentsResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	srcEntries, err := s.ds.GetExtendedDirContents(ctx, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	dstEntries := make([]*api.DirContentsResponse_Entry, len(srcEntries))
+	for i, src := range srcEntries {
+		dstEntries[i] = &api.DirContentsResponse_Entry{
+			ID:                   int64(src.ID),
+			Name:                 src.Name,
+			IsDirty:              src.IsDirty,
+			IsDir:                src.IsDir,
+			Size:                 src.Size,
+			ModTimeSeconds:       src.ModTime.Unix(),
+			BlockID:              src.BID[:],
+			PopulatedRegionCount: int32(src.PopulatedRegionCount),
+			PopulatedSize:        src.PopulatedSize}
+	}
+
+	return &api.DirContentsResponse{Entries: dstEntries}, nil
+}
+
+func (s *apiService) Push(ctx context.Context, req *api.PushRequest) (*api.PushResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Pushing %s to %s", req.Path, req.Label)
+	options := []core.PushOption{core.PushMessage(req.Message)}
+	if len(req.ExpectedBlockID) > 0 {
+		options = append(options, core.PushExpecting(toBID(req.ExpectedBlockID)))
+	}
+	if req.Force {
+		options = append(options, core.PushForce())
+	}
+	err = s.ds.Push(ctx, inode, req.Label, options...)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	node, err := s.ds.GetAttr(ctx, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.PushResponse{BlockID: node.BID[:]}, nil
+}
+
+// AddRemote links a remote source into the repo. The source can be a GCS object or prefix (gs://bucket/key), a
+// URL (http:// or https://) or a label, either as pufs:///label or just the name of the label.
+func (s *apiService) AddRemote(ctx context.Context, req *api.AddRemoteRequest) (*api.AddRemoteResponse, error) {
+	parent, name, err := s.lookupParent(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	inode, err := addRemoteSource(ctx, s.ds, parent, name, req.Source)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.AddRemoteResponse{ID: int64(inode)}, nil
+}
+
+func (s *apiService) Mkdir(ctx context.Context, req *api.MkdirRequest) (*api.MkdirResponse, error) {
+	parent, name, err := s.lookupParent(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	inode, err := s.ds.MakeDir(ctx, parent, name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.MkdirResponse{ID: int64(inode)}, nil
+}
+
+func (s *apiService) Remove(ctx context.Context, req *api.RemoveRequest) (*api.RemoveResponse, error) {
+	parent, name, err := s.lookupParent(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ds.Remove(ctx, parent, name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.RemoveResponse{}, nil
+}
+
+func (s *apiService) Rename(ctx context.Context, req *api.RenameRequest) (*api.RenameResponse, error) {
+	srcParent, srcName, err := s.lookupParent(ctx, req.SrcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dstParent, dstName, err := s.lookupParent(ctx, req.DstPath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ds.Rename(ctx, srcParent, srcName, dstParent, dstName)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.RenameResponse{}, nil
+}
+
+func (s *apiService) Freeze(ctx context.Context, req *api.FreezeRequest) (*api.FreezeResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	BID, err := s.ds.Freeze(inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.FreezeResponse{BlockID: BID[:]}, nil
+}
+
+func (s *apiService) Prefetch(ctx context.Context, req *api.PrefetchRequest) (*api.PrefetchResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := s.ds.Prefetch(ctx, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.PrefetchResponse{Size: size}, nil
+}
+
+func (s *apiService) Evict(ctx context.Context, req *api.EvictRequest) (*api.EvictResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	freed, err := s.ds.Evict(ctx, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.EvictResponse{BytesFreed: freed}, nil
+}
+
+func (s *apiService) Refresh(ctx context.Context, req *api.RefreshRequest) (*api.RefreshResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.ds.Refresh(ctx, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.RefreshResponse{UpdatedCount: int64(count)}, nil
+}
+
+func (s *apiService) GetStats(ctx context.Context, req *api.StatsRequest) (*api.StatsResponse, error) {
+	stats, err := s.ds.GetFSStats()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.StatsResponse{LocalTotal: stats.LocalTotal,
+		LocalFree:    stats.LocalFree,
+		LocalAvail:   stats.LocalAvail,
+		FreezerUsed:  stats.FreezerUsed,
+		WritableUsed: stats.WritableUsed,
+		CacheQuota:   stats.CacheQuota,
+		InodeCount:   stats.INodeCount,
+		MaxINodes:    stats.MaxINodes}
+
+	for _, block := range s.ds.GetActiveTransfers(time.Second) {
+		BID := block.BID
+		for _, t := range block.Transfers {
+			resp.Transfers = append(resp.Transfers, &api.StatsResponse_Transfer{BlockID: BID[:],
+				StartTimeSeconds: t.StartTime.Unix(),
+				Start:            t.Start,
+				Offset:           t.Offset,
+				End:              t.MaxPendingEnd,
+				BytesPerSecond:   t.TransferRate})
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *apiService) ListLabels(ctx context.Context, req *api.ListLabelsRequest) (*api.ListLabelsResponse, error) {
+	labels, err := s.ds.ListLabels(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.ListLabelsResponse{Labels: labels}, nil
+}
+
+// GetLabelHistory returns every version of a label which was pushed, oldest first
+func (s *apiService) GetLabelHistory(ctx context.Context, req *api.LabelHistoryRequest) (*api.LabelHistoryResponse, error) {
+	history, err := s.ds.GetLabelHistory(ctx, req.Label)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	versions := make([]*api.LabelHistoryResponse_Version, len(history))
+	for i, v := range history {
+		version := &api.LabelHistoryResponse_Version{Version: int32(v.Version),
+			TimeNanos: v.Time.UnixNano(),
+			Host:      v.Host,
+			User:      v.User,
+			BlockID:   v.BID[:],
+			Message:   v.Message}
+		if v.Parent != core.NABlock {
+			version.Parent = v.Parent[:]
+		}
+		versions[i] = version
+	}
+	return &api.LabelHistoryResponse{Versions: versions}, nil
+}
+
+// labelName returns the label spec refers to, which may be given as a pufs:// URL
+func labelName(spec string) string {
+	if pufsmatch := PUFSUrlExp.FindStringSubmatch(spec); pufsmatch != nil {
+		return pufsmatch[1]
+	}
+	return spec
+}
+
+// resolveTree finds the tree that spec refers to: "." or a path starting with "./" for a directory in the repo
+// (including changes which haven't been pushed), a block ID as printed by pufs, or a label, which can be given as
+// pufs:///label and can include a version (label@N or label@<timestamp>)
+func (s *apiService) resolveTree(ctx context.Context, spec string) (core.TreeRef, error) {
+	if spec == "." || strings.HasPrefix(spec, "./") {
+		inode, err := s.lookup(ctx, spec)
+		if err != nil {
+			return core.TreeRef{}, err
+		}
+		return core.RepoTree(inode), nil
+	}
+
+	if BID, err := parseBID(spec); err == nil {
+		return core.FrozenTree(BID), nil
+	}
+
+	BID, err := s.ds.ResolveLabel(ctx, labelName(spec))
+	if err != nil {
+		return core.TreeRef{}, toStatusError(err)
+	}
+	return core.FrozenTree(BID), nil
+}
+
+// Diff compares two trees, each of which can be a label, a block ID or a directory in the repo
+func (s *apiService) Diff(ctx context.Context, req *api.DiffRequest) (*api.DiffResponse, error) {
+	from, err := s.resolveTree(ctx, req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolveTree(ctx, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := s.ds.Diff(ctx, from, to)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	dstChanges := make([]*api.DiffResponse_Change, len(changes))
+	for i, c := range changes {
+		dst := &api.DiffResponse_Change{Kind: string(c.Kind),
+			Path:    c.Path,
+			OldPath: c.OldPath,
+			IsDir:   c.IsDir,
+			OldSize: c.OldSize,
+			Size:    c.Size}
+		if c.OldBID != core.NABlock {
+			dst.OldBlockID = c.OldBID[:]
+		}
+		if c.BID != core.NABlock {
+			dst.BlockID = c.BID[:]
+		}
+		dstChanges[i] = dst
+	}
+	return &api.DiffResponse{Changes: dstChanges}, nil
+}
+
+// resolveFrozenTree is resolveTree for specs which must name a frozen tree rather than a directory in the repo
+func (s *apiService) resolveFrozenTree(ctx context.Context, spec string) (core.BlockID, error) {
+	tree, err := s.resolveTree(ctx, spec)
+	if err != nil {
+		return core.NABlock, err
+	}
+	if tree.INode != core.InvalidINode {
+		return core.NABlock, status.Errorf(codes.InvalidArgument, "%s is in the repo, but only labels and block IDs can be merged", spec)
+	}
+	return tree.BID, nil
+}
+
+// resolveMergeLabel returns the root label points to, reusing the root of the merge input it names if there is one so
+// that the push expects the same version that was merged. Returns NABlock if the label doesn't exist yet.
+func (s *apiService) resolveMergeLabel(ctx context.Context, label string, inputs map[string]core.BlockID) (core.BlockID, error) {
+	for spec, BID := range inputs {
+		if spec != "" && labelName(spec) == label {
+			return BID, nil
+		}
+	}
+
+	BID, err := s.ds.ResolveLabel(ctx, label)
+	if err == core.UndefinedRootErr {
+		return core.NABlock, nil
+	} else if err != nil {
+		return core.NABlock, toStatusError(err)
+	}
+	return BID, nil
+}
+
+// Merge combines the changes made since base in a and b, which can each be a label or a block ID. If a label is given
+// and there were no conflicts, the label is pushed to point at the merged tree.
+func (s *apiService) Merge(ctx context.Context, req *api.MergeRequest) (*api.MergeResponse, error) {
+	base := core.NABlock
+	var err error
+	if req.Base != "" {
+		base, err = s.resolveFrozenTree(ctx, req.Base)
+		if err != nil {
+			return nil, err
+		}
+	}
+	a, err := s.resolveFrozenTree(ctx, req.A)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.resolveFrozenTree(ctx, req.B)
+	if err != nil {
+		return nil, err
+	}
+
+	// the label is only pushed to if it still points where it did before the merge, so that a push made while
+	// merging isn't lost
+	var labelBID core.BlockID
+	if req.Label != "" {
+		labelBID, err = s.resolveMergeLabel(ctx, req.Label, map[string]core.BlockID{req.Base: base, req.A: a, req.B: b})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.ds.Merge(ctx, base, a, b)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.MergeResponse{Conflicts: make([]*api.MergeResponse_Conflict, len(result.Conflicts))}
+	for i, c := range result.Conflicts {
+		resp.Conflicts[i] = &api.MergeResponse_Conflict{Kind: string(c.Kind), Path: c.Path}
+	}
+	if len(result.Conflicts) > 0 {
+		return resp, nil
+	}
+	resp.BlockID = result.BID[:]
+
+	if req.Label != "" {
+		log.Printf("Pushing merged tree to %s", req.Label)
+		err = s.ds.PushTree(ctx, result.BID, req.Label, core.PushMessage(req.Message), core.PushExpecting(labelBID))
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+	}
+	return resp, nil
+}
+
+// CreateSnapshot freezes a directory in the repo and keeps its root locally under a name
+func (s *apiService) CreateSnapshot(ctx context.Context, req *api.CreateSnapshotRequest) (*api.CreateSnapshotResponse, error) {
+	inode, err := s.lookup(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.ds.CreateSnapshot(ctx, inode, req.Name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.CreateSnapshotResponse{BlockID: snapshot.BID[:]}, nil
+}
+
+func (s *apiService) ListSnapshots(ctx context.Context, req *api.ListSnapshotsRequest) (*api.ListSnapshotsResponse, error) {
+	snapshots, err := s.ds.ListSnapshots()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	dstSnapshots := make([]*api.ListSnapshotsResponse_Snapshot, len(snapshots))
+	for i, snapshot := range snapshots {
+		dstSnapshots[i] = &api.ListSnapshotsResponse_Snapshot{Name: snapshot.Name,
+			Path:         snapshot.Path,
+			BlockID:      snapshot.BID[:],
+			CreatedNanos: snapshot.Created.UnixNano()}
+	}
+	return &api.ListSnapshotsResponse{Snapshots: dstSnapshots}, nil
+}
+
+// RestoreSnapshot replaces a directory with a snapshot, by default the directory the snapshot was taken of
+func (s *apiService) RestoreSnapshot(ctx context.Context, req *api.RestoreSnapshotRequest) (*api.RestoreSnapshotResponse, error) {
+	restorePath := req.Path
+	if restorePath == "" {
+		snapshot, err := s.ds.GetSnapshot(req.Name)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		restorePath = snapshot.Path
+	}
+
+	inode, err := s.lookup(ctx, restorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Restoring snapshot %s to %s", req.Name, restorePath)
+	err = s.ds.RestoreSnapshot(ctx, req.Name, inode)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.RestoreSnapshotResponse{}, nil
+}
+
+func (s *apiService) DeleteSnapshot(ctx context.Context, req *api.DeleteSnapshotRequest) (*api.DeleteSnapshotResponse, error) {
+	err := s.ds.DeleteSnapshot(req.Name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.DeleteSnapshotResponse{}, nil
+}
+
+// Checkout mounts a version of a label at a path, replacing whichever version was mounted there before
+func (s *apiService) Checkout(ctx context.Context, req *api.CheckoutRequest) (*api.CheckoutResponse, error) {
+	parent, name, err := s.lookupParent(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	label := req.Label
+	if pufsmatch := PUFSUrlExp.FindStringSubmatch(label); pufsmatch != nil {
+		label = pufsmatch[1]
+	}
+	log.Printf("Checking out %s to %s", label, req.Path)
+	_, BID, err := s.ds.Checkout(ctx, parent, name, label, req.Force)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.CheckoutResponse{BlockID: BID[:]}, nil
+}
+
+// UnmountLabel removes a mounted label from the repo and releases its lease
+func (s *apiService) UnmountLabel(ctx context.Context, req *api.UnmountLabelRequest) (*api.UnmountLabelResponse, error) {
+	parent, name, err := s.lookupParent(ctx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ds.UnmountLabel(ctx, parent, name, req.Force)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &api.UnmountLabelResponse{}, nil
+}
+
+// Apply makes the repo match a map, which the client has already checked and expanded into individual links
+func (s *apiService) Apply(ctx context.Context, req *api.ApplyRequest) (*api.ApplyResponse, error) {
+	mm := &MountMap{Links: make([]*Link, len(req.Links))}
+	links := make([]*plannedLink, len(req.Links))
+	for i, l := range req.Links {
+		mm.Links[i] = &Link{Source: l.Source, Path: l.Path, Generation: l.Generation, ETag: l.Etag}
+		links[i] = &plannedLink{Path: l.Path, Source: l.Source, Generation: l.Generation, ETag: l.Etag}
+	}
+	err := validateMap(mm)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	changes, applied, err := reconcileMap(ctx, s.ds, s.repoPath, links, reconcileOptions{Prune: req.Prune, Force: req.Force, DryRun: req.DryRun})
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	dstChanges := make([]*api.ApplyResponse_Change, len(changes))
+	for i, c := range changes {
+		dstChanges[i] = &api.ApplyResponse_Change{Action: c.Action, Path: c.Path, Source: c.Source, Detail: c.Detail}
+	}
+	return &api.ApplyResponse{Changes: dstChanges, Applied: applied}, nil
+}
+
+// ListLeases returns every lease on the remote, marking those which belong to this repo's mounts
+func (s *apiService) ListLeases(ctx context.Context, req *api.ListLeasesRequest) (*api.ListLeasesResponse, error) {
+	leases, err := s.ds.ListLeases(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	own := make(map[string]bool)
+	for _, m := range s.ds.GetMounts() {
+		own[m.LeaseName] = true
+	}
+
+	dstLeases := make([]*api.ListLeasesResponse_Lease, len(leases))
+	for i, lease := range leases {
+		dstLeases[i] = &api.ListLeasesResponse_Lease{Name: lease.Name,
+			BlockID:     lease.BID[:],
+			ExpiryNanos: lease.Expiry.UnixNano(),
+			Host:        lease.Host,
+			Repo:        lease.Repo,
+			IsOwn:       own[lease.Name]}
+	}
+	return &api.ListLeasesResponse{Leases: dstLeases}, nil
+}
+
+// GetStatus reports what the repo is currently doing: transfers in progress and recently completed, how much is
+// cached, what hasn't been pushed and which blocks are mounted. Uptime is only reported for a mounted repo.
+func (s *apiService) GetStatus(ctx context.Context, req *api.StatusRequest) (*api.StatusResponse, error) {
+	dsStatus, err := s.ds.GetStatus(time.Second)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.StatusResponse{FreezerUsed: dsStatus.FreezerUsed,
+		FreezerBlocks:  int64(dsStatus.FreezerBlocks),
+		DirtyFileCount: int64(dsStatus.DirtyFileCount),
+		DirtyFileBytes: dsStatus.DirtyFileBytes,
+		WritableUsed:   dsStatus.WritableUsed,
+		MountPoint:     s.mountPoint,
+		RepoPath:       s.repoPath}
+
+	if s.mountPoint != "" {
+		resp.UptimeSeconds = int64(time.Now().Sub(s.startTime) / time.Second)
+	}
+
+	for _, block := range dsStatus.Transfers {
+		BID := block.BID
+		for _, t := range block.Transfers {
+			resp.Transfers = append(resp.Transfers, &api.StatusResponse_Transfer{BlockID: BID[:],
+				StartTimeSeconds: t.StartTime.Unix(),
+				Start:            t.Start,
+				Offset:           t.Offset,
+				End:              t.MaxPendingEnd,
+				BytesPerSecond:   t.TransferRate})
+		}
+	}
+
+	for _, c := range dsStatus.CopyHistory {
+		BID := c.BID
+		copyStatus := &api.StatusResponse_Copy{BlockID: BID[:],
+			Start:          c.Start,
+			End:            c.End,
+			StartTimeNanos: c.StartTime.UnixNano(),
+			Complete:       c.Complete}
+		if c.Complete {
+			copyStatus.EndTimeNanos = c.EndTime.UnixNano()
+		}
+		resp.Copies = append(resp.Copies, copyStatus)
+	}
+
+	for _, m := range dsStatus.Mounts {
+		BID := m.BID
+		resp.Mounts = append(resp.Mounts, &api.StatusResponse_Mount{ID: int64(m.INode),
+			Path:               m.Path,
+			BlockID:            BID[:],
+			LeaseName:          m.LeaseName,
+			LastRenewalSeconds: m.LastLeaseRenewal.Unix(),
+			LeaseExpirySeconds: m.LeaseExpiry.Unix()})
+	}
+
+	return resp, nil
+}
+
+func parseEventTypes(names []string) ([]core.EventType, error) {
+	types := make([]core.EventType, 0, len(names))
+	for _, name := range names {
+		valid := false
+		for _, t := range core.AllEventTypes {
+			if string(t) == name {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, status.Errorf(codes.InvalidArgument, "Unknown event type: %s", name)
+		}
+		types = append(types, core.EventType(name))
+	}
+	return types, nil
+}
+
+// WatchEvents streams events from the DataStore until the client goes away. If a path is given, only events for
+// files and directories at or below that path are sent, which excludes events that aren't tied to a single file
+// (ie: fetches).
+// the most paths remembered per event stream before they're all forgotten
+const maxEventPaths = 10000
+
+// eventPaths remembers the paths of the nodes which events were about, until anything in the repo changes, as nodes
+// may have been renamed or removed
+type eventPaths struct {
+	ds      *core.DataStore
+	changes uint64
+	paths   map[core.INode]string
+}
+
+func newEventPaths(ds *core.DataStore) *eventPaths {
+	return &eventPaths{ds: ds, changes: ds.ChangeCount(), paths: make(map[core.INode]string)}
+}
+
+// get returns the path of inode, or "" if it no longer exists
+func (e *eventPaths) get(inode core.INode) string {
+	if inode == core.InvalidINode {
+		return ""
+	}
+
+	changes := e.ds.ChangeCount()
+	if changes != e.changes || len(e.paths) >= maxEventPaths {
+		e.paths = make(map[core.INode]string)
+		e.changes = changes
+	}
+
+	p, ok := e.paths[inode]
+	if !ok {
+		var err error
+		p, err = e.ds.GetPath(inode)
+		if err != nil {
+			// the node may have been deleted since the event was generated
+			p = ""
+		}
+		e.paths[inode] = p
+	}
+	return p
+}
+
+func (s *apiService) WatchEvents(req *api.WatchEventsRequest, stream api.Pufs_WatchEventsServer) error {
+	if s.events == nil {
+		return status.Error(codes.FailedPrecondition, "Events are only available from a mounted repo")
+	}
+
+	types, err := parseEventTypes(req.Types)
+	if err != nil {
+		return err
+	}
+
+	pathFilter := ""
+	if req.Path != "" {
+		pathFilter = cleanRepoPath(req.Path)
+	}
+
+	sub := s.events.Subscribe(core.EventFilter{Types: types}, watchEventsBufferSize)
+	defer sub.Close()
+
+	paths := newEventPaths(s.ds)
+
+	ctx := stream.Context()
+	for {
+		var event *core.Event
+		select {
+		case <-ctx.Done():
+			return nil
+		case event = <-sub.Events():
+		}
+
+		p := paths.get(event.INode)
+
+		if pathFilter != "" && pathFilter != "." {
+			if p == "" || (p != pathFilter && !strings.HasPrefix(p, pathFilter+"/")) {
+				continue
+			}
+		}
+
+		err = stream.Send(&api.Event{Type: string(event.Type),
+			TimeNanos:      event.Time.UnixNano(),
+			Pid:            int64(event.Pid),
+			ID:             int64(event.INode),
+			Path:           p,
+			BlockID:        event.BID[:],
+			Offset:         event.Offset,
+			Length:         event.Length,
+			DurationMicros: int64(event.Duration / time.Microsecond),
+			Label:          event.Label,
+			Writable:       event.Writable,
+			BlockCount:     int64(event.BlockCount),
+			Source:         event.Source,
+			Pinned:         event.Pinned,
+			Dropped:        sub.Dropped()})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,769 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeNetworkClient struct {
+	generation int64
+}
+
+func (c *fakeNetworkClient) GetGCSAttr(ctx context.Context, bucket string, key string) (*core.GCSAttrs, error) {
+	return &core.GCSAttrs{Generation: c.generation, Size: 10, ModTime: time.Now()}, nil
+}
+
+func (c *fakeNetworkClient) GetHTTPAttr(ctx context.Context, url string) (*core.HTTPAttrs, error) {
+	return &core.HTTPAttrs{ETag: "x", Size: 10}, nil
+}
+
+func newTestDataStore(require *require.Assertions, repo *core.RemoteRefFactoryMem, options ...core.DataStoreOption) *core.DataStore {
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+
+	ds, err := core.NewDataStore(dir, repo, core.NewMemRemoteRefFactory2(repo),
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}), options...)
+	require.Nil(err)
+
+	return ds
+}
+
+// newTestRepoDir creates an empty repo directory, for the files which are kept alongside the DataStore
+func newTestRepoDir(require *require.Assertions) string {
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	require.Nil(os.Mkdir(path.Join(dir, ".pufs"), 0700))
+	return dir
+}
+
+// startTestService serves the api for ds over an in-memory connection and returns a client connected to it
+func startTestService(require *require.Assertions, ds *core.DataStore, events *core.EventBroker) (api.PufsClient, func()) {
+	lis := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	api.RegisterPufsServer(server, newAPIService(ds, newTestRepoDir(require), "mount", events))
+	go server.Serve(lis)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return lis.Dial()
+	}))
+	require.Nil(err)
+
+	return api.NewPufsClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func requireCode(require *require.Assertions, code codes.Code, err error) {
+	require.NotNil(err)
+	s, ok := status.FromError(err)
+	require.True(ok)
+	require.Equal(code, s.Code(), s.Message())
+}
+
+func findEntry(entries []*api.DirContentsResponse_Entry, name string) *api.DirContentsResponse_Entry {
+	for _, e := range entries {
+		if e.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestServiceEndToEnd(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+	repo := core.NewRemoteRefFactoryMem()
+	data := []byte("hello world")
+
+	ds1 := newTestDataStore(require, repo)
+	client1, stop1 := startTestService(require, ds1, nil)
+	defer stop1()
+
+	mkdirResp, err := client1.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+
+	_, err = client1.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	requireCode(require, codes.AlreadyExists, err)
+
+	_, err = client1.Mkdir(ctx, &api.MkdirRequest{Path: "missing/b"})
+	requireCode(require, codes.NotFound, err)
+
+	_, err = client1.Remove(ctx, &api.RemoveRequest{Path: "missing"})
+	requireCode(require, codes.NotFound, err)
+
+	_, err = client1.Remove(ctx, &api.RemoveRequest{Path: "."})
+	requireCode(require, codes.InvalidArgument, err)
+
+	_, err = ds1.AddImmutableBytes(ctx, core.INode(mkdirResp.ID), "f", data)
+	require.Nil(err)
+
+	_, err = client1.Rename(ctx, &api.RenameRequest{SrcPath: "a/f", DstPath: "/a/g"})
+	require.Nil(err)
+
+	listing, err := client1.GetDirContents(ctx, &api.DirContentsRequest{Path: "a"})
+	require.Nil(err)
+	require.Nil(findEntry(listing.Entries, "f"))
+	require.NotNil(findEntry(listing.Entries, "g"))
+
+	_, err = client1.GetDirContents(ctx, &api.DirContentsRequest{Path: "missing"})
+	requireCode(require, codes.NotFound, err)
+
+	_, err = client1.Remove(ctx, &api.RemoveRequest{Path: "a"})
+	requireCode(require, codes.FailedPrecondition, err)
+
+	_, w, err := ds1.CreateWritable(ctx, core.INode(mkdirResp.ID), "w")
+	require.Nil(err)
+	_, err = w.Write(data)
+	require.Nil(err)
+	w.Release()
+
+	statusResp, err := client1.GetStatus(ctx, &api.StatusRequest{})
+	require.Nil(err)
+	require.Equal(int64(1), statusResp.DirtyFileCount)
+	require.Equal(int64(len(data)), statusResp.DirtyFileBytes)
+	require.Empty(statusResp.Mounts)
+
+	_, err = client1.Remove(ctx, &api.RemoveRequest{Path: "a/w"})
+	require.Nil(err)
+
+	freezeResp, err := client1.Freeze(ctx, &api.FreezeRequest{Path: "."})
+	require.Nil(err)
+	require.Len(freezeResp.BlockID, len(core.BlockID{}))
+
+	pushResp, err := client1.Push(ctx, &api.PushRequest{Path: ".", Label: "v1"})
+	require.Nil(err)
+	require.Equal(freezeResp.BlockID, pushResp.BlockID)
+
+	// data that only exists locally can't be evicted
+	_, err = client1.Evict(ctx, &api.EvictRequest{Path: "a/g"})
+	requireCode(require, codes.FailedPrecondition, err)
+
+	// a second repo can mount what was pushed, and fetch and evict it
+	ds2 := newTestDataStore(require, repo)
+	client2, stop2 := startTestService(require, ds2, nil)
+	defer stop2()
+
+	_, err = client2.AddRemote(ctx, &api.AddRemoteRequest{Path: "v1", Source: "pufs:///v1"})
+	require.Nil(err)
+
+	_, err = client2.AddRemote(ctx, &api.AddRemoteRequest{Path: "v2", Source: "pufs:///v2"})
+	requireCode(require, codes.NotFound, err)
+
+	prefetchResp, err := client2.Prefetch(ctx, &api.PrefetchRequest{Path: "v1"})
+	require.Nil(err)
+	require.Equal(int64(len(data)), prefetchResp.Size)
+
+	statusResp, err = client2.GetStatus(ctx, &api.StatusRequest{})
+	require.Nil(err)
+	require.Len(statusResp.Mounts, 1)
+	require.Equal("v1", statusResp.Mounts[0].Path)
+	require.True(statusResp.Mounts[0].LeaseExpirySeconds > time.Now().Unix())
+	require.True(statusResp.FreezerBlocks > 0)
+	require.NotEmpty(statusResp.Copies)
+	require.True(statusResp.Copies[len(statusResp.Copies)-1].Complete)
+	require.Equal(int64(0), statusResp.DirtyFileCount)
+
+	listing, err = client2.GetDirContents(ctx, &api.DirContentsRequest{Path: "v1/a"})
+	require.Nil(err)
+	require.Equal(int64(len(data)), findEntry(listing.Entries, "g").PopulatedSize)
+
+	evictResp, err := client2.Evict(ctx, &api.EvictRequest{Path: "v1"})
+	require.Nil(err)
+	require.True(evictResp.BytesFreed > 0)
+
+	listing, err = client2.GetDirContents(ctx, &api.DirContentsRequest{Path: "v1/a"})
+	require.Nil(err)
+	require.Equal(int64(0), findEntry(listing.Entries, "g").PopulatedSize)
+
+	// and evicted data is fetched again on the next read
+	prefetchResp, err = client2.Prefetch(ctx, &api.PrefetchRequest{Path: "v1/a/g"})
+	require.Nil(err)
+	require.Equal(int64(len(data)), prefetchResp.Size)
+
+	statsResp, err := client2.GetStats(ctx, &api.StatsRequest{})
+	require.Nil(err)
+	require.True(statsResp.InodeCount > 0)
+	require.True(statsResp.MaxINodes > statsResp.InodeCount)
+
+	// linked GCS objects are updated by refresh when their generation changes
+	_, err = client1.Refresh(ctx, &api.RefreshRequest{Path: "."})
+	require.Nil(err)
+
+	network := &fakeNetworkClient{generation: 1}
+	ds1.SetClients(network)
+	_, err = client1.AddRemote(ctx, &api.AddRemoteRequest{Path: "a/obj", Source: "gs://bucket/key"})
+	require.Nil(err)
+
+	refreshResp, err := client1.Refresh(ctx, &api.RefreshRequest{Path: "."})
+	require.Nil(err)
+	require.Equal(int64(0), refreshResp.UpdatedCount)
+
+	network.generation = 2
+	refreshResp, err = client1.Refresh(ctx, &api.RefreshRequest{Path: "a"})
+	require.Nil(err)
+	require.Equal(int64(1), refreshResp.UpdatedCount)
+
+	refreshResp, err = client1.Refresh(ctx, &api.RefreshRequest{Path: "a/obj"})
+	require.Nil(err)
+	require.Equal(int64(0), refreshResp.UpdatedCount)
+}
+
+func TestWatchEvents(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := core.NewEventBroker()
+	ds := newTestDataStore(require, core.NewRemoteRefFactoryMem(), core.WithMonitor(events))
+	client, stop := startTestService(require, ds, events)
+	defer stop()
+
+	_, err := client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "b"})
+	require.Nil(err)
+	a, err := ds.GetINodeForPath(ctx, "a")
+	require.Nil(err)
+	b, err := ds.GetINodeForPath(ctx, "b")
+	require.Nil(err)
+
+	badStream, err := client.WatchEvents(ctx, &api.WatchEventsRequest{Types: []string{"bogus"}})
+	require.Nil(err)
+	_, err = badStream.Recv()
+	requireCode(require, codes.InvalidArgument, err)
+
+	stream, err := client.WatchEvents(ctx, &api.WatchEventsRequest{Types: []string{string(core.OpenEvent)}, Path: "/a"})
+	require.Nil(err)
+
+	// the server subscribes asynchronously, so keep opening files until events start arriving
+	done := make(chan bool)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			for _, dir := range []core.INode{b, a} {
+				_, w, err := ds.CreateWritable(ctx, dir, fmt.Sprintf("f%d", i))
+				if err == nil {
+					w.Release()
+				}
+			}
+		}
+	}()
+	defer close(done)
+
+	for i := 0; i < 3; i++ {
+		event, err := stream.Recv()
+		require.Nil(err)
+		require.Equal(string(core.OpenEvent), event.Type)
+		require.True(strings.HasPrefix(event.Path, "a/f"), event.Path)
+		require.True(event.Writable)
+	}
+}
+
+func TestWatchEventsAfterRename(t *testing.T) {
+	require := require.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := core.NewEventBroker()
+	ds := newTestDataStore(require, core.NewRemoteRefFactoryMem(), core.WithMonitor(events))
+	client, stop := startTestService(require, ds, events)
+	defer stop()
+
+	a, err := ds.MakeDir(ctx, core.RootINode, "a")
+	require.Nil(err)
+	x, w, err := ds.CreateWritable(ctx, a, "x")
+	require.Nil(err)
+	w.Release()
+
+	stream, err := client.WatchEvents(ctx, &api.WatchEventsRequest{Types: []string{string(core.OpenEvent)}})
+	require.Nil(err)
+
+	// keep opening the same file until events start arriving
+	done := make(chan bool)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+			r, err := ds.GetReadRef(ctx, x)
+			if err == nil {
+				r.Release()
+			}
+		}
+	}()
+	defer close(done)
+
+	event, err := stream.Recv()
+	require.Nil(err)
+	require.Equal("a/x", event.Path)
+
+	// the same node is reported under its new path once it's moved
+	require.Nil(ds.Rename(ctx, core.RootINode, "a", core.RootINode, "c"))
+	for event.Path == "a/x" {
+		event, err = stream.Recv()
+		require.Nil(err)
+	}
+	require.Equal("c/x", event.Path)
+}
+
+func TestServiceLabels(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	_, err := client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "results", Message: "first"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "b"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "results"})
+	require.Nil(err)
+
+	labelsResp, err := client.ListLabels(ctx, &api.ListLabelsRequest{})
+	require.Nil(err)
+	require.Equal([]string{"results"}, labelsResp.Labels)
+
+	historyResp, err := client.GetLabelHistory(ctx, &api.LabelHistoryRequest{Label: "results"})
+	require.Nil(err)
+	require.Len(historyResp.Versions, 2)
+	require.Equal("first", historyResp.Versions[0].Message)
+	require.Empty(historyResp.Versions[0].Parent)
+	require.Equal(historyResp.Versions[0].BlockID, historyResp.Versions[1].Parent)
+
+	var out bytes.Buffer
+	printLabelHistory(&out, "results", historyResp.Versions)
+	require.True(strings.HasPrefix(out.String(), "results@2 "))
+	require.Contains(out.String(), "results@1 ")
+	require.Contains(out.String(), "    first\n")
+
+	// pushes which expect the label to be somewhere else are rejected
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "results", ExpectedBlockID: historyResp.Versions[0].BlockID})
+	requireCode(require, codes.Aborted, err)
+	latest, err := parseBID(base64x(toBID(historyResp.Versions[1].BlockID)))
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "results", ExpectedBlockID: latest[:]})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "results", ExpectedBlockID: core.NABlock[:], Force: true})
+	require.Nil(err)
+
+	_, err = client.GetLabelHistory(ctx, &api.LabelHistoryRequest{Label: "missing"})
+	requireCode(require, codes.NotFound, err)
+
+	// earlier versions can be mounted
+	_, err = client.AddRemote(ctx, &api.AddRemoteRequest{Path: "old", Source: "pufs:///results@1"})
+	require.Nil(err)
+	listing, err := client.GetDirContents(ctx, &api.DirContentsRequest{Path: "old"})
+	require.Nil(err)
+	require.NotNil(findEntry(listing.Entries, "a"))
+	require.Nil(findEntry(listing.Entries, "b"))
+
+	_, err = client.AddRemote(ctx, &api.AddRemoteRequest{Path: "future", Source: "pufs:///results@5"})
+	requireCode(require, codes.NotFound, err)
+}
+
+func TestServiceDiff(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	_, err := client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	pushResp, err := client.Push(ctx, &api.PushRequest{Path: ".", Label: "v1"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "b"})
+	require.Nil(err)
+
+	// unpushed changes are compared against the label, however it's written
+	for _, from := range []string{"v1", "pufs:///v1", "v1@1", base64x(toBID(pushResp.BlockID))} {
+		resp, err := client.Diff(ctx, &api.DiffRequest{From: from, To: "."})
+		require.Nil(err)
+		require.Len(resp.Changes, 1)
+		require.Equal("added", resp.Changes[0].Kind)
+		require.Equal("b", resp.Changes[0].Path)
+		require.True(resp.Changes[0].IsDir)
+	}
+
+	resp, err := client.Diff(ctx, &api.DiffRequest{From: "v1", To: "./a"})
+	require.Nil(err)
+	require.Len(resp.Changes, 1)
+	require.Equal("removed", resp.Changes[0].Kind)
+	require.Equal("a", resp.Changes[0].Path)
+
+	var out bytes.Buffer
+	printDiff(&out, resp.Changes)
+	require.Equal("D a/\n", out.String())
+
+	out.Reset()
+	require.Nil(printDiffJSON(&out, resp.Changes))
+	require.Contains(out.String(), `"kind": "removed"`)
+
+	_, err = client.Diff(ctx, &api.DiffRequest{From: "missing", To: "."})
+	requireCode(require, codes.NotFound, err)
+	_, err = client.Diff(ctx, &api.DiffRequest{From: "v1", To: "./missing"})
+	requireCode(require, codes.NotFound, err)
+}
+
+func TestServiceMerge(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	_, err := client.Push(ctx, &api.PushRequest{Path: ".", Label: "base"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "left"})
+	require.Nil(err)
+	_, err = client.Remove(ctx, &api.RemoveRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "b"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "right"})
+	require.Nil(err)
+
+	resp, err := client.Merge(ctx, &api.MergeRequest{Base: "base", A: "left", B: "pufs:///right", Label: "merged", Message: "combined"})
+	require.Nil(err)
+	require.Empty(resp.Conflicts)
+
+	history, err := client.GetLabelHistory(ctx, &api.LabelHistoryRequest{Label: "merged"})
+	require.Nil(err)
+	require.Len(history.Versions, 1)
+	require.Equal(resp.BlockID, history.Versions[0].BlockID)
+	require.Equal("combined", history.Versions[0].Message)
+
+	diff, err := client.Diff(ctx, &api.DiffRequest{From: "base", To: "merged"})
+	require.Nil(err)
+	require.Len(diff.Changes, 2)
+	require.Equal("a", diff.Changes[0].Path)
+	require.Equal("b", diff.Changes[1].Path)
+
+	// changing a on one side while removing it on the other is a conflict
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "a/c"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "other"})
+	require.Nil(err)
+	resp, err = client.Merge(ctx, &api.MergeRequest{Base: "left", A: "other", B: "right", Label: "conflicted"})
+	require.Nil(err)
+	require.Empty(resp.BlockID)
+	require.Len(resp.Conflicts, 1)
+	require.Equal("modified-deleted", resp.Conflicts[0].Kind)
+	require.Equal("a", resp.Conflicts[0].Path)
+	_, err = client.Diff(ctx, &api.DiffRequest{From: "conflicted", To: "."})
+	requireCode(require, codes.NotFound, err)
+
+	_, err = client.Merge(ctx, &api.MergeRequest{Base: "base", A: ".", B: "left"})
+	requireCode(require, codes.InvalidArgument, err)
+}
+
+// movingLabelRepo moves a label to another root just before it's looked up for the nth time, as if another client
+// had pushed to it in between
+type movingLabelRepo struct {
+	*core.RemoteRefFactoryMem
+	label  string
+	moveOn int
+	moveTo core.BlockID
+	calls  int
+}
+
+func (r *movingLabelRepo) GetRoot(ctx context.Context, name string) (core.BlockID, error) {
+	if name == r.label {
+		r.calls++
+		if r.calls == r.moveOn {
+			r.RemoteRefFactoryMem.SetRoot(ctx, name, r.moveTo)
+		}
+	}
+	return r.RemoteRefFactoryMem.GetRoot(ctx, name)
+}
+
+func TestServiceMergeLabelMoved(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	mem := core.NewRemoteRefFactoryMem()
+	repo := &movingLabelRepo{RemoteRefFactoryMem: mem}
+	dir, err := ioutil.TempDir("", "test")
+	require.Nil(err)
+	ds, err := core.NewDataStore(dir, repo, core.NewMemRemoteRefFactory2(mem),
+		core.NewMemStore([][]byte{core.ChunkStat}),
+		core.NewMemStore([][]byte{core.ChildNodeBucket, core.NodeBucket}))
+	require.Nil(err)
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "base"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "main"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "left"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "b"})
+	require.Nil(err)
+	_, err = client.Push(ctx, &api.PushRequest{Path: ".", Label: "right"})
+	require.Nil(err)
+	right, err := mem.GetRoot(ctx, "right")
+	require.Nil(err)
+
+	// the label being merged into is one of the inputs, and is moved after it's resolved for the merge
+	*repo = movingLabelRepo{RemoteRefFactoryMem: mem, label: "left", moveOn: 2, moveTo: right}
+	_, err = client.Merge(ctx, &api.MergeRequest{Base: "base", A: "left", B: "right", Label: "left"})
+	requireCode(require, codes.Aborted, err)
+	root, err := mem.GetRoot(ctx, "left")
+	require.Nil(err)
+	require.Equal(right, root)
+
+	// the label isn't one of the inputs, and is moved after it's resolved before the merge
+	main, err := mem.GetRoot(ctx, "main")
+	require.Nil(err)
+	*repo = movingLabelRepo{RemoteRefFactoryMem: mem, label: "main", moveOn: 2, moveTo: right}
+	_, err = client.Merge(ctx, &api.MergeRequest{Base: "base", A: "base", B: "right", Label: "main"})
+	requireCode(require, codes.Aborted, err)
+	root, err = mem.GetRoot(ctx, "main")
+	require.Nil(err)
+	require.Equal(right, root)
+	require.NotEqual(main, root)
+
+	// with nothing moving the label, the merge is pushed
+	*repo = movingLabelRepo{RemoteRefFactoryMem: mem}
+	resp, err := client.Merge(ctx, &api.MergeRequest{Base: "base", A: "left", B: "right", Label: "main"})
+	require.Nil(err)
+	root, err = mem.GetRoot(ctx, "main")
+	require.Nil(err)
+	require.Equal(resp.BlockID, root[:])
+}
+
+func TestServiceSnapshots(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	_, err := client.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "a/b"})
+	require.Nil(err)
+
+	createResp, err := client.CreateSnapshot(ctx, &api.CreateSnapshotRequest{Path: "a", Name: "s1"})
+	require.Nil(err)
+	_, err = client.CreateSnapshot(ctx, &api.CreateSnapshotRequest{Path: "a", Name: "s1"})
+	requireCode(require, codes.AlreadyExists, err)
+	_, err = client.CreateSnapshot(ctx, &api.CreateSnapshotRequest{Path: "missing", Name: "s2"})
+	requireCode(require, codes.NotFound, err)
+
+	listResp, err := client.ListSnapshots(ctx, &api.ListSnapshotsRequest{})
+	require.Nil(err)
+	require.Len(listResp.Snapshots, 1)
+	require.Equal("s1", listResp.Snapshots[0].Name)
+	require.Equal("a", listResp.Snapshots[0].Path)
+	require.Equal(createResp.BlockID, listResp.Snapshots[0].BlockID)
+
+	var out bytes.Buffer
+	printSnapshots(&out, listResp.Snapshots)
+	require.Contains(out.String(), "s1 a ")
+
+	// restoring without a path puts back the directory the snapshot was taken of
+	_, err = client.Remove(ctx, &api.RemoveRequest{Path: "a/b"})
+	require.Nil(err)
+	_, err = client.RestoreSnapshot(ctx, &api.RestoreSnapshotRequest{Name: "s1"})
+	require.Nil(err)
+	listing, err := client.GetDirContents(ctx, &api.DirContentsRequest{Path: "a"})
+	require.Nil(err)
+	require.NotNil(findEntry(listing.Entries, "b"))
+
+	// or it can replace a different directory
+	_, err = client.Mkdir(ctx, &api.MkdirRequest{Path: "c"})
+	require.Nil(err)
+	_, err = client.RestoreSnapshot(ctx, &api.RestoreSnapshotRequest{Name: "s1", Path: "c"})
+	require.Nil(err)
+	listing, err = client.GetDirContents(ctx, &api.DirContentsRequest{Path: "c"})
+	require.Nil(err)
+	require.NotNil(findEntry(listing.Entries, "b"))
+
+	_, err = client.DeleteSnapshot(ctx, &api.DeleteSnapshotRequest{Name: "s1"})
+	require.Nil(err)
+	_, err = client.DeleteSnapshot(ctx, &api.DeleteSnapshotRequest{Name: "s1"})
+	requireCode(require, codes.NotFound, err)
+	_, err = client.RestoreSnapshot(ctx, &api.RestoreSnapshotRequest{Name: "s1"})
+	requireCode(require, codes.NotFound, err)
+}
+
+func TestServiceCheckout(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds1 := newTestDataStore(require, repo)
+	client1, stop1 := startTestService(require, ds1, nil)
+	defer stop1()
+
+	_, err := client1.Mkdir(ctx, &api.MkdirRequest{Path: "v1"})
+	require.Nil(err)
+	_, err = client1.Push(ctx, &api.PushRequest{Path: ".", Label: "ref"})
+	require.Nil(err)
+	_, err = client1.Mkdir(ctx, &api.MkdirRequest{Path: "v2"})
+	require.Nil(err)
+	_, err = client1.Push(ctx, &api.PushRequest{Path: ".", Label: "ref"})
+	require.Nil(err)
+
+	ds2 := newTestDataStore(require, repo)
+	client2, stop2 := startTestService(require, ds2, nil)
+	defer stop2()
+
+	_, err = client2.Checkout(ctx, &api.CheckoutRequest{Path: "data", Label: "pufs:///ref@1"})
+	require.Nil(err)
+	listing, err := client2.GetDirContents(ctx, &api.DirContentsRequest{Path: "data"})
+	require.Nil(err)
+	require.Nil(findEntry(listing.Entries, "v2"))
+
+	_, err = client2.Checkout(ctx, &api.CheckoutRequest{Path: "data", Label: "ref"})
+	require.Nil(err)
+	listing, err = client2.GetDirContents(ctx, &api.DirContentsRequest{Path: "data"})
+	require.Nil(err)
+	require.NotNil(findEntry(listing.Entries, "v2"))
+
+	_, err = client2.Mkdir(ctx, &api.MkdirRequest{Path: "data/local"})
+	require.Nil(err)
+	_, err = client2.Checkout(ctx, &api.CheckoutRequest{Path: "data", Label: "ref@1"})
+	requireCode(require, codes.FailedPrecondition, err)
+	_, err = client2.UnmountLabel(ctx, &api.UnmountLabelRequest{Path: "data"})
+	requireCode(require, codes.FailedPrecondition, err)
+	_, err = client2.UnmountLabel(ctx, &api.UnmountLabelRequest{Path: "data/local"})
+	requireCode(require, codes.NotFound, err)
+
+	_, err = client2.UnmountLabel(ctx, &api.UnmountLabelRequest{Path: "data", Force: true})
+	require.Nil(err)
+	_, err = client2.GetDirContents(ctx, &api.DirContentsRequest{Path: "data"})
+	requireCode(require, codes.NotFound, err)
+	statusResp, err := client2.GetStatus(ctx, &api.StatusRequest{})
+	require.Nil(err)
+	require.Empty(statusResp.Mounts)
+}
+
+func TestServiceListLeases(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds1 := newTestDataStore(require, repo)
+	client1, stop1 := startTestService(require, ds1, nil)
+	defer stop1()
+
+	_, err := client1.Mkdir(ctx, &api.MkdirRequest{Path: "a"})
+	require.Nil(err)
+	_, err = client1.Push(ctx, &api.PushRequest{Path: ".", Label: "ref"})
+	require.Nil(err)
+
+	ds2 := newTestDataStore(require, repo)
+	client2, stop2 := startTestService(require, ds2, nil)
+	defer stop2()
+
+	checkoutResp, err := client2.Checkout(ctx, &api.CheckoutRequest{Path: "data", Label: "ref"})
+	require.Nil(err)
+
+	resp, err := client2.ListLeases(ctx, &api.ListLeasesRequest{})
+	require.Nil(err)
+	require.Len(resp.Leases, 1)
+	lease := resp.Leases[0]
+	require.Equal(checkoutResp.BlockID, lease.BlockID)
+	require.True(lease.IsOwn)
+	require.NotEqual("", lease.Repo)
+
+	// the same lease isn't one of ds1's
+	resp, err = client1.ListLeases(ctx, &api.ListLeasesRequest{})
+	require.Nil(err)
+	require.Len(resp.Leases, 1)
+	require.False(resp.Leases[0].IsOwn)
+
+	var out bytes.Buffer
+	printLeases(&out, resp.Leases, time.Unix(0, lease.ExpiryNanos).Add(time.Second))
+	require.Contains(out.String(), lease.Name)
+	require.Contains(out.String(), "expired")
+	require.Contains(out.String(), lease.Repo)
+}
+
+func TestServiceApply(t *testing.T) {
+	GobRegisterTypes()
+	require := require.New(t)
+	ctx := context.Background()
+
+	repo := core.NewRemoteRefFactoryMem()
+	ds := newTestDataStore(require, repo)
+	ds.SetClients(&fakeNetworkClient{generation: 1})
+	client, stop := startTestService(require, ds, nil)
+	defer stop()
+
+	req := &api.ApplyRequest{Links: []*api.ApplyRequest_Link{{Path: "in/a", Source: "gs://bucket/a"}}, DryRun: true}
+	resp, err := client.Apply(ctx, req)
+	require.Nil(err)
+	require.False(resp.Applied)
+	require.Len(resp.Changes, 1)
+	require.Equal(addChange, resp.Changes[0].Action)
+	_, err = client.GetDirContents(ctx, &api.DirContentsRequest{Path: "in"})
+	requireCode(require, codes.NotFound, err)
+
+	req.DryRun = false
+	resp, err = client.Apply(ctx, req)
+	require.Nil(err)
+	require.True(resp.Applied)
+	listing, err := client.GetDirContents(ctx, &api.DirContentsRequest{Path: "in"})
+	require.Nil(err)
+	require.NotNil(findEntry(listing.Entries, "a"))
+
+	var out bytes.Buffer
+	printMapChanges(&out, resp.Changes)
+	require.Equal("add in/a gs://bucket/a\n", out.String())
+
+	_, err = client.Apply(ctx, &api.ApplyRequest{Links: []*api.ApplyRequest_Link{{Path: "../a", Source: "gs://bucket/a"}}})
+	requireCode(require, codes.InvalidArgument, err)
+}
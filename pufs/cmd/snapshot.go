@@ -0,0 +1,126 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Keep local copies of directories in the repo and return to them later",
+	Long: `Keep local copies of directories in the repo and return to them later. Snapshots are never pushed, and
+the blocks they refer to are kept in the cache until the snapshot is deleted.`,
+}
+
+var snapshotCreateCmd = &cobra.Command{
+	Use:   "create [repo] [name] [path]",
+	Short: "Snapshot a directory in the repo (by default, the whole repo)",
+	Args:  cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := args[1]
+		snapshotPath := "."
+		if len(args) > 2 {
+			snapshotPath = args[2]
+		}
+
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.CreateSnapshot(context.Background(), &api.CreateSnapshotRequest{Path: snapshotPath, Name: name})
+		if err != nil {
+			log.Fatalf("Could not snapshot %s: %s", snapshotPath, errorMessage(err))
+		}
+		fmt.Printf("%s\n", base64x(toBID(resp.BlockID)))
+	},
+}
+
+func printSnapshots(w io.Writer, snapshots []*api.ListSnapshotsResponse_Snapshot) {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, s := range snapshots {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Path, time.Unix(0, s.CreatedNanos).Format(time.RFC3339), base64x(toBID(s.BlockID)))
+	}
+	tw.Flush()
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list [repo]",
+	Short: "List snapshots, oldest first",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		resp, err := client.ListSnapshots(context.Background(), &api.ListSnapshotsRequest{})
+		if err != nil {
+			log.Fatalf("Could not list snapshots: %s", errorMessage(err))
+		}
+		printSnapshots(os.Stdout, resp.Snapshots)
+	},
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore [repo] [name] [path]",
+	Short: "Replace a directory with a snapshot",
+	Long: `Replace a directory with a snapshot, by default the directory the snapshot was taken of. Everything
+in the directory is discarded, including changes which haven't been pushed.`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		req := &api.RestoreSnapshotRequest{Name: args[1]}
+		if len(args) > 2 {
+			req.Path = args[2]
+		}
+
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		_, err := client.RestoreSnapshot(context.Background(), req)
+		if err != nil {
+			log.Fatalf("Could not restore snapshot %s: %s", req.Name, errorMessage(err))
+		}
+	},
+}
+
+var snapshotDeleteCmd = &cobra.Command{
+	Use:   "delete [repo] [name]",
+	Short: "Delete a snapshot, allowing its blocks to be evicted",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, closeClient := getRepoClient(args[0])
+		defer closeClient()
+
+		_, err := client.DeleteSnapshot(context.Background(), &api.DeleteSnapshotRequest{Name: args[1]})
+		if err != nil {
+			log.Fatalf("Could not delete snapshot %s: %s", args[1], errorMessage(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotCreateCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotDeleteCmd)
+}
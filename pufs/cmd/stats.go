@@ -0,0 +1,63 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats [path]",
+	Short: "Show cache usage and active transfers for a repo or mount",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, _, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		resp, err := client.GetStats(ctx, &api.StatsRequest{})
+		if err != nil {
+			log.Fatalf("Could not get stats for %s: %s", args[0], errorMessage(err))
+		}
+
+		fmt.Printf("Cached blocks: %s\n", fmtNum(resp.FreezerUsed))
+		fmt.Printf("Writable files: %s\n", fmtNum(resp.WritableUsed))
+		if resp.CacheQuota > 0 {
+			fmt.Printf("Cache quota: %s\n", fmtNum(resp.CacheQuota))
+		}
+		fmt.Printf("Local disk: %s free of %s\n", fmtNum(int64(resp.LocalAvail)), fmtNum(int64(resp.LocalTotal)))
+		fmt.Printf("INodes: %d of %d\n", resp.InodeCount, resp.MaxINodes)
+
+		now := time.Now()
+		for _, t := range resp.Transfers {
+			var BID core.BlockID
+			copy(BID[:], t.BlockID)
+			started := now.Sub(time.Unix(t.StartTimeSeconds, 0)) / time.Second
+			fmt.Printf("Transfer of %s started %ds ago: %d-%d (now @ %d), %s/s\n", base64x(BID), started, t.Start, t.End, t.Offset, fmtNum(int64(t.BytesPerSecond)))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,134 @@
+// Copyright © 2018 NAME HERE <EMAIL ADDRESS>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pgm/sply2/api"
+	"github.com/pgm/sply2/core"
+	"github.com/spf13/cobra"
+)
+
+func toBID(b []byte) core.BlockID {
+	var BID core.BlockID
+	copy(BID[:], b)
+	return BID
+}
+
+func printStatus(w io.Writer, resp *api.StatusResponse) {
+	now := time.Now()
+
+	fmt.Fprintf(w, "Repo: %s\n", resp.RepoPath)
+	if resp.MountPoint != "" {
+		fmt.Fprintf(w, "Mounted at %s for %s\n", resp.MountPoint, time.Duration(resp.UptimeSeconds)*time.Second)
+	} else {
+		fmt.Fprintf(w, "Not mounted\n")
+	}
+	fmt.Fprintf(w, "Cached blocks: %d (%s)\n", resp.FreezerBlocks, fmtNum(resp.FreezerUsed))
+	fmt.Fprintf(w, "Unpushed files: %d (%s), writable files using %s\n", resp.DirtyFileCount, fmtNum(resp.DirtyFileBytes), fmtNum(resp.WritableUsed))
+
+	fmt.Fprintf(w, "\nMounts:\n")
+	if len(resp.Mounts) == 0 {
+		fmt.Fprintf(w, "  (none)\n")
+	}
+	for _, m := range resp.Mounts {
+		p := m.Path
+		if p == "" {
+			p = fmt.Sprintf("(inode %d)", m.ID)
+		}
+		expires := time.Unix(m.LeaseExpirySeconds, 0).Sub(now) / time.Second * time.Second
+		fmt.Fprintf(w, "  %s: %s, lease %s renewed %s ago, expires in %s\n", p, base64x(toBID(m.BlockID)), m.LeaseName,
+			now.Sub(time.Unix(m.LastRenewalSeconds, 0))/time.Second*time.Second, expires)
+	}
+
+	fmt.Fprintf(w, "\nActive transfers:\n")
+	if len(resp.Transfers) == 0 {
+		fmt.Fprintf(w, "  (none)\n")
+	}
+	for _, t := range resp.Transfers {
+		started := now.Sub(time.Unix(t.StartTimeSeconds, 0)) / time.Second
+		fmt.Fprintf(w, "  %s: %d-%d (now @ %d), started %ds ago, %s/s\n", base64x(toBID(t.BlockID)), t.Start, t.End, t.Offset, started, fmtNum(int64(t.BytesPerSecond)))
+	}
+
+	fmt.Fprintf(w, "\nRecent copies:\n")
+	if len(resp.Copies) == 0 {
+		fmt.Fprintf(w, "  (none)\n")
+	}
+	for _, c := range resp.Copies {
+		startTime := time.Unix(0, c.StartTimeNanos)
+		var state string
+		if c.Complete {
+			elapsed := time.Unix(0, c.EndTimeNanos).Sub(startTime)
+			state = fmt.Sprintf("took %s", elapsed)
+			if elapsed > 0 {
+				state += fmt.Sprintf(" (%s/s)", fmtNum(int64(float64(c.End-c.Start)/elapsed.Seconds())))
+			}
+		} else {
+			state = "in progress"
+		}
+		fmt.Fprintf(w, "  %s %s: %d-%d (%s) %s\n", startTime.Format("15:04:05"), base64x(toBID(c.BlockID)), c.Start, c.End, fmtNum(c.End-c.Start), state)
+	}
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status [repo-or-mount]",
+	Short: "Show transfers, cache usage, unpushed changes and mounts for a repo",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		watch, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			panic(err)
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			panic(err)
+		}
+
+		client, _, closeClient := findRepoClient(args[0])
+		defer closeClient()
+
+		ctx := context.Background()
+		for {
+			resp, err := client.GetStatus(ctx, &api.StatusRequest{})
+			if err != nil {
+				log.Fatalf("Could not get status of %s: %s", args[0], errorMessage(err))
+			}
+
+			if watch {
+				// clear the terminal before redrawing
+				fmt.Print("\033[H\033[2J")
+			}
+			printStatus(os.Stdout, resp)
+
+			if !watch {
+				break
+			}
+			time.Sleep(interval)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().BoolP("watch", "w", false, "Keep refreshing the status until interrupted")
+	statusCmd.Flags().Duration("interval", 2*time.Second, "How often to refresh the status with --watch")
+}
@@ -21,7 +21,7 @@ import (
 
 	"cloud.google.com/go/storage"
 	"github.com/pgm/sply2/core"
-	"google.golang.org/api/option"
+	"github.com/pgm/sply2/remote"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -125,10 +125,11 @@ to quickly create a Cobra application.`,
 			log.Fatalf("Could not parse GCS path: %s", destination)
 		}
 
+		requireNotMounted(repoPath)
 		ds, _ := openExistingDataStore(repoPath)
 		ctx := context.Background()
 
-		client, err := storage.NewClient(ctx, option.WithServiceAccountFile(credentialsPath))
+		client, err := remote.NewGCSClient(ctx, credentialsPath)
 		if err != nil {
 			log.Fatalf("Failed to create client: %v", err)
 		}
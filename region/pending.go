@@ -59,6 +59,7 @@ func (c *callStatus) IsRunning() bool {
 }
 
 type PendingReadsImp struct {
+	scheduler *FetchScheduler
 	mutex     sync.Mutex
 	flushCond *sync.Cond
 	writers   []*MarkingWriter
@@ -75,8 +76,10 @@ type PendingReadsStatus struct {
 	TransferRate  float32
 }
 
-func NewPendingReads() PendingReads {
-	p := &PendingReadsImp{}
+// NewPendingReads creates a PendingReads whose copies are started by scheduler. A nil scheduler runs all copies
+// immediately.
+func NewPendingReads(scheduler *FetchScheduler) PendingReads {
+	p := &PendingReadsImp{scheduler: scheduler}
 	p.flushCond = sync.NewCond(&p.mutex)
 	return p
 }
@@ -138,6 +141,7 @@ type Marker interface {
 
 type waitingCaller struct {
 	active     bool
+	priority   Priority
 	end        int64
 	resultChan chan<- error
 }
@@ -147,10 +151,14 @@ type MarkingWriter struct {
 	startTime     time.Time
 	originalStart int64
 	active        bool
+	ctx           context.Context
 	cancelFunc    context.CancelFunc
-	marker        Marker
-	writer        io.Writer
-	offset        int64
+	slot          *slotRequest
+	// set once the copy has a slot, after which it's only canceled once it has read far enough past its callers
+	started bool
+	marker  Marker
+	writer  io.Writer
+	offset  int64
 
 	// how many bytes past lastMaxReadMark do we want to keep reading?
 	readheadSize int64
@@ -252,6 +260,17 @@ func (m *MarkingWriter) updatedPendingEnd() {
 	}
 }
 
+// priority returns Foreground if any caller which is blocked on this copy is in the foreground. Must be called with
+// the owner's mutex held.
+func (m *MarkingWriter) priority() Priority {
+	for _, c := range m.callers {
+		if c.active && c.priority == Foreground {
+			return Foreground
+		}
+	}
+	return Background
+}
+
 func (m *MarkingWriter) addCaller(ctx context.Context, caller *waitingCaller) {
 	m.callers = append(m.callers, caller)
 	go (func() {
@@ -261,6 +280,10 @@ func (m *MarkingWriter) addCaller(ctx context.Context, caller *waitingCaller) {
 		defer m.owner.mutex.Unlock()
 		caller.active = false
 		m.removeInactive()
+		if len(m.callers) == 0 && !m.started {
+			// nobody is waiting for this copy any more, so don't start it
+			m.cancelFunc()
+		}
 	})()
 }
 
@@ -273,7 +296,7 @@ func (p *PendingReadsImp) StartBackgroundCopy(rootCtx context.Context, marker Ma
 
 	requiredResultChan := make(chan error, 1)
 
-	caller := &waitingCaller{active: true, end: end, resultChan: requiredResultChan}
+	caller := &waitingCaller{active: true, priority: GetPriority(rootCtx), end: end, resultChan: requiredResultChan}
 
 	p.mutex.Lock()
 	// look to see if there's a copy in progress that we can join
@@ -289,6 +312,8 @@ func (p *PendingReadsImp) StartBackgroundCopy(rootCtx context.Context, marker Ma
 				// log.Printf("Read (%d-%d) joining existing pending read: %p (%d-%d)", start, end, w, w.pendingStart, w.pendingEnd)
 				w.addCaller(rootCtx, caller)
 				w.updatedPendingEnd()
+				// if this copy hasn't started yet, it may need to jump the queue now that a read is waiting on it
+				p.scheduler.promote(w.slot, caller.priority)
 				joined = true
 			} else {
 				log.Printf("Warning: Read (%d-%d) could have also joined: %v. Check for race condition?", start, end, w)
@@ -306,7 +331,9 @@ func (p *PendingReadsImp) StartBackgroundCopy(rootCtx context.Context, marker Ma
 		taskCtx, task = trace.NewTask(cancelableCtx, "MarkingCopy")
 		markingWriter = &MarkingWriter{owner: p,
 			active:        true,
+			ctx:           taskCtx,
 			cancelFunc:    cancelFunc,
+			slot:          &slotRequest{priority: caller.priority},
 			marker:        marker,
 			writer:        writer,
 			offset:        start,
@@ -328,8 +355,16 @@ func (p *PendingReadsImp) StartBackgroundCopy(rootCtx context.Context, marker Ma
 
 	if !joined {
 		go executeThenCleanup(func() error {
-			err := copier.Copy(taskCtx, start, length, markingWriter)
-			log.Printf("copier.Copy returned err: %v", err)
+			// the copy is shared by every caller which joins it, so it isn't tied to the first caller's context
+			err := p.scheduler.acquire(taskCtx, markingWriter.slot)
+			if err == nil {
+				p.mutex.Lock()
+				markingWriter.started = true
+				p.mutex.Unlock()
+				err = copier.Copy(taskCtx, start, length, markingWriter)
+				log.Printf("copier.Copy returned err: %v", err)
+				p.scheduler.release(err)
+			}
 			// if err == context.Canceled {
 			// 	missing := markingWriter.marker.GetFirstMissingRegion(start, length)
 			// 	if missing == nil {
@@ -476,9 +511,21 @@ func (m *MarkingWriter) Write(buffer []byte) (int, error) {
 	pendingStart := m.pendingStart
 	pendingEnd := m.pendingEnd
 	minUncommited := m.minUncommited
+	priority := m.priority()
 	m.offsetHistory.Record(offset)
 	m.owner.mutex.Unlock()
 
+	err = m.owner.scheduler.waitForBytes(m.ctx, int64(n), priority)
+	if err != nil {
+		return n, err
+	}
+
+	// let any reads which are waiting for a slot go ahead of readahead and prefetching
+	err = m.owner.scheduler.yield(m.ctx, m.slot, priority)
+	if err != nil {
+		return n, err
+	}
+
 	//log.Printf("Wrote: offset=%d, pendingStart=%d, pendingEnd=%d, minUncommited=%d", offset, pendingStart, pendingEnd, minUncommited)
 	if offset-pendingStart >= minUncommited || offset >= pendingEnd {
 		missing := m.marker.GetFirstMissingRegion(pendingStart, m.maxPendingEnd)
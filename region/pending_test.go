@@ -192,7 +192,7 @@ func TestBasicPendingSequence(t *testing.T) {
 	maxWindowSize := int64(10)
 	writer := bytes.NewBuffer(make([]byte, 1000))
 
-	p := NewPendingReads()
+	p := NewPendingReads(nil)
 	var call CallStatus
 
 	// expect Copy is called when we invoke StartBackgroundCopy
@@ -266,7 +266,7 @@ func TestJoinExistingRequest(t *testing.T) {
 	maxWindowSize := int64(10)
 	writer := bytes.NewBuffer(make([]byte, 1000))
 
-	p := NewPendingReads()
+	p := NewPendingReads(nil)
 	var call CallStatus
 
 	// expect Copy is called when we invoke StartBackgroundCopy
@@ -325,3 +325,62 @@ func TestJoinExistingRequest(t *testing.T) {
 	history.AssertExpectationsCalled()
 
 }
+
+// emptyMarker reports that nothing has been copied yet
+type emptyMarker struct{}
+
+func (m *emptyMarker) GetFirstMissingRegion(start int64, end int64) *Region {
+	return &Region{start, end}
+}
+
+func (m *emptyMarker) AddRegion(start int64, end int64) {
+}
+
+// zeroCopier copies zeros, counting how many copies were started
+type zeroCopier struct {
+	mutex  sync.Mutex
+	copies int
+}
+
+func (c *zeroCopier) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
+	c.mutex.Lock()
+	c.copies++
+	c.mutex.Unlock()
+	_, err := writer.Write(make([]byte, len))
+	return err
+}
+
+func (c *zeroCopier) count() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.copies
+}
+
+func TestQueuedCopyOutlivesFirstCaller(t *testing.T) {
+	require := require.New(t)
+	s := NewFetchScheduler(MaxConcurrentCopies(1))
+	p := NewPendingReads(s)
+	copier := &zeroCopier{}
+
+	// another copy holds the only slot, so these queue up
+	_, busy := startAcquire(s, Foreground)
+	require.True(isGranted(busy, time.Second))
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	p.StartBackgroundCopy(firstCtx, &emptyMarker{}, copier, 0, 10, 20, 5, 0, &bytes.Buffer{})
+	second := p.StartBackgroundCopy(context.Background(), &emptyMarker{}, copier, 0, 10, 20, 5, 0, &bytes.Buffer{})
+
+	abandonedCtx, cancelAbandoned := context.WithCancel(context.Background())
+	p.StartBackgroundCopy(abandonedCtx, &emptyMarker{}, copier, 50, 60, 70, 5, 0, &bytes.Buffer{})
+
+	// the read which started the copy gives up, but the one which joined it is still waiting
+	cancelFirst()
+	// and nobody is waiting for the other copy at all
+	cancelAbandoned()
+	time.Sleep(20 * time.Millisecond)
+
+	s.release(nil)
+	require.Nil(second.Wait())
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(1, copier.count())
+}
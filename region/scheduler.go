@@ -0,0 +1,343 @@
+package region
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Priority int
+
+const (
+	// copies which a read is blocked on
+	Foreground Priority = iota
+	// readahead past what was asked for, and prefetching
+	Background
+)
+
+type priorityKey struct{}
+
+// WithBackgroundPriority returns a context which marks any copies started with it as background work, so that they
+// wait behind copies which reads are blocked on
+func WithBackgroundPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, priorityKey{}, Background)
+}
+
+// GetPriority returns the priority of copies started with ctx
+func GetPriority(ctx context.Context) Priority {
+	priority, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok {
+		return Foreground
+	}
+	return priority
+}
+
+// ThrottledError is implemented by errors returned from a Copier when the remote has asked for requests to slow down
+type ThrottledError interface {
+	error
+	Throttled() bool
+}
+
+func isThrottled(err error) bool {
+	t, ok := err.(ThrottledError)
+	return ok && t.Throttled()
+}
+
+type SchedulerConfig struct {
+	// the number of copies from the remote which can run at once (0 for unlimited)
+	maxConcurrent int
+	// the maximum rate of bytes copied from the remote, across all copies (0 for unlimited)
+	bytesPerSecond int64
+	// how long to stop starting new copies after the remote throttles us. Doubles with each consecutive throttle.
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type SchedulerOption func(config *SchedulerConfig)
+
+// MaxConcurrentCopies sets the number of copies from the remote which can run at once
+func MaxConcurrentCopies(count int) SchedulerOption {
+	return func(config *SchedulerConfig) {
+		config.maxConcurrent = count
+	}
+}
+
+// MaxBytesPerSecond caps the rate of bytes copied from the remote, across all copies
+func MaxBytesPerSecond(bytesPerSecond int64) SchedulerOption {
+	return func(config *SchedulerConfig) {
+		config.bytesPerSecond = bytesPerSecond
+	}
+}
+
+// ThrottleBackoff sets how long to stop starting new copies after the remote throttles a request. Each consecutive
+// throttled request doubles the delay, up to max.
+func ThrottleBackoff(min time.Duration, max time.Duration) SchedulerOption {
+	return func(config *SchedulerConfig) {
+		config.minBackoff = min
+		config.maxBackoff = max
+	}
+}
+
+// a copy waiting for a slot
+type slotRequest struct {
+	priority Priority
+	granted  chan bool
+}
+
+// FetchScheduler is shared by all of the copies from a remote. It limits how many run at once, and how fast they
+// copy, and gives copies which a read is blocked on precedence over background work.
+type FetchScheduler struct {
+	config SchedulerConfig
+
+	mutex   sync.Mutex
+	active  int
+	waiting []*slotRequest
+
+	// token bucket of bytes, which goes negative when copies have written more than the rate allows
+	available          float64
+	lastRefill         time.Time
+	foregroundSleeping int
+
+	backoff     time.Duration
+	pausedUntil time.Time
+	resumeTimer *time.Timer
+	throttles   int
+	bytesCopied int64
+}
+
+type SchedulerStats struct {
+	Active           int
+	QueuedForeground int
+	QueuedBackground int
+	// the number of copies which the remote throttled
+	Throttles   int
+	BytesCopied int64
+}
+
+func NewFetchScheduler(options ...SchedulerOption) *FetchScheduler {
+	config := SchedulerConfig{minBackoff: 100 * time.Millisecond, maxBackoff: 10 * time.Second}
+	for _, option := range options {
+		option(&config)
+	}
+	return &FetchScheduler{config: config, available: float64(config.bytesPerSecond), lastRefill: time.Now()}
+}
+
+// grant hands out free slots to waiting requests, foreground first. Must be called with the mutex held.
+func (s *FetchScheduler) grant() {
+	if time.Now().Before(s.pausedUntil) {
+		return
+	}
+	for _, priority := range []Priority{Foreground, Background} {
+		remaining := s.waiting[:0]
+		for _, req := range s.waiting {
+			if req.priority == priority && (s.config.maxConcurrent <= 0 || s.active < s.config.maxConcurrent) {
+				s.active++
+				req.granted <- true
+			} else {
+				remaining = append(remaining, req)
+			}
+		}
+		s.waiting = remaining
+	}
+}
+
+func (s *FetchScheduler) removeWaiting(req *slotRequest) bool {
+	for i, w := range s.waiting {
+		if w == req {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// acquire blocks until the copy req can start, or ctx is done
+func (s *FetchScheduler) acquire(ctx context.Context, req *slotRequest) error {
+	if s == nil {
+		return nil
+	}
+
+	req.granted = make(chan bool, 1)
+	s.mutex.Lock()
+	s.waiting = append(s.waiting, req)
+	s.grant()
+	s.mutex.Unlock()
+
+	select {
+	case <-req.granted:
+		return nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if !s.removeWaiting(req) {
+			// granted at the same time as we gave up, so hand the slot back
+			s.active--
+			s.grant()
+		}
+		return ctx.Err()
+	}
+}
+
+// promote raises the priority of req, if it's still waiting for a slot
+func (s *FetchScheduler) promote(req *slotRequest, priority Priority) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if priority < req.priority {
+		req.priority = priority
+		s.grant()
+	}
+}
+
+// yield hands the slot held by a background copy to a foreground copy which is waiting for one, and then blocks
+// until req gets a slot again. It does nothing if the copy has become foreground, or no foreground copy is waiting.
+func (s *FetchScheduler) yield(ctx context.Context, req *slotRequest, priority Priority) error {
+	if s == nil || priority == Foreground {
+		return nil
+	}
+
+	s.mutex.Lock()
+	req.priority = priority
+	foregroundWaiting := false
+	for _, w := range s.waiting {
+		if w.priority == Foreground {
+			foregroundWaiting = true
+			break
+		}
+	}
+	if !foregroundWaiting {
+		s.mutex.Unlock()
+		return nil
+	}
+	s.active--
+	s.grant()
+	s.mutex.Unlock()
+
+	err := s.acquire(ctx, req)
+	if err != nil {
+		// the copy still calls release when it stops, so count the slot as held until then
+		s.mutex.Lock()
+		s.active++
+		s.mutex.Unlock()
+	}
+	return err
+}
+
+// release frees the slot held by a copy which finished with err. If the remote throttled it, no new copies start
+// until the backoff has elapsed.
+func (s *FetchScheduler) release(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.active--
+
+	if isThrottled(err) {
+		s.throttles++
+		s.backoff *= 2
+		if s.backoff < s.config.minBackoff {
+			s.backoff = s.config.minBackoff
+		}
+		if s.backoff > s.config.maxBackoff {
+			s.backoff = s.config.maxBackoff
+		}
+		s.pausedUntil = time.Now().Add(s.backoff)
+		if s.resumeTimer != nil {
+			s.resumeTimer.Stop()
+		}
+		s.resumeTimer = time.AfterFunc(s.backoff, func() {
+			s.mutex.Lock()
+			defer s.mutex.Unlock()
+			s.grant()
+		})
+	} else if err == nil {
+		s.backoff = 0
+	}
+
+	s.grant()
+}
+
+// waitForBytes is called after a copy has written length bytes, and blocks until the bandwidth cap allows more. Background
+// copies also wait while any foreground copy is waiting.
+func (s *FetchScheduler) waitForBytes(ctx context.Context, length int64, priority Priority) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	s.bytesCopied += length
+	if s.config.bytesPerSecond <= 0 {
+		s.mutex.Unlock()
+		return nil
+	}
+
+	rate := float64(s.config.bytesPerSecond)
+	deducted := false
+	for {
+		now := time.Now()
+		s.available += rate * now.Sub(s.lastRefill).Seconds()
+		if s.available > rate {
+			s.available = rate
+		}
+		s.lastRefill = now
+
+		if !deducted && (priority == Foreground || (s.available > 0 && s.foregroundSleeping == 0)) {
+			s.available -= float64(length)
+			deducted = true
+		}
+
+		var delay time.Duration
+		if !deducted {
+			// let the foreground copies go first
+			delay = 10 * time.Millisecond
+		} else if s.available < 0 {
+			delay = time.Duration(-s.available / rate * float64(time.Second))
+		} else {
+			s.mutex.Unlock()
+			return nil
+		}
+
+		if priority == Foreground {
+			s.foregroundSleeping++
+		}
+		s.mutex.Unlock()
+
+		timer := time.NewTimer(delay)
+		var err error
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+		}
+
+		s.mutex.Lock()
+		if priority == Foreground {
+			s.foregroundSleeping--
+		}
+		if err != nil || deducted {
+			s.mutex.Unlock()
+			return err
+		}
+	}
+}
+
+func (s *FetchScheduler) Stats() SchedulerStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := SchedulerStats{Active: s.active, Throttles: s.throttles, BytesCopied: s.bytesCopied}
+	for _, req := range s.waiting {
+		if req.priority == Foreground {
+			stats.QueuedForeground++
+		} else {
+			stats.QueuedBackground++
+		}
+	}
+	return stats
+}
@@ -0,0 +1,145 @@
+package region
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type mockThrottledErr struct{}
+
+func (e *mockThrottledErr) Error() string {
+	return "slow down"
+}
+
+func (e *mockThrottledErr) Throttled() bool {
+	return true
+}
+
+// startAcquire requests a slot in the background, returning a channel which is closed once it's granted
+func startAcquire(s *FetchScheduler, priority Priority) (*slotRequest, chan bool) {
+	req := &slotRequest{priority: priority}
+	done := make(chan bool)
+	go func() {
+		err := s.acquire(context.Background(), req)
+		if err == nil {
+			close(done)
+		}
+	}()
+	return req, done
+}
+
+func isGranted(done chan bool, wait time.Duration) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(wait):
+		return false
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	require := require.New(t)
+	s := NewFetchScheduler(MaxConcurrentCopies(1))
+
+	_, first := startAcquire(s, Foreground)
+	require.True(isGranted(first, time.Second))
+
+	// a background and then a foreground copy queue up behind the first
+	_, background := startAcquire(s, Background)
+	require.False(isGranted(background, 20*time.Millisecond))
+	_, foreground := startAcquire(s, Foreground)
+	require.False(isGranted(foreground, 20*time.Millisecond))
+	require.Equal(SchedulerStats{Active: 1, QueuedForeground: 1, QueuedBackground: 1}, s.Stats())
+
+	// the foreground copy goes next, despite being queued last
+	s.release(nil)
+	require.True(isGranted(foreground, time.Second))
+	require.False(isGranted(background, 20*time.Millisecond))
+
+	// a background copy which a read joins jumps ahead of other background copies
+	_, otherBackground := startAcquire(s, Background)
+	time.Sleep(20 * time.Millisecond)
+	promoted, promotedDone := startAcquire(s, Background)
+	time.Sleep(20 * time.Millisecond)
+	s.promote(promoted, Foreground)
+
+	s.release(nil)
+	require.True(isGranted(promotedDone, time.Second))
+	require.False(isGranted(otherBackground, 20*time.Millisecond))
+
+	s.release(nil)
+	require.True(isGranted(background, time.Second))
+}
+
+func TestSchedulerYield(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	s := NewFetchScheduler(MaxConcurrentCopies(1))
+
+	background, backgroundDone := startAcquire(s, Background)
+	require.True(isGranted(backgroundDone, time.Second))
+
+	// nothing is waiting, so the background copy keeps its slot
+	require.Nil(s.yield(ctx, background, Background))
+	require.Equal(SchedulerStats{Active: 1}, s.Stats())
+
+	// a read queues up, so the background copy steps aside until the read's copy is done
+	_, foreground := startAcquire(s, Foreground)
+	require.False(isGranted(foreground, 20*time.Millisecond))
+	yielded := make(chan bool)
+	go func() {
+		require.Nil(s.yield(ctx, background, Background))
+		close(yielded)
+	}()
+	require.True(isGranted(foreground, time.Second))
+	require.False(isGranted(yielded, 20*time.Millisecond))
+
+	s.release(nil)
+	require.True(isGranted(yielded, time.Second))
+	require.Equal(SchedulerStats{Active: 1}, s.Stats())
+}
+
+func TestSchedulerThrottleBackoff(t *testing.T) {
+	require := require.New(t)
+	s := NewFetchScheduler(MaxConcurrentCopies(1), ThrottleBackoff(100*time.Millisecond, time.Second))
+
+	_, first := startAcquire(s, Foreground)
+	require.True(isGranted(first, time.Second))
+	_, second := startAcquire(s, Foreground)
+
+	// the remote throttled the first copy, so the next one waits for the backoff even though a slot is free
+	s.release(&mockThrottledErr{})
+	require.False(isGranted(second, 50*time.Millisecond))
+	require.True(isGranted(second, time.Second))
+	require.Equal(1, s.Stats().Throttles)
+
+	// other errors don't trigger a backoff
+	_, third := startAcquire(s, Foreground)
+	s.release(errors.New("failed"))
+	require.True(isGranted(third, 50*time.Millisecond))
+}
+
+func TestSchedulerBandwidth(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+	s := NewFetchScheduler(MaxBytesPerSecond(1000))
+
+	// the first second's worth is available immediately
+	start := time.Now()
+	require.Nil(s.waitForBytes(ctx, 1000, Foreground))
+	require.True(time.Now().Sub(start) < 100*time.Millisecond)
+
+	// after which copies are held to the rate
+	require.Nil(s.waitForBytes(ctx, 200, Background))
+	require.True(time.Now().Sub(start) >= 150*time.Millisecond)
+	require.Equal(int64(1200), s.Stats().BytesCopied)
+
+	// waiting gives up when the copy is canceled
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.Equal(context.Canceled, s.waitForBytes(canceled, 5000, Foreground))
+}
@@ -0,0 +1,109 @@
+package remote
+
+import (
+	"errors"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	"golang.org/x/net/context"
+	"google.golang.org/api/option"
+)
+
+var ConflictingBucketOptionsErr = errors.New("Bucket cannot be both anonymous and use a credentials file")
+
+// BucketOptions describes how to access a bucket which needs something other than the repo's default credentials
+type BucketOptions struct {
+	// the project billed for requests to a requester-pays bucket
+	UserProject string `json:"user_project,omitempty" yaml:"user_project,omitempty"`
+	// a service account file to use for this bucket instead of the repo's credentials
+	Credentials string `json:"credentials,omitempty" yaml:"credentials,omitempty"`
+	// access the bucket without any credentials (for public buckets)
+	Anonymous bool `json:"anonymous,omitempty" yaml:"anonymous,omitempty"`
+}
+
+// NewGCSClient creates a client which authenticates with the service account in credentialsPath, or with
+// Application Default Credentials if credentialsPath is empty
+func NewGCSClient(ctx context.Context, credentialsPath string) (*storage.Client, error) {
+	if credentialsPath == "" {
+		return storage.NewClient(ctx)
+	}
+	return storage.NewClient(ctx, option.WithServiceAccountFile(credentialsPath))
+}
+
+// the key anonymous clients are stored under in bucketClients.clients. Can't collide with a credentials path.
+const anonymousClientKey = ""
+
+type bucketClient struct {
+	client  *storage.Client
+	options *BucketOptions
+}
+
+// bucketClients holds the clients used for buckets which have BucketOptions. Clients are shared between buckets which
+// use the same credentials.
+type bucketClients struct {
+	mutex   sync.RWMutex
+	buckets map[string]*bucketClient
+	clients map[string]*storage.Client
+}
+
+// SetBucketOptions configures how bucket is accessed. Any client this requires is created immediately, so that
+// problems with the credentials are reported here rather than on first read.
+func (rrf *RemoteRefFactoryImp) SetBucketOptions(ctx context.Context, bucket string, options *BucketOptions) error {
+	if options.Anonymous && options.Credentials != "" {
+		return ConflictingBucketOptionsErr
+	}
+
+	rrf.bucketClients.mutex.Lock()
+	defer rrf.bucketClients.mutex.Unlock()
+
+	if rrf.bucketClients.buckets == nil {
+		rrf.bucketClients.buckets = make(map[string]*bucketClient)
+		rrf.bucketClients.clients = make(map[string]*storage.Client)
+	}
+
+	client := rrf.GCSClient
+	if options.Anonymous || options.Credentials != "" {
+		key := options.Credentials
+		if options.Anonymous {
+			key = anonymousClientKey
+		}
+		client = rrf.bucketClients.clients[key]
+		if client == nil {
+			var err error
+			if options.Anonymous {
+				client, err = storage.NewClient(ctx, option.WithoutAuthentication())
+			} else {
+				client, err = storage.NewClient(ctx, option.WithServiceAccountFile(options.Credentials))
+			}
+			if err != nil {
+				return err
+			}
+			rrf.bucketClients.clients[key] = client
+		}
+	}
+
+	rrf.bucketClients.buckets[bucket] = &bucketClient{client: client, options: options}
+	return nil
+}
+
+// clientFor returns the client to use for bucket, and the project to bill requests to (if any)
+func (rrf *RemoteRefFactoryImp) clientFor(bucket string) (*storage.Client, string) {
+	rrf.bucketClients.mutex.RLock()
+	defer rrf.bucketClients.mutex.RUnlock()
+
+	bc, ok := rrf.bucketClients.buckets[bucket]
+	if !ok {
+		return rrf.GCSClient, ""
+	}
+	return bc.client, bc.options.UserProject
+}
+
+// bucketHandle returns a handle for bucket using whichever client and user project have been configured for it
+func (rrf *RemoteRefFactoryImp) bucketHandle(bucket string) *storage.BucketHandle {
+	client, userProject := rrf.clientFor(bucket)
+	b := client.Bucket(bucket)
+	if userProject != "" {
+		b = b.UserProject(userProject)
+	}
+	return b
+}
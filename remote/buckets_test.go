@@ -0,0 +1,43 @@
+package remote
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+func TestBucketOptions(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	defaultClient, err := storage.NewClient(ctx, option.WithoutAuthentication())
+	require.Nil(err)
+	rrf := NewRemoteRefFactory(defaultClient, "bucket", "prefix/")
+
+	// buckets without options use the repo's client
+	client, userProject := rrf.clientFor("other")
+	require.True(client == defaultClient)
+	require.Equal("", userProject)
+
+	// requester-pays buckets use the repo's client, billed to the given project
+	require.Nil(rrf.SetBucketOptions(ctx, "requester-pays", &BucketOptions{UserProject: "billing"}))
+	client, userProject = rrf.clientFor("requester-pays")
+	require.True(client == defaultClient)
+	require.Equal("billing", userProject)
+
+	// anonymous buckets share a single client
+	require.Nil(rrf.SetBucketOptions(ctx, "public-1", &BucketOptions{Anonymous: true}))
+	require.Nil(rrf.SetBucketOptions(ctx, "public-2", &BucketOptions{Anonymous: true}))
+	public1, _ := rrf.clientFor("public-1")
+	public2, _ := rrf.clientFor("public-2")
+	require.True(public1 != defaultClient)
+	require.True(public1 == public2)
+
+	require.Equal(ConflictingBucketOptionsErr, rrf.SetBucketOptions(ctx, "b", &BucketOptions{Anonymous: true, Credentials: "creds.json"}))
+	require.NotNil(rrf.SetBucketOptions(ctx, "b", &BucketOptions{Credentials: "/does/not/exist.json"}))
+	client, _ = rrf.clientFor("b")
+	require.True(client == defaultClient)
+}
@@ -0,0 +1,59 @@
+package remote
+
+import (
+	"io"
+	"net"
+	"net/http"
+
+	"cloud.google.com/go/storage"
+	"github.com/pgm/sply2/core"
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+)
+
+// classifyStatus returns the class of failure an HTTP status code represents, or false if it isn't one we classify
+func classifyStatus(code int) (core.RemoteErrClass, bool) {
+	switch {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		return core.RemoteThrottled, true
+	case code == http.StatusNotFound || code == http.StatusGone:
+		return core.RemoteNotFound, true
+	case code == http.StatusPreconditionFailed:
+		return core.RemotePreconditionFailed, true
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return core.RemotePermissionDenied, true
+	case code == http.StatusRequestTimeout || code >= 500:
+		return core.RemoteTransient, true
+	}
+	return 0, false
+}
+
+// classifyError wraps err in a core.RemoteErr describing what kind of failure it was, so that the freezer knows
+// whether to retry it. Errors which aren't recognized are returned as is.
+func classifyError(err error) error {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+
+	if _, ok := err.(*core.RemoteErr); ok {
+		return err
+	}
+
+	if err == storage.ErrObjectNotExist || err == storage.ErrBucketNotExist {
+		return &core.RemoteErr{Class: core.RemoteNotFound, Err: err}
+	}
+
+	if apiErr, ok := err.(*googleapi.Error); ok {
+		if class, ok := classifyStatus(apiErr.Code); ok {
+			return &core.RemoteErr{Class: class, Err: err}
+		}
+		return err
+	}
+
+	// connections which were dropped or timed out
+	if _, ok := err.(net.Error); ok || err == io.ErrUnexpectedEOF {
+		return &core.RemoteErr{Class: core.RemoteTransient, Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,41 @@
+package remote
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"cloud.google.com/go/storage"
+	"github.com/pgm/sply2/core"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/api/googleapi"
+)
+
+func TestClassifyError(t *testing.T) {
+	require := require.New(t)
+
+	classOf := func(err error) string {
+		class, ok := core.GetRemoteErrClass(classifyError(err))
+		if !ok {
+			return "unclassified"
+		}
+		return class.String()
+	}
+
+	require.Equal("throttled", classOf(&googleapi.Error{Code: 429}))
+	require.Equal("throttled", classOf(&googleapi.Error{Code: 503}))
+	require.Equal("transient", classOf(&googleapi.Error{Code: 500}))
+	require.Equal("not found", classOf(&googleapi.Error{Code: 404}))
+	require.Equal("not found", classOf(storage.ErrObjectNotExist))
+	require.Equal("precondition failed", classOf(&googleapi.Error{Code: 412}))
+	require.Equal("permission denied", classOf(&googleapi.Error{Code: 403}))
+	require.Equal("transient", classOf(io.ErrUnexpectedEOF))
+	require.Equal("transient", classOf(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}))
+
+	require.Equal("unclassified", classOf(&googleapi.Error{Code: 400}))
+	require.Equal("unclassified", classOf(errors.New("disk full")))
+	require.Equal(context.Canceled, classifyError(context.Canceled))
+	require.Nil(classifyError(nil))
+}
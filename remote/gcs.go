@@ -9,7 +9,6 @@ import (
 	"io/ioutil"
 	"runtime/trace"
 	"strings"
-	"time"
 
 	// Imports the Google Cloud Storage client package.
 
@@ -44,8 +43,13 @@ type RemoteRefFactoryImp struct {
 	Bucket         string
 	RootKeyPrefix  string
 	LeaseKeyPrefix string
-	CASKeyPrefix   string
-	GCSClient      *storage.Client
+	// each version of a label is stored under HistoryKeyPrefix + label + "/"
+	HistoryKeyPrefix string
+	CASKeyPrefix     string
+	// the client used for any bucket without its own BucketOptions
+	GCSClient *storage.Client
+
+	bucketClients bucketClients
 }
 
 // func (rrf *RemoteRefFactoryImp) GetChildNodes(ctx context.Context, remoteSource interface{}) ([]*core.RemoteFile, error) {
@@ -93,26 +97,53 @@ type RemoteRefFactoryImp struct {
 // 	return result, nil
 // }
 
-type Lease struct {
-	Expiry time.Time
-	BID    core.BlockID
-}
-
-func (rrf *RemoteRefFactoryImp) SetLease(ctx context.Context, name string, expiry time.Time, BID core.BlockID) error {
-	b := rrf.GCSClient.Bucket(rrf.Bucket)
-	o := b.Object(rrf.LeaseKeyPrefix + name)
+// SetLease writes lease with gob. The name is taken from the key, so it isn't stored in the object itself.
+func (rrf *RemoteRefFactoryImp) SetLease(ctx context.Context, lease *core.Lease) error {
+	b := rrf.bucketHandle(rrf.Bucket)
+	o := b.Object(rrf.LeaseKeyPrefix + lease.Name)
 	w := o.NewWriter(ctx)
-	defer w.Close()
 	enc := gob.NewEncoder(w)
-	err := enc.Encode(&Lease{Expiry: expiry, BID: BID})
+	err := enc.Encode(&core.Lease{Expiry: lease.Expiry, BID: lease.BID, Host: lease.Host, Repo: lease.Repo})
 	if err != nil {
-		return err
+		w.Close()
+		return classifyError(err)
 	}
-	return nil
+	return classifyError(w.Close())
+}
+
+// ListLeases reads every lease under LeaseKeyPrefix. Leases written before the owning repo was recorded have an
+// empty Host and Repo.
+func (rrf *RemoteRefFactoryImp) ListLeases(ctx context.Context) ([]*core.Lease, error) {
+	b := rrf.bucketHandle(rrf.Bucket)
+	leases := make([]*core.Lease, 0)
+	it := b.Objects(ctx, &storage.Query{Prefix: rrf.LeaseKeyPrefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, classifyError(err)
+		}
+
+		r, err := b.Object(attrs.Name).NewReader(ctx)
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		var lease core.Lease
+		err = gob.NewDecoder(r).Decode(&lease)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse %s: %s", attrs.Name, err)
+		}
+		lease.Name = strings.TrimPrefix(attrs.Name, rrf.LeaseKeyPrefix)
+		leases = append(leases, &lease)
+	}
+	return leases, nil
 }
 
 func (rrf *RemoteRefFactoryImp) SetRoot(ctx context.Context, name string, BID core.BlockID) error {
-	b := rrf.GCSClient.Bucket(rrf.Bucket)
+	b := rrf.bucketHandle(rrf.Bucket)
 	o := b.Object(rrf.RootKeyPrefix + name)
 	w := o.NewWriter(ctx)
 	defer w.Close()
@@ -126,10 +157,72 @@ func (rrf *RemoteRefFactoryImp) SetRoot(ctx context.Context, name string, BID co
 	return nil
 }
 
+// CompareAndSetRoot reads the label along with its generation, and then only overwrites that generation, so a label
+// moved by another push in between fails the write's precondition
+func (rrf *RemoteRefFactoryImp) CompareAndSetRoot(ctx context.Context, name string, expected core.BlockID, BID core.BlockID) error {
+	o := rrf.bucketHandle(rrf.Bucket).Object(rrf.RootKeyPrefix + name)
+
+	var conditions storage.Conditions
+	attrs, err := o.Attrs(ctx)
+	if err == storage.ErrObjectNotExist {
+		if expected != core.NABlock {
+			return core.LabelConflictErr
+		}
+		conditions.DoesNotExist = true
+	} else if err != nil {
+		return classifyError(err)
+	} else {
+		current, err := readRoot(ctx, o.Generation(attrs.Generation))
+		if err != nil {
+			return err
+		}
+		if current != expected {
+			return core.LabelConflictErr
+		}
+		conditions.GenerationMatch = attrs.Generation
+	}
+
+	w := o.If(conditions).NewWriter(ctx)
+	_, err = w.Write([]byte(base64.URLEncoding.EncodeToString(BID[:])))
+	if err != nil {
+		w.Close()
+		return classifyError(err)
+	}
+	err = classifyError(w.Close())
+	if class, ok := core.GetRemoteErrClass(err); ok && class == core.RemotePreconditionFailed {
+		return core.LabelConflictErr
+	}
+	return err
+}
+
+func readRoot(ctx context.Context, o *storage.ObjectHandle) (core.BlockID, error) {
+	r, err := o.NewReader(ctx)
+	if err != nil {
+		return core.NABlock, classifyError(err)
+	}
+	defer r.Close()
+
+	buffer, err := ioutil.ReadAll(r)
+	if err != nil {
+		return core.NABlock, classifyError(err)
+	}
+
+	var BID core.BlockID
+	dbuffer, err := base64.URLEncoding.DecodeString(string(buffer))
+	if err != nil {
+		return core.NABlock, err
+	}
+	copy(BID[:], dbuffer)
+	return BID, nil
+}
+
 func (rrf *RemoteRefFactoryImp) GetRoot(ctx context.Context, name string) (core.BlockID, error) {
-	b := rrf.GCSClient.Bucket(rrf.Bucket)
+	b := rrf.bucketHandle(rrf.Bucket)
 	o := b.Object(rrf.RootKeyPrefix + name)
 	r, err := o.NewReader(ctx)
+	if err == storage.ErrObjectNotExist {
+		return core.NABlock, core.UndefinedRootErr
+	}
 	if err != nil {
 		return core.NABlock, err
 	}
@@ -156,7 +249,7 @@ func (rrf *RemoteRefFactoryImp) GetGCSAttr(ctx context.Context, bucket string, k
 		return &core.GCSAttrs{IsDir: true}, nil
 	}
 
-	b := rrf.GCSClient.Bucket(bucket)
+	b := rrf.bucketHandle(bucket)
 	o := b.Object(key)
 	attrs, err := o.Attrs(ctx)
 
@@ -181,7 +274,7 @@ func (rrf *RemoteRefFactoryImp) Push(ctx context.Context, BID core.BlockID, rr c
 	// TODO: need to add a check for that case
 	key := core.GetBlockKey(rrf.CASKeyPrefix, BID)
 	// fmt.Println("bucket " + rrf.CASBucket + " " + key)
-	CASBucketRef := rrf.GCSClient.Bucket(rrf.Bucket)
+	CASBucketRef := rrf.bucketHandle(rrf.Bucket)
 	objHandle := CASBucketRef.Object(key).If(storage.Conditions{DoesNotExist: true})
 	writer := objHandle.NewWriter(ctx)
 	defer writer.Close()
@@ -201,13 +294,16 @@ func NewRemoteRefFactory(client *storage.Client, Bucket string, KeyPrefix string
 		panic("Prefix must end in /")
 	}
 	return &RemoteRefFactoryImp{GCSClient: client, Bucket: Bucket, CASKeyPrefix: KeyPrefix + "CAS/",
-		RootKeyPrefix:  KeyPrefix + "root/",
-		LeaseKeyPrefix: KeyPrefix + "lease/"}
+		RootKeyPrefix:    KeyPrefix + "root/",
+		LeaseKeyPrefix:   KeyPrefix + "lease/",
+		HistoryKeyPrefix: KeyPrefix + "history/"}
 }
 
 type GCSRef struct {
 	Owner  *RemoteRefFactoryImp
 	Source *core.GCSObjectSource
+	// read Source.Generation even if it's no longer the live version of the object
+	Pinned bool
 }
 
 func (r *GCSRef) GetSize() int64 {
@@ -216,7 +312,13 @@ func (r *GCSRef) GetSize() int64 {
 
 func (r *GCSRef) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
 	defer trace.StartRegion(ctx, "GCSCopy").End()
-	return copyRegion(ctx, r.Owner.GCSClient, r.Source.Bucket, r.Source.Key, r.Source.Generation, offset, len, writer)
+	return copyRegion(ctx, r.Owner.bucketHandle(r.Source.Bucket), r.Source.Key, r.Source.Generation, r.Pinned, offset, len, writer)
+}
+
+// Pin returns a ref which reads the same generation as r, even after the object has been overwritten. This only works
+// if the bucket has object versioning enabled, as otherwise the old generation is deleted.
+func (r *GCSRef) Pin() core.RemoteRef {
+	return &GCSRef{Owner: r.Owner, Source: r.Source, Pinned: true}
 }
 
 func (r *GCSRef) GetSource() interface{} {
@@ -224,7 +326,7 @@ func (r *GCSRef) GetSource() interface{} {
 }
 
 func (r *GCSRef) GetChildNodes(ctx context.Context) ([]*core.RemoteFile, error) {
-	return getChildNodes(ctx, r.Owner.GCSClient, r.Source.Bucket, r.Source.Key)
+	return getChildNodes(ctx, r.Owner.bucketHandle(r.Source.Bucket), r.Source.Bucket, r.Source.Key)
 }
 
 func (rf *RemoteRefFactoryImp) GetRef(source interface{}) core.RemoteRef {
@@ -255,8 +357,24 @@ func randomBlockID() core.BlockID {
 	return BID
 }
 
-func getChildNodes(ctx context.Context, GCSClient *storage.Client, Bucket string, Key string) ([]*core.RemoteFile, error) {
-	b := GCSClient.Bucket(Bucket)
+// ListObjects returns the names of the objects in bucket which start with prefix, in order
+func (rrf *RemoteRefFactoryImp) ListObjects(ctx context.Context, bucket string, prefix string) ([]string, error) {
+	it := rrf.bucketHandle(bucket).Objects(ctx, &storage.Query{Prefix: prefix, Versions: false})
+	names := make([]string, 0)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		names = append(names, attrs.Name)
+	}
+	return names, nil
+}
+
+func getChildNodes(ctx context.Context, b *storage.BucketHandle, Bucket string, Key string) ([]*core.RemoteFile, error) {
 	it := b.Objects(ctx, &storage.Query{Delimiter: "/", Prefix: Key, Versions: false})
 	result := make([]*core.RemoteFile, 0, 100)
 	for {
@@ -299,11 +417,16 @@ func getChildNodes(ctx context.Context, GCSClient *storage.Client, Bucket string
 	return result, nil
 }
 
-func copyRegion(ctx context.Context, GCSClient *storage.Client, Bucket string, Key string, Generation int64, offset int64, len int64, writer io.Writer) error {
-	b := GCSClient.Bucket(Bucket)
+// copyRegion copies part of an object. If pinned is false, the copy fails with a precondition error if the object
+// is no longer at the given generation. Otherwise, that generation is read regardless.
+func copyRegion(ctx context.Context, b *storage.BucketHandle, Key string, Generation int64, pinned bool, offset int64, len int64, writer io.Writer) error {
 	objHandle := b.Object(Key)
 	if Generation != 0 {
-		objHandle = objHandle.If(storage.Conditions{GenerationMatch: Generation})
+		if pinned {
+			objHandle = objHandle.Generation(Generation)
+		} else {
+			objHandle = objHandle.If(storage.Conditions{GenerationMatch: Generation})
+		}
 	}
 
 	var reader io.ReadCloser
@@ -314,17 +437,17 @@ func copyRegion(ctx context.Context, GCSClient *storage.Client, Bucket string, K
 	// 	reader, err = objHandle.NewReader(ctx)
 	// }
 	if err != nil {
-		return err
+		return classifyError(err)
 	}
 	defer reader.Close()
 
 	n, err := io.Copy(writer, reader)
 	if err != nil {
-		return err
+		return classifyError(err)
 	}
 
 	if len >= 0 && n != len {
-		return fmt.Errorf("Expected to copy to copy %d bytes but copied %d", len, n)
+		return &core.RemoteErr{Class: core.RemoteTransient, Err: fmt.Errorf("Expected to copy to copy %d bytes but copied %d", len, n)}
 	}
 
 	return nil
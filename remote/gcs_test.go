@@ -83,6 +83,14 @@ func (m *mockFrozenReader) Read(ctx context.Context, p []byte) (n int, err error
 	return m.reader.Read(p)
 }
 
+func (m *mockFrozenReader) ReadAt(ctx context.Context, p []byte, offset int64) (n int, err error) {
+	_, err = m.reader.Seek(offset, 0)
+	if err != nil {
+		return 0, err
+	}
+	return m.reader.Read(p)
+}
+
 func (m *mockFrozenReader) Seek(offset int64, b int) (n int64, err error) {
 	return m.reader.Seek(offset, b)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/pgm/sply2/core"
 	"golang.org/x/net/context"
@@ -19,9 +20,26 @@ func (r *URLRef) GetSize() int64 {
 	return r.Source.Size
 }
 
+// ifMatchETag returns the If-Match header which only matches etag, quoting it if the server returned it without
+// quotes. Returns "" for a missing or weak ETag, as If-Match never matches weak ETags.
+func ifMatchETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, "W/") {
+		return ""
+	}
+	if strings.HasPrefix(etag, `"`) && strings.HasSuffix(etag, `"`) && len(etag) > 1 {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
 func (r *URLRef) Copy(ctx context.Context, offset int64, len int64, writer io.Writer) error {
 	req, err := http.NewRequest("GET", r.Source.URL, nil)
-	req.Header.Add("If-Match", `"r.ETag"`)
+	if err != nil {
+		return err
+	}
+	if ifMatch := ifMatchETag(r.Source.ETag); ifMatch != "" {
+		req.Header.Add("If-Match", ifMatch)
+	}
 	if offset != 0 || len != r.Source.Size {
 		// if r.Source.AcceptsRanges {
 		req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+len-1))
@@ -31,20 +49,25 @@ func (r *URLRef) Copy(ctx context.Context, offset int64, len int64, writer io.Wr
 	}
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return err
+		return classifyError(err)
 	}
+	defer res.Body.Close()
 
-	if res.StatusCode != 200 {
-		return errors.New(fmt.Sprintf("Status code: %d", res.StatusCode))
+	if res.StatusCode != 200 && res.StatusCode != 206 {
+		err = errors.New(fmt.Sprintf("Status code: %d", res.StatusCode))
+		if class, ok := classifyStatus(res.StatusCode); ok {
+			return &core.RemoteErr{Class: class, Err: err}
+		}
+		return err
 	}
 
 	n, err := io.Copy(writer, res.Body)
 	if err != nil {
-		return err
+		return classifyError(err)
 	}
 
 	if n != len {
-		return errors.New("Did not copy requested length")
+		return &core.RemoteErr{Class: core.RemoteTransient, Err: errors.New("Did not copy requested length")}
 	}
 
 	return nil
@@ -63,6 +86,10 @@ func (rrf *RemoteRefFactoryImp) GetHTTPAttr(ctx context.Context, url string) (*c
 	if err != nil {
 		return nil, err
 	}
+	res.Body.Close()
+	if res.StatusCode >= 400 {
+		return nil, fmt.Errorf("HEAD %s returned %s", url, res.Status)
+	}
 	contentlength := res.ContentLength
 	etag := res.Header.Get("ETag")
 	// rangeDef := res.Header.Get("Accept-Ranges")
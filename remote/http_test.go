@@ -1,5 +1,57 @@
 package remote
 
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pgm/sply2/core"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+)
+
+func TestURLRefCopyIfMatch(t *testing.T) {
+	require := require.New(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Match") != "" && r.Header.Get("If-Match") != `"v1"` {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	copyWithETag := func(etag string) (string, error) {
+		r := &URLRef{Source: &core.URLSource{URL: server.URL, ETag: etag, Size: 5}}
+		var buf bytes.Buffer
+		err := r.Copy(ctx, 0, 5, &buf)
+		return buf.String(), err
+	}
+
+	// the ETag is sent as the server returned it, or quoted if it wasn't
+	data, err := copyWithETag(`"v1"`)
+	require.Nil(err)
+	require.Equal("hello", data)
+	data, err = copyWithETag("v1")
+	require.Nil(err)
+	require.Equal("hello", data)
+
+	// weak ETags can't be used with If-Match, so aren't sent
+	_, err = copyWithETag(`W/"v0"`)
+	require.Nil(err)
+
+	_, err = copyWithETag(`"v2"`)
+	class, ok := core.GetRemoteErrClass(err)
+	require.True(ok)
+	require.Equal(core.RemotePreconditionFailed, class)
+
+	r := &URLRef{Source: &core.URLSource{URL: "://bad", Size: 5}}
+	require.NotNil(r.Copy(ctx, 0, 5, &bytes.Buffer{}))
+}
+
 // func TestURLRemote(t *testing.T) {
 // 	require := require.New(t)
 
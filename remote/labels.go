@@ -0,0 +1,181 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/pgm/sply2/core"
+	"golang.org/x/net/context"
+	"google.golang.org/api/iterator"
+)
+
+// historyKey returns the key of the given version of the label name. Versions are zero padded so they list in order.
+func (rrf *RemoteRefFactoryImp) historyKey(name string, version int) string {
+	return fmt.Sprintf("%s%s/%010d", rrf.HistoryKeyPrefix, name, version)
+}
+
+// how many times AddLabelVersion tries the next version number, when other pushes keep taking it first
+const maxLabelVersionAttempts = 5
+
+// AddLabelVersion writes version as the next entry in the history of name. Each entry is written only if it doesn't
+// already exist, so two pushes racing for the same version number can't overwrite each other. The one which loses
+// takes the next number instead.
+func (rrf *RemoteRefFactoryImp) AddLabelVersion(ctx context.Context, name string, version *core.LabelVersion) error {
+	var err error
+	for attempt := 0; attempt < maxLabelVersionAttempts; attempt++ {
+		err = rrf.writeNextLabelVersion(ctx, name, version)
+		if class, ok := core.GetRemoteErrClass(err); !ok || class != core.RemotePreconditionFailed {
+			return err
+		}
+	}
+	return err
+}
+
+func (rrf *RemoteRefFactoryImp) writeNextLabelVersion(ctx context.Context, name string, version *core.LabelVersion) error {
+	latest, err := rrf.GetLabelVersionCount(ctx, name)
+	if err != nil && err != core.UndefinedRootErr {
+		return err
+	}
+
+	added := *version
+	added.Version = latest + 1
+	buffer, err := json.Marshal(&added)
+	if err != nil {
+		return err
+	}
+
+	o := rrf.bucketHandle(rrf.Bucket).Object(rrf.historyKey(name, added.Version)).If(storage.Conditions{DoesNotExist: true})
+	w := o.NewWriter(ctx)
+	w.ContentType = "application/json"
+	_, err = w.Write(buffer)
+	if err != nil {
+		w.Close()
+		return classifyError(err)
+	}
+	return classifyError(w.Close())
+}
+
+// listLabelVersions returns the version numbers in the history of name, in order, from the names of the objects
+// holding them
+func (rrf *RemoteRefFactoryImp) listLabelVersions(ctx context.Context, name string) ([]int, error) {
+	prefix := rrf.HistoryKeyPrefix + name + "/"
+	versions := make([]int, 0)
+	it := rrf.bucketHandle(rrf.Bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		version, err := strconv.Atoi(strings.TrimPrefix(attrs.Name, prefix))
+		if err != nil {
+			// not a history entry
+			continue
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// checkLabelExists distinguishes a label without history (or without the version asked for) from one which doesn't
+// exist, returning core.UndefinedRootErr for the latter
+func (rrf *RemoteRefFactoryImp) checkLabelExists(ctx context.Context, name string) error {
+	_, err := rrf.bucketHandle(rrf.Bucket).Object(rrf.RootKeyPrefix + name).Attrs(ctx)
+	if err == storage.ErrObjectNotExist {
+		return core.UndefinedRootErr
+	}
+	return classifyError(err)
+}
+
+func (rrf *RemoteRefFactoryImp) readLabelVersion(ctx context.Context, key string) (*core.LabelVersion, error) {
+	r, err := rrf.bucketHandle(rrf.Bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return nil, classifyError(err)
+	}
+	defer r.Close()
+
+	var version core.LabelVersion
+	err = json.NewDecoder(r).Decode(&version)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse %s: %s", key, err)
+	}
+	return &version, nil
+}
+
+// GetLabelVersionCount returns the latest version of name, which only needs the history to be listed rather than read
+func (rrf *RemoteRefFactoryImp) GetLabelVersionCount(ctx context.Context, name string) (int, error) {
+	versions, err := rrf.listLabelVersions(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, rrf.checkLabelExists(ctx, name)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// GetLabelVersion reads a single version of name
+func (rrf *RemoteRefFactoryImp) GetLabelVersion(ctx context.Context, name string, version int) (*core.LabelVersion, error) {
+	v, err := rrf.readLabelVersion(ctx, rrf.historyKey(name, version))
+	if class, ok := core.GetRemoteErrClass(err); ok && class == core.RemoteNotFound {
+		err = rrf.checkLabelExists(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return nil, core.UnknownLabelVersionErr
+	}
+	return v, err
+}
+
+// GetLabelHistory reads every version of name, oldest first. Labels which were pushed before history was recorded
+// have an empty history.
+func (rrf *RemoteRefFactoryImp) GetLabelHistory(ctx context.Context, name string) ([]*core.LabelVersion, error) {
+	versions, err := rrf.listLabelVersions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		err = rrf.checkLabelExists(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	history := make([]*core.LabelVersion, 0, len(versions))
+	for _, version := range versions {
+		v, err := rrf.readLabelVersion(ctx, rrf.historyKey(name, version))
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, v)
+	}
+	return history, nil
+}
+
+// ListLabels returns the names of all labels, sorted
+func (rrf *RemoteRefFactoryImp) ListLabels(ctx context.Context) ([]string, error) {
+	labels := make([]string, 0)
+	it := rrf.bucketHandle(rrf.Bucket).Objects(ctx, &storage.Query{Prefix: rrf.RootKeyPrefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, classifyError(err)
+		}
+		name := strings.TrimPrefix(attrs.Name, rrf.RootKeyPrefix)
+		if name != "" {
+			labels = append(labels, name)
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}